@@ -2,13 +2,16 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime/pprof"
+	"strings"
 	"syscall"
 
 	"github.com/AntoineGS/tidydots/internal/config"
@@ -25,11 +28,28 @@ var (
 	osOverride  string
 	dryRun      bool
 	verbose     bool
+	strict      bool
 	interactive bool
 	noMerge     bool
 	forceDelete bool
 	forceRender bool
 	cpuProfile  string
+	hostnameOvr string
+	distroOvr   string
+	userOvr     string
+	dataOvr     []string
+	allHosts    bool
+	materialize bool
+	listRuns    bool
+	restoreTo   string
+	overwrite   bool
+	jsonOutput  bool
+	jobs        int
+	assumeYes   bool
+	allPackages bool
+	locked      bool
+	importFrom  string
+	importAll   bool
 	logFile     *os.File
 )
 
@@ -88,9 +108,10 @@ Run without arguments to start the interactive TUI.`,
 	}
 
 	rootCmd.PersistentFlags().StringVarP(&configDir, "dir", "d", "", "Override configurations directory (ignores app config)")
-	rootCmd.PersistentFlags().StringVarP(&osOverride, "os", "o", "", "Override OS detection (linux or windows)")
+	rootCmd.PersistentFlags().StringVarP(&osOverride, "os", "o", "", "Override OS detection (linux, windows or darwin)")
 	rootCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be done without making changes")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "Fail on missing template keys and report all template errors")
 	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpuprofile", "", "Write CPU profile to file (e.g. cpu.prof)")
 	_ = rootCmd.PersistentFlags().MarkHidden("cpuprofile")
 
@@ -115,6 +136,8 @@ The repo should contain a tidydots.yaml file with your path definitions.`,
 	restoreCmd.Flags().BoolVar(&noMerge, "no-merge", false, "Disable merge mode, return error if target exists")
 	restoreCmd.Flags().BoolVar(&forceDelete, "force", false, "When combined with --no-merge, delete existing files without prompting")
 	restoreCmd.Flags().BoolVar(&forceRender, "force-render", false, "Force re-render of templates, skipping 3-way merge")
+	restoreCmd.Flags().BoolVar(&jsonOutput, "json", false, "Print progress events as JSON lines on stdout (logs go to stderr)")
+	restoreCmd.Flags().IntVarP(&jobs, "jobs", "j", 1, "Number of entries to restore concurrently")
 
 	backupCmd := &cobra.Command{
 		Use:   "backup",
@@ -123,6 +146,7 @@ The repo should contain a tidydots.yaml file with your path definitions.`,
 		RunE:  runBackup,
 	}
 	backupCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Run in interactive mode")
+	backupCmd.Flags().BoolVar(&jsonOutput, "json", false, "Print progress events as JSON lines on stdout (logs go to stderr)")
 
 	listCmd := &cobra.Command{
 		Use:   "list",
@@ -140,6 +164,52 @@ Packages are filtered based on their filters (os, hostname, user).`,
 		RunE: runInstall,
 	}
 	installCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Run in interactive mode")
+	installCmd.Flags().BoolVar(&locked, "locked", false, "Install the versions recorded in "+packages.LockFileName)
+
+	lockCmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Record installed package versions in " + packages.LockFileName,
+		Long: `Write the installed version of each package from your configuration to
+` + packages.LockFileName + ` next to tidydots.yaml, so 'tidydots install --locked' installs the
+same versions on another machine.
+Only package managers that report installed versions are recorded. Versions
+recorded for other package managers are kept.`,
+		RunE: runLock,
+	}
+
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall <package-names...> | --all",
+		Short: "Uninstall packages through the manager that installed them",
+		Long: `Remove packages from your configuration with the method that installed them:
+the package manager reporting them as installed, the uninstall command of an
+installer package, or deleting the clone of a git package.
+Name the packages to uninstall, or pass --all to uninstall every installed package.
+The packages are listed and confirmation is asked before anything is removed.`,
+		RunE: runUninstall,
+	}
+	uninstallCmd.Flags().BoolVar(&allPackages, "all", false, "Uninstall every installed package in the configuration")
+	uninstallCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Uninstall without asking for confirmation")
+
+	outdatedCmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "List installed packages with a newer version available",
+		Long: `Display the packages from your configuration whose package manager offers a
+newer version, with the installed and available versions.
+Managers that cannot list upgrades are reported separately.`,
+		RunE: runOutdated,
+	}
+
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade [package-names...]",
+		Short: "Upgrade installed packages from your configuration",
+		Long: `Upgrade the packages from your configuration with the package manager that
+installed them, and pull the clones of git packages.
+Only packages tracked in tidydots.yaml are upgraded, never the whole system.
+Pacman, yay and paru packages are not upgraded, since Arch does not support
+partial upgrades; run a full -Syu for them instead.
+If no package names are provided, every installed package is upgraded.`,
+		RunE: runUpgrade,
+	}
 
 	listPkgsCmd := &cobra.Command{
 		Use:   "list-packages",
@@ -148,7 +218,97 @@ Packages are filtered based on their filters (os, hostname, user).`,
 		RunE:  runListPackages,
 	}
 
-	rootCmd.AddCommand(initCmd, restoreCmd, backupCmd, listCmd, installCmd, listPkgsCmd)
+	renderCmd := &cobra.Command{
+		Use:   "render <file.tmpl>",
+		Short: "Render a template and print the result",
+		Long: `Render a template file with the detected platform context and print the output.
+Use --hostname, --distro, --user and --data to preview other machines, or
+--all-hosts to render once for every host listed under 'hosts:' in tidydots.yaml.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runRender,
+	}
+	renderCmd.Flags().StringVar(&hostnameOvr, "hostname", "", "Override the hostname in the template context")
+	renderCmd.Flags().StringVar(&distroOvr, "distro", "", "Override the distribution in the template context")
+	renderCmd.Flags().StringVar(&userOvr, "user", "", "Override the user in the template context")
+	renderCmd.Flags().StringArrayVar(&dataOvr, "data", nil, "Set a custom template value as key=value (repeatable)")
+	renderCmd.Flags().BoolVar(&allHosts, "all-hosts", false, "Render for every host defined in tidydots.yaml")
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove links whose entry no longer exists in the config",
+		Long: `Find symlinks recorded by earlier restores whose entry was removed from
+tidydots.yaml, and remove them. Use --materialize to replace each link with a
+copy of the content it pointed to instead.`,
+		RunE: runPrune,
+	}
+	pruneCmd.Flags().BoolVar(&materialize, "materialize", false, "Replace orphaned links with a copy of their content instead of removing them")
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback [run-id]",
+		Short: "Undo the changes made by a previous restore",
+		Long: `Revert every step a restore run performed: recreate removed symlinks, move
+adopted and merged content back to its target and restore files that --force
+deleted. Without a run ID, the most recent run that was not rolled back yet is
+reverted. Use --list to show the journaled runs.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runRollback,
+	}
+	rollbackCmd.Flags().BoolVar(&listRuns, "list", false, "List journaled restore runs instead of rolling back")
+
+	snapshotsCmd := &cobra.Command{
+		Use:   "snapshots [snapshot-id]",
+		Short: "List content archived before restore removed or overwrote it",
+		Long: `List the snapshots taken on this host. Restore archives every file or folder
+it is about to delete (--force, replacing a merged folder) and every rendered
+file a --force-render would overwrite. With a snapshot ID, list its files.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runSnapshots,
+	}
+
+	snapshotsRestoreCmd := &cobra.Command{
+		Use:   "restore <snapshot-id> <path>",
+		Short: "Restore a file or folder from a snapshot",
+		Long: `Copy an archived file or folder back to its original path. The path may
+also point inside an archived folder to restore a single file from it.`,
+		Args: cobra.ExactArgs(2),
+		RunE: runSnapshotsRestore,
+	}
+	snapshotsRestoreCmd.Flags().StringVar(&restoreTo, "to", "", "Restore to this path instead of the original location")
+	snapshotsRestoreCmd.Flags().BoolVar(&overwrite, "force", false, "Replace existing content at the destination (it is archived first)")
+	snapshotsCmd.AddCommand(snapshotsRestoreCmd)
+
+	packagesCmd := &cobra.Command{
+		Use:   "packages",
+		Short: "Manage the packages of tidydots.yaml",
+	}
+
+	packagesImportCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Add packages installed on this machine to tidydots.yaml",
+		Long: `List the packages installed on request with a package manager, leaving out
+dependencies, and add the ones tidydots.yaml does not configure yet.
+A package joins the application of the same name when it has no package for
+the manager; otherwise a new application is created.
+Each package is confirmed unless --all is given.`,
+		Args: cobra.NoArgs,
+		RunE: runPackagesImport,
+	}
+	packagesImportCmd.Flags().StringVar(&importFrom, "from", "", "Package manager to import from ("+strings.Join(packages.ImportManagers(), ", ")+")")
+	packagesImportCmd.Flags().BoolVar(&importAll, "all", false, "Import every package without asking for confirmation")
+	_ = packagesImportCmd.MarkFlagRequired("from")
+	packagesCmd.AddCommand(packagesImportCmd)
+
+	helperCmd := &cobra.Command{
+		Use:    manager.HelperCommand,
+		Short:  "Serve file operations for sudo entries (started by tidydots under sudo)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return manager.ServePrivileged(os.Stdin, os.Stdout)
+		},
+	}
+
+	rootCmd.AddCommand(initCmd, restoreCmd, backupCmd, listCmd, installCmd, lockCmd, uninstallCmd, outdatedCmd, upgradeCmd, listPkgsCmd, renderCmd, pruneCmd, rollbackCmd, snapshotsCmd, packagesCmd, helperCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -189,10 +349,14 @@ func runInit(_ *cobra.Command, args []string) error {
 		fmt.Println("You'll need to create it before using tidydots.")
 	}
 
-	// Save app config
+	// Save app config, keeping machine-local encryption settings from a previous init
 	appCfg := &config.AppConfig{
 		ConfigDir: absPath,
 	}
+	if existing, err := config.LoadAppConfig(); err == nil {
+		appCfg.EncryptionKeyFile = existing.EncryptionKeyFile
+		appCfg.EncryptionPassphrase = existing.EncryptionPassphrase
+	}
 
 	if err := config.SaveAppConfig(appCfg); err != nil {
 		return fmt.Errorf("saving app config: %w", err)
@@ -236,20 +400,33 @@ func loadConfig() (*config.Config, *platform.Platform, string, error) {
 	}
 
 	cfg.BackupRoot = cfgDir
+	if strict {
+		cfg.Strict = true
+	}
+
+	plat, err := detectPlatform()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	// Paths are kept with ~ in the config for portability
+	// They will be expanded when needed for file operations
 
+	return cfg, plat, configFile, nil
+}
+
+// detectPlatform detects the current platform and applies the --os override.
+func detectPlatform() (*platform.Platform, error) {
 	plat := platform.Detect()
 
 	if osOverride != "" {
-		if osOverride != platform.OSLinux && osOverride != platform.OSWindows {
-			return nil, nil, "", fmt.Errorf("invalid OS override: %s (must be 'linux' or 'windows')", osOverride)
+		if !platform.IsSupportedOS(osOverride) {
+			return nil, fmt.Errorf("invalid OS override: %s (must be one of %s)", osOverride, strings.Join(platform.SupportedOS, ", "))
 		}
 		plat = plat.WithOS(osOverride)
 	}
 
-	// Paths are kept with ~ in the config for portability
-	// They will be expanded when needed for file operations
-
-	return cfg, plat, configFile, nil
+	return plat, nil
 }
 
 func createManager() (*manager.Manager, error) {
@@ -258,8 +435,8 @@ func createManager() (*manager.Manager, error) {
 		return nil, err
 	}
 
-	fmt.Printf("Detected OS: %s\n", plat.OS)
-	fmt.Printf("Config directory: %s\n", cfg.BackupRoot)
+	fmt.Fprintf(statusOut(), "Detected OS: %s\n", plat.OS)
+	fmt.Fprintf(statusOut(), "Config directory: %s\n", cfg.BackupRoot)
 
 	mgr := manager.New(cfg, plat)
 	mgr.DryRun = dryRun
@@ -267,12 +444,20 @@ func createManager() (*manager.Manager, error) {
 	mgr.NoMerge = noMerge
 	mgr.ForceDelete = forceDelete
 	mgr.ForceRender = forceRender
+	mgr.Jobs = jobs
 
 	// Initialize state store for template render tracking
 	if err := mgr.InitStateStore(); err != nil {
 		fmt.Printf("Warning: could not initialize template state store: %v\n", err)
 	}
 
+	// Encryption keys live in the app config; without one, encrypted entries report an error
+	if appCfg, err := config.LoadAppConfig(); err == nil {
+		if err := mgr.InitCipher(appCfg); err != nil {
+			fmt.Printf("Warning: could not initialize encryption: %v\n", err)
+		}
+	}
+
 	return mgr, nil
 }
 
@@ -302,10 +487,10 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	defer mgr.Close() //nolint:errcheck // best-effort cleanup
 
 	if dryRun {
-		fmt.Println("=== DRY RUN MODE ===")
+		fmt.Fprintln(statusOut(), "=== DRY RUN MODE ===")
 	}
 
-	return runRestoreWithManager(mgr)
+	return runRestoreWithManager(withJSONEvents(mgr))
 }
 
 func runRestoreWithManager(m manager.Restorer) error {
@@ -324,16 +509,43 @@ func runBackup(cmd *cobra.Command, args []string) error {
 	defer mgr.Close() //nolint:errcheck // best-effort cleanup
 
 	if dryRun {
-		fmt.Println("=== DRY RUN MODE ===")
+		fmt.Fprintln(statusOut(), "=== DRY RUN MODE ===")
 	}
 
-	return runBackupWithManager(mgr)
+	return runBackupWithManager(withJSONEvents(mgr))
 }
 
 func runBackupWithManager(m manager.Backuper) error {
 	return runWithCancellation(m.BackupWithContext)
 }
 
+// statusOut returns where human-readable status lines go. With --json, stdout
+// is reserved for events.
+func statusOut() io.Writer {
+	if jsonOutput {
+		return os.Stderr
+	}
+
+	return os.Stdout
+}
+
+// withJSONEvents sends the manager's progress events to stdout as JSON lines
+// when --json is set, moving its logs to stderr.
+func withJSONEvents(mgr *manager.Manager) *manager.Manager {
+	if !jsonOutput {
+		return mgr
+	}
+
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	return mgr.WithLogger(logger).WithEventSink(manager.NewJSONEventSink(os.Stdout))
+}
+
 // runWithCancellation runs a context-aware function with signal-based cancellation.
 // It sets up SIGINT/SIGTERM handling and cancels the context when a signal is received.
 func runWithCancellation(fn func(ctx context.Context) error) error {
@@ -377,12 +589,12 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	fmt.Printf("Detected OS: %s\n", plat.OS)
-	fmt.Printf("Config directory: %s\n", cfg.BackupRoot)
+	fmt.Fprintf(statusOut(), "Detected OS: %s\n", plat.OS)
+	fmt.Fprintf(statusOut(), "Config directory: %s\n", cfg.BackupRoot)
 
 	// Create template engine for when expression evaluation
 	tmplCtx := tmpl.NewContextFromPlatform(plat)
-	engine := tmpl.NewEngine(tmplCtx)
+	engine := tmpl.NewEngine(tmplCtx).WithStrict(cfg.Strict)
 
 	// Get filtered package entries
 	packageEntries := cfg.GetFilteredPackages(engine)
@@ -395,7 +607,22 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		Packages:        packages.FromApplications(packageEntries),
 		DefaultManager:  packages.PackageManager(cfg.DefaultManager),
 		ManagerPriority: convertToPackageManagers(cfg.ManagerPriority),
-	}, plat.OS, dryRun, verbose)
+	}, plat.OS, dryRun, verbose).WithMachine(config.NewMachine(plat))
+
+	if locked {
+		lockPath := filepath.Join(cfg.BackupRoot, packages.LockFileName)
+
+		lock, err := packages.LoadLock(lockPath)
+		if err != nil {
+			return err
+		}
+
+		if len(lock.Packages) == 0 {
+			return fmt.Errorf("no locked versions in %s: run 'tidydots lock' first", lockPath)
+		}
+
+		pkgMgr = pkgMgr.WithLock(lock)
+	}
 
 	fmt.Printf("Available package managers: %v\n", pkgMgr.Available)
 	if pkgMgr.Preferred != "" {
@@ -446,6 +673,379 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// loadPackageManager returns a package manager for the packages of the
+// configuration that match this machine.
+func loadPackageManager() (*packages.Manager, error) {
+	cfg, plat, _, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return newPackageManager(cfg, plat)
+}
+
+// newPackageManager returns a package manager for the packages of cfg that
+// match the platform.
+func newPackageManager(cfg *config.Config, plat *platform.Platform) (*packages.Manager, error) {
+	// Create template engine for when expression evaluation
+	tmplCtx := tmpl.NewContextFromPlatform(plat)
+	engine := tmpl.NewEngine(tmplCtx).WithStrict(cfg.Strict)
+
+	packageEntries := cfg.GetFilteredPackages(engine)
+	if len(packageEntries) == 0 {
+		return nil, fmt.Errorf("no matching packages configured in tidydots.yaml")
+	}
+
+	return packages.NewManager(&packages.Config{
+		Packages:        packages.FromApplications(packageEntries),
+		DefaultManager:  packages.PackageManager(cfg.DefaultManager),
+		ManagerPriority: convertToPackageManagers(cfg.ManagerPriority),
+	}, plat.OS, dryRun, verbose).WithMachine(config.NewMachine(plat)), nil
+}
+
+func runLock(_ *cobra.Command, _ []string) error {
+	cfg, plat, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	pkgMgr, err := newPackageManager(cfg, plat)
+	if err != nil {
+		return err
+	}
+
+	lockPath := filepath.Join(cfg.BackupRoot, packages.LockFileName)
+
+	lock, err := packages.LoadLock(lockPath)
+	if err != nil {
+		return err
+	}
+
+	versions := pkgMgr.UpdateLock(lock, pkgMgr.Config.Packages)
+	if len(versions) == 0 {
+		fmt.Println("No installed package reports its version")
+		return nil
+	}
+
+	for _, v := range versions {
+		fmt.Printf("  %s (%s): %s\n", v.Name, v.Method, v.Installed)
+	}
+
+	if dryRun {
+		fmt.Printf("\nWould write %d versions to %s\n", len(versions), lockPath)
+		return nil
+	}
+
+	if err := lock.Save(lockPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nLocked %d versions in %s\n", len(versions), lockPath)
+
+	return nil
+}
+
+func runPackagesImport(_ *cobra.Command, _ []string) error {
+	if !packages.CanImport(importFrom) {
+		return fmt.Errorf("cannot import from %q: expected one of %s", importFrom, strings.Join(packages.ImportManagers(), ", "))
+	}
+
+	cfgDir, err := getConfigDir()
+	if err != nil {
+		return err
+	}
+
+	configFile := filepath.Join(cfgDir, "tidydots.yaml")
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("loading config from %s: %w", configFile, err)
+	}
+
+	installed, err := packages.ExplicitPackages(context.Background(), importFrom)
+	if err != nil {
+		return err
+	}
+
+	candidates := packages.PlanImport(cfg.Applications, importFrom, installed)
+	if len(candidates) == 0 {
+		fmt.Printf("All %d packages installed with %s are in tidydots.yaml\n", len(installed), importFrom)
+		return nil
+	}
+
+	fmt.Printf("%d of %d packages installed with %s are not in tidydots.yaml\n\n", len(candidates), len(installed), importFrom)
+
+	if dryRun {
+		fmt.Println("=== DRY RUN MODE ===")
+	}
+
+	selected := candidates
+	if !importAll {
+		in := bufio.NewReader(os.Stdin)
+		selected = nil
+
+		for _, c := range candidates {
+			question := fmt.Sprintf("Add %s as a new application?", c.PackageName)
+			if c.Merge {
+				question = fmt.Sprintf("Add %s to application %s?", c.PackageName, c.AppName)
+			}
+
+			if confirm(in, os.Stdout, question) {
+				selected = append(selected, c)
+			}
+		}
+
+		fmt.Println()
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("Nothing to import")
+		return nil
+	}
+
+	for _, c := range selected {
+		action := "[new]"
+		if c.Merge {
+			action = "[merge]"
+		}
+
+		fmt.Printf("%s %s: %s %s\n", action, c.AppName, importFrom, c.PackageName)
+	}
+
+	if dryRun {
+		fmt.Printf("\nWould import %d packages into %s\n", len(selected), configFile)
+		return nil
+	}
+
+	packages.ApplyImport(cfg, importFrom, selected)
+
+	if err := config.Save(cfg, configFile); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nImported %d packages into %s\n", len(selected), configFile)
+
+	return nil
+}
+
+// checkUninstallArgs requires either package names or --all, so that running
+// uninstall without arguments never removes every package.
+func checkUninstallArgs(args []string, all bool) error {
+	switch {
+	case all && len(args) > 0:
+		return fmt.Errorf("--all cannot be combined with package names")
+	case !all && len(args) == 0:
+		return fmt.Errorf("no packages given: name the packages to uninstall, or pass --all to uninstall every installed package")
+	}
+
+	return nil
+}
+
+func runUninstall(_ *cobra.Command, args []string) error {
+	if err := checkUninstallArgs(args, allPackages); err != nil {
+		return err
+	}
+
+	pkgMgr, err := loadPackageManager()
+	if err != nil {
+		return err
+	}
+
+	candidates, err := selectPackages(pkgMgr.Config.Packages, args)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Println("=== DRY RUN MODE ===")
+	}
+
+	var toRemove []packages.Package
+
+	for _, pkg := range candidates {
+		method := pkgMgr.UninstallMethod(pkg)
+		if method == "none" {
+			if len(args) > 0 {
+				fmt.Printf("[skip] %s: not installed\n", pkg.Name)
+			}
+
+			continue
+		}
+
+		toRemove = append(toRemove, pkg)
+		fmt.Printf("  %s (via %s)\n", pkg.Name, method)
+	}
+
+	if len(toRemove) == 0 {
+		fmt.Println("Nothing to uninstall")
+		return nil
+	}
+
+	if !dryRun && !assumeYes && !confirm(os.Stdin, os.Stdout, fmt.Sprintf("Uninstall %d package(s)?", len(toRemove))) {
+		fmt.Println("Aborted")
+		return nil
+	}
+
+	results := pkgMgr.UninstallAll(toRemove)
+
+	successCount := 0
+	failCount := 0
+	for _, r := range results {
+		if r.Success {
+			fmt.Printf("[ok] %s: %s\n", r.Package, r.Message)
+			successCount++
+		} else {
+			fmt.Printf("[error] %s: %s\n", r.Package, r.Message)
+			failCount++
+		}
+	}
+
+	fmt.Printf("\nUninstall complete: %d successful, %d failed\n", successCount, failCount)
+
+	if failCount > 0 {
+		return fmt.Errorf("%d packages failed to uninstall", failCount)
+	}
+	return nil
+}
+
+func runOutdated(_ *cobra.Command, _ []string) error {
+	pkgMgr, err := loadPackageManager()
+	if err != nil {
+		return err
+	}
+
+	var outdated, unchecked []packages.PackageVersion
+
+	for _, v := range pkgMgr.Versions(pkgMgr.Config.Packages) {
+		switch {
+		case !v.Checked:
+			unchecked = append(unchecked, v)
+		case v.Outdated:
+			outdated = append(outdated, v)
+		}
+	}
+
+	if len(outdated) == 0 {
+		fmt.Println("All packages are up to date")
+	} else {
+		fmt.Println("Outdated packages:")
+
+		for _, v := range outdated {
+			held := ""
+			if v.Held {
+				held = " (held by version constraint)"
+			}
+
+			fmt.Printf("  %s (%s): %s%s\n", v.Name, v.Method, v.Change(), held)
+		}
+	}
+
+	if len(unchecked) > 0 {
+		fmt.Println("\nCannot check for upgrades:")
+
+		for _, v := range unchecked {
+			fmt.Printf("  %s (%s)\n", v.Name, v.Method)
+		}
+	}
+
+	return nil
+}
+
+func runUpgrade(_ *cobra.Command, args []string) error {
+	pkgMgr, err := loadPackageManager()
+	if err != nil {
+		return err
+	}
+
+	candidates, err := selectPackages(pkgMgr.Config.Packages, args)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Println("=== DRY RUN MODE ===")
+	}
+
+	var toUpgrade []packages.Package
+
+	for _, pkg := range candidates {
+		if pkgMgr.UpgradeMethod(pkg) == "none" {
+			if len(args) > 0 {
+				fmt.Printf("[skip] %s: not installed by git or a package manager\n", pkg.Name)
+			}
+
+			continue
+		}
+
+		toUpgrade = append(toUpgrade, pkg)
+	}
+
+	results := pkgMgr.UpgradeAll(toUpgrade)
+
+	successCount := 0
+	failCount := 0
+	for _, r := range results {
+		if r.Success {
+			fmt.Printf("[ok] %s: %s\n", r.Package, r.Message)
+			successCount++
+		} else {
+			fmt.Printf("[error] %s: %s\n", r.Package, r.Message)
+			failCount++
+		}
+	}
+
+	fmt.Printf("\nUpgrade complete: %d successful, %d failed\n", successCount, failCount)
+
+	if failCount > 0 {
+		return fmt.Errorf("%d packages failed to upgrade", failCount)
+	}
+	return nil
+}
+
+// selectPackages returns the packages named in names, in configuration order,
+// or all packages when no names are given. Unknown names are an error.
+func selectPackages(pkgs []packages.Package, names []string) ([]packages.Package, error) {
+	if len(names) == 0 {
+		return pkgs, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	var selected []packages.Package
+	for _, pkg := range pkgs {
+		if wanted[pkg.Name] {
+			selected = append(selected, pkg)
+			delete(wanted, pkg.Name)
+		}
+	}
+
+	for _, name := range names {
+		if wanted[name] {
+			return nil, fmt.Errorf("no matching package named %q in tidydots.yaml", name)
+		}
+	}
+
+	return selected, nil
+}
+
+// confirm asks a yes/no question on out and reads the answer from in. Only
+// "y" or "yes" confirm; anything else, including end of input, declines.
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+
+	return false
+}
+
 func runListPackages(_ *cobra.Command, _ []string) error {
 	cfg, plat, _, err := loadConfig()
 	if err != nil {
@@ -454,7 +1054,7 @@ func runListPackages(_ *cobra.Command, _ []string) error {
 
 	// Create template engine for when expression evaluation
 	tmplCtx := tmpl.NewContextFromPlatform(plat)
-	engine := tmpl.NewEngine(tmplCtx)
+	engine := tmpl.NewEngine(tmplCtx).WithStrict(cfg.Strict)
 
 	// Get filtered package entries
 	packageEntries := cfg.GetFilteredPackages(engine)
@@ -468,7 +1068,7 @@ func runListPackages(_ *cobra.Command, _ []string) error {
 		Packages:        packages.FromApplications(packageEntries),
 		DefaultManager:  packages.PackageManager(cfg.DefaultManager),
 		ManagerPriority: convertToPackageManagers(cfg.ManagerPriority),
-	}, plat.OS, false, verbose)
+	}, plat.OS, false, verbose).WithMachine(config.NewMachine(plat))
 
 	fmt.Printf("Available package managers: %v\n\n", pkgMgr.Available)
 
@@ -498,3 +1098,271 @@ func convertToPackageManagers(strs []string) []packages.PackageManager {
 	}
 	return result
 }
+
+func runPrune(_ *cobra.Command, _ []string) error {
+	mgr, err := createManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close() //nolint:errcheck // best-effort cleanup
+
+	if dryRun {
+		fmt.Println("=== DRY RUN MODE ===")
+	}
+
+	pruned, err := mgr.Prune(materialize)
+	if len(pruned) == 0 && err == nil {
+		fmt.Println("No orphaned links found")
+		return nil
+	}
+
+	fmt.Printf("\nPrune complete: %d orphaned path(s) handled\n", len(pruned))
+
+	return err
+}
+
+func runRollback(_ *cobra.Command, args []string) error {
+	mgr, err := createManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close() //nolint:errcheck // best-effort cleanup
+
+	if listRuns {
+		runs, err := mgr.RestoreRuns()
+		if err != nil {
+			return err
+		}
+
+		if len(runs) == 0 {
+			fmt.Println("No journaled restore runs")
+			return nil
+		}
+
+		for _, run := range runs {
+			fmt.Printf("%s  %-11s  %s\n", run.ID, run.Status, run.StartedAt.Local().Format("2006-01-02 15:04:05"))
+		}
+
+		return nil
+	}
+
+	if dryRun {
+		fmt.Println("=== DRY RUN MODE ===")
+	}
+
+	var runID string
+	if len(args) == 1 {
+		runID = args[0]
+	}
+
+	reverted, err := mgr.Rollback(runID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nRollback complete: run %s reverted\n", reverted)
+
+	return nil
+}
+
+func runSnapshots(_ *cobra.Command, args []string) error {
+	mgr, err := createManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close() //nolint:errcheck // best-effort cleanup
+
+	if len(args) == 1 {
+		files, err := mgr.SnapshotFiles(args[0])
+		if err != nil {
+			return err
+		}
+
+		for _, f := range files {
+			path := f.Path
+			if f.IsDir {
+				path += string(filepath.Separator)
+			}
+			fmt.Printf("%-11s  %s\n", f.Reason, path)
+		}
+
+		return nil
+	}
+
+	snapshots, err := mgr.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		fmt.Println("No snapshots")
+		return nil
+	}
+
+	for _, snap := range snapshots {
+		fmt.Printf("%s  %s  %d path(s)\n", snap.ID, snap.CreatedAt.Local().Format("2006-01-02 15:04:05"), snap.Files)
+	}
+
+	return nil
+}
+
+func runSnapshotsRestore(_ *cobra.Command, args []string) error {
+	mgr, err := createManager()
+	if err != nil {
+		return err
+	}
+	defer mgr.Close() //nolint:errcheck // best-effort cleanup
+
+	if dryRun {
+		fmt.Println("=== DRY RUN MODE ===")
+	}
+
+	path, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+
+	dest := restoreTo
+	if dest != "" {
+		if dest, err = filepath.Abs(dest); err != nil {
+			return err
+		}
+	}
+
+	written, err := mgr.RestoreFromSnapshot(args[0], path, dest, overwrite)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %s\n", written)
+
+	return nil
+}
+
+func runRender(_ *cobra.Command, args []string) error {
+	data, err := parseDataFlags(dataOvr)
+	if err != nil {
+		return err
+	}
+
+	if allHosts {
+		if hostnameOvr != "" || distroOvr != "" || userOvr != "" {
+			return fmt.Errorf("--all-hosts cannot be combined with --hostname, --distro or --user")
+		}
+
+		cfg, plat, _, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		return renderAllHosts(args[0], plat, cfg.Hosts, data, cfg.Strict)
+	}
+
+	plat, err := detectPlatform()
+	if err != nil {
+		return err
+	}
+
+	if hostnameOvr != "" {
+		plat = plat.WithHostname(hostnameOvr)
+	}
+	if distroOvr != "" {
+		plat = plat.WithDistro(distroOvr)
+	}
+	if userOvr != "" {
+		plat = plat.WithUser(userOvr)
+	}
+
+	out, err := renderTemplateFile(args[0], plat, data, strict)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// renderAllHosts renders the template once per known host, printing each
+// result under a header. Every host is rendered even if an earlier one fails.
+func renderAllHosts(path string, base *platform.Platform, hosts []config.Host, data map[string]string, strictMode bool) error {
+	if len(hosts) == 0 {
+		return fmt.Errorf("no hosts defined in tidydots.yaml; add a 'hosts:' list to use --all-hosts")
+	}
+
+	failCount := 0
+	for _, host := range hosts {
+		fmt.Printf("=== %s ===\n", host.Name)
+
+		out, err := renderTemplateFile(path, hostPlatform(base, host), mergeData(host.Data, data), strictMode)
+		if err != nil {
+			fmt.Printf("[error] %v\n\n", err)
+			failCount++
+			continue
+		}
+
+		fmt.Println(string(out))
+	}
+
+	if failCount > 0 {
+		return fmt.Errorf("%d of %d hosts failed to render", failCount, len(hosts))
+	}
+	return nil
+}
+
+// hostPlatform returns a copy of base describing the given host. Fields the
+// host leaves empty keep the detected values.
+func hostPlatform(base *platform.Platform, host config.Host) *platform.Platform {
+	plat := base.WithHostname(host.Name)
+	if host.OS != "" {
+		plat = plat.WithOS(host.OS)
+	}
+	if host.Distro != "" {
+		plat = plat.WithDistro(host.Distro)
+	}
+	if host.User != "" {
+		plat = plat.WithUser(host.User)
+	}
+	return plat
+}
+
+// renderTemplateFile renders the template at path with a context built from
+// plat, exposing data to the template as .Data. In strict mode missing keys
+// are errors.
+func renderTemplateFile(path string, plat *platform.Platform, data map[string]string, strictMode bool) ([]byte, error) {
+	content, err := os.ReadFile(path) //nolint:gosec // path is provided by the user
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+
+	tmplCtx := tmpl.NewContextFromPlatform(plat)
+	for k, v := range data {
+		tmplCtx.Data[k] = v
+	}
+
+	return tmpl.NewEngine(tmplCtx).WithStrict(strictMode).RenderBytes(filepath.Base(path), content)
+}
+
+// parseDataFlags converts repeated key=value flags into a map.
+func parseDataFlags(pairs []string) (map[string]string, error) {
+	data := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --data value %q (expected key=value)", pair)
+		}
+		data[k] = v
+	}
+	return data, nil
+}
+
+// mergeData returns base overlaid with overrides.
+func mergeData(base, overrides map[string]string) map[string]string {
+	result := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range overrides {
+		result[k] = v
+	}
+	return result
+}
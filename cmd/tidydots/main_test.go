@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/packages"
+	"github.com/AntoineGS/tidydots/internal/platform"
 )
 
 func TestRunInit(t *testing.T) {
@@ -221,6 +224,91 @@ func TestConvertToPackageManagers(t *testing.T) {
 	}
 }
 
+func TestParseDataFlags(t *testing.T) {
+	tests := []struct {
+		want    map[string]string
+		name    string
+		input   []string
+		wantErr bool
+	}{
+		{name: "none", input: nil, want: map[string]string{}},
+		{name: "single", input: []string{"theme=dark"}, want: map[string]string{"theme": "dark"}},
+		{name: "value with equals", input: []string{"opts=a=b"}, want: map[string]string{"opts": "a=b"}},
+		{name: "empty value", input: []string{"empty="}, want: map[string]string{"empty": ""}},
+		{name: "missing equals", input: []string{"theme"}, wantErr: true},
+		{name: "empty key", input: []string{"=dark"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDataFlags(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDataFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseDataFlags() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("parseDataFlags()[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderTemplateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.conf.tmpl")
+	content := "{{ .Hostname }} {{ .Distro }} {{ .User }} {{ .Data.theme }}"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	base := &platform.Platform{OS: platform.OSLinux, Hostname: "desktop", Distro: "arch", User: "me"}
+	plat := base.WithHostname("laptop").WithDistro("ubuntu").WithUser("work")
+
+	got, err := renderTemplateFile(path, plat, map[string]string{"theme": "dark"}, false)
+	if err != nil {
+		t.Fatalf("renderTemplateFile() error = %v", err)
+	}
+	if string(got) != "laptop ubuntu work dark" {
+		t.Errorf("renderTemplateFile() = %q, want %q", got, "laptop ubuntu work dark")
+	}
+
+	if _, err := renderTemplateFile(path, plat, nil, true); err == nil {
+		t.Error("renderTemplateFile() expected error for missing data key in strict mode")
+	}
+
+	if _, err := renderTemplateFile(filepath.Join(t.TempDir(), "missing.tmpl"), plat, nil, false); err == nil {
+		t.Error("renderTemplateFile() expected error for missing file")
+	}
+}
+
+func TestHostPlatform(t *testing.T) {
+	base := &platform.Platform{OS: platform.OSLinux, Hostname: "desktop", Distro: "arch", User: "me"}
+
+	got := hostPlatform(base, config.Host{Name: "work-pc", OS: platform.OSWindows, User: "corp"})
+	if got.Hostname != "work-pc" || got.OS != platform.OSWindows || got.User != "corp" {
+		t.Errorf("hostPlatform() = %+v, want overridden hostname, os and user", got)
+	}
+	if got.Distro != "arch" {
+		t.Errorf("hostPlatform().Distro = %q, want detected value %q", got.Distro, "arch")
+	}
+	if base.Hostname != "desktop" {
+		t.Error("hostPlatform() modified the base platform")
+	}
+}
+
+func TestRenderAllHosts_NoHosts(t *testing.T) {
+	base := &platform.Platform{OS: platform.OSLinux}
+	if err := renderAllHosts("unused.tmpl", base, nil, nil, false); err == nil {
+		t.Error("renderAllHosts() expected error when no hosts are defined")
+	}
+}
+
 // contains checks if substr is in s
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
@@ -235,3 +323,83 @@ func searchSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestSelectPackages(t *testing.T) {
+	pkgs := []packages.Package{{Name: "neovim"}, {Name: "ripgrep"}, {Name: "fzf"}}
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "no names selects all", want: []string{"neovim", "ripgrep", "fzf"}},
+		{name: "keeps config order", names: []string{"fzf", "neovim"}, want: []string{"neovim", "fzf"}},
+		{name: "unknown name", names: []string{"neovim", "emacs"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectPackages(pkgs, tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectPackages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("selectPackages() returned %d packages, want %d", len(got), len(tt.want))
+			}
+
+			for i, pkg := range got {
+				if pkg.Name != tt.want[i] {
+					t.Errorf("selectPackages()[%d] = %q, want %q", i, pkg.Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "YES\n", want: true},
+		{input: "n\n", want: false},
+		{input: "\n", want: false},
+		{input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := confirm(strings.NewReader(tt.input), &out, "Uninstall?"); got != tt.want {
+			t.Errorf("confirm(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+
+		if out.String() != "Uninstall? [y/N] " {
+			t.Errorf("confirm() prompt = %q, want %q", out.String(), "Uninstall? [y/N] ")
+		}
+	}
+}
+
+func TestCheckUninstallArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		all     bool
+		wantErr bool
+	}{
+		{name: "no names without --all", wantErr: true},
+		{name: "names", args: []string{"neovim"}},
+		{name: "--all", all: true},
+		{name: "names with --all", args: []string{"neovim"}, all: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkUninstallArgs(tt.args, tt.all); (err != nil) != tt.wantErr {
+				t.Errorf("checkUninstallArgs(%v, %v) error = %v, wantErr %v", tt.args, tt.all, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -10,10 +10,19 @@ import (
 )
 
 // AppConfig is the minimal configuration stored in ~/.config/tidydots/
-// It only contains the path to the configurations repository
+// It contains the path to the configurations repository and machine-local
+// secrets that must never be committed to it
 type AppConfig struct {
 	// ConfigDir is the path to the configurations repository
 	ConfigDir string `yaml:"config_dir"`
+
+	// EncryptionKeyFile is the path to a file holding the key used for
+	// encrypted backup files. Takes precedence over EncryptionPassphrase.
+	EncryptionKeyFile string `yaml:"encryption_key_file,omitempty"`
+
+	// EncryptionPassphrase is used to derive keys for encrypted backup files
+	// when no key file is configured.
+	EncryptionPassphrase string `yaml:"encryption_passphrase,omitempty"`
 }
 
 const (
@@ -80,7 +89,7 @@ func SaveAppConfig(cfg *AppConfig) error {
 	}
 
 	// Add a header comment
-	content := fmt.Sprintf("# tidydots app configuration\n# This file only stores the path to your configurations repository\n\n%s", string(data))
+	content := fmt.Sprintf("# tidydots app configuration\n# This file stores the path to your configurations repository and machine-local settings\n\n%s", string(data))
 
 	// Use 0600 permissions to restrict access to owner only
 	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
@@ -18,12 +18,25 @@ type Config struct {
 	DefaultManager  string        `yaml:"default_manager,omitempty"`
 	ManagerPriority []string      `yaml:"manager_priority,omitempty"`
 	Applications    []Application `yaml:"applications,omitempty"`
+	Hosts           []Host        `yaml:"hosts,omitempty"`
+	Strict          bool          `yaml:"strict,omitempty"`
+}
+
+// Host describes a known machine used to preview templates for every host
+// before pushing. Empty fields fall back to the current platform's values.
+type Host struct {
+	Data   map[string]string `yaml:"data,omitempty"`
+	Name   string            `yaml:"name"`
+	OS     string            `yaml:"os,omitempty"`
+	Distro string            `yaml:"distro,omitempty"`
+	User   string            `yaml:"user,omitempty"`
 }
 
 // URLInstallSpec defines URL-based installation
 type URLInstallSpec struct {
 	URL     string `yaml:"url"`
-	Command string `yaml:"command"` // Use {file} as placeholder for downloaded file
+	Command string `yaml:"command"`          // Use {file} as placeholder for downloaded file
+	SHA256  string `yaml:"sha256,omitempty"` // Checksum the download must match, in hex
 }
 
 // Load reads and parses the configuration file from the given path.
@@ -151,17 +164,29 @@ type PathRenderer interface {
 // then performs standard ~ and env var expansion. If the path contains no {{ delimiters,
 // it falls back directly to ExpandPath for backward compatibility.
 func ExpandPathWithTemplate(path string, envVars map[string]string, renderer PathRenderer) string {
-	if path == "" || renderer == nil || !strings.Contains(path, "{{") {
+	expanded, err := RenderPath(path, envVars, renderer)
+	if err != nil {
+		slog.Warn("template rendering failed, falling back to path expansion", "path", path, "error", err)
 		return ExpandPath(path, envVars)
 	}
 
+	return expanded
+}
+
+// RenderPath renders Go template expressions in the path and expands ~ and env
+// vars like ExpandPathWithTemplate, but returns the render error instead of
+// falling back to the raw path.
+func RenderPath(path string, envVars map[string]string, renderer PathRenderer) (string, error) {
+	if path == "" || renderer == nil || !strings.Contains(path, "{{") {
+		return ExpandPath(path, envVars), nil
+	}
+
 	rendered, err := renderer.RenderString("path", path)
 	if err != nil {
-		slog.Warn("template rendering failed, falling back to path expansion", "path", path, "error", err)
-		return ExpandPath(path, envVars)
+		return "", err
 	}
 
-	return ExpandPath(rendered, envVars)
+	return ExpandPath(rendered, envVars), nil
 }
 
 // ExpandPath expands ~ and environment variables in a single path.
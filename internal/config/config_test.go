@@ -1114,6 +1114,99 @@ applications:
 	}
 }
 
+func TestLoadWithFlatpakAndSnapPackages(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	configContent := `
+version: 3
+
+applications:
+  - name: firefox
+    package:
+      managers:
+        apt: firefox
+        flatpak:
+          name: org.mozilla.firefox
+          remote: flathub
+          scope: user
+  - name: code
+    package:
+      managers:
+        flatpak: com.visualstudio.code
+        snap:
+          name: code
+          classic: true
+          channel: latest/stable
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	firefox := cfg.Applications[0].Package.Managers["flatpak"]
+	if firefox.PackageName != "org.mozilla.firefox" {
+		t.Errorf("flatpak PackageName = %q, want org.mozilla.firefox", firefox.PackageName)
+	}
+	if firefox.Flatpak == nil || *firefox.Flatpak != (FlatpakOptions{Remote: "flathub", Scope: FlatpakScopeUser}) {
+		t.Errorf("flatpak options = %+v, want remote flathub, scope user", firefox.Flatpak)
+	}
+
+	code := cfg.Applications[1].Package
+	if name, ok := code.GetManagerString("flatpak"); !ok || name != "com.visualstudio.code" {
+		t.Errorf("GetManagerString(flatpak) = %q, %v, want com.visualstudio.code, true", name, ok)
+	}
+	if name, ok := code.GetManagerString("snap"); !ok || name != "code" {
+		t.Errorf("GetManagerString(snap) = %q, %v, want code, true", name, ok)
+	}
+	if snap := code.Managers["snap"].Snap; snap == nil || !snap.Classic || snap.Channel != "latest/stable" {
+		t.Errorf("snap options = %+v, want classic on latest/stable", snap)
+	}
+}
+
+func TestValidateConfig_FlatpakOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   ManagerValue
+		wantErr bool
+	}{
+		{"plain name", ManagerValue{PackageName: "org.gimp.GIMP"}, false},
+		{"user scope", ManagerValue{PackageName: "org.gimp.GIMP", Flatpak: &FlatpakOptions{Scope: "user"}}, false},
+		{"unknown scope", ManagerValue{PackageName: "org.gimp.GIMP", Flatpak: &FlatpakOptions{Scope: "global"}}, true},
+		{"object without name", ManagerValue{Snap: &SnapOptions{Classic: true}}, true},
+		{"version constraint", ManagerValue{PackageName: "org.gimp.GIMP", Version: ">=2.10"}, false},
+		{"invalid version constraint", ManagerValue{PackageName: "org.gimp.GIMP", Version: "<2.10"}, true},
+		{"version without name", ManagerValue{Version: "2.10.36"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{
+				Version:    3,
+				BackupRoot: "/backup",
+				Applications: []Application{{
+					Name:    "app",
+					Package: &EntryPackage{Managers: map[string]ManagerValue{"flatpak": tt.value}},
+				}},
+			}
+
+			err := ValidateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestManagerValueMarshalYAML(t *testing.T) {
 	t.Parallel()
 
@@ -1137,6 +1230,60 @@ func TestManagerValueMarshalYAML(t *testing.T) {
 		}
 	})
 
+	t.Run("flatpak and snap options marshal as objects", func(t *testing.T) {
+		t.Parallel()
+		ep := EntryPackage{
+			Managers: map[string]ManagerValue{
+				"flatpak": {PackageName: "org.gimp.GIMP", Flatpak: &FlatpakOptions{Remote: "flathub"}},
+				"snap":    {PackageName: "code", Snap: &SnapOptions{Classic: true}},
+			},
+		}
+
+		out, err := yaml.Marshal(&ep)
+		if err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		}
+
+		var ep2 EntryPackage
+		if err := yaml.Unmarshal(out, &ep2); err != nil {
+			t.Fatalf("Unmarshal error: %v\n%s", err, out)
+		}
+
+		if v := ep2.Managers["flatpak"]; v.PackageName != "org.gimp.GIMP" || v.Flatpak == nil || v.Flatpak.Remote != "flathub" {
+			t.Errorf("Round-trip flatpak = %+v, want org.gimp.GIMP from flathub", v)
+		}
+		if v := ep2.Managers["snap"]; v.PackageName != "code" || v.Snap == nil || !v.Snap.Classic {
+			t.Errorf("Round-trip snap = %+v, want classic code", v)
+		}
+	})
+
+	t.Run("version constraint marshals as object", func(t *testing.T) {
+		t.Parallel()
+		ep := EntryPackage{
+			Managers: map[string]ManagerValue{
+				"apt":     {PackageName: "ripgrep", Version: "14.1.*"},
+				"flatpak": {PackageName: "org.gimp.GIMP", Version: ">=2.10", Flatpak: &FlatpakOptions{Remote: "flathub"}},
+			},
+		}
+
+		out, err := yaml.Marshal(&ep)
+		if err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		}
+
+		var ep2 EntryPackage
+		if err := yaml.Unmarshal(out, &ep2); err != nil {
+			t.Fatalf("Unmarshal error: %v\n%s", err, out)
+		}
+
+		if v := ep2.Managers["apt"]; v.PackageName != "ripgrep" || v.Version != "14.1.*" {
+			t.Errorf("Round-trip apt = %+v, want ripgrep 14.1.*", v)
+		}
+		if v := ep2.Managers["flatpak"]; v.Version != ">=2.10" || v.Flatpak == nil || v.Flatpak.Remote != "flathub" {
+			t.Errorf("Round-trip flatpak = %+v, want >=2.10 from flathub", v)
+		}
+	})
+
 	t.Run("installer manager marshals as object", func(t *testing.T) {
 		t.Parallel()
 		ep := EntryPackage{
@@ -1217,3 +1364,120 @@ func TestManagerValueMarshalYAML(t *testing.T) {
 		}
 	})
 }
+
+func TestVersionConstraint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		constraint string
+		installed  string
+		want       bool
+	}{
+		{"", "1.0.0", true},
+		{"14.1.0", "14.1.0", true},
+		{"=14.1.0", "14.1.0", true},
+		{"14.1.0", "14.1.1", false},
+		{"2.43.0", "1:2.43.0-1ubuntu7", true},
+		{"1:2.43.0-1ubuntu7", "1:2.43.0-1ubuntu7", true},
+		{"1:2.43.0-1ubuntu7", "1:2.43.0-1ubuntu8", false},
+		{"1.21.5", "v1.21.5", true},
+		{"14.1.*", "14.1.3", true},
+		{"14.1.*", "14.10.0", false},
+		{"14.*", "14", true},
+		{">=1.9", "1.10.0", true},
+		{">=1.10", "1.9.2", false},
+		{">=2.0", "2.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint+"/"+tt.installed, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := ParseVersionConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseVersionConstraint(%q) error = %v", tt.constraint, err)
+			}
+
+			if got := c.Allows(tt.installed); got != tt.want {
+				t.Errorf("%q.Allows(%q) = %v, want %v", tt.constraint, tt.installed, got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{">=", ".*", "<1.0", "1.*.2", "1.0 2.0"} {
+		if _, err := ParseVersionConstraint(invalid); err == nil {
+			t.Errorf("ParseVersionConstraint(%q) error = nil, want error", invalid)
+		}
+	}
+}
+
+func TestValidateConfig_URLChecksum(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		sha256  string
+		wantErr bool
+	}{
+		{"no checksum", "", false},
+		{"valid checksum", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", false},
+		{"uppercase checksum", "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08", false},
+		{"too short", "9f86d081", true},
+		{"not hex", strings.Repeat("z", 64), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{
+				Version:    3,
+				BackupRoot: "/backup",
+				Applications: []Application{{
+					Name: "app",
+					Package: &EntryPackage{URL: map[string]URLInstallSpec{
+						"linux": {URL: "https://example.com/install.sh", Command: "sh {file}", SHA256: tt.sha256},
+					}},
+				}},
+			}
+
+			err := ValidateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_HostOS(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		os      string
+		wantErr bool
+	}{
+		{"no os", "", false},
+		{"linux", "linux", false},
+		{"darwin", "darwin", false},
+		{"misspelled macos", "macos", true},
+		{"wrong case", "Windows", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{
+				Version:    3,
+				BackupRoot: "/backup",
+				Hosts:      []Host{{Name: "laptop", OS: tt.os}},
+			}
+
+			err := ValidateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
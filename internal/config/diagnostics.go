@@ -0,0 +1,47 @@
+package config
+
+// CheckTemplates renders every template used in the configuration for the
+// given machine and returns one *TemplateError per failure. It checks application
+// when expressions, then the backup and target paths of config entries in
+// applications that match. Applications whose when evaluates to false are
+// skipped, since their paths may rely on values only present on matching hosts.
+func (c *Config) CheckTemplates(renderer PathRenderer, mc Machine) []error {
+	if renderer == nil {
+		return nil
+	}
+
+	var errs []error
+
+	for _, app := range c.Applications {
+		match, err := EvaluateWhenWithError(app.When, renderer)
+		if err != nil {
+			errs = append(errs, &TemplateError{Application: app.Name, Field: "when", Value: app.When, Err: err})
+			continue
+		}
+
+		if !match {
+			continue
+		}
+
+		for _, entry := range app.Entries {
+			if !entry.IsConfig() {
+				continue
+			}
+
+			if _, err := RenderPath(entry.Backup, nil, renderer); err != nil {
+				errs = append(errs, &TemplateError{
+					Application: app.Name, Entry: entry.Name, Field: "backup", Value: entry.Backup, Err: err,
+				})
+			}
+
+			target, key := selectTarget(entry.Targets, mc)
+			if _, err := RenderPath(target, nil, renderer); err != nil {
+				errs = append(errs, &TemplateError{
+					Application: app.Name, Entry: entry.Name, Field: "targets." + key, Value: target, Err: err,
+				})
+			}
+		}
+	}
+
+	return errs
+}
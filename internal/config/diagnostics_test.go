@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// typoRenderer fails on any template containing "Hostnme" and renders
+// everything else to "true".
+type typoRenderer struct{}
+
+func (typoRenderer) RenderString(_, tmplStr string) (string, error) {
+	if strings.Contains(tmplStr, "Hostnme") {
+		return "", errors.New("can't evaluate field Hostnme")
+	}
+
+	return "true", nil
+}
+
+func TestCheckTemplates(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Applications: []Application{
+			{
+				Name: "broken-when",
+				When: `{{ eq .Hostnme "desktop" }}`,
+				Entries: []SubEntry{
+					{Name: "skipped", Backup: "./{{ .Hostnme }}", Targets: map[string]string{"linux": "~/x"}},
+				},
+			},
+			{
+				Name: "nvim",
+				Entries: []SubEntry{
+					{Name: "config", Backup: "./nvim", Targets: map[string]string{"linux": "~/.config/{{ .Hostnme }}"}},
+					{Name: "bad-backup", Backup: "./{{ .Hostnme }}/zsh", Targets: map[string]string{"linux": "~/.zshrc"}},
+					{Name: "other-os", Backup: "./ps", Targets: map[string]string{"windows": "{{ .Hostnme }}"}},
+				},
+			},
+		},
+	}
+
+	errs := cfg.CheckTemplates(typoRenderer{}, Machine{OS: "linux"})
+	if len(errs) != 3 {
+		t.Fatalf("CheckTemplates() returned %d errors, want 3: %v", len(errs), errs)
+	}
+
+	want := []struct{ app, entry, field string }{
+		{"broken-when", "", "when"},
+		{"nvim", "config", "targets.linux"},
+		{"nvim", "bad-backup", "backup"},
+	}
+
+	for i, w := range want {
+		var tmplErr *TemplateError
+		if !errors.As(errs[i], &tmplErr) {
+			t.Fatalf("errs[%d] = %T, want *TemplateError", i, errs[i])
+		}
+
+		if tmplErr.Application != w.app || tmplErr.Entry != w.entry || tmplErr.Field != w.field {
+			t.Errorf("errs[%d] = {%s %s %s}, want {%s %s %s}",
+				i, tmplErr.Application, tmplErr.Entry, tmplErr.Field, w.app, w.entry, w.field)
+		}
+	}
+}
+
+func TestCheckTemplates_NilRenderer(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{Applications: []Application{{Name: "a", When: "{{ .Hostnme }}"}}}
+	if errs := cfg.CheckTemplates(nil, Machine{OS: "linux"}); len(errs) != 0 {
+		t.Errorf("CheckTemplates(nil) = %v, want no errors", errs)
+	}
+}
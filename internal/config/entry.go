@@ -9,11 +9,16 @@ import (
 // ManagerValue represents a typed value for a package manager entry.
 // It holds either a package name string (for traditional managers like pacman, apt),
 // a GitPackage configuration (for git repositories), or an InstallerPackage
-// configuration (for shell command-based installation).
+// configuration (for shell command-based installation). Flatpak and snap
+// packages may also carry install options alongside their name, and any
+// named package may carry a version constraint (see ParseVersionConstraint).
 type ManagerValue struct {
 	PackageName string
+	Version     string
 	Git         *GitPackage
 	Installer   *InstallerPackage
+	Flatpak     *FlatpakOptions
+	Snap        *SnapOptions
 }
 
 // IsGit returns true if this manager value represents a git package configuration.
@@ -22,7 +27,8 @@ func (v ManagerValue) IsGit() bool { return v.Git != nil }
 // IsInstaller returns true if this manager value represents an installer package configuration.
 func (v ManagerValue) IsInstaller() bool { return v.Installer != nil }
 
-// MarshalYAML writes non-git/non-installer manager values as plain strings
+// MarshalYAML writes non-git/non-installer manager values as plain strings,
+// or as objects when a version or flatpak or snap options are set
 func (v ManagerValue) MarshalYAML() (interface{}, error) {
 	if v.IsGit() {
 		return v.Git, nil
@@ -32,9 +38,58 @@ func (v ManagerValue) MarshalYAML() (interface{}, error) {
 		return v.Installer, nil
 	}
 
+	if v.Flatpak != nil && !v.Flatpak.IsZero() {
+		return struct {
+			Name           string `yaml:"name"`
+			Version        string `yaml:"version,omitempty"`
+			FlatpakOptions `yaml:",inline"`
+		}{v.PackageName, v.Version, *v.Flatpak}, nil
+	}
+
+	if v.Snap != nil && !v.Snap.IsZero() {
+		return struct {
+			Name        string `yaml:"name"`
+			Version     string `yaml:"version,omitempty"`
+			SnapOptions `yaml:",inline"`
+		}{v.PackageName, v.Version, *v.Snap}, nil
+	}
+
+	if v.Version != "" {
+		return struct {
+			Name    string `yaml:"name"`
+			Version string `yaml:"version"`
+		}{v.PackageName, v.Version}, nil
+	}
+
 	return v.PackageName, nil
 }
 
+// Flatpak installation scopes.
+const (
+	FlatpakScopeUser   = "user"
+	FlatpakScopeSystem = "system"
+)
+
+// FlatpakOptions holds the install options of a flatpak package, written as
+// {name, remote, scope} instead of a plain application ID.
+type FlatpakOptions struct {
+	Remote string `yaml:"remote,omitempty"` // e.g. "flathub"; flatpak asks when unset and ambiguous
+	Scope  string `yaml:"scope,omitempty"`  // "user" or "system"; flatpak's default when unset
+}
+
+// IsZero returns true when no option is set.
+func (o FlatpakOptions) IsZero() bool { return o == FlatpakOptions{} }
+
+// SnapOptions holds the install options of a snap package, written as
+// {name, classic, channel} instead of a plain snap name.
+type SnapOptions struct {
+	Channel string `yaml:"channel,omitempty"` // e.g. "latest/stable" or "edge"
+	Classic bool   `yaml:"classic,omitempty"` // install with classic confinement
+}
+
+// IsZero returns true when no option is set.
+func (o SnapOptions) IsZero() bool { return o == SnapOptions{} }
+
 // EntryPackage contains package installation configuration
 type EntryPackage struct {
 	Managers map[string]ManagerValue   `yaml:"managers,omitempty"` // manager -> package name or GitPackage
@@ -50,12 +105,20 @@ type GitPackage struct {
 	Sudo    bool              `yaml:"sudo,omitempty"`
 }
 
+// TargetFor returns the clone destination for a machine, using the most
+// specific matching key (see SelectTarget).
+func (g *GitPackage) TargetFor(mc Machine) string {
+	return SelectTarget(g.Targets, mc)
+}
+
 // InstallerPackage represents a shell command-based package installation configuration.
 // Command is an OS-specific map of shell commands to run for installation.
 // Binary is an optional name used to check if the software is already installed via PATH lookup.
+// Uninstall is an optional OS-specific map of shell commands that remove the software.
 type InstallerPackage struct {
-	Command map[string]string `yaml:"command"`
-	Binary  string            `yaml:"binary,omitempty"`
+	Command   map[string]string `yaml:"command"`
+	Binary    string            `yaml:"binary,omitempty"`
+	Uninstall map[string]string `yaml:"uninstall,omitempty"`
 }
 
 // UnmarshalYAML implements custom YAML unmarshaling for EntryPackage
@@ -117,12 +180,14 @@ func (ep *EntryPackage) UnmarshalYAML(node *yaml.Node) error {
 				ep.Managers[key] = ManagerValue{Installer: &installerPkg}
 
 			default:
-				// Traditional managers are strings
-				str, ok := value.(string)
-				if !ok {
-					return fmt.Errorf("manager %s must be a string, got %T", key, value)
+				// Traditional managers are strings, or objects with a name
+				// and a version or flatpak/snap options
+				mv, err := decodeOptionsManager(key, value)
+				if err != nil {
+					return err
 				}
-				ep.Managers[key] = ManagerValue{PackageName: str}
+
+				ep.Managers[key] = mv
 			}
 		}
 	}
@@ -133,6 +198,52 @@ func (ep *EntryPackage) UnmarshalYAML(node *yaml.Node) error {
 	return nil
 }
 
+// decodeOptionsManager decodes a package manager value, given either as a
+// package name or as an object with a name, an optional version constraint
+// and, for flatpak and snap, install options.
+func decodeOptionsManager(key string, value interface{}) (ManagerValue, error) {
+	if str, ok := value.(string); ok {
+		return ManagerValue{PackageName: str}, nil
+	}
+
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return ManagerValue{}, fmt.Errorf("manager %s must be a string or an object, got %T", key, value)
+	}
+
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return ManagerValue{}, fmt.Errorf("marshaling %s config: %w", key, err)
+	}
+
+	var named struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &named); err != nil {
+		return ManagerValue{}, fmt.Errorf("unmarshaling %s config: %w", key, err)
+	}
+
+	mv := ManagerValue{PackageName: named.Name, Version: named.Version}
+
+	switch key {
+	case "flatpak":
+		var opts FlatpakOptions
+		if err := yaml.Unmarshal(data, &opts); err != nil {
+			return ManagerValue{}, fmt.Errorf("unmarshaling %s config: %w", key, err)
+		}
+		mv.Flatpak = &opts
+	case "snap":
+		var opts SnapOptions
+		if err := yaml.Unmarshal(data, &opts); err != nil {
+			return ManagerValue{}, fmt.Errorf("unmarshaling %s config: %w", key, err)
+		}
+		mv.Snap = &opts
+	}
+
+	return mv, nil
+}
+
 // GetManagerString returns the manager value as a string, or empty string if not found or not a string
 func (ep *EntryPackage) GetManagerString(manager string) (string, bool) {
 	if ep.Managers == nil {
@@ -188,11 +299,17 @@ type Application struct {
 
 // SubEntry represents an individual configuration entry within an application
 type SubEntry struct {
-	Targets map[string]string `yaml:"targets,omitempty"`
-	Name    string            `yaml:"name"`
-	Backup  string            `yaml:"backup,omitempty"`
-	Files   []string          `yaml:"files,omitempty"`
-	Sudo    bool              `yaml:"sudo,omitempty"`
+	Targets   map[string]string `yaml:"targets,omitempty"`
+	Name      string            `yaml:"name"`
+	Backup    string            `yaml:"backup,omitempty"`
+	Files     []string          `yaml:"files,omitempty"`
+	Sudo      bool              `yaml:"sudo,omitempty"`
+	Encrypted bool              `yaml:"encrypted,omitempty"` // store files as .enc ciphertext in the backup
+	Ignore    []string          `yaml:"ignore,omitempty"`    // glob patterns left out of folder entries
+	// Permissions applied to every managed file, declared as mode/owner/group on the entry
+	Permissions `yaml:",inline"`
+	// FilePermissions overrides Permissions for files matching a path relative to the entry
+	FilePermissions map[string]Permissions `yaml:"file_permissions,omitempty"`
 }
 
 // IsConfig returns true if this is a config type sub-entry
@@ -205,13 +322,16 @@ func (s *SubEntry) IsFolder() bool {
 	return s.IsConfig() && len(s.Files) == 0
 }
 
-// GetTarget returns the target path for the specified OS
+// GetTarget returns the target path for the specified OS, considering only
+// plain OS keys. Use TargetFor to also match distro, host and WSL keys.
 func (s *SubEntry) GetTarget(osType string) string {
-	if target, ok := s.Targets[osType]; ok {
-		return target
-	}
+	return s.TargetFor(Machine{OS: osType})
+}
 
-	return ""
+// TargetFor returns the target path for a machine, using the most specific
+// matching key (see SelectTarget).
+func (s *SubEntry) TargetFor(mc Machine) string {
+	return SelectTarget(s.Targets, mc)
 }
 
 // HasPackage returns true if the application has package installation configuration
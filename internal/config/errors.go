@@ -9,6 +9,7 @@ import (
 var (
 	ErrUnsupportedVersion = errors.New("unsupported config version")
 	ErrInvalidConfig      = errors.New("invalid configuration")
+	ErrInvalidMode        = errors.New("invalid file mode")
 )
 
 // FieldError represents a validation error for a specific field
@@ -36,3 +37,25 @@ func NewFieldError(entry, field, value string, err error) *FieldError {
 		Err:   err,
 	}
 }
+
+// TemplateError reports a template that failed to render in a config field
+// such as an application's when expression or an entry's backup or target path.
+type TemplateError struct {
+	Err         error
+	Application string
+	Entry       string
+	Field       string
+	Value       string
+}
+
+func (e *TemplateError) Error() string {
+	if e.Entry == "" {
+		return fmt.Sprintf("application %s: %s %q: %v", e.Application, e.Field, e.Value, e.Err)
+	}
+
+	return fmt.Sprintf("application %s, entry %s: %s %q: %v", e.Application, e.Entry, e.Field, e.Value, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
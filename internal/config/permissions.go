@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Permissions declares the mode and ownership of managed files. Empty fields
+// leave the corresponding attribute as it is.
+type Permissions struct {
+	Mode  string `yaml:"mode,omitempty"`  // octal permission bits, e.g. "0600"
+	Owner string `yaml:"owner,omitempty"` // user name or numeric uid
+	Group string `yaml:"group,omitempty"` // group name or numeric gid
+}
+
+// IsZero reports whether no attribute is declared.
+func (p Permissions) IsZero() bool {
+	return p.Mode == "" && p.Owner == "" && p.Group == ""
+}
+
+// Merge returns p with the attributes declared in over replacing its own.
+func (p Permissions) Merge(over Permissions) Permissions {
+	if over.Mode != "" {
+		p.Mode = over.Mode
+	}
+
+	if over.Owner != "" {
+		p.Owner = over.Owner
+	}
+
+	if over.Group != "" {
+		p.Group = over.Group
+	}
+
+	return p
+}
+
+// FileMode parses Mode. It returns false when no mode is declared.
+func (p Permissions) FileMode() (fs.FileMode, bool, error) {
+	if p.Mode == "" {
+		return 0, false, nil
+	}
+
+	mode, err := ParseMode(p.Mode)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return mode, true, nil
+}
+
+// ParseMode parses an octal permission string such as "0600" or "755".
+func ParseMode(s string) (fs.FileMode, error) {
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 0o7777 {
+		return 0, fmt.Errorf("%w: %q (expected octal such as \"0600\")", ErrInvalidMode, s)
+	}
+
+	mode := fs.FileMode(n).Perm()
+	if n&0o4000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if n&0o2000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if n&0o1000 != 0 {
+		mode |= fs.ModeSticky
+	}
+
+	return mode, nil
+}
+
+// FormatMode formats the permission bits of mode the way ParseMode reads them.
+func FormatMode(mode fs.FileMode) string {
+	n := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		n |= 0o4000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		n |= 0o2000
+	}
+	if mode&fs.ModeSticky != 0 {
+		n |= 0o1000
+	}
+
+	return fmt.Sprintf("%04o", n)
+}
+
+// PermissionsFor returns the permissions declared for a managed file, given
+// its path relative to the entry. Entry-level permissions apply to every file;
+// file_permissions keys matching rel override them, glob keys first (in sorted
+// order) and an exact key last.
+func (s *SubEntry) PermissionsFor(rel string) Permissions {
+	perms := s.Permissions
+	rel = filepath.ToSlash(rel)
+
+	keys := make([]string, 0, len(s.FilePermissions))
+	for k := range s.FilePermissions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if k == rel {
+			continue
+		}
+
+		if ok, _ := path.Match(k, rel); ok { //nolint:errcheck // bad patterns never match
+			perms = perms.Merge(s.FilePermissions[k])
+		}
+	}
+
+	if exact, ok := s.FilePermissions[rel]; ok {
+		perms = perms.Merge(exact)
+	}
+
+	return perms
+}
+
+// HasPermissions reports whether the entry declares any permissions.
+func (s *SubEntry) HasPermissions() bool {
+	return !s.Permissions.IsZero() || len(s.FilePermissions) > 0
+}
+
+// validatePermissions checks the modes and file_permissions keys of an entry.
+func (s *SubEntry) validatePermissions() error {
+	if _, _, err := s.Permissions.FileMode(); err != nil {
+		return err
+	}
+
+	for k, p := range s.FilePermissions {
+		if _, err := path.Match(k, ""); err != nil {
+			return fmt.Errorf("file_permissions %q: %w", k, err)
+		}
+
+		if _, _, err := p.FileMode(); err != nil {
+			return fmt.Errorf("file_permissions %q: %w", k, err)
+		}
+	}
+
+	return nil
+}
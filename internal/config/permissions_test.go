@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    fs.FileMode
+		wantErr bool
+	}{
+		{"0600", 0o600, false},
+		{"755", 0o755, false},
+		{"4755", 0o755 | fs.ModeSetuid, false},
+		{"1777", 0o777 | fs.ModeSticky, false},
+		{"0800", 0, true},
+		{"17777", 0, true},
+		{"rw-r--r--", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseMode(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMode) {
+					t.Errorf("ParseMode(%q) error = %v, want ErrInvalidMode", tt.in, err)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("ParseMode(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			}
+			if s := FormatMode(got); s != "0"+tt.in && s != tt.in {
+				t.Errorf("FormatMode(%v) = %q, want %q", got, s, tt.in)
+			}
+		})
+	}
+}
+
+func TestSubEntry_PermissionsFor(t *testing.T) {
+	t.Parallel()
+
+	entry := SubEntry{
+		Permissions: Permissions{Mode: "0644", Owner: "root"},
+		FilePermissions: map[string]Permissions{
+			"*.key":        {Mode: "0600"},
+			"ssh/*":        {Group: "ssh"},
+			"ssh/host.key": {Mode: "0640"},
+		},
+	}
+
+	tests := []struct {
+		rel  string
+		want Permissions
+	}{
+		{"app.conf", Permissions{Mode: "0644", Owner: "root"}},
+		{"tls.key", Permissions{Mode: "0600", Owner: "root"}},
+		{"ssh/config", Permissions{Mode: "0644", Owner: "root", Group: "ssh"}},
+		{"ssh/host.key", Permissions{Mode: "0640", Owner: "root", Group: "ssh"}},
+	}
+
+	for _, tt := range tests {
+		if got := entry.PermissionsFor(tt.rel); got != tt.want {
+			t.Errorf("PermissionsFor(%q) = %+v, want %+v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestSubEntry_PermissionsYAML(t *testing.T) {
+	t.Parallel()
+
+	data := `
+name: sshd
+mode: "0600"
+owner: root
+file_permissions:
+  banner:
+    mode: "0644"
+`
+
+	var entry SubEntry
+	if err := yaml.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Mode != "0600" || entry.Owner != "root" || entry.FilePermissions["banner"].Mode != "0644" {
+		t.Errorf("unmarshaled %+v", entry)
+	}
+	if err := entry.validatePermissions(); err != nil {
+		t.Errorf("validatePermissions() error = %v", err)
+	}
+
+	entry.FilePermissions["banner"] = Permissions{Mode: "rw"}
+	if err := entry.validatePermissions(); !errors.Is(err, ErrInvalidMode) {
+		t.Errorf("validatePermissions() error = %v, want ErrInvalidMode", err)
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AntoineGS/tidydots/internal/platform"
+)
+
+// Target key forms, from most to least specific. A target map may mix them;
+// the most specific key matching the machine wins.
+//
+//	host:<hostname>  one machine, compared case-insensitively
+//	<os>/<distro>    one Linux distribution, e.g. "linux/arch"
+//	wsl              Linux running under WSL
+//	<os>             every machine running the OS, e.g. "linux"
+//
+// macOS ("darwin") machines fall back to "linux" keys, which they matched
+// before macOS was a separate OS.
+const (
+	hostKeyPrefix = "host:"
+	wslKey        = "wsl"
+)
+
+// osFallback maps an OS to the key used for it when it has none of its own.
+var osFallback = map[string]string{"darwin": "linux"}
+
+// OSKeys returns the keys of an OS-keyed map that apply to osType, most
+// specific first.
+func OSKeys(osType string) []string {
+	if fallback, ok := osFallback[osType]; ok {
+		return []string{osType, fallback}
+	}
+
+	return []string{osType}
+}
+
+// ForOS looks up osType in an OS-keyed map such as a package's custom
+// commands, honoring OS fallbacks.
+func ForOS[V any](values map[string]V, osType string) (V, bool) {
+	for _, key := range OSKeys(osType) {
+		if v, ok := values[key]; ok {
+			return v, true
+		}
+	}
+
+	var zero V
+
+	return zero, false
+}
+
+// Machine identifies the computer target keys are matched against.
+type Machine struct {
+	OS       string
+	Distro   string
+	Hostname string
+	WSL      bool
+}
+
+// NewMachine returns the attributes of plat that target keys are matched
+// against.
+func NewMachine(plat *platform.Platform) Machine {
+	return Machine{OS: plat.OS, Distro: plat.Distro, Hostname: plat.Hostname, WSL: plat.IsWSL}
+}
+
+// SelectTarget returns the value of the most specific key in targets that
+// matches mc, or "" when no key matches.
+func SelectTarget(targets map[string]string, mc Machine) string {
+	target, _ := selectTarget(targets, mc)
+	return target
+}
+
+// selectTarget is SelectTarget that also returns the matching key.
+func selectTarget(targets map[string]string, mc Machine) (string, string) {
+	if len(targets) == 0 {
+		return "", ""
+	}
+
+	if mc.Hostname != "" {
+		for key, target := range targets {
+			if host, ok := strings.CutPrefix(key, hostKeyPrefix); ok && strings.EqualFold(host, mc.Hostname) {
+				return target, key
+			}
+		}
+	}
+
+	var candidates []string
+	if mc.Distro != "" {
+		candidates = append(candidates, mc.OS+"/"+mc.Distro)
+	}
+	if mc.WSL {
+		candidates = append(candidates, wslKey)
+	}
+	candidates = append(candidates, OSKeys(mc.OS)...)
+
+	for _, key := range candidates {
+		if target, ok := targets[key]; ok {
+			return target, key
+		}
+	}
+
+	return "", ""
+}
+
+// validateTargetKey checks that key has one of the supported forms.
+func validateTargetKey(key string) error {
+	switch {
+	case key == wslKey:
+		return nil
+	case strings.HasPrefix(key, hostKeyPrefix):
+		if strings.TrimPrefix(key, hostKeyPrefix) == "" {
+			return fmt.Errorf("target key %q: missing hostname", key)
+		}
+		return nil
+	}
+
+	osName, distro, hasDistro := strings.Cut(key, "/")
+	if osName == "" || (hasDistro && (distro == "" || strings.Contains(distro, "/"))) {
+		return fmt.Errorf("target key %q: expected <os>, <os>/<distro>, host:<hostname> or wsl", key)
+	}
+
+	return nil
+}
+
+// validateTargets checks every key of a target map. Host keys differing only
+// in case are rejected, since hostnames are matched case-insensitively and
+// either could be selected.
+func validateTargets(targets map[string]string) error {
+	hosts := make(map[string]string)
+
+	for key := range targets {
+		if err := validateTargetKey(key); err != nil {
+			return err
+		}
+
+		host, ok := strings.CutPrefix(key, hostKeyPrefix)
+		if !ok {
+			continue
+		}
+
+		if other, dup := hosts[strings.ToLower(host)]; dup {
+			return fmt.Errorf("target keys %q and %q name the same host", other, key)
+		}
+
+		hosts[strings.ToLower(host)] = key
+	}
+
+	return nil
+}
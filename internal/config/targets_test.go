@@ -0,0 +1,81 @@
+package config
+
+import "testing"
+
+func TestSelectTarget(t *testing.T) {
+	t.Parallel()
+
+	targets := map[string]string{
+		"linux":            "~/.config/app",
+		"linux/arch":       "~/.config/app-arch",
+		"wsl":              "~/.config/app-wsl",
+		"host:work-laptop": "~/work/app",
+		"windows":          "~/AppData/app",
+	}
+
+	tests := []struct {
+		name string
+		mc   Machine
+		want string
+	}{
+		{"os only", Machine{OS: "linux", Distro: "ubuntu", Hostname: "desktop"}, "~/.config/app"},
+		{"distro beats os", Machine{OS: "linux", Distro: "arch", Hostname: "desktop"}, "~/.config/app-arch"},
+		{"distro beats wsl", Machine{OS: "linux", Distro: "arch", WSL: true}, "~/.config/app-arch"},
+		{"wsl beats os", Machine{OS: "linux", Distro: "ubuntu", WSL: true}, "~/.config/app-wsl"},
+		{"host beats everything", Machine{OS: "linux", Distro: "arch", Hostname: "Work-Laptop", WSL: true}, "~/work/app"},
+		{"other os", Machine{OS: "windows", Hostname: "desktop"}, "~/AppData/app"},
+		{"darwin falls back to linux", Machine{OS: "darwin", Hostname: "macbook"}, "~/.config/app"},
+		{"no match", Machine{OS: "freebsd"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := SelectTarget(targets, tt.mc); got != tt.want {
+				t.Errorf("SelectTarget(%+v) = %q, want %q", tt.mc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectTarget_DarwinKey(t *testing.T) {
+	t.Parallel()
+
+	targets := map[string]string{"linux": "~/.config/app", "darwin": "~/Library/Application Support/app"}
+
+	if got := SelectTarget(targets, Machine{OS: "darwin"}); got != targets["darwin"] {
+		t.Errorf("SelectTarget(darwin) = %q, want the darwin key", got)
+	}
+	if got := SelectTarget(targets, Machine{OS: "linux"}); got != targets["linux"] {
+		t.Errorf("SelectTarget(linux) = %q, want the linux key", got)
+	}
+}
+
+func TestValidateTargetKey(t *testing.T) {
+	t.Parallel()
+
+	for _, key := range []string{"linux", "windows", "linux/arch", "wsl", "host:work-laptop"} {
+		if err := validateTargetKey(key); err != nil {
+			t.Errorf("validateTargetKey(%q) error = %v", key, err)
+		}
+	}
+
+	for _, key := range []string{"", "host:", "linux/", "/arch", "linux/arch/x"} {
+		if err := validateTargetKey(key); err == nil {
+			t.Errorf("validateTargetKey(%q) = nil, want an error", key)
+		}
+	}
+}
+
+func TestValidateTargets_CaseDuplicateHosts(t *testing.T) {
+	t.Parallel()
+
+	if err := validateTargets(map[string]string{"host:Work": "~/a", "host:laptop": "~/b"}); err != nil {
+		t.Errorf("validateTargets() error = %v", err)
+	}
+
+	if err := validateTargets(map[string]string{"host:Work": "~/a", "host:work": "~/b"}); err == nil {
+		t.Error("validateTargets() = nil, want an error for hosts differing only in case")
+	}
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strings"
+
+	"github.com/AntoineGS/tidydots/internal/ignore"
+	"github.com/AntoineGS/tidydots/internal/platform"
 )
 
 // ValidatePath checks a path for potential security issues.
@@ -60,8 +64,99 @@ func ValidateConfig(cfg *Config) []error {
 			}
 
 			subNames[entry.Name] = true
+
+			if len(entry.Ignore) > 0 {
+				if !entry.IsFolder() {
+					errs = append(errs, fmt.Errorf("%w: application %q entry %q: ignore is only supported on folder entries", ErrInvalidConfig, app.Name, entry.Name))
+				} else if err := ignore.Validate(entry.Ignore); err != nil {
+					errs = append(errs, fmt.Errorf("%w: application %q entry %q: %w", ErrInvalidConfig, app.Name, entry.Name, err))
+				}
+			}
+
+			if err := validateTargets(entry.Targets); err != nil {
+				errs = append(errs, fmt.Errorf("%w: application %q entry %q: %w", ErrInvalidConfig, app.Name, entry.Name, err))
+			}
+
+			if err := entry.validatePermissions(); err != nil {
+				errs = append(errs, fmt.Errorf("%w: application %q entry %q: %w", ErrInvalidConfig, app.Name, entry.Name, err))
+			}
+		}
+
+		if app.Package != nil {
+			for name, mv := range app.Package.Managers {
+				if err := validateManagerValue(mv); err != nil {
+					errs = append(errs, fmt.Errorf("%w: application %q package %s: %w", ErrInvalidConfig, app.Name, name, err))
+				}
+			}
+
+			for osKey, spec := range app.Package.URL {
+				if !validSHA256(spec.SHA256) {
+					errs = append(errs, fmt.Errorf("%w: application %q url %s: sha256 %q must be 64 hexadecimal digits", ErrInvalidConfig, app.Name, osKey, spec.SHA256))
+				}
+			}
+		}
+	}
+
+	// Validate hosts
+	hostNames := make(map[string]bool)
+
+	for _, host := range cfg.Hosts {
+		if host.Name == "" {
+			errs = append(errs, fmt.Errorf("%w: host has empty name", ErrInvalidConfig))
+
+			continue
+		}
+
+		if hostNames[host.Name] {
+			errs = append(errs, fmt.Errorf("%w: duplicate host name %q", ErrInvalidConfig, host.Name))
+		}
+
+		hostNames[host.Name] = true
+
+		if host.OS != "" && !platform.IsSupportedOS(host.OS) {
+			errs = append(errs, fmt.Errorf("%w: host %q has unsupported os %q (must be one of %s)",
+				ErrInvalidConfig, host.Name, host.OS, strings.Join(platform.SupportedOS, ", ")))
 		}
 	}
 
 	return errs
 }
+
+// validSHA256 reports whether a checksum is empty or 64 hexadecimal digits.
+func validSHA256(sum string) bool {
+	if sum == "" {
+		return true
+	}
+
+	_, err := hex.DecodeString(sum)
+
+	return len(sum) == 64 && err == nil
+}
+
+// validateManagerValue checks the parts of a package manager value that
+// decoding cannot: git target keys, version constraints and flatpak and snap
+// options.
+func validateManagerValue(mv ManagerValue) error {
+	switch {
+	case mv.IsGit():
+		return validateTargets(mv.Git.Targets)
+	case mv.Flatpak != nil || mv.Snap != nil || mv.Version != "":
+		if mv.PackageName == "" {
+			return fmt.Errorf("name is required")
+		}
+	}
+
+	if _, err := ParseVersionConstraint(mv.Version); err != nil {
+		return err
+	}
+
+	if mv.Flatpak != nil {
+		switch mv.Flatpak.Scope {
+		case "", FlatpakScopeUser, FlatpakScopeSystem:
+		default:
+			return fmt.Errorf("scope %q: expected %q or %q", mv.Flatpak.Scope, FlatpakScopeUser, FlatpakScopeSystem)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VersionConstraint is the version requirement of a package manager value:
+// an exact version ("1.2.3" or "=1.2.3"), a prefix ("1.2.*") or a minimum
+// (">=1.2"). The zero value allows any version.
+type VersionConstraint struct {
+	op      string // "=", "*" or ">="; "" allows any version
+	version string
+}
+
+// ParseVersionConstraint parses a version constraint. An empty string allows
+// any version.
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	s = strings.TrimSpace(s)
+
+	var c VersionConstraint
+
+	switch {
+	case s == "":
+		return c, nil
+	case strings.HasPrefix(s, ">="):
+		c = VersionConstraint{op: ">=", version: strings.TrimSpace(s[2:])}
+	case strings.HasSuffix(s, ".*"):
+		c = VersionConstraint{op: "*", version: strings.TrimSuffix(s, ".*")}
+	default:
+		c = VersionConstraint{op: "=", version: strings.TrimSpace(strings.TrimPrefix(s, "="))}
+	}
+
+	if c.version == "" || strings.ContainsAny(c.version, " *<>=") {
+		return VersionConstraint{}, fmt.Errorf("invalid version constraint %q: expected \"1.2.3\", \"1.2.*\" or \">=1.2\"", s)
+	}
+
+	return c, nil
+}
+
+// ExactVersion returns the version of an exact constraint.
+func (c VersionConstraint) ExactVersion() (string, bool) {
+	return c.version, c.op == "="
+}
+
+// String returns the constraint as written in the configuration.
+func (c VersionConstraint) String() string {
+	switch c.op {
+	case ">=":
+		return ">=" + c.version
+	case "*":
+		return c.version + ".*"
+	}
+
+	return c.version
+}
+
+// Allows reports whether an installed version satisfies the constraint.
+// Unless the constraint names them, an epoch ("1:") and a package release
+// ("-1", "_0") on the installed version are ignored, so "2.43.0" allows
+// "1:2.43.0-1ubuntu7".
+func (c VersionConstraint) Allows(version string) bool {
+	if c.op == "" || version == c.version {
+		return true
+	}
+
+	version = baseVersion(version)
+
+	switch c.op {
+	case "*":
+		return version == c.version || strings.HasPrefix(version, c.version+".")
+	case ">=":
+		return CompareVersions(version, c.version) >= 0
+	}
+
+	return version == strings.TrimPrefix(c.version, "v")
+}
+
+// baseVersion strips the epoch and package release of a version.
+func baseVersion(version string) string {
+	if _, rest, ok := strings.Cut(version, ":"); ok {
+		version = rest
+	}
+
+	if i := strings.IndexAny(version, "-_"); i > 0 {
+		version = version[:i]
+	}
+
+	return strings.TrimPrefix(version, "v")
+}
+
+// CompareVersions compares two dotted versions segment by segment, numerically
+// when both segments are numbers, and returns -1, 0 or 1. Missing segments
+// sort first, so "1.2" < "1.2.1".
+func CompareVersions(a, b string) int {
+	split := func(v string) []string {
+		return strings.FieldsFunc(strings.TrimPrefix(v, "v"), func(r rune) bool {
+			return r == '.' || r == '-' || r == '_' || r == '+' || r == '~'
+		})
+	}
+
+	as, bs := split(a), split(b)
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareSegments(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+
+	return 0
+}
+
+// compareSegments compares two version segments, numerically when both are
+// made of digits.
+func compareSegments(a, b string) int {
+	isNumber := func(s string) bool {
+		return strings.Trim(s, "0123456789") == ""
+	}
+
+	if isNumber(a) && isNumber(b) {
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return strings.Compare(a, b)
+}
@@ -5,20 +5,28 @@ import "strings"
 // EvaluateWhen evaluates a template-based when expression.
 // Empty when returns true (always match). Nil renderer returns false.
 // The template is rendered and the trimmed result is checked against "true".
-// Any render error results in false (no match).
+// Any render error results in false (no match); use EvaluateWhenWithError
+// to surface it.
 func EvaluateWhen(when string, renderer PathRenderer) bool {
+	match, _ := EvaluateWhenWithError(when, renderer)
+	return match
+}
+
+// EvaluateWhenWithError evaluates a when expression like EvaluateWhen, but
+// returns the render error instead of swallowing it.
+func EvaluateWhenWithError(when string, renderer PathRenderer) (bool, error) {
 	if strings.TrimSpace(when) == "" {
-		return true
+		return true, nil
 	}
 
 	if renderer == nil {
-		return false
+		return false, nil
 	}
 
 	result, err := renderer.RenderString("when", when)
 	if err != nil {
-		return false
+		return false, err
 	}
 
-	return strings.TrimSpace(result) == "true"
+	return strings.TrimSpace(result) == "true", nil
 }
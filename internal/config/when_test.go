@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -102,3 +103,19 @@ func TestEvaluateWhen(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateWhenWithError(t *testing.T) {
+	t.Parallel()
+
+	renderErr := fmt.Errorf("missing key")
+
+	match, err := EvaluateWhenWithError("{{ .Missing }}", &mockWhenRenderer{err: renderErr})
+	if match || !errors.Is(err, renderErr) {
+		t.Errorf("EvaluateWhenWithError() = (%v, %v), want (false, %v)", match, err, renderErr)
+	}
+
+	match, err = EvaluateWhenWithError("{{ true }}", &mockWhenRenderer{result: "true"})
+	if !match || err != nil {
+		t.Errorf("EvaluateWhenWithError() = (%v, %v), want (true, nil)", match, err)
+	}
+}
@@ -0,0 +1,227 @@
+// Package encryption provides symmetric encryption for secret files stored in the backup repository.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	encSuffix       = ".enc"
+	decryptedSuffix = ".enc.decrypted"
+
+	// header is the first line of every encrypted file. It identifies the format
+	// version so the layout can evolve without breaking existing repositories.
+	header = "tidydots-encrypted v1\n"
+
+	saltSize = 16
+	keySize  = 32
+
+	// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256.
+	pbkdf2Iterations = 600_000
+
+	// lineWidth wraps the base64 payload so encrypted files diff reasonably in git.
+	lineWidth = 76
+)
+
+// Sentinel errors for encryption operations
+var (
+	ErrNotEncrypted = errors.New("not a tidydots encrypted file")
+	ErrDecrypt      = errors.New("decryption failed (wrong key or corrupted file)")
+	ErrEmptySecret  = errors.New("encryption secret is empty")
+)
+
+// Cipher encrypts and decrypts file contents with AES-256-GCM. A fresh key is
+// derived for every file from the configured secret and a random salt, so
+// identical plaintexts never produce identical ciphertexts.
+type Cipher struct {
+	derive func(salt []byte) ([]byte, error)
+}
+
+// NewPassphraseCipher creates a Cipher whose keys are derived from a passphrase
+// using PBKDF2-HMAC-SHA256.
+func NewPassphraseCipher(passphrase string) (*Cipher, error) {
+	if passphrase == "" {
+		return nil, ErrEmptySecret
+	}
+
+	secret := []byte(passphrase)
+
+	return &Cipher{
+		derive: func(salt []byte) ([]byte, error) {
+			return pbkdf2.Key(sha256.New, string(secret), salt, pbkdf2Iterations, keySize)
+		},
+	}, nil
+}
+
+// NewKeyFileCipher creates a Cipher whose keys are derived from the contents of
+// a key file using HKDF-SHA256. Surrounding whitespace in the file is ignored so
+// keys generated with e.g. `openssl rand -base64 32 > key` work as-is.
+func NewKeyFileCipher(path string) (*Cipher, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path from app config
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+
+	secret := bytes.TrimSpace(data)
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("key file %s: %w", path, ErrEmptySecret)
+	}
+
+	return &Cipher{
+		derive: func(salt []byte) ([]byte, error) {
+			return hkdf.Key(sha256.New, secret, salt, "tidydots file encryption", keySize)
+		},
+	}, nil
+}
+
+// Encrypt returns the armored ciphertext for plaintext.
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generating salt: %w", err)
+	}
+
+	aead, err := c.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	payload := make([]byte, 0, saltSize+len(nonce)+len(plaintext)+aead.Overhead())
+	payload = append(payload, salt...)
+	payload = append(payload, nonce...)
+	payload = aead.Seal(payload, nonce, plaintext, []byte(header))
+
+	return armor(payload), nil
+}
+
+// Decrypt returns the plaintext for armored ciphertext produced by Encrypt.
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	payload, err := dearmor(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < saltSize {
+		return nil, ErrDecrypt
+	}
+
+	salt := payload[:saltSize]
+
+	aead, err := c.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := payload[saltSize:]
+	if len(rest) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(header))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+func (c *Cipher) aead(salt []byte) (cipher.AEAD, error) {
+	key, err := c.derive(salt)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// armor encodes the binary payload as the header followed by wrapped base64 lines.
+func armor(payload []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(payload)
+
+	var b strings.Builder
+	b.WriteString(header)
+
+	for len(encoded) > lineWidth {
+		b.WriteString(encoded[:lineWidth])
+		b.WriteByte('\n')
+		encoded = encoded[lineWidth:]
+	}
+
+	b.WriteString(encoded)
+	b.WriteByte('\n')
+
+	return []byte(b.String())
+}
+
+// dearmor validates the header and decodes the base64 payload.
+func dearmor(data []byte) ([]byte, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, header) {
+		return nil, ErrNotEncrypted
+	}
+
+	body := strings.Join(strings.Fields(strings.TrimPrefix(text, header)), "")
+
+	payload, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotEncrypted, err)
+	}
+
+	return payload, nil
+}
+
+// IsEncrypted returns true if data starts with the tidydots encryption header.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")), []byte(header))
+}
+
+// IsEncryptedFile returns true if the filename has an .enc suffix.
+func IsEncryptedFile(filename string) bool {
+	return strings.HasSuffix(filename, encSuffix)
+}
+
+// IsDecryptedFile returns true if the filename is an .enc.decrypted file.
+func IsDecryptedFile(filename string) bool {
+	return strings.HasSuffix(filename, decryptedSuffix)
+}
+
+// EncryptedPath returns the path where the ciphertext for plainPath is stored (appends .enc).
+func EncryptedPath(plainPath string) string {
+	return plainPath + encSuffix
+}
+
+// DecryptedPath returns the private decrypted copy path for an encrypted file (appends .decrypted).
+func DecryptedPath(encPath string) string {
+	return encPath + ".decrypted"
+}
+
+// TargetName strips the .enc suffix to get the final target filename.
+func TargetName(encFilename string) string {
+	return strings.TrimSuffix(encFilename, encSuffix)
+}
+
+// GitignorePattern is the pattern that keeps decrypted copies out of the repository.
+const GitignorePattern = "*" + decryptedSuffix
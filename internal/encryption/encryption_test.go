@@ -0,0 +1,178 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newKeyFileCipher(t *testing.T, key string) *Cipher {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte(key), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewKeyFileCipher(path)
+	if err != nil {
+		t.Fatalf("NewKeyFileCipher() error = %v", err)
+	}
+	return c
+}
+
+func TestKeyFileCipher_RoundTrip(t *testing.T) {
+	c := newKeyFileCipher(t, "super-secret-key\n")
+	plaintext := []byte("Host github.com\n  IdentityFile ~/.ssh/id_ed25519\n")
+
+	ciphertext, err := c.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	if bytes.Contains(ciphertext, []byte("github.com")) {
+		t.Error("ciphertext contains plaintext")
+	}
+
+	if !IsEncrypted(ciphertext) {
+		t.Error("IsEncrypted() = false for ciphertext")
+	}
+
+	got, err := c.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Decrypt() = %q, want %q", got, plaintext)
+	}
+}
+
+func TestPassphraseCipher_RoundTrip(t *testing.T) {
+	c, err := NewPassphraseCipher("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := c.Encrypt([]byte("machine api.example.com login me password hunter2"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	got, err := c.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+
+	if string(got) != "machine api.example.com login me password hunter2" {
+		t.Errorf("Decrypt() = %q", got)
+	}
+}
+
+func TestEncrypt_NonDeterministic(t *testing.T) {
+	c := newKeyFileCipher(t, "key")
+
+	a, err := c.Encrypt([]byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.Encrypt([]byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(a, b) {
+		t.Error("encrypting the same plaintext twice produced identical ciphertext")
+	}
+}
+
+func TestDecrypt_Errors(t *testing.T) {
+	c := newKeyFileCipher(t, "right-key")
+	other := newKeyFileCipher(t, "wrong-key")
+
+	ciphertext, err := c.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(string(ciphertext), "\n")
+	tampered := []byte(strings.Replace(string(ciphertext), lines[1], strings.Repeat("A", len(lines[1])), 1))
+
+	tests := []struct {
+		wantErr error
+		cipher  *Cipher
+		name    string
+		data    []byte
+	}{
+		{name: "wrong key", cipher: other, data: ciphertext, wantErr: ErrDecrypt},
+		{name: "tampered payload", cipher: c, data: tampered, wantErr: ErrDecrypt},
+		{name: "plaintext input", cipher: c, data: []byte("not encrypted"), wantErr: ErrNotEncrypted},
+		{name: "header only", cipher: c, data: []byte(header), wantErr: ErrDecrypt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cipher.Decrypt(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecrypt_CRLF(t *testing.T) {
+	c := newKeyFileCipher(t, "key")
+
+	ciphertext, err := c.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a checkout with core.autocrlf=true
+	crlf := bytes.ReplaceAll(ciphertext, []byte("\n"), []byte("\r\n"))
+
+	got, err := c.Decrypt(crlf)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(got) != "secret" {
+		t.Errorf("Decrypt() = %q, want %q", got, "secret")
+	}
+}
+
+func TestNewCipher_EmptySecret(t *testing.T) {
+	if _, err := NewPassphraseCipher(""); !errors.Is(err, ErrEmptySecret) {
+		t.Errorf("NewPassphraseCipher(\"\") error = %v, want ErrEmptySecret", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("  \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeyFileCipher(path); !errors.Is(err, ErrEmptySecret) {
+		t.Errorf("NewKeyFileCipher(blank) error = %v, want ErrEmptySecret", err)
+	}
+
+	if _, err := NewKeyFileCipher(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("NewKeyFileCipher(missing) expected error")
+	}
+}
+
+func TestPathHelpers(t *testing.T) {
+	if got := EncryptedPath("/b/netrc"); got != "/b/netrc.enc" {
+		t.Errorf("EncryptedPath() = %q", got)
+	}
+	if got := DecryptedPath("/b/netrc.enc"); got != "/b/netrc.enc.decrypted" {
+		t.Errorf("DecryptedPath() = %q", got)
+	}
+	if got := TargetName("netrc.enc"); got != "netrc" {
+		t.Errorf("TargetName() = %q", got)
+	}
+	if !IsEncryptedFile("netrc.enc") || IsEncryptedFile("netrc.enc.decrypted") || IsEncryptedFile("netrc") {
+		t.Error("IsEncryptedFile() misclassified a name")
+	}
+	if !IsDecryptedFile("netrc.enc.decrypted") || IsDecryptedFile("netrc.enc") {
+		t.Error("IsDecryptedFile() misclassified a name")
+	}
+}
@@ -0,0 +1,187 @@
+// Package ignore matches paths inside a folder entry against gitignore-style
+// glob patterns, so caches, lock files and other machine-generated state can be
+// kept out of the repository.
+//
+// Supported syntax:
+//   - blank lines and lines starting with # are skipped (in ignore files)
+//   - *, ? and [...] match within one path segment, as in path.Match
+//   - ** matches any number of segments
+//   - a pattern without a slash matches a file or directory name at any depth
+//   - a pattern containing a slash is matched against the path relative to the
+//     entry root; a leading slash only anchors it
+//   - a trailing slash only matches directories
+//
+// A matched directory excludes everything below it. Negation (!) is not supported.
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the repository-level ignore file, read from the backup root.
+// Its patterns apply to every folder entry.
+const FileName = ".tidydotsignore"
+
+// ErrNegation is returned for patterns starting with "!", which are not supported.
+var ErrNegation = errors.New("negated patterns are not supported")
+
+// Matcher reports whether paths relative to an entry root are ignored.
+// A nil Matcher ignores nothing.
+type Matcher struct {
+	patterns []pattern
+}
+
+type pattern struct {
+	segments []string
+	anchored bool // matched against the whole relative path
+	dirOnly  bool
+}
+
+// New compiles patterns into a Matcher. Blank patterns are skipped.
+func New(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+
+	for _, raw := range patterns {
+		p, ok, err := compile(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+
+	return m, nil
+}
+
+// Parse returns the patterns in an ignore file, skipping blank lines and comments.
+func Parse(data []byte) []string {
+	var patterns []string
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	return patterns
+}
+
+// Validate reports the first malformed pattern, if any.
+func Validate(patterns []string) error {
+	_, err := New(patterns)
+	return err
+}
+
+func compile(raw string) (pattern, bool, error) {
+	p := strings.TrimSpace(raw)
+	if p == "" {
+		return pattern{}, false, nil
+	}
+
+	if strings.HasPrefix(p, "!") {
+		return pattern{}, false, fmt.Errorf("%w: %q", ErrNegation, raw)
+	}
+
+	var result pattern
+
+	if strings.HasSuffix(p, "/") {
+		result.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+
+	if strings.Contains(p, "/") {
+		result.anchored = true
+		p = strings.TrimPrefix(p, "/")
+	}
+
+	if p == "" {
+		return pattern{}, false, fmt.Errorf("%w: %q", path.ErrBadPattern, raw)
+	}
+
+	result.segments = strings.Split(p, "/")
+	for _, seg := range result.segments {
+		if _, err := path.Match(seg, ""); err != nil {
+			return pattern{}, false, fmt.Errorf("%w: %q", err, raw)
+		}
+	}
+
+	return result, true, nil
+}
+
+// Empty reports whether the matcher has no patterns.
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.patterns) == 0
+}
+
+// Match reports whether rel, a path relative to the entry root, is ignored.
+// isDir tells whether rel itself is a directory. A path is also ignored when
+// any of its parent directories is.
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	if m.Empty() {
+		return false
+	}
+
+	rel = filepath.ToSlash(filepath.Clean(rel))
+	if rel == "." || rel == "" {
+		return false
+	}
+
+	segments := strings.Split(rel, "/")
+	for i := 1; i <= len(segments); i++ {
+		dir := i < len(segments) || isDir
+
+		for _, p := range m.patterns {
+			if p.match(segments[:i], dir) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (p pattern) match(segments []string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	if !p.anchored {
+		ok, _ := path.Match(p.segments[0], segments[len(segments)-1]) //nolint:errcheck // validated in compile
+		return ok
+	}
+
+	return matchSegments(p.segments, segments)
+}
+
+func matchSegments(pat, segments []string) bool {
+	if len(pat) == 0 {
+		return len(segments) == 0
+	}
+
+	if pat[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pat[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	ok, _ := path.Match(pat[0], segments[0]) //nolint:errcheck // validated in compile
+
+	return ok && matchSegments(pat[1:], segments[1:])
+}
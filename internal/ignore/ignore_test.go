@@ -0,0 +1,89 @@
+package ignore
+
+import (
+	"errors"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		patterns []string
+		rel      string
+		isDir    bool
+		want     bool
+	}{
+		{"name at root", []string{"lazy-lock.json"}, "lazy-lock.json", false, true},
+		{"name at depth", []string{".DS_Store"}, "lua/plugins/.DS_Store", false, true},
+		{"glob on name", []string{"*.log"}, "logs/debug.log", false, true},
+		{"glob does not cross segments", []string{"*.log"}, "debug.log.old", false, false},
+		{"ignored parent directory", []string{"cache"}, "cache/a/b.bin", false, true},
+		{"directory-only pattern on directory", []string{"cache/"}, "cache", true, true},
+		{"directory-only pattern on file", []string{"cache/"}, "cache", false, false},
+		{"directory-only pattern on contents", []string{"cache/"}, "cache/data", false, true},
+		{"anchored path", []string{"lua/generated.lua"}, "lua/generated.lua", false, true},
+		{"anchored path elsewhere", []string{"lua/generated.lua"}, "plugin/lua/generated.lua", false, false},
+		{"leading slash anchors", []string{"/init.lua"}, "lua/init.lua", false, false},
+		{"leading slash at root", []string{"/init.lua"}, "init.lua", false, true},
+		{"double star prefix", []string{"**/state.json"}, "a/b/state.json", false, true},
+		{"double star middle", []string{"lua/**/*.bak"}, "lua/x/y/z.bak", false, true},
+		{"double star zero segments", []string{"lua/**/*.bak"}, "lua/z.bak", false, true},
+		{"no match", []string{"*.log", "cache/"}, "init.lua", false, false},
+		{"root is never ignored", []string{"*"}, ".", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := New(tt.patterns)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if got := m.Match(tt.rel, tt.isDir); got != tt.want {
+				t.Errorf("Match(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcher_Nil(t *testing.T) {
+	t.Parallel()
+
+	var m *Matcher
+	if !m.Empty() || m.Match("anything", false) {
+		t.Error("nil Matcher should be empty and match nothing")
+	}
+}
+
+func TestNew_InvalidPatterns(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New([]string{"[a-"}); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("New([a-) error = %v, want ErrBadPattern", err)
+	}
+
+	if _, err := New([]string{"!keep.me"}); !errors.Is(err, ErrNegation) {
+		t.Errorf("New(!keep.me) error = %v, want ErrNegation", err)
+	}
+
+	if _, err := New([]string{"/"}); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("New(/) error = %v, want ErrBadPattern", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("# caches\n*.log\n\n  lazy-lock.json  \r\n#.DS_Store\ncache/\n")
+
+	want := []string{"*.log", "lazy-lock.json", "cache/"}
+	if got := Parse(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %q, want %q", got, want)
+	}
+}
@@ -4,13 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
-	"os"
-	"os/exec"
 	"path/filepath"
 
 	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/encryption"
+	"github.com/AntoineGS/tidydots/internal/ignore"
 	tmpl "github.com/AntoineGS/tidydots/internal/template"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 // BackupWithContext backs up configurations with context support
@@ -28,7 +30,14 @@ func (m *Manager) Backup() error {
 		return err
 	}
 
+	m = m.dryRunView()
+
 	m.logger.Info("backing up configurations", slog.String("os", m.Platform.OS)) //nolint:dupl // similar structure to restoreV3, but semantically different
+
+	if err := m.checkTemplates(); err != nil {
+		return err
+	}
+
 	apps := m.GetApplications()
 
 	var errs []error
@@ -55,7 +64,7 @@ func (m *Manager) Backup() error {
 				continue
 			}
 
-			target := subEntry.GetTarget(m.Platform.OS)
+			target := subEntry.TargetFor(config.NewMachine(m.Platform))
 			if target == "" {
 				m.logger.Debug("skipping entry",
 					slog.String("app", app.Name),
@@ -81,25 +90,67 @@ func (m *Manager) Backup() error {
 	return errors.Join(errs...)
 }
 
+// backupSubEntry backs up one entry, reporting its progress as events.
 func (m *Manager) backupSubEntry(appName string, subEntry config.SubEntry, target string) error {
+	em := *m.dryRunView()
+	em.entryOp = OpBackup
+	em.entryApp = appName
+	em.entryName = subEntry.Name
+
+	em.emit(EntryStarted{EntryEvent: em.entryEvent(subEntry.Name), Target: target})
+
+	if err := em.backupEntry(appName, subEntry, target); err != nil {
+		em.emit(EntryFailed{EntryEvent: em.entryEvent(subEntry.Name), Err: err})
+		return err
+	}
+
+	em.emit(EntryDone{
+		EntryEvent: em.entryEvent(subEntry.Name),
+		Target:     target,
+		Backup:     em.resolvePath(subEntry.Backup),
+	})
+
+	return nil
+}
+
+func (m *Manager) backupEntry(appName string, subEntry config.SubEntry, target string) error {
 	backupPath := m.resolvePath(subEntry.Backup)
 
+	var err error
 	if subEntry.IsFolder() {
-		return m.backupFolderSubEntry(appName, subEntry, backupPath, target)
+		err = m.backupFolderSubEntry(appName, subEntry, backupPath, target)
+	} else {
+		err = m.backupFilesSubEntry(appName, subEntry, backupPath, target)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Encrypted entries: capture edits made through the decrypted copies and
+	// encrypt any new plaintext that was just copied into the backup
+	if err := m.reencryptModified(subEntry, backupPath); err != nil {
+		return err
+	}
+
+	if err := m.sealPlaintextInBackup(subEntry, backupPath, "backup"); err != nil {
+		return err
 	}
 
-	return m.backupFilesSubEntry(appName, subEntry, backupPath, target)
+	return m.recordPermissions(appName, subEntry, target)
 }
 
 func (m *Manager) backupFolderSubEntry(_ string, subEntry config.SubEntry, backup, target string) error {
+	m = m.dryRunView()
+
 	// Similar to existing backupFolder logic
-	if !pathExists(target) {
+	if !m.pathExists(target) {
 		m.logger.Debug("target folder does not exist", slog.String("path", target))
 		return nil
 	}
 
 	// Skip symlinks - they point to our backup already
-	if isSymlink(target) {
+	if m.isSymlink(target) {
 		m.logger.Debug("skipping symlink", slog.String("path", target))
 		return nil
 	}
@@ -108,53 +159,88 @@ func (m *Manager) backupFolderSubEntry(_ string, subEntry config.SubEntry, backu
 		slog.String("from", target),
 		slog.String("to", backup))
 
-	if !m.DryRun {
-		if err := os.MkdirAll(filepath.Dir(backup), DirPerms); err != nil {
-			return NewPathError("backup", backup, fmt.Errorf("creating parent directory: %w", err))
+	if err := m.fs.MkdirAll(filepath.Dir(backup), DirPerms); err != nil {
+		return NewPathError("backup", backup, fmt.Errorf("creating parent directory: %w", err))
+	}
+
+	ign, err := m.ignoreMatcher(subEntry)
+	if err != nil {
+		return NewPathError("backup", backup, err)
+	}
+
+	// Copy source folder contents into backup directory (e.g., /source/nvim/* -> /backup/*)
+	if ign.Empty() {
+		return m.fileOps(subEntry.Sudo).CopyContents(target, backup)
+	}
+
+	return m.copyContentsFiltered(subEntry, target, backup, ign)
+}
+
+// copyContentsFiltered copies the contents of target into backup, leaving out
+// paths matched by ign.
+func (m *Manager) copyContentsFiltered(subEntry config.SubEntry, target, backup string, ign *ignore.Matcher) error {
+	ops := m.fileOps(subEntry.Sudo)
+
+	return vfs.WalkDir(m.fs, target, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return NewPathError("backup", path, err)
 		}
 
-		// Copy source folder contents into backup directory (e.g., /source/nvim/* -> /backup/*)
-		if subEntry.Sudo {
-			cmd := exec.CommandContext(m.ctx, "sudo", "cp", "-rT", target, backup) //nolint:gosec // intentional sudo command
-			return cmd.Run()
+		if skip, skipErr := skipIgnored(ign, target, path, d); skip {
+			m.logger.Debug("skipping ignored path", slog.String("path", path))
+			return skipErr
 		}
 
-		return copyDir(target, backup)
-	}
+		rel, err := filepath.Rel(target, path)
+		if err != nil {
+			return NewPathError("backup", path, err)
+		}
 
-	return nil
+		dst := filepath.Join(backup, rel)
+
+		if d.IsDir() {
+			err = ops.MkdirAll(dst)
+		} else {
+			err = ops.CopyContents(path, dst)
+		}
+		if err != nil {
+			return NewPathError("backup", dst, err)
+		}
+
+		return nil
+	})
 }
 
 func (m *Manager) backupFilesSubEntry(_ string, subEntry config.SubEntry, backup, target string) error {
+	m = m.dryRunView()
+
 	// Similar to existing backupFiles logic
-	if !pathExists(target) {
+	if !m.pathExists(target) {
 		m.logger.Debug("target directory does not exist", slog.String("path", target))
 		return nil
 	}
 
-	if !m.DryRun {
-		if err := os.MkdirAll(backup, DirPerms); err != nil {
-			return NewPathError("backup", backup, fmt.Errorf("creating backup directory: %w", err))
-		}
+	if err := m.fs.MkdirAll(backup, DirPerms); err != nil {
+		return NewPathError("backup", backup, fmt.Errorf("creating backup directory: %w", err))
 	}
 
 	for _, file := range subEntry.Files {
 		srcFile := filepath.Join(target, file)
 		dstFile := filepath.Join(backup, file)
 
-		if !pathExists(srcFile) {
+		if !m.pathExists(srcFile) {
 			m.logger.Debug("source file does not exist", slog.String("path", srcFile))
 			continue
 		}
 
 		// Skip symlinks
-		if isSymlink(srcFile) {
+		if m.isSymlink(srcFile) {
 			m.logger.Debug("skipping symlink", slog.String("path", srcFile))
 			continue
 		}
 
-		// Skip template-generated artifacts
-		if tmpl.IsRenderedFile(file) || tmpl.IsConflictFile(file) {
+		// Skip template-generated artifacts and decrypted copies
+		if tmpl.IsRenderedFile(file) || tmpl.IsConflictFile(file) || encryption.IsDecryptedFile(file) {
 			m.logger.Debug("skipping template artifact", slog.String("path", srcFile))
 			continue
 		}
@@ -163,17 +249,8 @@ func (m *Manager) backupFilesSubEntry(_ string, subEntry config.SubEntry, backup
 			slog.String("from", srcFile),
 			slog.String("to", dstFile))
 
-		if !m.DryRun {
-			if subEntry.Sudo {
-				cmd := exec.CommandContext(m.ctx, "sudo", "cp", srcFile, dstFile) //nolint:gosec // intentional sudo command
-				if err := cmd.Run(); err != nil {
-					return NewPathError("backup", srcFile, fmt.Errorf("copying file: %w", err))
-				}
-			} else {
-				if err := copyFile(srcFile, dstFile); err != nil {
-					return NewPathError("backup", srcFile, fmt.Errorf("copying file: %w", err))
-				}
-			}
+		if err := m.fileOps(subEntry.Sudo).CopyContents(srcFile, dstFile); err != nil {
+			return NewPathError("backup", srcFile, fmt.Errorf("copying file: %w", err))
 		}
 	}
 
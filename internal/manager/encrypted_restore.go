@@ -0,0 +1,386 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/encryption"
+	tmpl "github.com/AntoineGS/tidydots/internal/template"
+	"github.com/AntoineGS/tidydots/internal/vfs"
+)
+
+// InitCipher configures encryption for encrypted backup files using the key
+// settings from the app config. A key file takes precedence over a passphrase.
+// It is a no-op when neither is configured.
+func (m *Manager) InitCipher(appCfg *config.AppConfig) error {
+	if appCfg == nil {
+		return nil
+	}
+
+	switch {
+	case appCfg.EncryptionKeyFile != "":
+		c, err := encryption.NewKeyFileCipher(config.ExpandPath(appCfg.EncryptionKeyFile, m.Platform.EnvVars))
+		if err != nil {
+			return fmt.Errorf("loading encryption key: %w", err)
+		}
+		m.cipher = c
+	case appCfg.EncryptionPassphrase != "":
+		c, err := encryption.NewPassphraseCipher(appCfg.EncryptionPassphrase)
+		if err != nil {
+			return fmt.Errorf("loading encryption passphrase: %w", err)
+		}
+		m.cipher = c
+	}
+
+	return nil
+}
+
+// encryptedFilesIn returns the absolute paths of the .enc files that belong to
+// a sub-entry. Folder entries are walked recursively; file entries only consider
+// the ciphertext of their listed files.
+func (m *Manager) encryptedFilesIn(subEntry config.SubEntry, backupDir string) []string {
+	if !m.pathExists(backupDir) {
+		return nil
+	}
+
+	var result []string
+
+	if !subEntry.IsFolder() {
+		for _, file := range subEntry.Files {
+			encPath := encryption.EncryptedPath(filepath.Join(backupDir, file))
+			if m.pathExists(encPath) {
+				result = append(result, encPath)
+			}
+		}
+
+		return result
+	}
+
+	_ = vfs.WalkDir(m.fs, backupDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return filepath.SkipDir
+		}
+
+		if !d.IsDir() && d.Type()&fs.ModeSymlink == 0 && encryption.IsEncryptedFile(d.Name()) {
+			result = append(result, path)
+		}
+
+		return nil
+	})
+
+	return result
+}
+
+// decryptFilesInBackup decrypts every .enc file of a sub-entry into its private
+// .enc.decrypted copy and links the plain filename to it, so the target symlink
+// resolves to readable content. Copies are only rewritten when the ciphertext
+// no longer matches them.
+func (m *Manager) decryptFilesInBackup(subEntry config.SubEntry, backupDir string) error {
+	encFiles := m.encryptedFilesIn(subEntry, backupDir)
+	if len(encFiles) == 0 {
+		return nil
+	}
+
+	if m.cipher == nil {
+		return NewPathError("restore", backupDir, ErrNoEncryptionKey)
+	}
+
+	for _, encPath := range encFiles {
+		if err := m.decryptFile(encPath); err != nil {
+			return err
+		}
+	}
+
+	m.ensureDecryptedIgnored()
+
+	return nil
+}
+
+// decryptFile decrypts a single .enc file and ensures the relative symlink
+// name → name.enc.decrypted exists next to it.
+func (m *Manager) decryptFile(encPath string) error {
+	ciphertext, err := m.fs.ReadFile(encPath)
+	if err != nil {
+		return NewPathError("restore", encPath, fmt.Errorf("reading encrypted file: %w", err))
+	}
+
+	plaintext, err := m.cipher.Decrypt(ciphertext)
+	if err != nil {
+		return NewPathError("restore", encPath, err)
+	}
+
+	decryptedPath := encryption.DecryptedPath(encPath)
+
+	current, readErr := m.fs.ReadFile(decryptedPath)
+	if readErr != nil || !bytes.Equal(current, plaintext) {
+		m.logger.Info("decrypting file",
+			slog.String("file", encPath),
+			slog.String("decrypted", decryptedPath))
+
+		if err := m.fs.WriteFile(decryptedPath, plaintext, FilePerms); err != nil {
+			return NewPathError("restore", decryptedPath, fmt.Errorf("writing decrypted file: %w", err))
+		}
+	}
+
+	linkPath := filepath.Join(filepath.Dir(encPath), encryption.TargetName(filepath.Base(encPath)))
+	if m.pathExists(linkPath) && !m.isSymlink(linkPath) {
+		return NewPathError("restore", linkPath, fmt.Errorf(
+			"plaintext file exists next to %s; remove one of them", filepath.Base(encPath)))
+	}
+
+	if err := m.ensureRelativeSymlink(linkPath, filepath.Base(decryptedPath)); err != nil {
+		return err
+	}
+
+	m.ensureLinkIgnored(linkPath)
+
+	return nil
+}
+
+// sealPlaintextInBackup encrypts plaintext files of an encrypted sub-entry that
+// were adopted, merged or backed up into the backup directory. The plaintext is
+// moved to the private decrypted copy and replaced by a relative symlink, so the
+// only thing left for git to track is the ciphertext.
+func (m *Manager) sealPlaintextInBackup(subEntry config.SubEntry, backupDir, op string) error {
+	if !subEntry.Encrypted || !m.pathExists(backupDir) {
+		return nil
+	}
+
+	var plainFiles []string
+
+	if subEntry.IsFolder() {
+		err := vfs.WalkDir(m.fs, backupDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if m.needsSealing(path, d.Name()) {
+				plainFiles = append(plainFiles, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return NewPathError(op, backupDir, fmt.Errorf("walking backup: %w", err))
+		}
+	} else {
+		for _, file := range subEntry.Files {
+			path := filepath.Join(backupDir, file)
+			if m.needsSealing(path, filepath.Base(path)) {
+				plainFiles = append(plainFiles, path)
+			}
+		}
+	}
+
+	if len(plainFiles) == 0 {
+		return nil
+	}
+
+	if m.cipher == nil {
+		return NewPathError(op, backupDir, ErrNoEncryptionKey)
+	}
+
+	for _, path := range plainFiles {
+		if err := m.sealFile(path, op); err != nil {
+			return err
+		}
+	}
+
+	m.ensureDecryptedIgnored()
+
+	return nil
+}
+
+// needsSealing reports whether path is a regular plaintext file that should be
+// encrypted. Symlinks, ciphertext, decrypted copies and template artifacts are skipped.
+func (m *Manager) needsSealing(path, name string) bool {
+	info, err := m.fs.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	return !encryption.IsEncryptedFile(name) &&
+		!encryption.IsDecryptedFile(name) &&
+		!tmpl.IsRenderedFile(name) &&
+		!tmpl.IsConflictFile(name)
+}
+
+// sealFile encrypts one plaintext file into name.enc, moves the plaintext to
+// name.enc.decrypted and links name to it.
+func (m *Manager) sealFile(path, op string) error {
+	encPath := encryption.EncryptedPath(path)
+	if m.pathExists(encPath) {
+		return NewPathError(op, path, fmt.Errorf(
+			"both %s and its encrypted copy exist; resolve the conflict manually", filepath.Base(path)))
+	}
+
+	m.logger.Info("encrypting file",
+		slog.String("file", path),
+		slog.String("encrypted", encPath))
+
+	plaintext, err := m.fs.ReadFile(path)
+	if err != nil {
+		return NewPathError(op, path, fmt.Errorf("reading plaintext: %w", err))
+	}
+
+	ciphertext, err := m.cipher.Encrypt(plaintext)
+	if err != nil {
+		return NewPathError(op, path, fmt.Errorf("encrypting: %w", err))
+	}
+
+	if err := m.fs.WriteFile(encPath, ciphertext, FilePerms); err != nil {
+		return NewPathError(op, encPath, fmt.Errorf("writing encrypted file: %w", err))
+	}
+
+	decryptedPath := encryption.DecryptedPath(encPath)
+	if err := m.fs.Rename(path, decryptedPath); err != nil {
+		return NewPathError(op, path, fmt.Errorf("moving plaintext to decrypted copy: %w", err))
+	}
+
+	if err := m.fs.Chmod(decryptedPath, FilePerms); err != nil {
+		return NewPathError(op, decryptedPath, fmt.Errorf("restricting permissions: %w", err))
+	}
+
+	if err := m.ensureRelativeSymlink(path, filepath.Base(decryptedPath)); err != nil {
+		return err
+	}
+
+	m.ensureLinkIgnored(path)
+
+	return nil
+}
+
+// reencryptModified re-encrypts every .enc file whose decrypted copy was edited
+// since it was last decrypted. Unchanged files are left alone so their
+// ciphertext (and the git history) stays stable.
+func (m *Manager) reencryptModified(subEntry config.SubEntry, backupDir string) error {
+	encFiles := m.encryptedFilesIn(subEntry, backupDir)
+	if len(encFiles) == 0 {
+		return nil
+	}
+
+	if m.cipher == nil {
+		return NewPathError("backup", backupDir, ErrNoEncryptionKey)
+	}
+
+	for _, encPath := range encFiles {
+		decryptedPath := encryption.DecryptedPath(encPath)
+
+		edited, err := m.fs.ReadFile(decryptedPath)
+		if err != nil {
+			continue // never decrypted on this machine, nothing to re-encrypt
+		}
+
+		ciphertext, err := m.fs.ReadFile(encPath)
+		if err != nil {
+			return NewPathError("backup", encPath, fmt.Errorf("reading encrypted file: %w", err))
+		}
+
+		original, err := m.cipher.Decrypt(ciphertext)
+		if err != nil {
+			return NewPathError("backup", encPath, err)
+		}
+
+		if bytes.Equal(original, edited) {
+			continue
+		}
+
+		m.logger.Info("re-encrypting modified file",
+			slog.String("decrypted", decryptedPath),
+			slog.String("encrypted", encPath))
+
+		updated, err := m.cipher.Encrypt(edited)
+		if err != nil {
+			return NewPathError("backup", encPath, fmt.Errorf("encrypting: %w", err))
+		}
+
+		if err := m.fs.WriteFile(encPath, updated, FilePerms); err != nil {
+			return NewPathError("backup", encPath, fmt.Errorf("writing encrypted file: %w", err))
+		}
+	}
+
+	return nil
+}
+
+// ensureDecryptedIgnored appends the decrypted-copy pattern to the repository's
+// .gitignore so plaintext secrets are never committed by accident.
+func (m *Manager) ensureDecryptedIgnored() {
+	m.ensureIgnored(encryption.GitignorePattern)
+}
+
+// gitignoreEscaper escapes the characters .gitignore treats as wildcards.
+var gitignoreEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+
+// ensureLinkIgnored adds linkPath, the plain name linked to a decrypted copy,
+// to the repository's .gitignore. The link only resolves where the file was
+// decrypted, so once committed it would dangle in every other checkout.
+func (m *Manager) ensureLinkIgnored(linkPath string) {
+	if m.Config.BackupRoot == "" {
+		return
+	}
+
+	root := config.ExpandPath(m.Config.BackupRoot, m.Platform.EnvVars)
+
+	rel, err := filepath.Rel(root, linkPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+
+	m.ensureIgnored("/" + gitignoreEscaper.Replace(filepath.ToSlash(rel)))
+}
+
+// gitignoreMu serializes .gitignore updates from entries restored concurrently.
+var gitignoreMu sync.Mutex
+
+// ensureIgnored appends pattern to the repository's .gitignore unless it is
+// already listed there.
+func (m *Manager) ensureIgnored(pattern string) {
+	if m.Config.BackupRoot == "" {
+		return
+	}
+
+	gitignoreMu.Lock()
+	defer gitignoreMu.Unlock()
+
+	root := config.ExpandPath(m.Config.BackupRoot, m.Platform.EnvVars)
+	gitignore := filepath.Join(root, ".gitignore")
+
+	content, err := m.fs.ReadFile(gitignore)
+	if err != nil && !os.IsNotExist(err) {
+		m.logger.Warn("could not read .gitignore", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return
+		}
+	}
+
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte(pattern+"\n")...)
+
+	m.logger.Info("adding pattern to .gitignore",
+		slog.String("path", gitignore),
+		slog.String("pattern", pattern))
+
+	if err := m.fs.WriteFile(gitignore, content, FilePerms); err != nil {
+		m.logger.Warn("could not update .gitignore", slog.String("error", err.Error()))
+	}
+}
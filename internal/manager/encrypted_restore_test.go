@@ -0,0 +1,277 @@
+package manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/encryption"
+	"github.com/AntoineGS/tidydots/internal/platform"
+)
+
+// setupEncryptionTest creates a backup root, a target dir and a manager with a
+// key-file cipher. Returns (backupRoot, targetDir, manager).
+func setupEncryptionTest(t *testing.T) (string, string, *Manager) {
+	t.Helper()
+
+	backupRoot := t.TempDir()
+	targetDir := t.TempDir()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("test-key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{BackupRoot: backupRoot, Version: 3}
+	plat := &platform.Platform{OS: platform.OSLinux, EnvVars: map[string]string{}}
+	mgr := New(cfg, plat)
+
+	if err := mgr.InitCipher(&config.AppConfig{EncryptionKeyFile: keyFile}); err != nil {
+		t.Fatalf("InitCipher() error = %v", err)
+	}
+
+	return backupRoot, targetDir, mgr
+}
+
+func writeEncrypted(t *testing.T, mgr *Manager, path, plaintext string) {
+	t.Helper()
+
+	ciphertext, err := mgr.cipher.Encrypt([]byte(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, ciphertext, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func decryptFileContent(t *testing.T, mgr *Manager, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path) //nolint:gosec // test path
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := mgr.cipher.Decrypt(data)
+	if err != nil {
+		t.Fatalf("decrypting %s: %v", path, err)
+	}
+	return string(plaintext)
+}
+
+func TestRestore_DecryptsFolderEntry(t *testing.T) {
+	backupRoot, targetDir, mgr := setupEncryptionTest(t)
+
+	backupDir := filepath.Join(backupRoot, "ssh")
+	writeEncrypted(t, mgr, filepath.Join(backupDir, "config.enc"), "Host *\n")
+	if err := os.WriteFile(filepath.Join(backupDir, "known_hosts"), []byte("plain"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(targetDir, ".ssh")
+	subEntry := config.SubEntry{Name: "ssh", Backup: "./ssh", Targets: map[string]string{"linux": target}}
+
+	if err := mgr.restoreSubEntry("ssh", subEntry, target); err != nil {
+		t.Fatalf("restoreSubEntry() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(target, "config")) //nolint:gosec // test path
+	if err != nil {
+		t.Fatalf("reading decrypted config through target: %v", err)
+	}
+	if string(got) != "Host *\n" {
+		t.Errorf("config content = %q, want %q", got, "Host *\n")
+	}
+
+	link, err := os.Readlink(filepath.Join(backupDir, "config"))
+	if err != nil {
+		t.Fatalf("expected relative symlink in backup: %v", err)
+	}
+	if link != "config.enc.decrypted" {
+		t.Errorf("symlink = %q, want %q", link, "config.enc.decrypted")
+	}
+
+	info, err := os.Stat(filepath.Join(backupDir, "config.enc.decrypted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != FilePerms {
+		t.Errorf("decrypted copy mode = %v, want %v", info.Mode().Perm(), FilePerms)
+	}
+
+	gitignore, err := os.ReadFile(filepath.Join(backupRoot, ".gitignore")) //nolint:gosec // test path
+	if err != nil {
+		t.Fatalf("expected .gitignore to be created: %v", err)
+	}
+	for _, want := range []string{encryption.GitignorePattern, "/ssh/config"} {
+		if !strings.Contains(string(gitignore), want+"\n") {
+			t.Errorf(".gitignore = %q, want it to contain %q", gitignore, want)
+		}
+	}
+
+	// Restoring again is idempotent
+	if err := mgr.restoreSubEntry("ssh", subEntry, target); err != nil {
+		t.Fatalf("second restoreSubEntry() error = %v", err)
+	}
+
+	again, err := os.ReadFile(filepath.Join(backupRoot, ".gitignore")) //nolint:gosec // test path
+	if err != nil || string(again) != string(gitignore) {
+		t.Errorf(".gitignore after second restore = %q, want %q unchanged", again, gitignore)
+	}
+}
+
+func TestRestore_DecryptsFilesEntry(t *testing.T) {
+	backupRoot, targetDir, mgr := setupEncryptionTest(t)
+
+	backupDir := filepath.Join(backupRoot, "netrc")
+	writeEncrypted(t, mgr, filepath.Join(backupDir, ".netrc.enc"), "machine example.com\n")
+
+	subEntry := config.SubEntry{Name: "netrc", Backup: "./netrc", Files: []string{".netrc"}}
+
+	if err := mgr.restoreSubEntry("netrc", subEntry, targetDir); err != nil {
+		t.Fatalf("restoreSubEntry() error = %v", err)
+	}
+
+	targetFile := filepath.Join(targetDir, ".netrc")
+	if !isSymlink(targetFile) {
+		t.Fatal("target .netrc is not a symlink")
+	}
+
+	got, err := os.ReadFile(targetFile) //nolint:gosec // test path
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "machine example.com\n" {
+		t.Errorf(".netrc content = %q", got)
+	}
+}
+
+func TestRestore_AdoptEncryptedEntrySealsPlaintext(t *testing.T) {
+	backupRoot, targetDir, mgr := setupEncryptionTest(t)
+
+	target := filepath.Join(targetDir, "secrets")
+	if err := os.MkdirAll(target, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "token"), []byte("abc123"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	subEntry := config.SubEntry{Name: "secrets", Backup: "./secrets", Encrypted: true}
+
+	if err := mgr.restoreSubEntry("secrets", subEntry, target); err != nil {
+		t.Fatalf("restoreSubEntry() error = %v", err)
+	}
+
+	backupDir := filepath.Join(backupRoot, "secrets")
+
+	if got := decryptFileContent(t, mgr, filepath.Join(backupDir, "token.enc")); got != "abc123" {
+		t.Errorf("decrypted token.enc = %q, want %q", got, "abc123")
+	}
+
+	if !isSymlink(filepath.Join(backupDir, "token")) {
+		t.Error("plaintext token in backup should be replaced by a symlink")
+	}
+
+	got, err := os.ReadFile(filepath.Join(target, "token")) //nolint:gosec // test path
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "abc123" {
+		t.Errorf("token through target = %q, want %q", got, "abc123")
+	}
+
+	gitignore, err := os.ReadFile(filepath.Join(backupRoot, ".gitignore")) //nolint:gosec // test path
+	if err != nil || !strings.Contains(string(gitignore), "/secrets/token\n") {
+		t.Errorf(".gitignore = %q, want it to ignore the token link", gitignore)
+	}
+}
+
+func TestBackup_ReencryptsModifiedDecryptedCopy(t *testing.T) {
+	backupRoot, targetDir, mgr := setupEncryptionTest(t)
+
+	backupDir := filepath.Join(backupRoot, "ssh")
+	encPath := filepath.Join(backupDir, "config.enc")
+	writeEncrypted(t, mgr, encPath, "old")
+
+	target := filepath.Join(targetDir, ".ssh")
+	subEntry := config.SubEntry{Name: "ssh", Backup: "./ssh", Encrypted: true}
+
+	if err := mgr.restoreSubEntry("ssh", subEntry, target); err != nil {
+		t.Fatalf("restoreSubEntry() error = %v", err)
+	}
+
+	before, err := os.ReadFile(encPath) //nolint:gosec // test path
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Backup without edits leaves the ciphertext untouched
+	if err := mgr.backupSubEntry("ssh", subEntry, target); err != nil {
+		t.Fatalf("backupSubEntry() error = %v", err)
+	}
+	unchanged, err := os.ReadFile(encPath) //nolint:gosec // test path
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(unchanged) {
+		t.Error("ciphertext changed although the decrypted copy was not edited")
+	}
+
+	// Edit through the target symlink, then back up
+	if err := os.WriteFile(filepath.Join(target, "config"), []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.backupSubEntry("ssh", subEntry, target); err != nil {
+		t.Fatalf("backupSubEntry() error = %v", err)
+	}
+
+	if got := decryptFileContent(t, mgr, encPath); got != "new" {
+		t.Errorf("re-encrypted content = %q, want %q", got, "new")
+	}
+}
+
+func TestRestore_EncryptedWithoutKey(t *testing.T) {
+	backupRoot, targetDir, keyed := setupEncryptionTest(t)
+
+	backupDir := filepath.Join(backupRoot, "ssh")
+	writeEncrypted(t, keyed, filepath.Join(backupDir, "config.enc"), "secret")
+
+	mgr := New(keyed.Config, keyed.Platform)
+	subEntry := config.SubEntry{Name: "ssh", Backup: "./ssh"}
+
+	err := mgr.restoreSubEntry("ssh", subEntry, filepath.Join(targetDir, ".ssh"))
+	if !errors.Is(err, ErrNoEncryptionKey) {
+		t.Fatalf("restoreSubEntry() error = %v, want ErrNoEncryptionKey", err)
+	}
+
+	if pathExists(filepath.Join(targetDir, ".ssh")) {
+		t.Error("target should not be linked when decryption is impossible")
+	}
+}
+
+func TestRestore_EncryptedDryRun(t *testing.T) {
+	backupRoot, targetDir, mgr := setupEncryptionTest(t)
+	mgr.DryRun = true
+
+	backupDir := filepath.Join(backupRoot, "ssh")
+	writeEncrypted(t, mgr, filepath.Join(backupDir, "config.enc"), "secret")
+
+	subEntry := config.SubEntry{Name: "ssh", Backup: "./ssh"}
+	if err := mgr.restoreSubEntry("ssh", subEntry, filepath.Join(targetDir, ".ssh")); err != nil {
+		t.Fatalf("restoreSubEntry() error = %v", err)
+	}
+
+	if pathExists(filepath.Join(backupDir, "config.enc.decrypted")) {
+		t.Error("dry-run should not write decrypted copies")
+	}
+	if pathExists(filepath.Join(backupRoot, ".gitignore")) {
+		t.Error("dry-run should not touch .gitignore")
+	}
+}
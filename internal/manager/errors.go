@@ -7,8 +7,17 @@ import (
 
 // Sentinel errors for common manager operations
 var (
-	ErrBackupNotFound = errors.New("backup not found")
-	ErrTargetExists   = errors.New("target already exists")
+	ErrBackupNotFound    = errors.New("backup not found")
+	ErrTargetExists      = errors.New("target already exists")
+	ErrNoEncryptionKey   = errors.New("encrypted files found but no encryption key is configured")
+	ErrTemplateErrors    = errors.New("template errors in configuration")
+	ErrNoStateStore      = errors.New("state store is not initialized")
+	ErrRunNotFound       = errors.New("restore run not found")
+	ErrRunRolledBack     = errors.New("restore run already rolled back")
+	ErrSnapshotNotFound  = errors.New("snapshot not found")
+	ErrNotInSnapshot     = errors.New("path is not archived in snapshot")
+	ErrHelperUnavailable = errors.New("privileged helper unavailable")
+	ErrUnknownHelperOp   = errors.New("unknown privileged helper operation")
 )
 
 // PathError records an error and the operation and path that caused it.
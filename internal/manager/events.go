@@ -0,0 +1,167 @@
+package manager
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Operations reported in events.
+const (
+	OpRestore = "restore"
+	OpBackup  = "backup"
+)
+
+// EntryEvent identifies the operation and entry an event belongs to.
+type EntryEvent struct {
+	Op    string `json:"op"`
+	App   string `json:"app"`
+	Entry string `json:"entry"`
+}
+
+func (e EntryEvent) entryEvent() EntryEvent { return e }
+
+// Event is a typed progress event emitted by restore and backup. It is one of
+// EntryStarted, SymlinkCreated, FileAdopted, MergeConflict, TemplateRendered,
+// EntryFailed or EntryDone.
+type Event interface {
+	entryEvent() EntryEvent
+}
+
+// EntryStarted is emitted before an entry is processed.
+type EntryStarted struct {
+	EntryEvent
+	Target string `json:"target"`
+}
+
+// SymlinkCreated is emitted when restore links a target to its backup.
+type SymlinkCreated struct {
+	EntryEvent
+	Target string `json:"target"`
+	Source string `json:"source"`
+}
+
+// FileAdopted is emitted when existing target content is moved into the backup.
+type FileAdopted struct {
+	EntryEvent
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MergeConflict is emitted when a merged target file already existed in the
+// backup and was kept under a new name.
+type MergeConflict struct {
+	EntryEvent
+	File      string `json:"file"`
+	RenamedTo string `json:"renamed_to"`
+}
+
+// TemplateRendered is emitted when a template is rendered into the backup.
+type TemplateRendered struct {
+	EntryEvent
+	Template string `json:"template"`
+	Rendered string `json:"rendered"`
+}
+
+// EntryFailed is emitted when an entry could not be processed.
+type EntryFailed struct {
+	Err error `json:"-"`
+	EntryEvent
+}
+
+// EntryDone is emitted when an entry was processed successfully.
+type EntryDone struct {
+	EntryEvent
+	Target string `json:"target"`
+	Backup string `json:"backup"`
+}
+
+// EventName returns the snake_case name of an event, as used in JSON output.
+func EventName(ev Event) string {
+	switch ev.(type) {
+	case EntryStarted:
+		return "entry_started"
+	case SymlinkCreated:
+		return "symlink_created"
+	case FileAdopted:
+		return "file_adopted"
+	case MergeConflict:
+		return "merge_conflict"
+	case TemplateRendered:
+		return "template_rendered"
+	case EntryFailed:
+		return "entry_failed"
+	case EntryDone:
+		return "entry_done"
+	default:
+		return "unknown"
+	}
+}
+
+// EventSink receives progress events. HandleEvent is called synchronously from
+// the operation, so it should return quickly.
+type EventSink interface {
+	HandleEvent(ev Event)
+}
+
+// EventSinkFunc adapts a function to the EventSink interface.
+type EventSinkFunc func(ev Event)
+
+// HandleEvent calls f(ev).
+func (f EventSinkFunc) HandleEvent(ev Event) {
+	f(ev)
+}
+
+// WithEventSink sets the sink that receives progress events
+func (m *Manager) WithEventSink(sink EventSink) *Manager {
+	m2 := *m
+	m2.events = sink
+
+	return &m2
+}
+
+// emit sends an event to the configured sink, if any.
+func (m *Manager) emit(ev Event) {
+	if m.events != nil {
+		m.events.HandleEvent(ev)
+	}
+}
+
+// entryEvent returns the identity of the entry currently being processed.
+func (m *Manager) entryEvent(entry string) EntryEvent {
+	return EntryEvent{Op: m.entryOp, App: m.entryApp, Entry: entry}
+}
+
+// jsonEventSink writes each event as one JSON object per line.
+type jsonEventSink struct {
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+// NewJSONEventSink returns a sink writing events to w as JSON lines. Each
+// object has an "event" name, the event fields, and "error" for failures.
+func NewJSONEventSink(w io.Writer) EventSink {
+	return &jsonEventSink{enc: json.NewEncoder(w)}
+}
+
+func (s *jsonEventSink) HandleEvent(ev Event) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+
+	fields := map[string]any{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return
+	}
+
+	fields["event"] = EventName(ev)
+	if failed, ok := ev.(EntryFailed); ok && failed.Err != nil {
+		fields["error"] = failed.Err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_ = s.enc.Encode(fields) //nolint:errcheck // best-effort progress output
+}
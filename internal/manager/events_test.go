@@ -0,0 +1,152 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+)
+
+// collectEvents returns a sink that appends events to the given slice.
+func collectEvents(events *[]Event) EventSink {
+	return EventSinkFunc(func(ev Event) {
+		*events = append(*events, ev)
+	})
+}
+
+func eventNames(events []Event) []string {
+	names := make([]string, len(events))
+	for i, ev := range events {
+		names[i] = EventName(ev)
+	}
+
+	return names
+}
+
+func TestRestore_EmitsEvents(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	nvimTarget := filepath.Join(homeDir, ".config", "nvim")
+	writeTestFile(t, filepath.Join(backupRoot, "nvim", "init.lua"), "-- repo")
+	writeTestFile(t, filepath.Join(backupRoot, "nvim", "theme.tmpl"), "os={{ .OS }}")
+	writeTestFile(t, filepath.Join(nvimTarget, "init.lua"), "-- local")
+	writeTestFile(t, filepath.Join(homeDir, ".zshrc"), "# local")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "nvim", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./nvim", Targets: map[string]string{"linux": nvimTarget}},
+		}},
+		{Name: "zsh", Entries: []config.SubEntry{
+			{Name: "zshrc", Backup: "./zsh", Files: []string{".zshrc", ".zprofile"}, Targets: map[string]string{"linux": homeDir}},
+		}},
+	})
+
+	var events []Event
+	err := mgr.WithEventSink(collectEvents(&events)).Restore()
+	if err == nil {
+		t.Fatal("Restore() expected error for missing .zprofile")
+	}
+
+	want := []string{
+		"entry_started", "merge_conflict", "symlink_created", "template_rendered", "entry_done",
+		"entry_started", "file_adopted", "symlink_created", "entry_failed",
+	}
+	if got := eventNames(events); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+
+	started, ok := events[0].(EntryStarted)
+	if !ok || started.Op != OpRestore || started.App != "nvim" || started.Entry != "config" || started.Target != nvimTarget {
+		t.Errorf("events[0] = %+v, want nvim/config restore start", events[0])
+	}
+
+	if conflict, ok := events[1].(MergeConflict); !ok || conflict.File != "init.lua" {
+		t.Errorf("events[1] = %+v, want conflict for init.lua", events[1])
+	}
+
+	if adopted, ok := events[6].(FileAdopted); !ok || adopted.From != filepath.Join(homeDir, ".zshrc") {
+		t.Errorf("events[6] = %+v, want .zshrc adoption", events[6])
+	}
+
+	failed, ok := events[8].(EntryFailed)
+	if !ok || failed.App != "zsh" || failed.Err == nil {
+		t.Fatalf("events[8] = %+v, want zsh failure", events[8])
+	}
+
+	var pathErr *PathError
+	if !errors.As(failed.Err, &pathErr) {
+		t.Errorf("EntryFailed.Err = %v, want a *PathError", failed.Err)
+	}
+}
+
+func TestBackup_EmitsEvents(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(homeDir, ".zshrc"), "# local")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "zsh", Entries: []config.SubEntry{
+			{Name: "zshrc", Backup: "./zsh", Files: []string{".zshrc"}, Targets: map[string]string{"linux": homeDir}},
+		}},
+	})
+
+	var events []Event
+	if err := mgr.WithEventSink(collectEvents(&events)).Backup(); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	if got, want := eventNames(events), []string{"entry_started", "entry_done"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+
+	done := events[1].(EntryDone)
+	if done.Op != OpBackup || done.Backup != filepath.Join(backupRoot, "zsh") {
+		t.Errorf("EntryDone = %+v, want backup into %s", done, filepath.Join(backupRoot, "zsh"))
+	}
+}
+
+func TestJSONEventSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONEventSink(&buf)
+
+	entry := EntryEvent{Op: OpRestore, App: "zsh", Entry: "zshrc"}
+	sink.HandleEvent(SymlinkCreated{EntryEvent: entry, Target: "/home/u/.zshrc", Source: "/repo/zsh/.zshrc"})
+	sink.HandleEvent(EntryFailed{EntryEvent: entry, Err: errors.New("boom")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+
+	var created map[string]string
+	if err := json.Unmarshal([]byte(lines[0]), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	wantCreated := map[string]string{
+		"event":  "symlink_created",
+		"op":     "restore",
+		"app":    "zsh",
+		"entry":  "zshrc",
+		"target": "/home/u/.zshrc",
+		"source": "/repo/zsh/.zshrc",
+	}
+	if !reflect.DeepEqual(created, wantCreated) {
+		t.Errorf("symlink_created = %v, want %v", created, wantCreated)
+	}
+
+	var failed map[string]string
+	if err := json.Unmarshal([]byte(lines[1]), &failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed["event"] != "entry_failed" || failed["error"] != "boom" {
+		t.Errorf("entry_failed = %v, want event and error fields", failed)
+	}
+}
@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/ignore"
+	"github.com/AntoineGS/tidydots/internal/vfs"
+)
+
+// ignoreMatcher returns the ignore rules for an entry. Only folder entries have
+// ignore rules; files entries list exactly what they manage.
+func (m *Manager) ignoreMatcher(subEntry config.SubEntry) (*ignore.Matcher, error) {
+	if !subEntry.IsFolder() {
+		return nil, nil
+	}
+
+	matcher, err := m.newIgnoreMatcher(subEntry.Ignore)
+	if err != nil {
+		return nil, fmt.Errorf("entry %q: %w", subEntry.Name, err)
+	}
+
+	return matcher, nil
+}
+
+// IgnoreMatcher returns the ignore rules for an entry: its own patterns and
+// the repository's .tidydotsignore. Files entries have none.
+func (m *Manager) IgnoreMatcher(subEntry config.SubEntry) (*ignore.Matcher, error) {
+	return m.ignoreMatcher(subEntry)
+}
+
+// newIgnoreMatcher combines the patterns in the repository's .tidydotsignore
+// with an entry's own patterns.
+func (m *Manager) newIgnoreMatcher(entryPatterns []string) (*ignore.Matcher, error) {
+	var patterns []string
+
+	if m.Config.BackupRoot != "" {
+		root := config.ExpandPath(m.Config.BackupRoot, m.Platform.EnvVars)
+		path := filepath.Join(root, ignore.FileName)
+
+		data, err := m.fs.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, NewPathError("read", path, err)
+		}
+
+		patterns = ignore.Parse(data)
+	}
+
+	return ignore.New(append(patterns, entryPatterns...))
+}
+
+// ignoreMatcherForBackup returns the ignore rules of the folder entry whose
+// backup is dir, for callers such as status checks that only know the path.
+// Rules that cannot be loaded are logged and treated as empty.
+func (m *Manager) ignoreMatcherForBackup(dir string) *ignore.Matcher {
+	dir = filepath.Clean(dir)
+
+	var patterns []string
+
+	for _, app := range m.Config.Applications {
+		for _, e := range app.Entries {
+			if e.IsFolder() && filepath.Clean(m.resolvePath(e.Backup)) == dir {
+				patterns = append(patterns, e.Ignore...)
+			}
+		}
+	}
+
+	matcher, err := m.newIgnoreMatcher(patterns)
+	if err != nil {
+		m.logger.Debug("ignore rules unavailable", slog.String("path", dir), slog.String("error", err.Error()))
+		return nil
+	}
+
+	return matcher
+}
+
+// skipIgnored applies ign during a walk rooted at root: it returns fs.SkipDir
+// for ignored directories and reports whether the current path should be skipped.
+func skipIgnored(ign *ignore.Matcher, root, path string, d fs.DirEntry) (bool, error) {
+	if ign.Empty() {
+		return false, nil
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || !ign.Match(rel, d.IsDir()) {
+		return false, nil
+	}
+
+	if d.IsDir() {
+		return true, fs.SkipDir
+	}
+
+	return true, nil
+}
+
+// hasIgnored reports whether anything under dir matches ign.
+func hasIgnored(fsys vfs.FS, ign *ignore.Matcher, dir string) bool {
+	if ign.Empty() {
+		return false
+	}
+
+	found := false
+	_ = vfs.WalkDir(fsys, dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if skip, _ := skipIgnored(ign, dir, path, d); skip {
+			found = true
+			return fs.SkipAll
+		}
+
+		return nil
+	})
+
+	return found
+}
@@ -0,0 +1,158 @@
+package manager
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/ignore"
+)
+
+func TestRestore_AdoptHonorsIgnore(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	target := filepath.Join(homeDir, ".config", "nvim")
+	writeTestFile(t, filepath.Join(target, "init.lua"), "lua")
+	writeTestFile(t, filepath.Join(target, "lazy-lock.json"), "{}")
+	writeTestFile(t, filepath.Join(target, "cache", "state.bin"), "state")
+	writeTestFile(t, filepath.Join(backupRoot, ignore.FileName), "# generated\ncache/\n")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "nvim", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./nvim", Ignore: []string{"lazy-lock.json"}, Targets: map[string]string{"linux": target}},
+		}},
+	})
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	backup := filepath.Join(backupRoot, "nvim")
+	if !symlinkPointsTo(mgr.fs, target, backup) {
+		t.Fatalf("%s is not linked to the backup", target)
+	}
+	assertFileContent(t, filepath.Join(backup, "init.lua"), "lua")
+
+	for _, ignored := range []string{"lazy-lock.json", "cache"} {
+		if pathExists(filepath.Join(backup, ignored)) {
+			t.Errorf("ignored %s was adopted into the backup", ignored)
+		}
+	}
+
+	// The ignored content is archived rather than lost
+	snapshots, err := mgr.Snapshots()
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("Snapshots() = %v, %v; want one snapshot of the ignored content", snapshots, err)
+	}
+	files, err := mgr.SnapshotFiles(snapshots[0].ID)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("SnapshotFiles() = %v, %v", files, err)
+	}
+	assertFileContent(t, filepath.Join(files[0].Stored, "lazy-lock.json"), "{}")
+}
+
+func TestRestore_MergeHonorsIgnore(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	target := filepath.Join(homeDir, "app")
+	writeTestFile(t, filepath.Join(backupRoot, "app", "app.conf"), "repo")
+	writeTestFile(t, filepath.Join(target, "extra.conf"), "extra")
+	writeTestFile(t, filepath.Join(target, "logs", "debug.log"), "log")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./app", Ignore: []string{"*.log"}, Targets: map[string]string{"linux": target}},
+		}},
+	})
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(backupRoot, "app", "extra.conf"), "extra")
+	if pathExists(filepath.Join(backupRoot, "app", "logs", "debug.log")) {
+		t.Error("ignored debug.log was merged into the backup")
+	}
+}
+
+func TestBackup_HonorsIgnore(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	target := filepath.Join(homeDir, "app")
+	writeTestFile(t, filepath.Join(target, "app.conf"), "conf")
+	writeTestFile(t, filepath.Join(target, "sub", "keep.conf"), "keep")
+	writeTestFile(t, filepath.Join(target, "sub", ".DS_Store"), "junk")
+	writeTestFile(t, filepath.Join(target, "cache", "blob"), "blob")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./app", Ignore: []string{".DS_Store", "/cache/"}, Targets: map[string]string{"linux": target}},
+		}},
+	})
+
+	if err := mgr.Backup(); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	backup := filepath.Join(backupRoot, "app")
+	assertFileContent(t, filepath.Join(backup, "app.conf"), "conf")
+	assertFileContent(t, filepath.Join(backup, "sub", "keep.conf"), "keep")
+
+	for _, ignored := range []string{filepath.Join("sub", ".DS_Store"), "cache"} {
+		if pathExists(filepath.Join(backup, ignored)) {
+			t.Errorf("ignored %s was backed up", ignored)
+		}
+	}
+}
+
+func TestHasOutdatedTemplates_HonorsIgnore(t *testing.T) {
+	backupRoot := t.TempDir()
+	backup := filepath.Join(backupRoot, "app")
+	writeTestFile(t, filepath.Join(backup, "vendor", "plugin.conf.tmpl"), "{{ .OS }}")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./app", Targets: map[string]string{"linux": t.TempDir()}},
+		}},
+	})
+
+	if !mgr.HasOutdatedTemplates(backup) {
+		t.Fatal("HasOutdatedTemplates() = false for a template that was never rendered")
+	}
+
+	writeTestFile(t, filepath.Join(backupRoot, ignore.FileName), "vendor/\n")
+
+	if mgr.HasOutdatedTemplates(backup) {
+		t.Error("HasOutdatedTemplates() = true for a template under an ignored directory")
+	}
+	if mgr.HasTemplateFiles(backup) {
+		t.Error("HasTemplateFiles() = true when every template is ignored")
+	}
+}
+
+func TestRestore_InvalidIgnorePattern(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(homeDir, "app", "app.conf"), "conf")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./app", Ignore: []string{"[a-"}, Targets: map[string]string{"linux": filepath.Join(homeDir, "app")}},
+		}},
+	})
+
+	err := mgr.Restore()
+	if !errors.Is(err, path.ErrBadPattern) {
+		t.Fatalf("Restore() error = %v, want ErrBadPattern", err)
+	}
+
+	if isSymlink(filepath.Join(homeDir, "app")) {
+		t.Error("entry with an invalid pattern was restored")
+	}
+}
@@ -0,0 +1,469 @@
+package manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/state"
+)
+
+// Journal operation kinds. Each records enough data to undo one destructive
+// restore step.
+const (
+	// opSymlinkRemoved: Path was a symlink pointing at Aux
+	opSymlinkRemoved = "symlink_removed"
+	// opSymlinkCreated: Path is a symlink created by restore
+	opSymlinkCreated = "symlink_created"
+	// opMoved: content was moved from Path to Aux (adoption, merge)
+	opMoved = "moved"
+	// opRemoved: Path was archived to Aux in the snapshot store, then removed
+	opRemoved = "removed"
+	// opDirCreated: Path is a directory created by restore
+	opDirCreated = "dir_created"
+)
+
+// keepRuns is the number of journaled restore runs kept per host.
+const keepRuns = 10
+
+// stateDirName is the machine-local directory, next to the state database,
+// that holds snapshots. It is added to the repository's .gitignore.
+const stateDirName = ".tidydots-state"
+
+// journal records the destructive steps of one restore run so that a failed
+// entry can be rolled back immediately and the whole run can be reverted later
+// with Rollback. Steps are kept in memory and, when a state store is
+// available, persisted in it.
+type journal struct {
+	store *state.Store
+	runID string
+	ops   []state.JournalOp
+	mu    sync.Mutex
+}
+
+// newRunID returns a sortable, unique restore run identifier.
+func newRunID() string {
+	var b [3]byte
+	_, _ = rand.Read(b[:]) //nolint:errcheck // crypto/rand.Read never fails
+
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b[:])
+}
+
+// stateDir returns the machine-local state directory inside the backup root.
+func (m *Manager) stateDir() string {
+	return filepath.Join(config.ExpandPath(m.Config.BackupRoot, m.Platform.EnvVars), stateDirName)
+}
+
+// beginJournal starts journaling a restore run. It is a no-op in dry-run mode.
+func (m *Manager) beginJournal() {
+	if m.DryRun {
+		return
+	}
+
+	runID := newRunID()
+	j := &journal{
+		store: m.stateStore,
+		runID: runID,
+	}
+
+	if j.store != nil {
+		if err := j.store.BeginRun(runID, m.Platform.Hostname); err != nil {
+			m.logger.Warn("could not persist restore journal, rollback will be unavailable",
+				slog.String("error", err.Error()))
+			j.store = nil
+		}
+	}
+
+	m.journal = j
+	m.logger.Debug("restore run started", slog.String("run", runID))
+}
+
+// finishJournal closes the current run with the given error. Runs that did not
+// change anything are dropped so `rollback` targets the last meaningful run.
+func (m *Manager) finishJournal(runErr error) {
+	j := m.journal
+	m.journal = nil
+
+	if j == nil || j.store == nil {
+		return
+	}
+
+	if len(j.ops) == 0 {
+		if err := j.store.DeleteRun(j.runID); err != nil {
+			m.logger.Warn("could not delete empty restore run", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	status := state.RunCompleted
+	if runErr != nil {
+		status = state.RunFailed
+	}
+
+	if err := j.store.FinishRun(j.runID, status); err != nil {
+		m.logger.Warn("could not finish restore run", slog.String("error", err.Error()))
+	}
+
+	m.logger.Info("restore run journaled", slog.String("run", j.runID))
+	m.pruneOldRuns()
+	m.pruneSnapshots()
+}
+
+// record appends a step for the current entry to the journal of the current run.
+func (m *Manager) record(subEntry config.SubEntry, kind, path, aux string) {
+	m.recordOp(m.entryApp, subEntry.Name, kind, path, aux, subEntry.Sudo)
+}
+
+func (m *Manager) recordOp(appName, entry, kind, path, aux string, sudo bool) {
+	j := m.journal
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	op := state.JournalOp{
+		RunID:       j.runID,
+		Seq:         len(j.ops) + 1,
+		Application: appName,
+		Entry:       entry,
+		Kind:        kind,
+		Path:        path,
+		Aux:         aux,
+		Sudo:        sudo,
+	}
+
+	if j.store != nil {
+		id, err := j.store.AddJournalOp(op)
+		if err != nil {
+			m.logger.Warn("could not persist journal step", slog.String("error", err.Error()))
+		}
+		op.ID = id
+	}
+
+	j.ops = append(j.ops, op)
+}
+
+// rollbackEntry undoes, in reverse order, the steps the current run performed
+// for one entry. It is called when restoring that entry fails part way.
+func (m *Manager) rollbackEntry(appName, entry string) error {
+	j := m.journal
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	var ops []state.JournalOp
+	for _, op := range j.ops {
+		if op.Application == appName && op.Entry == entry && !op.Undone {
+			ops = append(ops, op)
+		}
+	}
+	j.mu.Unlock()
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	m.logger.Warn("rolling back entry",
+		slog.String("app", appName),
+		slog.String("entry", entry),
+		slog.Int("steps", len(ops)))
+
+	err := m.undoOps(ops)
+
+	j.mu.Lock()
+	for i := range j.ops {
+		if j.ops[i].Application == appName && j.ops[i].Entry == entry {
+			j.ops[i].Undone = true
+		}
+	}
+	j.mu.Unlock()
+
+	return err
+}
+
+// Rollback reverts every step of a previous restore run, newest first. An
+// empty runID selects the most recent run on this host that was not already
+// rolled back. It returns the ID of the reverted run.
+func (m *Manager) Rollback(runID string) (string, error) {
+	if m.stateStore == nil {
+		return "", ErrNoStateStore
+	}
+
+	run, err := m.findRun(runID)
+	if err != nil {
+		return "", err
+	}
+
+	ops, err := m.stateStore.ListJournalOps(run.ID)
+	if err != nil {
+		return run.ID, err
+	}
+
+	var pending []state.JournalOp
+	for _, op := range ops {
+		if !op.Undone {
+			pending = append(pending, op)
+		}
+	}
+
+	m.logger.Info("rolling back restore run",
+		slog.String("run", run.ID),
+		slog.Int("steps", len(pending)))
+
+	if err := m.undoOps(pending); err != nil {
+		return run.ID, err
+	}
+
+	if m.DryRun {
+		return run.ID, nil
+	}
+
+	if err := m.stateStore.FinishRun(run.ID, state.RunRolledBack); err != nil {
+		return run.ID, err
+	}
+
+	return run.ID, nil
+}
+
+// RestoreRuns returns the journaled restore runs on this host, newest first.
+func (m *Manager) RestoreRuns() ([]state.RestoreRun, error) {
+	if m.stateStore == nil {
+		return nil, ErrNoStateStore
+	}
+
+	return m.stateStore.ListRuns(m.Platform.Hostname, 0)
+}
+
+func (m *Manager) findRun(runID string) (*state.RestoreRun, error) {
+	if runID != "" {
+		run, err := m.stateStore.GetRun(runID)
+		if err != nil {
+			return nil, err
+		}
+		if run == nil {
+			return nil, fmt.Errorf("%w: %s", ErrRunNotFound, runID)
+		}
+		if run.Status == state.RunRolledBack {
+			return nil, fmt.Errorf("%w: %s", ErrRunRolledBack, runID)
+		}
+		return run, nil
+	}
+
+	runs, err := m.stateStore.ListRuns(m.Platform.Hostname, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range runs {
+		if runs[i].Status != state.RunRolledBack {
+			return &runs[i], nil
+		}
+	}
+
+	return nil, ErrRunNotFound
+}
+
+// undoOps reverts steps in reverse order. Every step is attempted; failures
+// are collected so one stuck path does not block the rest of the rollback.
+func (m *Manager) undoOps(ops []state.JournalOp) error {
+	var errs []error
+
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+
+		m.logger.Info("undoing step",
+			slog.String("kind", op.Kind),
+			slog.String("path", op.Path))
+
+		if m.DryRun {
+			continue
+		}
+
+		if err := m.undoOp(op); err != nil {
+			errs = append(errs, NewPathError("rollback", op.Path, err))
+			continue
+		}
+
+		if m.stateStore != nil && op.ID != 0 {
+			if err := m.stateStore.MarkJournalOpUndone(op.ID); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) undoOp(op state.JournalOp) error {
+	switch op.Kind {
+	case opSymlinkCreated:
+		if !m.isSymlink(op.Path) {
+			return nil
+		}
+		return m.fileOps(op.Sudo).Remove(op.Path)
+	case opSymlinkRemoved:
+		if m.pathExists(op.Path) {
+			if !m.isSymlink(op.Path) {
+				return fmt.Errorf("cannot restore symlink, path is occupied")
+			}
+			if err := m.fileOps(op.Sudo).Remove(op.Path); err != nil {
+				return err
+			}
+		}
+		return m.fileOps(op.Sudo).Symlink(op.Aux, op.Path)
+	case opMoved, opRemoved:
+		// Content went from Path to Aux; put it back
+		if m.pathExists(op.Path) {
+			if !m.isSymlink(op.Path) {
+				return fmt.Errorf("cannot move content back, path is occupied")
+			}
+			if err := m.fileOps(op.Sudo).Remove(op.Path); err != nil {
+				return err
+			}
+		}
+		if err := m.fileOps(op.Sudo).MkdirAll(filepath.Dir(op.Path)); err != nil {
+			return err
+		}
+		if op.Kind == opRemoved {
+			// Keep the snapshot so the content stays recoverable after rollback
+			return m.fileOps(op.Sudo).Copy(op.Aux, op.Path)
+		}
+		return m.fileOps(op.Sudo).Move(op.Aux, op.Path)
+	case opDirCreated:
+		// Only remove the directory if nothing else was put in it
+		if err := m.fileOps(op.Sudo).Remove(op.Path); err != nil && m.pathExists(op.Path) {
+			m.logger.Debug("keeping created directory", slog.String("path", op.Path))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown journal step %q", op.Kind)
+	}
+}
+
+// pruneOldRuns drops journaled runs beyond keepRuns, with their snapshots.
+func (m *Manager) pruneOldRuns() {
+	if m.stateStore == nil {
+		return
+	}
+
+	runs, err := m.stateStore.ListRuns(m.Platform.Hostname, 0)
+	if err != nil || len(runs) <= keepRuns {
+		return
+	}
+
+	for _, run := range runs[keepRuns:] {
+		if err := m.stateStore.DeleteRun(run.ID); err != nil {
+			m.logger.Warn("could not delete old restore run", slog.String("error", err.Error()))
+			continue
+		}
+
+		m.deleteSnapshot(run.ID)
+	}
+}
+
+// --- Journaled filesystem steps used by restore ---
+
+// jRemoveSymlink removes an incorrect symlink, recording where it pointed.
+func (m *Manager) jRemoveSymlink(subEntry config.SubEntry, path string) error {
+	link, err := m.fs.Readlink(path)
+	if err != nil {
+		return fmt.Errorf("reading symlink: %w", err)
+	}
+
+	if err := m.fileOps(subEntry.Sudo).Remove(path); err != nil {
+		return err
+	}
+
+	m.record(subEntry, opSymlinkRemoved, path, link)
+
+	return nil
+}
+
+// jCreateSymlink creates the restore symlink target → source.
+func (m *Manager) jCreateSymlink(subEntry config.SubEntry, source, target string) error {
+	if err := createSymlink(m.fs, m.fileOps(subEntry.Sudo), source, target); err != nil {
+		return err
+	}
+
+	m.record(subEntry, opSymlinkCreated, target, source)
+	m.emit(SymlinkCreated{EntryEvent: m.entryEvent(subEntry.Name), Target: target, Source: source})
+
+	return nil
+}
+
+// jMove moves target content into the backup (adoption).
+func (m *Manager) jMove(subEntry config.SubEntry, from, to string) error {
+	if err := m.fileOps(subEntry.Sudo).Move(from, to); err != nil {
+		return err
+	}
+
+	m.record(subEntry, opMoved, from, to)
+	m.emit(FileAdopted{EntryEvent: m.entryEvent(subEntry.Name), From: from, To: to})
+
+	return nil
+}
+
+// jRemove removes a target after archiving it in the snapshot store, so a
+// rollback or `snapshots restore` can put it back exactly as it was.
+func (m *Manager) jRemove(subEntry config.SubEntry, path string) error {
+	stored, err := m.snapshot(path, subEntry.Sudo, snapshotRemoved, true)
+	if err != nil {
+		return err
+	}
+
+	m.record(subEntry, opRemoved, path, stored)
+
+	return nil
+}
+
+// jMkdirAll creates a directory and its parents, recording every directory
+// that did not exist so rollback can remove what restore created. Directories
+// that already existed are never recorded.
+func (m *Manager) jMkdirAll(subEntry config.SubEntry, dir string) error {
+	// Missing directories, deepest first, up to the first existing ancestor
+	var created []string
+	for d := dir; !m.pathExists(d); d = filepath.Dir(d) {
+		created = append(created, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	if len(created) == 0 {
+		return nil
+	}
+
+	if err := m.fileOps(subEntry.Sudo).MkdirAll(dir); err != nil {
+		return err
+	}
+
+	// Record outermost first so reverse-order undo removes the deepest directory first
+	for i := len(created) - 1; i >= 0; i-- {
+		m.record(subEntry, opDirCreated, created[i], "")
+	}
+
+	return nil
+}
+
+// recordMerge journals the files a merge moved from the target into the backup
+// and reports the conflicts.
+func (m *Manager) recordMerge(subEntry config.SubEntry, targetDir, backupDir string, summary *MergeSummary) {
+	for _, rel := range summary.MergedFiles {
+		m.recordOp(m.entryApp, subEntry.Name, opMoved, filepath.Join(targetDir, rel), filepath.Join(backupDir, rel), subEntry.Sudo)
+	}
+
+	for _, c := range summary.ConflictFiles {
+		renamed := filepath.Join(filepath.Dir(filepath.Join(backupDir, c.OriginalName)), c.RenamedTo)
+		m.recordOp(m.entryApp, subEntry.Name, opMoved, filepath.Join(targetDir, c.OriginalName), renamed, subEntry.Sudo)
+		m.emit(MergeConflict{EntryEvent: m.entryEvent(subEntry.Name), File: c.OriginalName, RenamedTo: c.RenamedTo})
+	}
+}
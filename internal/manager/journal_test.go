@@ -0,0 +1,262 @@
+package manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/platform"
+	"github.com/AntoineGS/tidydots/internal/state"
+)
+
+// newJournalTestManager returns a manager with a state store over the given
+// applications, rooted at a fresh backup directory.
+func newJournalTestManager(t *testing.T, backupRoot string, apps []config.Application) *Manager {
+	t.Helper()
+
+	cfg := &config.Config{Version: 3, BackupRoot: backupRoot, Applications: apps}
+	plat := &platform.Platform{OS: platform.OSLinux, Hostname: "desktop", EnvVars: map[string]string{}}
+
+	mgr := New(cfg, plat)
+	if err := mgr.InitStateStore(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = mgr.Close() })
+
+	return mgr
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+
+	if isSymlink(path) {
+		t.Fatalf("%s is a symlink, want a regular file", path)
+	}
+
+	got, err := os.ReadFile(path) //nolint:gosec // test path
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", path, got, want)
+	}
+}
+
+func TestRollback_RevertsMergeAndAdoption(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	nvimTarget := filepath.Join(homeDir, ".config", "nvim")
+	writeTestFile(t, filepath.Join(backupRoot, "nvim", "init.lua"), "-- repo")
+	writeTestFile(t, filepath.Join(nvimTarget, "local.lua"), "-- local")
+	writeTestFile(t, filepath.Join(homeDir, ".zshrc"), "# local zsh")
+
+	gitTarget := filepath.Join(homeDir, "new", "dir")
+	writeTestFile(t, filepath.Join(backupRoot, "git", "config"), "[user]")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "nvim", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./nvim", Targets: map[string]string{"linux": nvimTarget}},
+		}},
+		{Name: "zsh", Entries: []config.SubEntry{
+			{Name: "zshrc", Backup: "./zsh", Files: []string{".zshrc"}, Targets: map[string]string{"linux": homeDir}},
+		}},
+		{Name: "git", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./git", Targets: map[string]string{"linux": gitTarget}},
+		}},
+	})
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if !isSymlink(nvimTarget) || !isSymlink(filepath.Join(homeDir, ".zshrc")) || !isSymlink(gitTarget) {
+		t.Fatal("Restore() did not create the expected symlinks")
+	}
+
+	runID, err := mgr.Rollback("")
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	// Merged file is back in the target, the folder is a real directory again
+	assertFileContent(t, filepath.Join(nvimTarget, "local.lua"), "-- local")
+	if pathExists(filepath.Join(nvimTarget, "init.lua")) {
+		t.Error("backup content should not be left in the target")
+	}
+	if pathExists(filepath.Join(backupRoot, "nvim", "local.lua")) {
+		t.Error("merged file should be removed from the backup")
+	}
+
+	// Adopted file is back in place and no longer in the backup
+	assertFileContent(t, filepath.Join(homeDir, ".zshrc"), "# local zsh")
+	if pathExists(filepath.Join(backupRoot, "zsh", ".zshrc")) {
+		t.Error("adopted file should be removed from the backup")
+	}
+
+	// Directories created for the link are removed
+	if pathExists(filepath.Join(homeDir, "new")) {
+		t.Error("directories created by restore should be removed")
+	}
+
+	run, err := mgr.stateStore.GetRun(runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != state.RunRolledBack {
+		t.Errorf("run status = %q, want %q", run.Status, state.RunRolledBack)
+	}
+
+	if _, err := mgr.Rollback(runID); !errors.Is(err, ErrRunRolledBack) {
+		t.Errorf("second Rollback() error = %v, want ErrRunRolledBack", err)
+	}
+	if _, err := mgr.Rollback(""); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("Rollback() with no pending run error = %v, want ErrRunNotFound", err)
+	}
+}
+
+func TestRollback_RestoresForceDeletedFile(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(backupRoot, "zsh", ".zshrc"), "# repo")
+	writeTestFile(t, filepath.Join(homeDir, ".zshrc"), "# local")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "zsh", Entries: []config.SubEntry{
+			{Name: "zshrc", Backup: "./zsh", Files: []string{".zshrc"}, Targets: map[string]string{"linux": homeDir}},
+		}},
+	})
+	mgr.NoMerge = true
+	mgr.ForceDelete = true
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if _, err := mgr.Rollback(""); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(homeDir, ".zshrc"), "# local")
+	assertFileContent(t, filepath.Join(backupRoot, "zsh", ".zshrc"), "# repo")
+
+	gitignore, err := os.ReadFile(filepath.Join(backupRoot, ".gitignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(gitignore), stateDirName+"/") {
+		t.Errorf(".gitignore = %q, want it to contain %s/", gitignore, stateDirName)
+	}
+}
+
+func TestRestore_RollsBackFailedEntry(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	// .zshrc is adopted, then .zprofile fails because it exists nowhere
+	writeTestFile(t, filepath.Join(homeDir, ".zshrc"), "# local")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "zsh", Entries: []config.SubEntry{
+			{Name: "zsh", Backup: "./zsh", Files: []string{".zshrc", ".zprofile"}, Targets: map[string]string{"linux": homeDir}},
+		}},
+	})
+
+	if err := mgr.Restore(); err == nil {
+		t.Fatal("Restore() expected error for missing source file")
+	}
+
+	assertFileContent(t, filepath.Join(homeDir, ".zshrc"), "# local")
+	if pathExists(filepath.Join(backupRoot, "zsh", ".zshrc")) {
+		t.Error("adoption should be rolled back")
+	}
+
+	runs, err := mgr.RestoreRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Status != state.RunFailed {
+		t.Fatalf("RestoreRuns() = %+v, want one failed run", runs)
+	}
+}
+
+func TestRestore_DryRunDoesNotJournal(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(backupRoot, "zsh", ".zshrc"), "# repo")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "zsh", Entries: []config.SubEntry{
+			{Name: "zshrc", Backup: "./zsh", Files: []string{".zshrc"}, Targets: map[string]string{"linux": homeDir}},
+		}},
+	})
+	mgr.DryRun = true
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	runs, err := mgr.RestoreRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("RestoreRuns() = %d runs, want 0 in dry-run", len(runs))
+	}
+}
+
+func TestRollback_KeepsExistingDirectories(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "existing")
+	if err := os.Mkdir(existing, 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := newJournalTestManager(t, t.TempDir(), nil)
+	subEntry := config.SubEntry{Name: "config"}
+
+	mgr.beginJournal()
+	mgr.entryApp = "app"
+
+	if err := mgr.jMkdirAll(subEntry, existing); err != nil {
+		t.Fatalf("jMkdirAll(existing) error = %v", err)
+	}
+	if len(mgr.journal.ops) != 0 {
+		t.Errorf("journal = %+v, want nothing recorded for an existing directory", mgr.journal.ops)
+	}
+
+	nested := filepath.Join(existing, "a", "b")
+	if err := mgr.jMkdirAll(subEntry, nested); err != nil {
+		t.Fatalf("jMkdirAll(nested) error = %v", err)
+	}
+	if len(mgr.journal.ops) != 2 {
+		t.Errorf("journal = %+v, want the two created directories", mgr.journal.ops)
+	}
+
+	if err := mgr.rollbackEntry("app", "config"); err != nil {
+		t.Fatalf("rollbackEntry() error = %v", err)
+	}
+	mgr.finishJournal(nil)
+
+	if pathExists(filepath.Join(existing, "a")) {
+		t.Error("directories created by the entry should be removed")
+	}
+	if !pathExists(existing) {
+		t.Error("pre-existing empty directory should be kept")
+	}
+}
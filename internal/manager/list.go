@@ -2,7 +2,10 @@ package manager
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
+
+	"github.com/AntoineGS/tidydots/internal/config"
 )
 
 // List displays all managed configuration entries with their current status.
@@ -23,7 +26,7 @@ func (m *Manager) List() error {
 				continue
 			}
 
-			target := entry.GetTarget(m.Platform.OS)
+			target := entry.TargetFor(config.NewMachine(m.Platform))
 			if target == "" {
 				continue
 			}
@@ -40,6 +43,17 @@ func (m *Manager) List() error {
 			fmt.Printf("     files: %s\n", files)
 			fmt.Printf("     backup: %s\n", m.resolvePath(entry.Backup))
 			fmt.Printf("     target: %s\n", target)
+
+			issues, err := m.permissionIssues(app.Name, entry, m.expandTarget(target))
+			if err != nil {
+				m.logger.Debug("permissions unavailable",
+					slog.String("entry", entry.Name),
+					slog.String("error", err.Error()))
+			}
+
+			for _, issue := range issues {
+				fmt.Printf("     permissions: %s\n", issue)
+			}
 		}
 
 		if app.HasPackage() {
@@ -4,17 +4,18 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"runtime"
 
 	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/encryption"
 	"github.com/AntoineGS/tidydots/internal/platform"
 	"github.com/AntoineGS/tidydots/internal/state"
 	tmpl "github.com/AntoineGS/tidydots/internal/template"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 // File permissions constants
@@ -37,11 +38,20 @@ type Manager struct {
 	logger         *slog.Logger
 	templateEngine *tmpl.Engine
 	stateStore     *state.Store
+	cipher         *encryption.Cipher
+	fs             vfs.FS // filesystem view; an overlay during dry runs
+	journal        *journal
+	helper         *privilegedHelper // shared by copies; started on first sudo operation
+	events         EventSink
+	entryOp        string // operation, application and entry being processed,
+	entryApp       string // for journaling and events
+	entryName      string
 	DryRun         bool
 	Verbose        bool
 	NoMerge        bool
 	ForceDelete    bool
 	ForceRender    bool
+	Jobs           int // restore workers; 0 or 1 restores sequentially
 }
 
 // New creates a new Manager instance with the given configuration and platform information.
@@ -55,7 +65,7 @@ func New(cfg *config.Config, plat *platform.Platform) *Manager {
 
 	// Create template engine
 	tmplCtx := tmpl.NewContextFromPlatform(plat)
-	engine := tmpl.NewEngine(tmplCtx)
+	engine := tmpl.NewEngine(tmplCtx).WithStrict(cfg.Strict)
 
 	return &Manager{
 		Config:         cfg,
@@ -63,6 +73,8 @@ func New(cfg *config.Config, plat *platform.Platform) *Manager {
 		ctx:            context.Background(), // Default context
 		logger:         slog.New(handler),
 		templateEngine: engine,
+		fs:             vfs.OS{},
+		helper:         newPrivilegedHelper(),
 	}
 }
 
@@ -81,12 +93,19 @@ func (m *Manager) InitStateStore() error {
 	return nil
 }
 
-// Close releases resources held by the Manager, including the state store.
+// Close releases resources held by the Manager, including the state store and
+// the privileged helper.
 func (m *Manager) Close() error {
+	var helperErr error
+	if m.helper != nil {
+		helperErr = m.helper.Close()
+	}
+
 	if m.stateStore != nil {
-		return m.stateStore.Close()
+		return errors.Join(m.stateStore.Close(), helperErr)
 	}
-	return nil
+
+	return helperErr
 }
 
 // WithContext returns a new Manager with the given context
@@ -105,6 +124,27 @@ func (m *Manager) WithLogger(logger *slog.Logger) *Manager {
 	return &m2
 }
 
+// WithFS returns a new Manager that reads and writes files through fsys
+func (m *Manager) WithFS(fsys vfs.FS) *Manager {
+	m2 := *m
+	m2.fs = fsys
+
+	return &m2
+}
+
+// dryRunView returns the manager to run an operation with. In dry-run mode it
+// is a copy whose filesystem is an in-memory overlay, so the whole plan runs
+// (adoption, merges, renders, symlinks) and later steps see the effects of
+// earlier ones without anything reaching the disk. A manager already running
+// on an overlay is returned as is, so nested operations share one view.
+func (m *Manager) dryRunView() *Manager {
+	if _, ok := m.fs.(*vfs.Overlay); ok || !m.DryRun {
+		return m
+	}
+
+	return m.WithFS(vfs.NewOverlay(m.fs))
+}
+
 // WithVerbose returns a new Manager with adjusted log level based on verbose flag.
 // This follows the builder pattern used by WithContext and WithLogger.
 func (m *Manager) WithVerbose(verbose bool) *Manager {
@@ -138,6 +178,32 @@ func (m *Manager) GetApplications() []config.Application {
 	return m.Config.GetFilteredApplications(m.templateEngine)
 }
 
+// TemplateDiagnostics returns every template error in the configuration's
+// when expressions, backup paths and targets for the current OS.
+func (m *Manager) TemplateDiagnostics() []error {
+	return m.Config.CheckTemplates(m.templateEngine, config.NewMachine(m.Platform))
+}
+
+// checkTemplates logs all template diagnostics so they are not silently
+// hidden by path fallbacks. In strict mode any diagnostic aborts the operation
+// before anything is touched.
+func (m *Manager) checkTemplates() error {
+	diags := m.TemplateDiagnostics()
+	if len(diags) == 0 {
+		return nil
+	}
+
+	for _, d := range diags {
+		m.logger.Warn("template error", slog.String("error", d.Error()))
+	}
+
+	if m.Config.Strict {
+		return fmt.Errorf("%w (%d found, strict mode): %w", ErrTemplateErrors, len(diags), errors.Join(diags...))
+	}
+
+	return nil
+}
+
 // resolvePath expands templates, ~ and environment variables in paths and resolves
 // relative paths against BackupRoot. This ensures paths work correctly even when
 // stored with ~ in config.
@@ -177,7 +243,7 @@ func (m *Manager) HasOutdatedTemplates(backupDir string) bool {
 			return filepath.SkipAll
 		}
 
-		content, readErr := os.ReadFile(path) //nolint:gosec // path from config
+		content, readErr := m.fs.ReadFile(path)
 		if readErr != nil {
 			return nil
 		}
@@ -208,7 +274,7 @@ func (m *Manager) HasModifiedRenderedFiles(backupDir string) bool {
 		}
 
 		renderedPath := tmpl.RenderedPath(path)
-		renderedContent, readErr := os.ReadFile(renderedPath) //nolint:gosec // path from config
+		renderedContent, readErr := m.fs.ReadFile(renderedPath)
 		if readErr != nil {
 			return nil
 		}
@@ -224,95 +290,67 @@ func (m *Manager) HasModifiedRenderedFiles(backupDir string) bool {
 	return modified
 }
 
-// HasTemplateFiles returns true if the directory contains any .tmpl files.
+// HasTemplateFiles returns true if the directory contains any .tmpl files that
+// are not ignored.
 func (m *Manager) HasTemplateFiles(dir string) bool {
-	return hasTemplateFiles(dir)
+	return hasTemplateFiles(m.fs, dir, m.ignoreMatcherForBackup(dir))
 }
 
 func isSymlink(path string) bool {
-	info, err := os.Lstat(path)
-	if err != nil {
-		return false
-	}
-
-	if info.Mode()&os.ModeSymlink != 0 {
-		return true
-	}
-
-	// On Windows, directory junctions (mklink /J) are not reported as
-	// ModeSymlink in recent Go versions, but Readlink still resolves them.
-	if runtime.GOOS == platform.OSWindows {
-		_, err := os.Readlink(path)
-		return err == nil
-	}
-
-	return false
+	return vfs.IsSymlink(vfs.OS{}, path)
 }
 
 func pathExists(path string) bool {
-	_, err := os.Lstat(path)
-	return err == nil
+	return vfs.Exists(vfs.OS{}, path)
 }
 
-func copyFile(src, dst string) (err error) {
-	srcFile, openErr := os.Open(src) //nolint:gosec // file path from config
-	if openErr != nil {
-		return fmt.Errorf("opening source: %w", openErr)
-	}
-
-	defer func() {
-		if closeErr := srcFile.Close(); closeErr != nil && err == nil {
-			err = fmt.Errorf("closing source file: %w", closeErr)
-		}
-	}()
+// isSymlink reports whether path is a symlink in the manager's filesystem view.
+func (m *Manager) isSymlink(path string) bool {
+	return vfs.IsSymlink(m.fs, path)
+}
 
-	srcInfo, statErr := srcFile.Stat()
-	if statErr != nil {
-		return fmt.Errorf("stating source: %w", statErr)
-	}
+// pathExists reports whether path exists in the manager's filesystem view.
+func (m *Manager) pathExists(path string) bool {
+	return vfs.Exists(m.fs, path)
+}
 
-	if mkdirErr := os.MkdirAll(filepath.Dir(dst), DirPerms); mkdirErr != nil {
-		return fmt.Errorf("creating destination directory: %w", mkdirErr)
+func copyFile(fsys vfs.FS, src, dst string) error {
+	srcInfo, err := fsys.Stat(src)
+	if err != nil {
+		return fmt.Errorf("stating source: %w", err)
 	}
 
-	dstFile, createErr := os.Create(dst) //nolint:gosec // path from config
-	if createErr != nil {
-		return fmt.Errorf("creating destination: %w", createErr)
+	data, err := fsys.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("reading source: %w", err)
 	}
 
-	defer func() {
-		if cerr := dstFile.Close(); cerr != nil && err == nil {
-			err = fmt.Errorf("closing destination: %w", cerr)
-		}
-	}()
-
-	if _, err = io.Copy(dstFile, srcFile); err != nil {
-		return fmt.Errorf("copying data: %w", err)
+	if err := fsys.MkdirAll(filepath.Dir(dst), DirPerms); err != nil {
+		return fmt.Errorf("creating destination directory: %w", err)
 	}
 
-	// Explicitly sync before setting permissions
-	if err = dstFile.Sync(); err != nil {
-		return fmt.Errorf("syncing destination: %w", err)
+	if err := fsys.WriteFile(dst, data, srcInfo.Mode().Perm()); err != nil {
+		return fmt.Errorf("writing destination: %w", err)
 	}
 
-	if err = os.Chmod(dst, srcInfo.Mode()); err != nil {
+	if err := fsys.Chmod(dst, srcInfo.Mode()); err != nil {
 		return fmt.Errorf("setting permissions: %w", err)
 	}
 
 	return nil
 }
 
-func copyDir(src, dst string) error {
-	srcInfo, err := os.Stat(src)
+func copyDir(fsys vfs.FS, src, dst string) error {
+	srcInfo, err := fsys.Stat(src)
 	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
+	if err := fsys.MkdirAll(dst, srcInfo.Mode()); err != nil {
 		return err
 	}
 
-	entries, err := os.ReadDir(src)
+	entries, err := fsys.ReadDir(src)
 	if err != nil {
 		return err
 	}
@@ -322,11 +360,11 @@ func copyDir(src, dst string) error {
 		dstPath := filepath.Join(dst, entry.Name())
 
 		if entry.IsDir() {
-			if err := copyDir(srcPath, dstPath); err != nil {
+			if err := copyDir(fsys, srcPath, dstPath); err != nil {
 				return err
 			}
 		} else {
-			if err := copyFile(srcPath, dstPath); err != nil {
+			if err := copyFile(fsys, srcPath, dstPath); err != nil {
 				return err
 			}
 		}
@@ -335,8 +373,8 @@ func copyDir(src, dst string) error {
 	return nil
 }
 
-func removeAll(path string) error {
-	info, err := os.Lstat(path)
+func removeAll(fsys vfs.FS, path string) error {
+	info, err := fsys.Lstat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -350,7 +388,7 @@ func removeAll(path string) error {
 		return nil
 	}
 
-	return os.RemoveAll(path)
+	return fsys.RemoveAll(path)
 }
 
 // ModifiedTemplate contains the diff data for a single modified template file.
@@ -377,7 +415,7 @@ func (m *Manager) GetModifiedTemplateFiles(backupDir string) ([]ModifiedTemplate
 		}
 
 		renderedPath := tmpl.RenderedPath(path)
-		renderedContent, readErr := os.ReadFile(renderedPath) //nolint:gosec // path from config
+		renderedContent, readErr := m.fs.ReadFile(renderedPath)
 		if readErr != nil {
 			return nil
 		}
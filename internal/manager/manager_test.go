@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 func TestNew(t *testing.T) {
@@ -163,7 +165,7 @@ func TestCopyFile(t *testing.T) {
 
 	dstFile := filepath.Join(tmpDir, "subdir", "dest.txt")
 
-	if err := copyFile(srcFile, dstFile); err != nil {
+	if err := copyFile(vfs.OS{}, srcFile, dstFile); err != nil {
 		t.Fatalf("copyFile() error = %v", err)
 	}
 
@@ -196,7 +198,7 @@ func TestCopyDir(t *testing.T) {
 
 	dstDir := filepath.Join(tmpDir, "dest")
 
-	if err := copyDir(srcDir, dstDir); err != nil {
+	if err := copyDir(vfs.OS{}, srcDir, dstDir); err != nil {
 		t.Fatalf("copyDir() error = %v", err)
 	}
 
@@ -226,7 +228,7 @@ func TestRemoveAll(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := removeAll(regularFile); err != nil {
+	if err := removeAll(vfs.OS{}, regularFile); err != nil {
 		t.Fatalf("removeAll(regular file) error = %v", err)
 	}
 
@@ -243,7 +245,7 @@ func TestRemoveAll(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := removeAll(dir); err != nil {
+	if err := removeAll(vfs.OS{}, dir); err != nil {
 		t.Fatalf("removeAll(dir) error = %v", err)
 	}
 
@@ -261,7 +263,7 @@ func TestRemoveAll(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := removeAll(symlink); err != nil {
+	if err := removeAll(vfs.OS{}, symlink); err != nil {
 		t.Fatalf("removeAll(symlink) error = %v", err)
 	}
 
@@ -270,7 +272,7 @@ func TestRemoveAll(t *testing.T) {
 	}
 
 	// Test non-existent path (should not error)
-	if err := removeAll(filepath.Join(tmpDir, "nonexistent")); err != nil {
+	if err := removeAll(vfs.OS{}, filepath.Join(tmpDir, "nonexistent")); err != nil {
 		t.Fatalf("removeAll(nonexistent) error = %v", err)
 	}
 }
@@ -327,7 +329,7 @@ func TestCopyFilePreservesPermissions(t *testing.T) {
 
 	dstFile := filepath.Join(tmpDir, "dest.sh")
 
-	if err := copyFile(srcFile, dstFile); err != nil {
+	if err := copyFile(vfs.OS{}, srcFile, dstFile); err != nil {
 		t.Fatalf("copyFile() error = %v", err)
 	}
 
@@ -347,7 +349,7 @@ func TestCopyFileNonexistentSource(t *testing.T) {
 	srcFile := filepath.Join(tmpDir, "nonexistent.txt")
 	dstFile := filepath.Join(tmpDir, "dest.txt")
 
-	err := copyFile(srcFile, dstFile)
+	err := copyFile(vfs.OS{}, srcFile, dstFile)
 	if err == nil {
 		t.Error("copyFile() should error for nonexistent source")
 	}
@@ -360,7 +362,7 @@ func TestCopyDirNonexistentSource(t *testing.T) {
 	srcDir := filepath.Join(tmpDir, "nonexistent")
 	dstDir := filepath.Join(tmpDir, "dest")
 
-	err := copyDir(srcDir, dstDir)
+	err := copyDir(vfs.OS{}, srcDir, dstDir)
 	if err == nil {
 		t.Error("copyDir() should error for nonexistent source")
 	}
@@ -522,3 +524,72 @@ func TestManager_SatisfiesInterface(t *testing.T) {
 	var _ Backuper = m
 	var _ Lister = m
 }
+
+func TestRestore_TemplateDiagnostics(t *testing.T) {
+	newConfig := func(t *testing.T, strict bool) (*config.Config, string) {
+		t.Helper()
+
+		backupRoot := t.TempDir()
+		targetDir := t.TempDir()
+
+		if err := os.MkdirAll(filepath.Join(backupRoot, "zsh"), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(backupRoot, "zsh", ".zshrc"), []byte("# zsh"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		return &config.Config{
+			Version:    3,
+			BackupRoot: backupRoot,
+			Strict:     strict,
+			Applications: []config.Application{
+				{
+					Name: "zsh",
+					Entries: []config.SubEntry{
+						{Name: "zshrc", Backup: "./zsh", Files: []string{".zshrc"}, Targets: map[string]string{"linux": targetDir}},
+					},
+				},
+				{
+					Name: "typo",
+					Entries: []config.SubEntry{
+						{Name: "cfg", Backup: "./typo", Targets: map[string]string{"linux": "{{ .Env.TIDYDOTS_TEST_MISSING }}/cfg"}},
+					},
+				},
+			},
+		}, targetDir
+	}
+
+	plat := &platform.Platform{OS: platform.OSLinux, EnvVars: map[string]string{}}
+
+	t.Run("strict aborts before restoring", func(t *testing.T) {
+		cfg, targetDir := newConfig(t, true)
+		mgr := New(cfg, plat)
+
+		if diags := mgr.TemplateDiagnostics(); len(diags) != 1 {
+			t.Fatalf("TemplateDiagnostics() = %v, want 1 error", diags)
+		}
+
+		err := mgr.Restore()
+		if !errors.Is(err, ErrTemplateErrors) {
+			t.Fatalf("Restore() error = %v, want ErrTemplateErrors", err)
+		}
+		if pathExists(filepath.Join(targetDir, ".zshrc")) {
+			t.Error("strict mode should not restore anything when templates fail")
+		}
+	})
+
+	t.Run("lenient reports and continues", func(t *testing.T) {
+		cfg, targetDir := newConfig(t, false)
+		mgr := New(cfg, plat)
+
+		if diags := mgr.TemplateDiagnostics(); len(diags) != 0 {
+			t.Fatalf("TemplateDiagnostics() = %v, want none outside strict mode", diags)
+		}
+
+		_ = mgr.Restore()
+		if !isSymlink(filepath.Join(targetDir, ".zshrc")) {
+			t.Error("lenient mode should still restore valid entries")
+		}
+	})
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"io/fs"
 	"log/slog"
-	"os"
 	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/AntoineGS/tidydots/internal/ignore"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 // MergeSummary tracks merge operations for a single application.
@@ -93,22 +95,19 @@ func generateConflictNameWithDate(filename string) string {
 // If the file doesn't exist in backup, it's merged directly.
 //
 // Parameters:
+//   - fsys: Filesystem to inspect
+//   - ops: File operations to use (the privileged helper for sudo entries)
 //   - targetFile: Path to the file in the target location
 //   - backupDir: Directory where backup files are stored
 //   - relativePath: Relative path of the file (used for the backup location)
-//   - useSudo: Whether to use sudo for file operations (not yet implemented)
 //   - summary: MergeSummary to record the operation
 //
 // Returns error if the operation fails.
-func mergeFile(targetFile, backupDir, relativePath string, useSudo bool, summary *MergeSummary) error {
-	if useSudo {
-		return fmt.Errorf("sudo not yet supported")
-	}
-
+func mergeFile(fsys vfs.FS, ops fileOps, targetFile, backupDir, relativePath string, summary *MergeSummary) error {
 	backupFile := filepath.Join(backupDir, relativePath)
 
 	// Check if file exists in backup (conflict)
-	if pathExists(backupFile) {
+	if vfs.Exists(fsys, backupFile) {
 		// CONFLICT: Rename target file and move to backup
 		filename := filepath.Base(relativePath)
 		conflictName := generateConflictNameWithDate(filename)
@@ -118,15 +117,8 @@ func mergeFile(targetFile, backupDir, relativePath string, useSudo bool, summary
 			"file", relativePath,
 			"renamed_to", conflictName)
 
-		// Try to rename first (faster if same device)
-		if err := os.Rename(targetFile, conflictPath); err != nil {
-			// If rename fails (cross-device), copy then remove
-			if copyErr := copyFile(targetFile, conflictPath); copyErr != nil {
-				return fmt.Errorf("copying conflict file: %w", copyErr)
-			}
-			if removeErr := os.Remove(targetFile); removeErr != nil {
-				return fmt.Errorf("removing original target file: %w", removeErr)
-			}
+		if err := ops.Move(targetFile, conflictPath); err != nil {
+			return fmt.Errorf("moving conflict file: %w", err)
 		}
 
 		summary.AddConflict(relativePath, conflictName)
@@ -136,19 +128,12 @@ func mergeFile(targetFile, backupDir, relativePath string, useSudo bool, summary
 	// NO CONFLICT: Move file to backup
 	// Create parent directory if needed
 	backupParent := filepath.Dir(backupFile)
-	if err := os.MkdirAll(backupParent, DirPerms); err != nil {
+	if err := ops.MkdirAll(backupParent); err != nil {
 		return fmt.Errorf("creating backup directory: %w", err)
 	}
 
-	// Try to rename first (faster if same device)
-	if err := os.Rename(targetFile, backupFile); err != nil {
-		// If rename fails (cross-device), copy then remove
-		if copyErr := copyFile(targetFile, backupFile); copyErr != nil {
-			return fmt.Errorf("copying file to backup: %w", copyErr)
-		}
-		if removeErr := os.Remove(targetFile); removeErr != nil {
-			return fmt.Errorf("removing original target file: %w", removeErr)
-		}
+	if err := ops.Move(targetFile, backupFile); err != nil {
+		return fmt.Errorf("moving file to backup: %w", err)
 	}
 
 	slog.Info("Merged file into backup",
@@ -158,24 +143,32 @@ func mergeFile(targetFile, backupDir, relativePath string, useSudo bool, summary
 	return nil
 }
 
-// MergeFolder recursively merges all files from targetDir into backupDir.
+// mergeFolder recursively merges all files from targetDir into backupDir.
 // It walks the target directory tree and calls mergeFile for each file found.
-// Directories are skipped (only files are processed).
+// Directories are skipped (only files are processed), and paths matching ign
+// are left in the target.
 // Individual file errors are logged but don't stop the overall operation.
 //
 // Parameters:
+//   - fsys: Filesystem to walk
+//   - ops: File operations to use (the privileged helper for sudo entries)
 //   - backupDir: Directory where backup files are stored
 //   - targetDir: Directory to merge files from
-//   - useSudo: Whether to use sudo for file operations (not yet implemented)
+//   - ign: Ignore rules of the entry (nil merges everything)
 //   - summary: MergeSummary to record all operations
 //
 // Returns error only if the directory walk itself fails.
-func MergeFolder(backupDir, targetDir string, useSudo bool, summary *MergeSummary) error {
-	return filepath.WalkDir(targetDir, func(path string, d fs.DirEntry, err error) error {
+func mergeFolder(fsys vfs.FS, ops fileOps, backupDir, targetDir string, ign *ignore.Matcher, summary *MergeSummary) error {
+	return vfs.WalkDir(fsys, targetDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
+		if skip, skipErr := skipIgnored(ign, targetDir, path, d); skip {
+			slog.Debug("Skipping ignored path", "path", path)
+			return skipErr
+		}
+
 		// Skip directories, only process files
 		if d.IsDir() {
 			return nil
@@ -193,7 +186,7 @@ func MergeFolder(backupDir, targetDir string, useSudo bool, summary *MergeSummar
 		}
 
 		// Merge the file
-		if err := mergeFile(path, backupDir, relativePath, useSudo, summary); err != nil {
+		if err := mergeFile(fsys, ops, path, backupDir, relativePath, summary); err != nil {
 			slog.Error("Failed to merge file",
 				"file", relativePath,
 				"error", err)
@@ -208,18 +201,20 @@ func MergeFolder(backupDir, targetDir string, useSudo bool, summary *MergeSummar
 // removeEmptyDirs removes empty directories in a bottom-up manner.
 // It walks the directory tree, collects all subdirectories (excluding the root),
 // and attempts to remove them in reverse order (deepest first).
-// Only truly empty directories will be removed; os.Remove will fail for non-empty dirs.
+// Only truly empty directories will be removed; ops.Remove fails for non-empty dirs.
 //
 // Parameters:
+//   - fsys: Filesystem to walk
+//   - ops: File operations to use (the privileged helper for sudo entries)
 //   - rootDir: The root directory to clean up (will not be removed itself)
 //
 // Returns error only if the directory walk itself fails.
 // Errors from individual directory removals are logged but don't stop the operation.
-func removeEmptyDirs(rootDir string) error {
+func removeEmptyDirs(fsys vfs.FS, ops fileOps, rootDir string) error {
 	// Collect all subdirectories (not the root itself)
 	var dirs []string
 
-	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+	err := vfs.WalkDir(fsys, rootDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -245,7 +240,7 @@ func removeEmptyDirs(rootDir string) error {
 	for i := len(dirs) - 1; i >= 0; i-- {
 		dir := dirs[i]
 
-		if err := os.Remove(dir); err != nil {
+		if err := ops.Remove(dir); err != nil {
 			// Ignore "directory not empty" errors (expected for dirs with content)
 			// Log other errors at debug level
 			if !errors.Is(err, syscall.ENOTEMPTY) {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 func TestMergeSummary_Add(t *testing.T) {
@@ -234,7 +235,7 @@ func TestMergeFile_NoConflict(t *testing.T) {
 	summary := NewMergeSummary("test-app")
 
 	// Act: Merge the file
-	err := mergeFile(targetFile, backupDir, "unique.txt", false, summary)
+	err := mergeFile(vfs.OS{}, fsOps{fs: vfs.OS{}}, targetFile, backupDir, "unique.txt", summary)
 
 	// Assert: No error
 	if err != nil {
@@ -290,7 +291,7 @@ func TestMergeFile_WithConflict(t *testing.T) {
 	summary := NewMergeSummary("test-app")
 
 	// Act: Merge the file
-	err := mergeFile(targetFile, backupDir, "config.json", false, summary)
+	err := mergeFile(vfs.OS{}, fsOps{fs: vfs.OS{}}, targetFile, backupDir, "config.json", summary)
 
 	// Assert: No error
 	if err != nil {
@@ -372,11 +373,11 @@ func TestMergeFolder_Recursive(t *testing.T) {
 	summary := NewMergeSummary("test-app")
 
 	// Act: Merge the entire folder
-	err := MergeFolder(backupDir, targetDir, false, summary)
+	err := mergeFolder(vfs.OS{}, fsOps{fs: vfs.OS{}}, backupDir, targetDir, nil, summary)
 
 	// Assert: No error
 	if err != nil {
-		t.Fatalf("MergeFolder() error = %v, want nil", err)
+		t.Fatalf("mergeFolder() error = %v, want nil", err)
 	}
 
 	// Assert: All files were merged
@@ -475,11 +476,11 @@ func TestMergeFolder_WithConflicts(t *testing.T) {
 	summary := NewMergeSummary("test-app")
 
 	// Act: Merge the entire folder
-	err := MergeFolder(backupDir, targetDir, false, summary)
+	err := mergeFolder(vfs.OS{}, fsOps{fs: vfs.OS{}}, backupDir, targetDir, nil, summary)
 
 	// Assert: No error
 	if err != nil {
-		t.Fatalf("MergeFolder() error = %v, want nil", err)
+		t.Fatalf("mergeFolder() error = %v, want nil", err)
 	}
 
 	// Assert: Unique files were merged
@@ -572,7 +573,7 @@ func TestRemoveEmptyDirs(t *testing.T) {
 	}
 
 	// Act: Remove empty directories
-	err := removeEmptyDirs(rootDir)
+	err := removeEmptyDirs(vfs.OS{}, fsOps{fs: vfs.OS{}}, rootDir)
 
 	// Assert: No error
 	if err != nil {
@@ -764,11 +765,11 @@ func TestMergeFolder_SymlinkInTarget(t *testing.T) {
 	summary := NewMergeSummary("test-app")
 
 	// Act: Merge the folder
-	err := MergeFolder(backupDir, targetDir, false, summary)
+	err := mergeFolder(vfs.OS{}, fsOps{fs: vfs.OS{}}, backupDir, targetDir, nil, summary)
 
 	// Assert: No error
 	if err != nil {
-		t.Fatalf("MergeFolder() error = %v, want nil", err)
+		t.Fatalf("mergeFolder() error = %v, want nil", err)
 	}
 
 	// Assert: 2 files were merged
@@ -870,9 +871,9 @@ func TestMergeFolder_DuplicateConflicts(t *testing.T) {
 	}
 
 	summary1 := NewMergeSummary("test-app")
-	err := MergeFolder(backupDir, targetDir, false, summary1)
+	err := mergeFolder(vfs.OS{}, fsOps{fs: vfs.OS{}}, backupDir, targetDir, nil, summary1)
 	if err != nil {
-		t.Fatalf("First MergeFolder() error = %v", err)
+		t.Fatalf("First mergeFolder() error = %v", err)
 	}
 
 	// Assert: First conflict file was created
@@ -894,9 +895,9 @@ func TestMergeFolder_DuplicateConflicts(t *testing.T) {
 	}
 
 	summary2 := NewMergeSummary("test-app")
-	err = MergeFolder(backupDir, targetDir, false, summary2)
+	err = mergeFolder(vfs.OS{}, fsOps{fs: vfs.OS{}}, backupDir, targetDir, nil, summary2)
 	if err != nil {
-		t.Fatalf("Second MergeFolder() error = %v", err)
+		t.Fatalf("Second mergeFolder() error = %v", err)
 	}
 
 	// Assert: Still only 1 conflict file (current behavior: overwrites)
@@ -927,11 +928,11 @@ func TestMergeFolder_EmptyTargetDir(t *testing.T) {
 	summary := NewMergeSummary("test-app")
 
 	// Act: Merge empty folder
-	err := MergeFolder(backupDir, targetDir, false, summary)
+	err := mergeFolder(vfs.OS{}, fsOps{fs: vfs.OS{}}, backupDir, targetDir, nil, summary)
 
 	// Assert: No error
 	if err != nil {
-		t.Fatalf("MergeFolder() error = %v, want nil", err)
+		t.Fatalf("mergeFolder() error = %v, want nil", err)
 	}
 
 	// Assert: No operations recorded
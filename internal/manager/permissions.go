@@ -0,0 +1,476 @@
+package manager
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/encryption"
+	"github.com/AntoineGS/tidydots/internal/platform"
+	tmpl "github.com/AntoineGS/tidydots/internal/template"
+	"github.com/AntoineGS/tidydots/internal/vfs"
+	"gopkg.in/yaml.v3"
+)
+
+// PermissionsFile records, at the backup root, the modes and ownership captured
+// on backup. Git only tracks the executable bit, so without it a fresh checkout
+// would restore every file with the checkout's default mode.
+const PermissionsFile = ".tidydots-permissions.yaml"
+
+// modeMask selects the mode bits tidydots manages.
+const modeMask = fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
+// permissionsManifest maps "app/entry" to the recorded permissions of each
+// managed file, keyed by its path relative to the target.
+type permissionsManifest map[string]map[string]config.Permissions
+
+// maxLinkHops bounds how many symlinks linksIntoBackup follows.
+const maxLinkHops = 8
+
+// permissionsMu serializes manifest updates from entries processed concurrently.
+var permissionsMu sync.Mutex
+
+// permissionChange is the difference between a managed file's current and
+// declared permissions.
+type permissionChange struct {
+	path       string
+	mode       fs.FileMode
+	setMode    bool
+	uid, gid   int // -1 when unchanged
+	info       fs.FileInfo
+	owner      string // declared owner and group, for reporting
+	group      string
+	curUID     int
+	curGID     int
+	hasCurrent bool // curUID and curGID are known
+}
+
+func permissionsKey(app, entry string) string {
+	return app + "/" + entry
+}
+
+func (m *Manager) permissionsPath() string {
+	if m.Config.BackupRoot == "" {
+		return ""
+	}
+
+	return filepath.Join(config.ExpandPath(m.Config.BackupRoot, m.Platform.EnvVars), PermissionsFile)
+}
+
+// loadPermissions reads the manifest. A missing manifest is empty.
+func (m *Manager) loadPermissions() (permissionsManifest, error) {
+	manifest := permissionsManifest{}
+
+	path := m.permissionsPath()
+	if path == "" {
+		return manifest, nil
+	}
+
+	data, err := m.fs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, NewPathError("read", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, NewPathError("read", path, fmt.Errorf("parsing permissions: %w", err))
+	}
+
+	return manifest, nil
+}
+
+func (m *Manager) savePermissions(manifest permissionsManifest) error {
+	path := m.permissionsPath()
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return NewPathError("write", path, err)
+	}
+
+	header := []byte("# Recorded by tidydots backup; restore applies these permissions.\n")
+	if err := m.fs.WriteFile(path, append(header, data...), 0o644); err != nil { //nolint:gosec // tracked in the repository
+		return NewPathError("write", path, err)
+	}
+
+	return nil
+}
+
+// managedFiles returns the files an entry manages, relative to its target.
+// Folder entries are listed from the backup: templates and encrypted files
+// count under the name they have in the target, and their rendered, conflict
+// and decrypted copies are skipped.
+func (m *Manager) managedFiles(subEntry config.SubEntry, backupPath string) ([]string, error) {
+	if !subEntry.IsFolder() {
+		return subEntry.Files, nil
+	}
+
+	ign, err := m.ignoreMatcher(subEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+
+	err = vfs.WalkDir(m.fs, backupPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if skip, skipErr := skipIgnored(ign, backupPath, path, d); skip {
+			return skipErr
+		}
+
+		name := d.Name()
+		if d.IsDir() || tmpl.IsRenderedFile(name) || tmpl.IsConflictFile(name) || encryption.IsDecryptedFile(name) {
+			return nil
+		}
+
+		switch {
+		case tmpl.IsTemplateFile(name):
+			name = tmpl.TargetName(name)
+		case encryption.IsEncryptedFile(name):
+			name = encryption.TargetName(name)
+		}
+
+		rel, err := filepath.Rel(backupPath, filepath.Join(filepath.Dir(path), name))
+		if err != nil {
+			return err
+		}
+
+		seen[rel] = true
+
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, NewPathError("walk", backupPath, err)
+	}
+
+	files := make([]string, 0, len(seen))
+	for rel := range seen {
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// permissionChanges compares the managed files under target with the
+// permissions recorded in the manifest and declared in the configuration,
+// which take precedence. Files that do not exist are skipped, as are files
+// linked into the backup repository (see linksIntoBackup).
+func (m *Manager) permissionChanges(app string, subEntry config.SubEntry, target string) ([]permissionChange, error) {
+	if runtime.GOOS == platform.OSWindows {
+		return nil, nil
+	}
+
+	permissionsMu.Lock()
+	manifest, err := m.loadPermissions()
+	permissionsMu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := manifest[permissionsKey(app, subEntry.Name)]
+	if len(recorded) == 0 && !subEntry.HasPermissions() {
+		return nil, nil
+	}
+
+	files, err := m.managedFiles(subEntry, m.resolvePath(subEntry.Backup))
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []permissionChange
+
+	for _, rel := range files {
+		want := recorded[filepath.ToSlash(rel)].Merge(subEntry.PermissionsFor(rel))
+		if want.IsZero() {
+			continue
+		}
+
+		path := filepath.Join(target, rel)
+		if m.linksIntoBackup(path, target, subEntry.IsFolder()) {
+			continue
+		}
+
+		info, err := m.fs.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, NewPathError("stat", path, err)
+		}
+
+		change, err := diffPermissions(path, info, want)
+		if err != nil {
+			return nil, NewPathError("permissions", path, err)
+		}
+
+		if change.setMode || change.uid != -1 || change.gid != -1 {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// linksIntoBackup reports whether path, a managed file under an entry's target,
+// is reached through a restore symlink and resolves to a file of the backup
+// repository. Those files belong to the user's checkout, so their permissions
+// are left alone. Rendered templates and decrypted secrets are generated for
+// this machine and ignored by git, so links to them do not count.
+func (m *Manager) linksIntoBackup(path, target string, folder bool) bool {
+	// Folder entries link the whole target directory into the backup
+	linked := folder && m.isSymlink(target)
+
+	for i := 0; i < maxLinkHops && m.isSymlink(path); i++ {
+		dest, err := m.fs.Readlink(path)
+		if err != nil {
+			break
+		}
+
+		if !filepath.IsAbs(dest) {
+			dest = filepath.Join(filepath.Dir(path), dest)
+		}
+
+		path, linked = dest, true
+	}
+
+	name := filepath.Base(path)
+
+	return linked && !tmpl.IsRenderedFile(name) && !encryption.IsDecryptedFile(name)
+}
+
+func diffPermissions(path string, info fs.FileInfo, want config.Permissions) (permissionChange, error) {
+	change := permissionChange{path: path, info: info, uid: -1, gid: -1, owner: want.Owner, group: want.Group}
+
+	mode, ok, err := want.FileMode()
+	if err != nil {
+		return change, err
+	}
+
+	if ok && info.Mode()&modeMask != mode {
+		change.mode = mode
+		change.setMode = true
+	}
+
+	if want.Owner == "" && want.Group == "" {
+		return change, nil
+	}
+
+	uid, gid, err := lookupOwner(want.Owner, want.Group)
+	if err != nil {
+		return change, err
+	}
+
+	change.curUID, change.curGID, change.hasCurrent = fileOwner(info)
+	if !change.hasCurrent {
+		return change, nil
+	}
+
+	if uid != -1 && uid != change.curUID {
+		change.uid = uid
+	}
+
+	if gid != -1 && gid != change.curGID {
+		change.gid = gid
+	}
+
+	return change, nil
+}
+
+// applyPermissions sets the declared and recorded permissions on the files of
+// a restored entry, through the privileged helper for sudo entries.
+func (m *Manager) applyPermissions(subEntry config.SubEntry, target string) error {
+	changes, err := m.permissionChanges(m.entryApp, subEntry, target)
+	if err != nil {
+		return err
+	}
+
+	ops := m.fileOps(subEntry.Sudo)
+
+	for _, c := range changes {
+		if c.setMode {
+			m.logger.Info("setting mode", slog.String("path", c.path), slog.String("mode", config.FormatMode(c.mode)))
+
+			if err := ops.Chmod(c.path, c.mode); err != nil {
+				return NewPathError("chmod", c.path, err)
+			}
+		}
+
+		if c.uid != -1 || c.gid != -1 {
+			m.logger.Info("setting owner",
+				slog.String("path", c.path),
+				slog.String("owner", c.owner),
+				slog.String("group", c.group))
+
+			if err := ops.Chown(c.path, c.uid, c.gid); err != nil {
+				return NewPathError("chown", c.path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// permissionIssues describes how the files of an entry differ from their
+// declared and recorded permissions.
+func (m *Manager) permissionIssues(app string, subEntry config.SubEntry, target string) ([]string, error) {
+	changes, err := m.permissionChanges(app, subEntry, target)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]string, 0, len(changes))
+
+	for _, c := range changes {
+		if c.setMode {
+			issues = append(issues, fmt.Sprintf("%s: mode %s, want %s",
+				c.path, config.FormatMode(c.info.Mode()), config.FormatMode(c.mode)))
+		}
+
+		if c.uid != -1 {
+			issues = append(issues, fmt.Sprintf("%s: owner %s, want %s", c.path, userName(c.curUID), c.owner))
+		}
+
+		if c.gid != -1 {
+			issues = append(issues, fmt.Sprintf("%s: group %s, want %s", c.path, groupName(c.curGID), c.group))
+		}
+	}
+
+	return issues, nil
+}
+
+// recordPermissions stores the modes of an entry's files in the manifest, so a
+// fresh checkout restores them. Modes a checkout produces anyway (0644 and
+// 0755) are not recorded; for sudo entries, owners other than the current user
+// are recorded too.
+func (m *Manager) recordPermissions(app string, subEntry config.SubEntry, target string) error {
+	if runtime.GOOS == platform.OSWindows || m.Config.BackupRoot == "" {
+		return nil
+	}
+
+	files, err := m.managedFiles(subEntry, m.resolvePath(subEntry.Backup))
+	if err != nil {
+		return err
+	}
+
+	section := map[string]config.Permissions{}
+
+	for _, rel := range files {
+		info, err := m.fs.Stat(filepath.Join(target, rel))
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		var p config.Permissions
+
+		if mode := info.Mode() & modeMask; mode != 0o644 && mode != 0o755 {
+			p.Mode = config.FormatMode(mode)
+		}
+
+		if uid, gid, ok := fileOwner(info); ok && subEntry.Sudo {
+			if uid != os.Getuid() {
+				p.Owner = userName(uid)
+			}
+
+			if gid != os.Getgid() {
+				p.Group = groupName(gid)
+			}
+		}
+
+		if !p.IsZero() {
+			section[filepath.ToSlash(rel)] = p
+		}
+	}
+
+	permissionsMu.Lock()
+	defer permissionsMu.Unlock()
+
+	manifest, err := m.loadPermissions()
+	if err != nil {
+		return err
+	}
+
+	key := permissionsKey(app, subEntry.Name)
+	if reflect.DeepEqual(manifest[key], section) || (len(manifest[key]) == 0 && len(section) == 0) {
+		return nil
+	}
+
+	if len(section) == 0 {
+		delete(manifest, key)
+	} else {
+		manifest[key] = section
+	}
+
+	return m.savePermissions(manifest)
+}
+
+// lookupOwner resolves an owner and group, given as names or numeric ids.
+// Empty values resolve to -1, which leaves the attribute unchanged.
+func lookupOwner(owner, group string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+
+	if owner != "" {
+		if uid, err = strconv.Atoi(owner); err != nil {
+			u, lookupErr := user.Lookup(owner)
+			if lookupErr != nil {
+				return 0, 0, fmt.Errorf("owner %q: %w", owner, lookupErr)
+			}
+
+			if uid, err = strconv.Atoi(u.Uid); err != nil {
+				return 0, 0, fmt.Errorf("owner %q: %w", owner, err)
+			}
+		}
+	}
+
+	if group != "" {
+		if gid, err = strconv.Atoi(group); err != nil {
+			g, lookupErr := user.LookupGroup(group)
+			if lookupErr != nil {
+				return 0, 0, fmt.Errorf("group %q: %w", group, lookupErr)
+			}
+
+			if gid, err = strconv.Atoi(g.Gid); err != nil {
+				return 0, 0, fmt.Errorf("group %q: %w", group, err)
+			}
+		}
+	}
+
+	return uid, gid, nil
+}
+
+// userName returns the name of a uid, or the uid itself when it has none.
+func userName(uid int) string {
+	id := strconv.Itoa(uid)
+	if u, err := user.LookupId(id); err == nil {
+		return u.Username
+	}
+
+	return id
+}
+
+// groupName returns the name of a gid, or the gid itself when it has none.
+func groupName(gid int) string {
+	id := strconv.Itoa(gid)
+	if g, err := user.LookupGroupId(id); err == nil {
+		return g.Name
+	}
+
+	return id
+}
@@ -0,0 +1,224 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/platform"
+)
+
+func skipOnWindows(t *testing.T) {
+	t.Helper()
+
+	if runtime.GOOS == platform.OSWindows {
+		t.Skip("modes and owners are not managed on Windows")
+	}
+}
+
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != want {
+		t.Errorf("%s mode = %04o, want %04o", path, got, want)
+	}
+}
+
+func TestRestore_AppliesDeclaredModes(t *testing.T) {
+	skipOnWindows(t)
+
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	backup := filepath.Join(backupRoot, "ssh")
+	writeTestFile(t, filepath.Join(backup, "config"), "Host *")
+	writeTestFile(t, filepath.Join(backup, "id_ed25519"), "key")
+	writeTestFile(t, filepath.Join(backup, "rc.tmpl"), "export OS={{ .OS }}")
+	for _, name := range []string{"config", "id_ed25519", "rc.tmpl"} {
+		if err := os.Chmod(filepath.Join(backup, name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target := filepath.Join(homeDir, ".ssh")
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "ssh", Entries: []config.SubEntry{{
+			Name:        "ssh",
+			Backup:      "./ssh",
+			Permissions: config.Permissions{Mode: "0600"},
+			FilePermissions: map[string]config.Permissions{
+				"config": {Mode: "0640"},
+				"rc":     {Mode: "0700"},
+			},
+			Targets: map[string]string{"linux": target},
+		}}},
+	})
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	// The target links into the backup, whose files belong to the checkout
+	assertMode(t, filepath.Join(backup, "id_ed25519"), 0o644)
+	assertMode(t, filepath.Join(backup, "config"), 0o644)
+	assertMode(t, filepath.Join(target, "rc"), 0o700) // the rendered file
+	assertMode(t, filepath.Join(backup, "rc.tmpl"), 0o644)
+}
+
+func TestRestore_LeavesLinkedBackupFilesAlone(t *testing.T) {
+	skipOnWindows(t)
+
+	backupRoot := t.TempDir()
+	backup := filepath.Join(backupRoot, "app")
+	writeTestFile(t, filepath.Join(backup, "token"), "secret")
+	if err := os.Chmod(filepath.Join(backup, "token"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(t.TempDir(), "app")
+	entry := config.SubEntry{
+		Name:            "config",
+		Backup:          "./app",
+		Files:           []string{"token"},
+		FilePermissions: map[string]config.Permissions{"token": {Mode: "0600"}},
+		Targets:         map[string]string{"linux": target},
+	}
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{{Name: "app", Entries: []config.SubEntry{entry}}})
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if info, err := os.Lstat(filepath.Join(target, "token")); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("target token is not a symlink into the backup: %v", err)
+	}
+	assertMode(t, filepath.Join(backup, "token"), 0o644)
+
+	issues, err := mgr.permissionIssues("app", entry, target)
+	if err != nil {
+		t.Fatalf("permissionIssues() error = %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("permissionIssues() = %q, want none for a linked backup file", issues)
+	}
+}
+
+func TestBackup_RecordsModesForFreshRestore(t *testing.T) {
+	skipOnWindows(t)
+
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	target := filepath.Join(homeDir, ".config", "app")
+	writeTestFile(t, filepath.Join(target, "token"), "secret")
+	writeTestFile(t, filepath.Join(target, "app.conf"), "conf")
+	if err := os.Chmod(filepath.Join(target, "app.conf"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	apps := []config.Application{
+		{Name: "app", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./app", Files: []string{"token", "app.conf"}, Targets: map[string]string{"linux": target}},
+		}},
+	}
+
+	if err := newJournalTestManager(t, backupRoot, apps).Backup(); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(backupRoot, PermissionsFile)) //nolint:gosec // test path
+	if err != nil {
+		t.Fatalf("reading manifest: %v", err)
+	}
+	if !strings.Contains(string(data), "token") || strings.Contains(string(data), "app.conf") {
+		t.Errorf("manifest = %q, want only the 0600 token recorded", data)
+	}
+
+	// A fresh checkout loses the mode. Files linked into the checkout are left
+	// alone, so restoring on a new machine gives it back to the rendered file
+	// once the token is a template of a folder entry
+	token := filepath.Join(backupRoot, "app", "token")
+	if err := os.Chmod(token, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(token, token+".tmpl"); err != nil {
+		t.Fatal(err)
+	}
+	newTarget := filepath.Join(t.TempDir(), "app")
+	apps[0].Entries[0].Files = nil
+	apps[0].Entries[0].Targets["linux"] = newTarget
+
+	if err := newJournalTestManager(t, backupRoot, apps).Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	assertMode(t, filepath.Join(newTarget, "token"), 0o600)
+	assertMode(t, filepath.Join(newTarget, "app.conf"), 0o644)
+}
+
+func TestPermissionIssues(t *testing.T) {
+	skipOnWindows(t)
+
+	backupRoot := t.TempDir()
+	target := filepath.Join(t.TempDir(), "app")
+	writeTestFile(t, filepath.Join(target, "secret"), "s")
+	if err := os.Chmod(filepath.Join(target, "secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := config.SubEntry{
+		Name:            "config",
+		Backup:          "./app",
+		Files:           []string{"secret"},
+		FilePermissions: map[string]config.Permissions{"secret": {Mode: "0600", Owner: "0"}},
+		Targets:         map[string]string{"linux": target},
+	}
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{{Name: "app", Entries: []config.SubEntry{entry}}})
+
+	issues, err := mgr.permissionIssues("app", entry, target)
+	if err != nil {
+		t.Fatalf("permissionIssues() error = %v", err)
+	}
+
+	want := 1
+	if os.Getuid() != 0 {
+		want = 2 // the owner differs too
+	}
+	if len(issues) != want || !strings.Contains(issues[0], "want 0600") {
+		t.Errorf("permissionIssues() = %q, want %d issues starting with the mode", issues, want)
+	}
+}
+
+func TestRestore_DryRunLeavesModes(t *testing.T) {
+	skipOnWindows(t)
+
+	backupRoot := t.TempDir()
+	backup := filepath.Join(backupRoot, "app")
+	writeTestFile(t, filepath.Join(backup, "key"), "k")
+	if err := os.Chmod(filepath.Join(backup, "key"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{{
+			Name:        "config",
+			Backup:      "./app",
+			Permissions: config.Permissions{Mode: "0600"},
+			Targets:     map[string]string{"linux": filepath.Join(t.TempDir(), "app")},
+		}}},
+	})
+	mgr.DryRun = true
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	assertMode(t, filepath.Join(backup, "key"), 0o644)
+}
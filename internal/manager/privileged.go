@@ -0,0 +1,446 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"syscall"
+
+	"github.com/AntoineGS/tidydots/internal/platform"
+	"github.com/AntoineGS/tidydots/internal/vfs"
+)
+
+// HelperCommand is the hidden subcommand that runs the privileged helper.
+// The CLI re-executes itself as `sudo tidydots <HelperCommand>` the first
+// time a sudo entry needs a file operation.
+const HelperCommand = "privileged-helper"
+
+// fileOps performs the filesystem changes made by restore, backup, merge and
+// rollback. fsOps runs them in-process; the privileged helper runs the same
+// operations as root.
+type fileOps interface {
+	// MkdirAll creates a directory and any missing parents.
+	MkdirAll(dir string) error
+	// Move renames from to to, copying then deleting across devices.
+	Move(from, to string) error
+	// Copy copies a file, directory or symlink, preserving symlinks as links.
+	Copy(from, to string) error
+	// CopyContents copies a file, or the contents of a directory into to,
+	// following symlinks.
+	CopyContents(from, to string) error
+	// Remove deletes a file, symlink or empty directory.
+	Remove(path string) error
+	// RemoveAll deletes a path recursively. Symlinks are left alone.
+	RemoveAll(path string) error
+	// Symlink creates target pointing at source.
+	Symlink(source, target string) error
+	// Chmod sets the mode of path, following symlinks.
+	Chmod(path string, mode fs.FileMode) error
+	// Chown sets the owner and group of path, following symlinks. An id of
+	// -1 leaves that attribute unchanged.
+	Chown(path string, uid, gid int) error
+}
+
+// fileOps returns the operations to use for an entry: the privileged helper
+// for sudo entries, operations on the manager's filesystem view otherwise.
+// Dry runs never elevate; their changes stay in the overlay.
+func (m *Manager) fileOps(sudo bool) fileOps {
+	if sudo && !m.DryRun && runtime.GOOS != platform.OSWindows && m.helper != nil {
+		return m.helper
+	}
+
+	return fsOps{fs: m.fs}
+}
+
+// fsOps performs file operations in the current process through a vfs.FS.
+type fsOps struct {
+	fs vfs.FS
+}
+
+func (o fsOps) MkdirAll(dir string) error {
+	return o.fs.MkdirAll(dir, DirPerms)
+}
+
+func (o fsOps) Move(from, to string) error {
+	if err := o.fs.Rename(from, to); err == nil {
+		return nil
+	}
+
+	// Cross-device: copy then remove
+	info, err := o.fs.Lstat(from)
+	if err != nil {
+		return fmt.Errorf("moving %s: %w", from, err)
+	}
+
+	if info.IsDir() {
+		err = copyDir(o.fs, from, to)
+	} else {
+		err = copyFile(o.fs, from, to)
+	}
+	if err != nil {
+		return fmt.Errorf("copying %s: %w", from, err)
+	}
+
+	return o.fs.RemoveAll(from)
+}
+
+func (o fsOps) Copy(from, to string) error {
+	info, err := o.fs.Lstat(from)
+	if err != nil {
+		return fmt.Errorf("copying %s: %w", from, err)
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		var link string
+		if link, err = o.fs.Readlink(from); err == nil {
+			err = o.fs.Symlink(link, to)
+		}
+	case info.IsDir():
+		err = copyDir(o.fs, from, to)
+	default:
+		err = copyFile(o.fs, from, to)
+	}
+
+	if err != nil {
+		return fmt.Errorf("copying %s: %w", from, err)
+	}
+
+	return nil
+}
+
+func (o fsOps) CopyContents(from, to string) error {
+	info, err := o.fs.Stat(from)
+	if err != nil {
+		return fmt.Errorf("copying %s: %w", from, err)
+	}
+
+	if info.IsDir() {
+		return copyDir(o.fs, from, to)
+	}
+
+	return copyFile(o.fs, from, to)
+}
+
+func (o fsOps) Remove(path string) error {
+	return o.fs.Remove(path)
+}
+
+func (o fsOps) RemoveAll(path string) error {
+	return removeAll(o.fs, path)
+}
+
+func (o fsOps) Symlink(source, target string) error {
+	return o.fs.Symlink(source, target)
+}
+
+func (o fsOps) Chmod(path string, mode fs.FileMode) error {
+	return o.fs.Chmod(path, mode)
+}
+
+func (o fsOps) Chown(path string, uid, gid int) error {
+	return o.fs.Chown(path, uid, gid)
+}
+
+// Operations understood by the privileged helper.
+const (
+	helperPing         = "ping"
+	helperMkdirAll     = "mkdir_all"
+	helperMove         = "move"
+	helperCopy         = "copy"
+	helperCopyContents = "copy_contents"
+	helperRemove       = "remove"
+	helperRemoveAll    = "remove_all"
+	helperSymlink      = "symlink"
+	helperChmod        = "chmod"
+	helperChown        = "chown"
+)
+
+// Error codes returned by the privileged helper, mapped back to fs errors.
+const (
+	helperErrNotExist   = "not_exist"
+	helperErrExist      = "exist"
+	helperErrPermission = "permission"
+	helperErrNotEmpty   = "not_empty"
+)
+
+// helperRequest is one line sent to the privileged helper.
+type helperRequest struct {
+	Op   string      `json:"op"`
+	Path string      `json:"path,omitempty"`
+	To   string      `json:"to,omitempty"`
+	Mode fs.FileMode `json:"mode,omitempty"`
+	UID  *int        `json:"uid,omitempty"`
+	GID  *int        `json:"gid,omitempty"`
+}
+
+// helperResponse is the helper's reply to one request.
+type helperResponse struct {
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+// HelperError is an error reported by the privileged helper. It unwraps to
+// fs.ErrNotExist, fs.ErrExist, fs.ErrPermission or syscall.ENOTEMPTY when the
+// failure was one of those.
+type HelperError struct {
+	Msg  string
+	Code string
+}
+
+func (e *HelperError) Error() string {
+	return e.Msg
+}
+
+func (e *HelperError) Unwrap() error {
+	switch e.Code {
+	case helperErrNotExist:
+		return fs.ErrNotExist
+	case helperErrExist:
+		return fs.ErrExist
+	case helperErrPermission:
+		return fs.ErrPermission
+	case helperErrNotEmpty:
+		return syscall.ENOTEMPTY
+	default:
+		return nil
+	}
+}
+
+// ServePrivileged runs the privileged helper: it reads JSON requests from r,
+// one per line, performs each with the same operations used for unprivileged
+// entries, and writes one JSON response per request to w. It returns when r
+// is closed.
+func ServePrivileged(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+
+	for {
+		var req helperRequest
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("reading request: %w", err)
+		}
+
+		var resp helperResponse
+		if err := serveRequest(req); err != nil {
+			resp.Error = err.Error()
+
+			// ENOTEMPTY also matches fs.ErrExist, so it is checked first
+			switch {
+			case errors.Is(err, syscall.ENOTEMPTY):
+				resp.Code = helperErrNotEmpty
+			case errors.Is(err, fs.ErrNotExist):
+				resp.Code = helperErrNotExist
+			case errors.Is(err, fs.ErrExist):
+				resp.Code = helperErrExist
+			case errors.Is(err, fs.ErrPermission):
+				resp.Code = helperErrPermission
+			}
+		}
+
+		if err := enc.Encode(resp); err != nil {
+			return fmt.Errorf("writing response: %w", err)
+		}
+	}
+}
+
+func serveRequest(req helperRequest) error {
+	ops := fsOps{fs: vfs.OS{}}
+
+	switch req.Op {
+	case helperPing:
+		return nil
+	case helperMkdirAll:
+		return ops.MkdirAll(req.Path)
+	case helperMove:
+		return ops.Move(req.Path, req.To)
+	case helperCopy:
+		return ops.Copy(req.Path, req.To)
+	case helperCopyContents:
+		return ops.CopyContents(req.Path, req.To)
+	case helperRemove:
+		return ops.Remove(req.Path)
+	case helperRemoveAll:
+		return ops.RemoveAll(req.Path)
+	case helperSymlink:
+		return ops.Symlink(req.Path, req.To)
+	case helperChmod:
+		return ops.Chmod(req.Path, req.Mode)
+	case helperChown:
+		if req.UID == nil || req.GID == nil {
+			return fmt.Errorf("%w: chown without uid and gid", ErrUnknownHelperOp)
+		}
+		return ops.Chown(req.Path, *req.UID, *req.GID)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownHelperOp, req.Op)
+	}
+}
+
+// helperConn is a running privileged helper.
+type helperConn struct {
+	enc  *json.Encoder
+	dec  *json.Decoder
+	in   io.Closer
+	wait func() error
+}
+
+// privilegedHelper is the client side of the privileged helper. The helper
+// is started on first use, so sudo prompts at most once per run, and
+// requests are serialized so concurrent restores never interleave.
+type privilegedHelper struct {
+	start func() (*helperConn, error)
+	conn  *helperConn
+	err   error
+	mu    sync.Mutex
+}
+
+// newPrivilegedHelper returns a helper client that starts
+// `sudo <this executable> privileged-helper` when first needed.
+func newPrivilegedHelper() *privilegedHelper {
+	return &privilegedHelper{start: startSudoHelper}
+}
+
+func startSudoHelper() (*helperConn, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locating executable: %w", err)
+	}
+
+	cmd := exec.Command("sudo", "--", exe, HelperCommand) //nolint:gosec // re-executes this binary under sudo
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting sudo: %w", err)
+	}
+
+	return &helperConn{
+		enc:  json.NewEncoder(stdin),
+		dec:  json.NewDecoder(stdout),
+		in:   stdin,
+		wait: cmd.Wait,
+	}, nil
+}
+
+// call sends one request and waits for its response.
+func (h *privilegedHelper) call(req helperRequest) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.err != nil {
+		return h.err
+	}
+
+	if h.conn == nil {
+		conn, err := h.start()
+		if err == nil {
+			h.conn = conn
+			err = h.roundTrip(helperRequest{Op: helperPing})
+		}
+		if err != nil {
+			h.err = fmt.Errorf("%w: %w", ErrHelperUnavailable, err)
+			return h.err
+		}
+	}
+
+	return h.roundTrip(req)
+}
+
+func (h *privilegedHelper) roundTrip(req helperRequest) error {
+	if err := h.conn.enc.Encode(req); err != nil {
+		return h.broken(err)
+	}
+
+	var resp helperResponse
+	if err := h.conn.dec.Decode(&resp); err != nil {
+		return h.broken(err)
+	}
+
+	if resp.Error != "" {
+		return &HelperError{Msg: resp.Error, Code: resp.Code}
+	}
+
+	return nil
+}
+
+// broken records that the helper stopped answering, so later calls fail fast.
+func (h *privilegedHelper) broken(err error) error {
+	if waitErr := h.conn.wait(); waitErr != nil {
+		err = waitErr
+	}
+
+	h.err = fmt.Errorf("%w: %w", ErrHelperUnavailable, err)
+
+	return h.err
+}
+
+// Close stops the helper if it was started.
+func (h *privilegedHelper) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.conn == nil || h.err != nil {
+		return nil
+	}
+
+	_ = h.conn.in.Close() //nolint:errcheck // closing stdin makes the helper exit
+	err := h.conn.wait()
+	h.err = ErrHelperUnavailable
+
+	return err
+}
+
+func (h *privilegedHelper) MkdirAll(dir string) error {
+	return h.call(helperRequest{Op: helperMkdirAll, Path: dir})
+}
+
+func (h *privilegedHelper) Move(from, to string) error {
+	return h.call(helperRequest{Op: helperMove, Path: from, To: to})
+}
+
+func (h *privilegedHelper) Copy(from, to string) error {
+	return h.call(helperRequest{Op: helperCopy, Path: from, To: to})
+}
+
+func (h *privilegedHelper) CopyContents(from, to string) error {
+	return h.call(helperRequest{Op: helperCopyContents, Path: from, To: to})
+}
+
+func (h *privilegedHelper) Remove(path string) error {
+	return h.call(helperRequest{Op: helperRemove, Path: path})
+}
+
+func (h *privilegedHelper) RemoveAll(path string) error {
+	return h.call(helperRequest{Op: helperRemoveAll, Path: path})
+}
+
+func (h *privilegedHelper) Symlink(source, target string) error {
+	return h.call(helperRequest{Op: helperSymlink, Path: source, To: target})
+}
+
+func (h *privilegedHelper) Chmod(path string, mode fs.FileMode) error {
+	return h.call(helperRequest{Op: helperChmod, Path: path, Mode: mode})
+}
+
+func (h *privilegedHelper) Chown(path string, uid, gid int) error {
+	return h.call(helperRequest{Op: helperChown, Path: path, UID: &uid, GID: &gid})
+}
@@ -0,0 +1,251 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/platform"
+	"github.com/AntoineGS/tidydots/internal/vfs"
+)
+
+// newLocalHelper returns a privileged helper served in-process over pipes,
+// and a function returning the operations it received once it is closed.
+func newLocalHelper(t *testing.T) (*privilegedHelper, func() []string) {
+	t.Helper()
+
+	var received bytes.Buffer
+
+	h := &privilegedHelper{start: func() (*helperConn, error) {
+		reqR, reqW := io.Pipe()
+		respR, respW := io.Pipe()
+
+		done := make(chan error, 1)
+		go func() {
+			err := ServePrivileged(io.TeeReader(reqR, &received), respW)
+			_ = respW.Close()
+			done <- err
+		}()
+
+		return &helperConn{
+			enc:  json.NewEncoder(reqW),
+			dec:  json.NewDecoder(respR),
+			in:   reqW,
+			wait: func() error { return <-done },
+		}, nil
+	}}
+
+	ops := func() []string {
+		var names []string
+		scanner := bufio.NewScanner(&received)
+		for scanner.Scan() {
+			var req helperRequest
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, req.Op)
+		}
+
+		return names
+	}
+
+	return h, ops
+}
+
+func TestPrivilegedHelper_Operations(t *testing.T) {
+	dir := t.TempDir()
+	h, ops := newLocalHelper(t)
+
+	src := filepath.Join(dir, "src", "file.txt")
+	writeTestFile(t, src, "content")
+
+	if err := h.MkdirAll(filepath.Join(dir, "a", "b")); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := h.Copy(src, filepath.Join(dir, "a", "b", "copy.txt")); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if err := h.Move(src, filepath.Join(dir, "a", "moved.txt")); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if err := h.Symlink(filepath.Join(dir, "a", "moved.txt"), filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+
+	assertFileContent(t, filepath.Join(dir, "a", "b", "copy.txt"), "content")
+	assertFileContent(t, filepath.Join(dir, "a", "moved.txt"), "content")
+	if !symlinkPointsTo(vfs.OS{}, filepath.Join(dir, "link"), filepath.Join(dir, "a", "moved.txt")) {
+		t.Error("Symlink() did not create the link")
+	}
+
+	err := h.Remove(filepath.Join(dir, "missing"))
+	var helperErr *HelperError
+	if !errors.As(err, &helperErr) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Remove(missing) error = %v, want a *HelperError wrapping fs.ErrNotExist", err)
+	}
+
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := []string{helperPing, helperMkdirAll, helperCopy, helperMove, helperSymlink, helperRemove}
+	if got := ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("helper received %v, want %v", got, want)
+	}
+}
+
+func TestPrivilegedHelper_Permissions(t *testing.T) {
+	if runtime.GOOS == platform.OSWindows {
+		t.Skip("modes and owners are not managed on Windows")
+	}
+
+	file := filepath.Join(t.TempDir(), "file.txt")
+	writeTestFile(t, file, "content")
+
+	h, ops := newLocalHelper(t)
+
+	if err := h.Chmod(file, 0o640); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	if err := h.Chown(file, os.Getuid(), -1); err != nil {
+		t.Fatalf("Chown() error = %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+
+	want := []string{helperPing, helperChmod, helperChown}
+	if got := ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("helper received %v, want %v", got, want)
+	}
+}
+
+func TestPrivilegedHelper_RemoveNotEmpty(t *testing.T) {
+	if runtime.GOOS == platform.OSWindows {
+		t.Skip("Windows does not report ENOTEMPTY")
+	}
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "full", "file.txt"), "content")
+	if err := os.MkdirAll(filepath.Join(root, "empty", "nested"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	h, _ := newLocalHelper(t)
+	t.Cleanup(func() { _ = h.Close() })
+
+	err := h.Remove(filepath.Join(root, "full"))
+	if !errors.Is(err, syscall.ENOTEMPTY) || !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Remove(non-empty dir) error = %v, want one matching syscall.ENOTEMPTY and fs.ErrExist", err)
+	}
+
+	if err := removeEmptyDirs(vfs.OS{}, h, root); err != nil {
+		t.Fatalf("removeEmptyDirs() error = %v", err)
+	}
+
+	if pathExists(filepath.Join(root, "empty")) {
+		t.Error("removeEmptyDirs() kept an empty directory")
+	}
+	assertFileContent(t, filepath.Join(root, "full", "file.txt"), "content")
+}
+
+func TestServePrivileged_UnknownOp(t *testing.T) {
+	in := bytes.NewBufferString(`{"op":"format","path":"/dev/sda"}` + "\n")
+	var out bytes.Buffer
+
+	if err := ServePrivileged(in, &out); err != nil {
+		t.Fatalf("ServePrivileged() error = %v", err)
+	}
+
+	var resp helperResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == "" {
+		t.Error("ServePrivileged() accepted an unknown operation")
+	}
+}
+
+func TestPrivilegedHelper_StartFailure(t *testing.T) {
+	starts := 0
+	h := &privilegedHelper{start: func() (*helperConn, error) {
+		starts++
+		return nil, errors.New("sudo: a password is required")
+	}}
+
+	for range 2 {
+		if err := h.MkdirAll(t.TempDir()); !errors.Is(err, ErrHelperUnavailable) {
+			t.Fatalf("MkdirAll() error = %v, want ErrHelperUnavailable", err)
+		}
+	}
+
+	if starts != 1 {
+		t.Errorf("helper started %d times, want 1", starts)
+	}
+}
+
+func TestRestore_SudoEntryUsesHelper(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	target := filepath.Join(homeDir, "etc", "app")
+	writeTestFile(t, filepath.Join(backupRoot, "app", "app.conf"), "repo")
+	writeTestFile(t, filepath.Join(target, "app.conf"), "local")
+	writeTestFile(t, filepath.Join(target, "extra.conf"), "extra")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./app", Sudo: true, Targets: map[string]string{"linux": target}},
+		}},
+	})
+
+	helper, ops := newLocalHelper(t)
+	mgr.helper = helper
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if !symlinkPointsTo(vfs.OS{}, target, filepath.Join(backupRoot, "app")) {
+		t.Fatalf("%s is not linked to the backup", target)
+	}
+	assertFileContent(t, filepath.Join(backupRoot, "app", "app.conf"), "repo")
+	assertFileContent(t, filepath.Join(backupRoot, "app", "extra.conf"), "extra")
+
+	if err := mgr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ops()
+	for _, op := range []string{helperMove, helperSymlink} {
+		found := false
+		for _, o := range got {
+			found = found || o == op
+		}
+		if !found {
+			t.Errorf("helper received %v, want a %s", got, op)
+		}
+	}
+
+	if _, err := os.Lstat(filepath.Join(target, "extra.conf")); err != nil {
+		t.Errorf("merged file not reachable through the symlink: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/encryption"
+	"github.com/AntoineGS/tidydots/internal/state"
+	tmpl "github.com/AntoineGS/tidydots/internal/template"
+)
+
+// recordManagedPaths stores the symlinks of a restored sub-entry in the state
+// store so they can be found again if the entry is later removed from the
+// config. Only links that actually point at the backup are recorded.
+func (m *Manager) recordManagedPaths(appName string, subEntry config.SubEntry, backupPath, target string) {
+	if m.DryRun || m.stateStore == nil {
+		return
+	}
+
+	record := func(targetPath, backupFile, mode string) {
+		sourcePath, ok := m.linkSource(targetPath, backupFile)
+		if !ok {
+			return
+		}
+
+		err := m.stateStore.RecordManagedPath(state.ManagedPath{
+			TargetPath:  targetPath,
+			SourcePath:  sourcePath,
+			Application: appName,
+			Entry:       subEntry.Name,
+			Mode:        mode,
+			Host:        m.Platform.Hostname,
+		})
+		if err != nil {
+			m.logger.Warn("could not record managed path",
+				slog.String("path", targetPath),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	if subEntry.IsFolder() {
+		record(target, backupPath, state.ModeFolder)
+		return
+	}
+
+	for _, file := range subEntry.Files {
+		record(filepath.Join(target, file), filepath.Join(backupPath, file), state.ModeFile)
+	}
+}
+
+// linkSource returns the path the symlink at targetPath points to when it
+// belongs to backupFile: the backup file itself, or for a template or an
+// encrypted file, the rendered or decrypted copy the backup file stands for.
+func (m *Manager) linkSource(targetPath, backupFile string) (string, bool) {
+	candidates := []string{
+		backupFile,
+		tmpl.RenderedPath(backupFile + ".tmpl"),
+		encryption.DecryptedPath(encryption.EncryptedPath(backupFile)),
+	}
+
+	for _, source := range candidates {
+		if symlinkPointsTo(m.fs, targetPath, source) {
+			return source, true
+		}
+	}
+
+	return "", false
+}
+
+// expectedManagedPaths returns the set of target paths the current config
+// would link on this OS. Applications are not filtered by when, so links of
+// apps that merely don't apply to this host are not treated as orphaned.
+func (m *Manager) expectedManagedPaths() map[string]bool {
+	expected := make(map[string]bool)
+
+	for _, app := range m.Config.Applications {
+		for _, subEntry := range app.Entries {
+			if !subEntry.IsConfig() {
+				continue
+			}
+
+			target := subEntry.TargetFor(config.NewMachine(m.Platform))
+			if target == "" {
+				continue
+			}
+
+			expandedTarget := m.expandTarget(target)
+
+			if subEntry.IsFolder() {
+				expected[expandedTarget] = true
+				continue
+			}
+
+			for _, file := range subEntry.Files {
+				expected[filepath.Join(expandedTarget, file)] = true
+			}
+		}
+	}
+
+	return expected
+}
+
+// FindOrphanedPaths returns the managed paths recorded on this host whose
+// target is no longer produced by any entry in the configuration.
+func (m *Manager) FindOrphanedPaths() ([]state.ManagedPath, error) {
+	if m.stateStore == nil {
+		return nil, ErrNoStateStore
+	}
+
+	recorded, err := m.stateStore.ListManagedPaths(m.Platform.Hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := m.expectedManagedPaths()
+
+	var orphans []state.ManagedPath
+	for _, p := range recorded {
+		if !expected[p.TargetPath] {
+			orphans = append(orphans, p)
+		}
+	}
+
+	return orphans, nil
+}
+
+// Prune removes orphaned symlinks left behind by entries that were removed
+// from the configuration. With materialize, each link is replaced by a copy of
+// the content it pointed to instead, so the application keeps its config.
+// Targets that are no longer the recorded symlink are left untouched and only
+// forgotten. It returns the orphaned paths that were handled.
+func (m *Manager) Prune(materialize bool) ([]state.ManagedPath, error) {
+	orphans, err := m.FindOrphanedPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+
+	for _, p := range orphans {
+		if err := m.checkContext(); err != nil {
+			return nil, err
+		}
+
+		if err := m.pruneManagedPath(p, materialize); err != nil {
+			m.logger.Error("prune failed",
+				slog.String("path", p.TargetPath),
+				slog.String("error", err.Error()))
+			errs = append(errs, err)
+		}
+	}
+
+	return orphans, errors.Join(errs...)
+}
+
+func (m *Manager) pruneManagedPath(p state.ManagedPath, materialize bool) error {
+	switch {
+	case !symlinkPointsTo(m.fs, p.TargetPath, p.SourcePath):
+		m.logger.Info("forgetting path that is no longer a tidydots link",
+			slog.String("path", p.TargetPath))
+	case materialize:
+		m.logger.Info("materializing orphaned link",
+			slog.String("path", p.TargetPath),
+			slog.String("source", p.SourcePath))
+
+		if !m.DryRun {
+			if err := m.materializeLink(p); err != nil {
+				return NewPathError("prune", p.TargetPath, err)
+			}
+		}
+	default:
+		m.logger.Info("removing orphaned link",
+			slog.String("path", p.TargetPath),
+			slog.String("source", p.SourcePath))
+
+		if !m.DryRun {
+			if err := m.fs.Remove(p.TargetPath); err != nil {
+				return NewPathError("prune", p.TargetPath, fmt.Errorf("removing symlink: %w", err))
+			}
+		}
+	}
+
+	if m.DryRun {
+		return nil
+	}
+
+	return m.stateStore.RemoveManagedPath(p.TargetPath, p.Host)
+}
+
+// materializeLink replaces a symlink with a copy of its source. The copy is
+// made next to the link first so the link is only removed once it succeeded.
+func (m *Manager) materializeLink(p state.ManagedPath) error {
+	tmpPath := p.TargetPath + ".tidydots-prune"
+
+	var err error
+	if p.Mode == state.ModeFolder {
+		err = copyDir(m.fs, p.SourcePath, tmpPath)
+	} else {
+		err = copyFile(m.fs, p.SourcePath, tmpPath)
+	}
+
+	if err != nil {
+		_ = m.fs.RemoveAll(tmpPath) //nolint:errcheck // best-effort cleanup of partial copy
+		return fmt.Errorf("copying source: %w", err)
+	}
+
+	if err := m.fs.Remove(p.TargetPath); err != nil {
+		_ = m.fs.RemoveAll(tmpPath) //nolint:errcheck // best-effort cleanup of partial copy
+		return fmt.Errorf("removing symlink: %w", err)
+	}
+
+	if err := m.fs.Rename(tmpPath, p.TargetPath); err != nil {
+		return fmt.Errorf("moving copy into place: %w", err)
+	}
+
+	return nil
+}
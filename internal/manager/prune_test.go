@@ -0,0 +1,245 @@
+package manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/platform"
+)
+
+// setupPruneTest restores a folder entry and a files entry with a state store,
+// then returns the manager, the nvim target folder and the .zshrc target file.
+func setupPruneTest(t *testing.T) (*Manager, string, string) {
+	t.Helper()
+
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	for path, content := range map[string]string{
+		"nvim/init.lua": "-- nvim",
+		"zsh/.zshrc":    "# zsh",
+	} {
+		full := filepath.Join(backupRoot, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nvimTarget := filepath.Join(homeDir, ".config", "nvim")
+
+	cfg := &config.Config{
+		Version:    3,
+		BackupRoot: backupRoot,
+		Applications: []config.Application{
+			{Name: "nvim", Entries: []config.SubEntry{
+				{Name: "config", Backup: "./nvim", Targets: map[string]string{"linux": nvimTarget}},
+			}},
+			{Name: "zsh", Entries: []config.SubEntry{
+				{Name: "zshrc", Backup: "./zsh", Files: []string{".zshrc"}, Targets: map[string]string{"linux": homeDir}},
+			}},
+		},
+	}
+
+	plat := &platform.Platform{OS: platform.OSLinux, Hostname: "desktop", EnvVars: map[string]string{}}
+	mgr := New(cfg, plat)
+	if err := mgr.InitStateStore(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = mgr.Close() })
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	return mgr, nvimTarget, filepath.Join(homeDir, ".zshrc")
+}
+
+func TestRestore_RecordsManagedPaths(t *testing.T) {
+	mgr, nvimTarget, zshrcTarget := setupPruneTest(t)
+
+	paths, err := mgr.stateStore.ListManagedPaths("desktop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, p := range paths {
+		got[p.TargetPath] = p.Mode
+	}
+
+	if got[nvimTarget] != "folder" || got[zshrcTarget] != "file" || len(got) != 2 {
+		t.Errorf("managed paths = %v, want nvim folder and .zshrc file", got)
+	}
+
+	orphans, err := mgr.FindOrphanedPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 0 {
+		t.Errorf("FindOrphanedPaths() = %v, want none while entries exist", orphans)
+	}
+}
+
+func TestPrune_RemovesOrphanedLinks(t *testing.T) {
+	mgr, nvimTarget, zshrcTarget := setupPruneTest(t)
+
+	// Drop the nvim application from the config
+	mgr.Config.Applications = mgr.Config.Applications[1:]
+
+	pruned, err := mgr.Prune(false)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].TargetPath != nvimTarget {
+		t.Fatalf("Prune() = %v, want only the nvim link", pruned)
+	}
+
+	if pathExists(nvimTarget) {
+		t.Error("orphaned nvim link should be removed")
+	}
+	if !isSymlink(zshrcTarget) {
+		t.Error(".zshrc link should be untouched")
+	}
+
+	if orphans, _ := mgr.FindOrphanedPaths(); len(orphans) != 0 {
+		t.Errorf("orphan record should be forgotten after prune, got %v", orphans)
+	}
+}
+
+func TestPrune_Materialize(t *testing.T) {
+	mgr, nvimTarget, zshrcTarget := setupPruneTest(t)
+
+	mgr.Config.Applications = nil
+
+	if _, err := mgr.Prune(true); err != nil {
+		t.Fatalf("Prune(true) error = %v", err)
+	}
+
+	if isSymlink(nvimTarget) || isSymlink(zshrcTarget) {
+		t.Fatal("materialized paths should no longer be symlinks")
+	}
+
+	content, err := os.ReadFile(filepath.Join(nvimTarget, "init.lua")) //nolint:gosec // test path
+	if err != nil || string(content) != "-- nvim" {
+		t.Errorf("materialized init.lua = %q, %v", content, err)
+	}
+
+	content, err = os.ReadFile(zshrcTarget) //nolint:gosec // test path
+	if err != nil || string(content) != "# zsh" {
+		t.Errorf("materialized .zshrc = %q, %v", content, err)
+	}
+}
+
+func TestPrune_LeavesReplacedTargets(t *testing.T) {
+	mgr, _, zshrcTarget := setupPruneTest(t)
+
+	// The user replaced the link with their own file
+	if err := os.Remove(zshrcTarget); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(zshrcTarget, []byte("# mine"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr.Config.Applications = mgr.Config.Applications[:1]
+
+	if _, err := mgr.Prune(false); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	content, err := os.ReadFile(zshrcTarget) //nolint:gosec // test path
+	if err != nil || string(content) != "# mine" {
+		t.Errorf("user file should be untouched, got %q, %v", content, err)
+	}
+}
+
+func TestPrune_DryRun(t *testing.T) {
+	mgr, nvimTarget, _ := setupPruneTest(t)
+
+	mgr.Config.Applications = mgr.Config.Applications[1:]
+	mgr.DryRun = true
+
+	if _, err := mgr.Prune(false); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	if !isSymlink(nvimTarget) {
+		t.Error("dry-run should not remove links")
+	}
+	if orphans, _ := mgr.FindOrphanedPaths(); len(orphans) != 1 {
+		t.Errorf("dry-run should keep the record, got %v", orphans)
+	}
+}
+
+func TestPrune_NoStateStore(t *testing.T) {
+	mgr := New(&config.Config{}, &platform.Platform{OS: platform.OSLinux})
+
+	if _, err := mgr.Prune(false); !errors.Is(err, ErrNoStateStore) {
+		t.Errorf("Prune() error = %v, want ErrNoStateStore", err)
+	}
+}
+
+// TestPrune_RenderedAndDecryptedLinks covers .zshrc links that point straight
+// at the rendered template or the decrypted copy instead of the backup file.
+func TestPrune_RenderedAndDecryptedLinks(t *testing.T) {
+	tests := []struct {
+		name   string
+		suffix string
+	}{
+		{"template", ".tmpl.rendered"},
+		{"encrypted", ".enc.decrypted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr, _, zshrcTarget := setupPruneTest(t)
+
+			backupFile := filepath.Join(mgr.Config.BackupRoot, "zsh", ".zshrc")
+			source := backupFile + tt.suffix
+			if err := os.WriteFile(source, []byte("# "+tt.name), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Remove(zshrcTarget); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(source, zshrcTarget); err != nil {
+				t.Fatal(err)
+			}
+
+			zsh := mgr.Config.Applications[1]
+			mgr.recordManagedPaths(zsh.Name, zsh.Entries[0], filepath.Dir(backupFile), filepath.Dir(zshrcTarget))
+
+			paths, err := mgr.stateStore.ListManagedPaths("desktop")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var recorded string
+			for _, p := range paths {
+				if p.TargetPath == zshrcTarget {
+					recorded = p.SourcePath
+				}
+			}
+			if recorded != source {
+				t.Fatalf("recorded source = %q, want %q", recorded, source)
+			}
+
+			mgr.Config.Applications = mgr.Config.Applications[:1]
+
+			if _, err := mgr.Prune(true); err != nil {
+				t.Fatalf("Prune(true) error = %v", err)
+			}
+
+			content, err := os.ReadFile(zshrcTarget) //nolint:gosec // test path
+			if err != nil || isSymlink(zshrcTarget) || string(content) != "# "+tt.name {
+				t.Errorf("materialized .zshrc = %q, %v", content, err)
+			}
+		})
+	}
+}
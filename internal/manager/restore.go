@@ -7,14 +7,13 @@ import (
 	"io/fs"
 	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
 
 	"github.com/AntoineGS/tidydots/internal/config"
-	"github.com/AntoineGS/tidydots/internal/platform"
+	"github.com/AntoineGS/tidydots/internal/ignore"
 	tmpl "github.com/AntoineGS/tidydots/internal/template"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 // RestoreWithContext restores configurations with context support
@@ -37,24 +36,72 @@ func (m *Manager) Restore() error {
 		slog.Int("version", m.Config.Version),
 	)
 
-	apps := m.GetApplications()
+	if err := m.checkTemplates(); err != nil {
+		return err
+	}
+
+	return m.RestoreEntries(m.restoreRefs())
+}
+
+// EntryRef selects one config entry of an application, with its expanded target path.
+type EntryRef struct {
+	SubEntry config.SubEntry
+	App      string
+	Target   string
+}
+
+// RestoreEntries restores the given entries as one journaled run, emitting
+// progress events for each. Restore uses it for every matching entry; callers
+// such as the TUI use it to restore a selection.
+func (m *Manager) RestoreEntries(entries []EntryRef) error {
+	m = m.dryRunView()
+
+	m.beginJournal()
+
+	err := m.restoreEntries(entries)
+	m.finishJournal(err)
+
+	return err
+}
+
+func (m *Manager) restoreEntries(entries []EntryRef) error {
+	if m.Jobs > 1 && len(entries) > 1 {
+		return m.restoreEntriesParallel(entries)
+	}
 
 	var errs []error
 
-	for _, app := range apps {
-		// Check context before each application
+	lastApp := ""
+	for _, ref := range entries {
+		// Check context before each entry
 		if err := m.checkContext(); err != nil {
 			return err
 		}
 
-		m.logger.Info("restoring application", slog.String("app", app.Name))
+		if ref.App != lastApp {
+			m.logger.Info("restoring application", slog.String("app", ref.App))
+			lastApp = ref.App
+		}
 
-		for _, subEntry := range app.Entries {
-			// Check context before each entry
-			if err := m.checkContext(); err != nil {
-				return err
-			}
+		if err := m.restoreSubEntry(ref.App, ref.SubEntry, ref.Target); err != nil {
+			m.logger.Error("restore failed",
+				slog.String("app", ref.App),
+				slog.String("entry", ref.SubEntry.Name),
+				slog.String("error", err.Error()))
+			errs = append(errs, err)
+		}
+	}
 
+	return errors.Join(errs...)
+}
+
+// restoreRefs returns the config entries of the filtered applications that
+// have a target on this OS.
+func (m *Manager) restoreRefs() []EntryRef {
+	var refs []EntryRef
+
+	for _, app := range m.GetApplications() {
+		for _, subEntry := range app.Entries {
 			// Only process config entries
 			if !subEntry.IsConfig() {
 				m.logger.Debug("skipping entry",
@@ -64,7 +111,7 @@ func (m *Manager) Restore() error {
 				continue
 			}
 
-			target := subEntry.GetTarget(m.Platform.OS)
+			target := subEntry.TargetFor(config.NewMachine(m.Platform))
 			if target == "" {
 				m.logger.Debug("skipping entry",
 					slog.String("app", app.Name),
@@ -75,28 +122,20 @@ func (m *Manager) Restore() error {
 			}
 
 			// Expand ~ and env vars in target path for file operations
-			expandedTarget := m.expandTarget(target)
-
-			if err := m.restoreSubEntry(app.Name, subEntry, expandedTarget); err != nil {
-				m.logger.Error("restore failed",
-					slog.String("app", app.Name),
-					slog.String("entry", subEntry.Name),
-					slog.String("error", err.Error()))
-				errs = append(errs, err)
-			}
+			refs = append(refs, EntryRef{App: app.Name, SubEntry: subEntry, Target: m.expandTarget(target)})
 		}
 	}
 
-	return errors.Join(errs...)
+	return refs
 }
 
 // symlinkPointsTo checks if a symlink at 'path' points to 'expectedTarget'
-func symlinkPointsTo(path, expectedTarget string) bool {
-	if !isSymlink(path) {
+func symlinkPointsTo(fsys vfs.FS, path, expectedTarget string) bool {
+	if !vfs.IsSymlink(fsys, path) {
 		return false
 	}
 
-	link, err := os.Readlink(path)
+	link, err := fsys.Readlink(path)
 	if err != nil {
 		return false
 	}
@@ -104,9 +143,9 @@ func symlinkPointsTo(path, expectedTarget string) bool {
 	return link == expectedTarget
 }
 
-func createSymlink(ctx context.Context, source, target string, useSudo bool) error {
+func createSymlink(fsys vfs.FS, ops fileOps, source, target string) error {
 	// Validate source exists
-	if _, err := os.Stat(source); err != nil {
+	if _, err := fsys.Stat(source); err != nil {
 		if os.IsNotExist(err) {
 			return NewPathError("restore", source, fmt.Errorf("symlink source does not exist"))
 		}
@@ -114,55 +153,119 @@ func createSymlink(ctx context.Context, source, target string, useSudo bool) err
 		return NewPathError("restore", source, fmt.Errorf("cannot access symlink source: %w", err))
 	}
 
-	if useSudo && runtime.GOOS != platform.OSWindows {
-		cmd := exec.CommandContext(ctx, "sudo", "ln", "-s", source, target) //nolint:gosec // intentional sudo command
-		return cmd.Run()
+	return ops.Symlink(source, target)
+}
+
+// restoreSubEntry restores one entry. If it fails part way, the steps already
+// taken for the entry are rolled back so the target is left as it was found.
+func (m *Manager) restoreSubEntry(appName string, subEntry config.SubEntry, target string) error {
+	em := *m.dryRunView()
+	em.entryOp = OpRestore
+	em.entryApp = appName
+	em.entryName = subEntry.Name
+
+	em.emit(EntryStarted{EntryEvent: em.entryEvent(subEntry.Name), Target: target})
+
+	err := em.restoreEntry(subEntry, target)
+	if err != nil {
+		if rbErr := m.rollbackEntry(appName, subEntry.Name); rbErr != nil {
+			m.logger.Error("rollback failed",
+				slog.String("app", appName),
+				slog.String("entry", subEntry.Name),
+				slog.String("error", rbErr.Error()))
+		}
+
+		em.emit(EntryFailed{EntryEvent: em.entryEvent(subEntry.Name), Err: err})
+
+		return err
 	}
 
-	return os.Symlink(source, target)
+	em.emit(EntryDone{
+		EntryEvent: em.entryEvent(subEntry.Name),
+		Target:     target,
+		Backup:     em.resolvePath(subEntry.Backup),
+	})
+
+	return nil
 }
 
-func (m *Manager) restoreSubEntry(_ string, subEntry config.SubEntry, target string) error {
+func (m *Manager) restoreEntry(subEntry config.SubEntry, target string) error {
 	backupPath := m.resolvePath(subEntry.Backup)
 
+	// Decrypt secrets first so plain names exist in the backup before merging or linking
+	if err := m.decryptFilesInBackup(subEntry, backupPath); err != nil {
+		return err
+	}
+
+	var err error
+
 	if subEntry.IsFolder() {
+		ign, ignErr := m.ignoreMatcher(subEntry)
+		if ignErr != nil {
+			return NewPathError("restore", backupPath, ignErr)
+		}
+
 		// Check if folder contains template files
-		if hasTemplateFiles(backupPath) {
-			return m.RestoreFolderWithTemplates(subEntry, backupPath, target)
+		if hasTemplateFiles(m.fs, backupPath, ign) {
+			err = m.RestoreFolderWithTemplates(subEntry, backupPath, target)
+		} else {
+			err = m.RestoreFolder(subEntry, backupPath, target)
 		}
-		return m.RestoreFolder(subEntry, backupPath, target)
+	} else {
+		err = m.RestoreFiles(subEntry, backupPath, target)
 	}
 
-	return m.RestoreFiles(subEntry, backupPath, target)
+	if err != nil {
+		return err
+	}
+
+	// Encrypt anything that was adopted or merged into the backup in plaintext
+	if err := m.sealPlaintextInBackup(subEntry, backupPath, "restore"); err != nil {
+		return err
+	}
+
+	// Git does not track modes or owners, so apply them once the files are in place
+	if err := m.applyPermissions(subEntry, target); err != nil {
+		return err
+	}
+
+	m.recordManagedPaths(m.entryApp, subEntry, backupPath, target)
+
+	return nil
 }
 
 // RestoreFolder creates a symlink from target to source for a folder entry.
 //
 //nolint:gocyclo // complexity acceptable for restore logic
 func (m *Manager) RestoreFolder(subEntry config.SubEntry, source, target string) error {
+	m = m.dryRunView()
+
+	ign, err := m.ignoreMatcher(subEntry)
+	if err != nil {
+		return NewPathError("restore", source, err)
+	}
+
 	// Check if already a symlink pointing to the correct source
-	if symlinkPointsTo(target, source) {
+	if symlinkPointsTo(m.fs, target, source) {
 		m.logger.Debug("already a symlink", slog.String("path", target))
 		return nil
 	}
 
 	// If it's a symlink but points to wrong location, remove it
-	if isSymlink(target) {
+	if m.isSymlink(target) {
 		m.logger.Info("removing incorrect symlink", slog.String("path", target))
-		if !m.DryRun {
-			if err := os.Remove(target); err != nil {
-				return NewPathError("restore", target, fmt.Errorf("removing incorrect symlink: %w", err))
-			}
+		if err := m.jRemoveSymlink(subEntry, target); err != nil {
+			return NewPathError("restore", target, fmt.Errorf("removing incorrect symlink: %w", err))
 		}
 	}
 
 	// Handle merge case: both source and target exist
-	if pathExists(source) && pathExists(target) && !isSymlink(target) {
+	if m.pathExists(source) && m.pathExists(target) && !m.isSymlink(target) {
 		if m.NoMerge {
 			if !m.ForceDelete {
 				// List files and return error
 				var fileList []string
-				err := filepath.WalkDir(target, func(path string, d fs.DirEntry, walkErr error) error {
+				err := vfs.WalkDir(m.fs, target, func(path string, d fs.DirEntry, walkErr error) error {
 					if walkErr != nil {
 						return walkErr
 					}
@@ -187,109 +290,82 @@ func (m *Manager) RestoreFolder(subEntry config.SubEntry, source, target string)
 				slog.String("target", target),
 				slog.String("backup", source))
 
-			if !m.DryRun {
-				summary := NewMergeSummary(subEntry.Name)
-				if err := MergeFolder(source, target, subEntry.Sudo, summary); err != nil {
-					return NewPathError("restore", target, fmt.Errorf("merging folder: %w", err))
-				}
-
-				// Log merge summary
-				if summary.HasOperations() {
-					m.logger.Info("merge complete",
-						slog.Int("merged", len(summary.MergedFiles)),
-						slog.Int("conflicts", len(summary.ConflictFiles)),
-						slog.Int("failed", len(summary.FailedFiles)))
-
-					for _, conflict := range summary.ConflictFiles {
-						m.logger.Warn("conflict resolved by renaming",
-							slog.String("file", conflict.OriginalName),
-							slog.String("renamed_to", conflict.RenamedTo))
-					}
+			summary := NewMergeSummary(subEntry.Name)
+			err := mergeFolder(m.fs, m.fileOps(subEntry.Sudo), source, target, ign, summary)
+			m.recordMerge(subEntry, target, source, summary)
+			if err != nil {
+				return NewPathError("restore", target, fmt.Errorf("merging folder: %w", err))
+			}
 
-					for _, failed := range summary.FailedFiles {
-						m.logger.Error("merge failed for file",
-							slog.String("file", failed.FileName),
-							slog.String("error", failed.Error))
-					}
+			// Log merge summary
+			if summary.HasOperations() {
+				m.logger.Info("merge complete",
+					slog.Int("merged", len(summary.MergedFiles)),
+					slog.Int("conflicts", len(summary.ConflictFiles)),
+					slog.Int("failed", len(summary.FailedFiles)))
+
+				for _, conflict := range summary.ConflictFiles {
+					m.logger.Warn("conflict resolved by renaming",
+						slog.String("file", conflict.OriginalName),
+						slog.String("renamed_to", conflict.RenamedTo))
 				}
 
-				// Remove empty directories after merge
-				if err := removeEmptyDirs(target); err != nil {
-					m.logger.Warn("failed to clean up empty directories",
-						slog.String("target", target),
-						slog.String("error", err.Error()))
+				for _, failed := range summary.FailedFiles {
+					m.logger.Error("merge failed for file",
+						slog.String("file", failed.FileName),
+						slog.String("error", failed.Error))
 				}
 			}
+
+			// Remove empty directories after merge
+			if err := removeEmptyDirs(m.fs, m.fileOps(subEntry.Sudo), target); err != nil {
+				m.logger.Warn("failed to clean up empty directories",
+					slog.String("target", target),
+					slog.String("error", err.Error()))
+			}
 		}
 	}
 
-	if !pathExists(source) && pathExists(target) {
+	if !m.pathExists(source) && m.pathExists(target) {
 		m.logger.Info("adopting folder",
 			slog.String("from", target),
 			slog.String("to", source))
 
-		if !m.DryRun {
-			backupParent := filepath.Dir(source)
-			if !pathExists(backupParent) {
-				if err := os.MkdirAll(backupParent, DirPerms); err != nil {
-					return NewPathError("adopt", source, fmt.Errorf("creating backup parent: %w", err))
-				}
+		backupParent := filepath.Dir(source)
+		if !m.pathExists(backupParent) {
+			if err := m.fs.MkdirAll(backupParent, DirPerms); err != nil {
+				return NewPathError("adopt", source, fmt.Errorf("creating backup parent: %w", err))
 			}
+		}
 
-			if subEntry.Sudo {
-				cmd := exec.CommandContext(m.ctx, "sudo", "mv", target, source) //nolint:gosec // intentional sudo command
-				if err := cmd.Run(); err != nil {
-					return NewPathError("adopt", target, fmt.Errorf("moving to backup: %w", err))
-				}
-			} else {
-				if err := os.Rename(target, source); err != nil {
-					return NewPathError("adopt", target, fmt.Errorf("moving to backup: %w", err))
-				}
+		if hasIgnored(m.fs, ign, target) {
+			// Ignored paths stay behind and are archived with the target below
+			if err := m.adoptFiltered(subEntry, source, target, ign); err != nil {
+				return NewPathError("adopt", target, err)
 			}
+		} else if err := m.jMove(subEntry, target, source); err != nil {
+			return NewPathError("adopt", target, fmt.Errorf("moving to backup: %w", err))
 		}
 	}
 
-	if !pathExists(source) {
-		if m.DryRun {
-			m.logger.Info("source folder does not exist (dry-run, skipping)", slog.String("path", source))
-			return nil
-		}
-
+	if !m.pathExists(source) {
 		return NewPathError("restore", source, fmt.Errorf("source folder does not exist"))
 	}
 
 	parentDir := filepath.Dir(target)
-	if !pathExists(parentDir) {
+	if !m.pathExists(parentDir) {
 		m.logger.Info("creating directory", slog.String("path", parentDir))
 
-		if !m.DryRun {
-			if subEntry.Sudo {
-				cmd := exec.CommandContext(m.ctx, "sudo", "mkdir", "-p", parentDir) //nolint:gosec // intentional sudo command
-				if err := cmd.Run(); err != nil {
-					return NewPathError("restore", parentDir, fmt.Errorf("creating parent: %w", err))
-				}
-			} else {
-				if err := os.MkdirAll(parentDir, DirPerms); err != nil {
-					return NewPathError("restore", parentDir, fmt.Errorf("creating parent: %w", err))
-				}
-			}
+		if err := m.jMkdirAll(subEntry, parentDir); err != nil {
+			return NewPathError("restore", parentDir, fmt.Errorf("creating parent: %w", err))
 		}
 	}
 
-	if pathExists(target) && !isSymlink(target) {
+	if m.pathExists(target) && !m.isSymlink(target) {
 		m.logger.Info("removing folder", slog.String("path", target))
 
-		if !m.DryRun {
-			if subEntry.Sudo {
-				cmd := exec.CommandContext(m.ctx, "sudo", "rm", "-rf", target) //nolint:gosec // intentional sudo command
-				if err := cmd.Run(); err != nil {
-					return NewPathError("restore", target, fmt.Errorf("removing existing: %w", err))
-				}
-			} else {
-				if err := removeAll(target); err != nil {
-					return NewPathError("restore", target, fmt.Errorf("removing existing: %w", err))
-				}
-			}
+		if err := m.jRemove(subEntry, target); err != nil {
+			return NewPathError("restore", target, fmt.Errorf("removing existing: %w", err))
 		}
 	}
 
@@ -297,10 +373,32 @@ func (m *Manager) RestoreFolder(subEntry config.SubEntry, source, target string)
 		slog.String("target", target),
 		slog.String("source", source))
 
-	if !m.DryRun {
-		return createSymlink(m.ctx, source, target, subEntry.Sudo)
+	return m.jCreateSymlink(subEntry, source, target)
+}
+
+// adoptFiltered adopts a folder whose content is partly ignored: everything
+// not matching ign is moved into a new backup folder and the ignored paths are
+// left in the target.
+func (m *Manager) adoptFiltered(subEntry config.SubEntry, source, target string, ign *ignore.Matcher) error {
+	if err := m.jMkdirAll(subEntry, source); err != nil {
+		return fmt.Errorf("creating backup folder: %w", err)
+	}
+
+	summary := NewMergeSummary(subEntry.Name)
+	err := mergeFolder(m.fs, m.fileOps(subEntry.Sudo), source, target, ign, summary)
+	m.recordMerge(subEntry, target, source, summary)
+	if err != nil {
+		return fmt.Errorf("moving to backup: %w", err)
+	}
+
+	if err := removeEmptyDirs(m.fs, m.fileOps(subEntry.Sudo), target); err != nil {
+		m.logger.Warn("failed to clean up empty directories",
+			slog.String("target", target),
+			slog.String("error", err.Error()))
 	}
 
+	m.emit(FileAdopted{EntryEvent: m.entryEvent(subEntry.Name), From: target, To: source})
+
 	return nil
 }
 
@@ -308,28 +406,19 @@ func (m *Manager) RestoreFolder(subEntry config.SubEntry, source, target string)
 //
 //nolint:gocyclo // complexity acceptable for restore logic
 func (m *Manager) RestoreFiles(subEntry config.SubEntry, source, target string) error {
-	if !pathExists(source) {
-		if !m.DryRun {
-			if err := os.MkdirAll(source, DirPerms); err != nil {
-				return NewPathError("restore", source, fmt.Errorf("creating backup directory: %w", err))
-			}
+	m = m.dryRunView()
+
+	if !m.pathExists(source) {
+		if err := m.fs.MkdirAll(source, DirPerms); err != nil {
+			return NewPathError("restore", source, fmt.Errorf("creating backup directory: %w", err))
 		}
 	}
 
-	if !pathExists(target) {
+	if !m.pathExists(target) {
 		m.logger.Info("creating directory", slog.String("path", target))
 
-		if !m.DryRun {
-			if subEntry.Sudo {
-				cmd := exec.CommandContext(m.ctx, "sudo", "mkdir", "-p", target) //nolint:gosec // intentional sudo command
-				if err := cmd.Run(); err != nil {
-					return NewPathError("restore", target, fmt.Errorf("creating target directory: %w", err))
-				}
-			} else {
-				if err := os.MkdirAll(target, DirPerms); err != nil {
-					return NewPathError("restore", target, fmt.Errorf("creating target directory: %w", err))
-				}
-			}
+		if err := m.jMkdirAll(subEntry, target); err != nil {
+			return NewPathError("restore", target, fmt.Errorf("creating target directory: %w", err))
 		}
 	}
 
@@ -338,23 +427,21 @@ func (m *Manager) RestoreFiles(subEntry config.SubEntry, source, target string)
 		dstFile := filepath.Join(target, file)
 
 		// Check if already a symlink pointing to correct source
-		if symlinkPointsTo(dstFile, srcFile) {
+		if symlinkPointsTo(m.fs, dstFile, srcFile) {
 			m.logger.Debug("already a symlink", slog.String("path", dstFile))
 			continue
 		}
 
 		// If it's a symlink but points to wrong location, remove it
-		if isSymlink(dstFile) {
+		if m.isSymlink(dstFile) {
 			m.logger.Info("removing incorrect symlink", slog.String("path", dstFile))
-			if !m.DryRun {
-				if err := os.Remove(dstFile); err != nil {
-					return NewPathError("restore", dstFile, fmt.Errorf("removing incorrect symlink: %w", err))
-				}
+			if err := m.jRemoveSymlink(subEntry, dstFile); err != nil {
+				return NewPathError("restore", dstFile, fmt.Errorf("removing incorrect symlink: %w", err))
 			}
 		}
 
 		// Handle merge case: both source and target file exist
-		if pathExists(srcFile) && pathExists(dstFile) && !isSymlink(dstFile) {
+		if m.pathExists(srcFile) && m.pathExists(dstFile) && !m.isSymlink(dstFile) {
 			if m.NoMerge {
 				if !m.ForceDelete {
 					return NewPathError("restore", dstFile, fmt.Errorf(
@@ -367,78 +454,49 @@ func (m *Manager) RestoreFiles(subEntry config.SubEntry, source, target string)
 					slog.String("target", dstFile),
 					slog.String("backup", srcFile))
 
-				if !m.DryRun {
-					summary := NewMergeSummary(subEntry.Name)
-					if err := mergeFile(dstFile, source, file, subEntry.Sudo, summary); err != nil {
-						return NewPathError("restore", dstFile, fmt.Errorf("merging file: %w", err))
+				summary := NewMergeSummary(subEntry.Name)
+				err := mergeFile(m.fs, m.fileOps(subEntry.Sudo), dstFile, source, file, summary)
+				m.recordMerge(subEntry, target, source, summary)
+				if err != nil {
+					return NewPathError("restore", dstFile, fmt.Errorf("merging file: %w", err))
+				}
+
+				// Log merge summary
+				if summary.HasOperations() {
+					for _, conflict := range summary.ConflictFiles {
+						m.logger.Warn("conflict resolved by renaming",
+							slog.String("file", conflict.OriginalName),
+							slog.String("renamed_to", conflict.RenamedTo))
 					}
 
-					// Log merge summary
-					if summary.HasOperations() {
-						for _, conflict := range summary.ConflictFiles {
-							m.logger.Warn("conflict resolved by renaming",
-								slog.String("file", conflict.OriginalName),
-								slog.String("renamed_to", conflict.RenamedTo))
-						}
-
-						for _, failed := range summary.FailedFiles {
-							m.logger.Error("merge failed for file",
-								slog.String("file", failed.FileName),
-								slog.String("error", failed.Error))
-						}
+					for _, failed := range summary.FailedFiles {
+						m.logger.Error("merge failed for file",
+							slog.String("file", failed.FileName),
+							slog.String("error", failed.Error))
 					}
 				}
 			}
 		}
 
-		if !pathExists(srcFile) && pathExists(dstFile) {
+		if !m.pathExists(srcFile) && m.pathExists(dstFile) {
 			m.logger.Info("adopting file",
 				slog.String("from", dstFile),
 				slog.String("to", srcFile))
 
-			if !m.DryRun {
-				if subEntry.Sudo {
-					cmd := exec.CommandContext(m.ctx, "sudo", "mv", dstFile, srcFile) //nolint:gosec // intentional sudo command
-					if err := cmd.Run(); err != nil {
-						return NewPathError("adopt", dstFile, fmt.Errorf("moving to backup: %w", err))
-					}
-				} else {
-					if err := os.Rename(dstFile, srcFile); err != nil {
-						if err := copyFile(dstFile, srcFile); err != nil {
-							return NewPathError("adopt", dstFile, fmt.Errorf("copying to backup: %w", err))
-						}
-
-						if err := os.Remove(dstFile); err != nil {
-							return NewPathError("adopt", dstFile, fmt.Errorf("removing original: %w", err))
-						}
-					}
-				}
+			if err := m.jMove(subEntry, dstFile, srcFile); err != nil {
+				return NewPathError("adopt", dstFile, fmt.Errorf("moving to backup: %w", err))
 			}
 		}
 
-		if !pathExists(srcFile) {
-			if m.DryRun {
-				m.logger.Info("source file does not exist (dry-run, skipping)", slog.String("path", srcFile))
-				continue
-			}
-
+		if !m.pathExists(srcFile) {
 			return NewPathError("restore", srcFile, fmt.Errorf("source file does not exist"))
 		}
 
-		if pathExists(dstFile) && !isSymlink(dstFile) {
+		if m.pathExists(dstFile) && !m.isSymlink(dstFile) {
 			m.logger.Info("removing file", slog.String("path", dstFile))
 
-			if !m.DryRun {
-				if subEntry.Sudo {
-					cmd := exec.CommandContext(m.ctx, "sudo", "rm", "-f", dstFile) //nolint:gosec // intentional sudo command
-					if err := cmd.Run(); err != nil {
-						return NewPathError("restore", dstFile, fmt.Errorf("removing existing file: %w", err))
-					}
-				} else {
-					if err := os.Remove(dstFile); err != nil {
-						return NewPathError("restore", dstFile, fmt.Errorf("removing existing file: %w", err))
-					}
-				}
+			if err := m.jRemove(subEntry, dstFile); err != nil {
+				return NewPathError("restore", dstFile, fmt.Errorf("removing existing file: %w", err))
 			}
 		}
 
@@ -446,28 +504,29 @@ func (m *Manager) RestoreFiles(subEntry config.SubEntry, source, target string)
 			slog.String("target", dstFile),
 			slog.String("source", srcFile))
 
-		if !m.DryRun {
-			if err := createSymlink(m.ctx, srcFile, dstFile, subEntry.Sudo); err != nil {
-				return NewPathError("restore", dstFile, fmt.Errorf("creating symlink: %w", err))
-			}
+		if err := m.jCreateSymlink(subEntry, srcFile, dstFile); err != nil {
+			return NewPathError("restore", dstFile, fmt.Errorf("creating symlink: %w", err))
 		}
 	}
 
 	return nil
 }
 
-// hasTemplateFiles returns true if the directory contains any .tmpl files.
-// It walks the directory tree and returns early on the first match.
-func hasTemplateFiles(dir string) bool {
-	if !pathExists(dir) {
+// hasTemplateFiles returns true if the directory contains any .tmpl files not
+// matched by ign. It walks the directory tree and returns early on the first match.
+func hasTemplateFiles(fsys vfs.FS, dir string, ign *ignore.Matcher) bool {
+	if !vfs.Exists(fsys, dir) {
 		return false
 	}
 
 	found := false
-	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+	_ = vfs.WalkDir(fsys, dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil || found {
 			return filepath.SkipDir
 		}
+		if skip, skipErr := skipIgnored(ign, dir, path, d); skip {
+			return skipErr
+		}
 		if !d.IsDir() && tmpl.IsTemplateFile(d.Name()) {
 			found = true
 			return filepath.SkipAll
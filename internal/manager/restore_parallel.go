@@ -0,0 +1,244 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// entryResult holds the outcome of one entry restored by a worker, with its
+// log records and events held back until it is its turn to be reported.
+type entryResult struct {
+	err      error
+	done     chan struct{}
+	logs     logBuffer
+	events   []Event
+	canceled bool
+}
+
+// restoreEntriesParallel restores entries on m.Jobs workers. Entries touching
+// overlapping paths are grouped and restored in order by a single worker.
+// Logs and events of each entry are flushed in the original entry order, so
+// the output matches a sequential restore.
+func (m *Manager) restoreEntriesParallel(entries []EntryRef) error {
+	results := make([]*entryResult, len(entries))
+	for i := range results {
+		results[i] = &entryResult{done: make(chan struct{})}
+	}
+
+	groups := m.groupIndependent(entries)
+	queue := make(chan []int, len(groups))
+	for _, group := range groups {
+		queue <- group
+	}
+	close(queue)
+
+	workers := min(m.Jobs, len(groups))
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for group := range queue {
+				for _, i := range group {
+					m.restoreBuffered(entries[i], results[i])
+				}
+			}
+		})
+	}
+
+	var errs []error
+	var ctxErr error
+
+	lastApp := ""
+	for i, ref := range entries {
+		res := results[i]
+		<-res.done
+
+		if res.canceled {
+			ctxErr = res.err
+			continue
+		}
+
+		if ref.App != lastApp {
+			m.logger.Info("restoring application", slog.String("app", ref.App))
+			lastApp = ref.App
+		}
+
+		res.logs.replay()
+		for _, ev := range res.events {
+			m.emit(ev)
+		}
+
+		if res.err != nil {
+			m.logger.Error("restore failed",
+				slog.String("app", ref.App),
+				slog.String("entry", ref.SubEntry.Name),
+				slog.String("error", res.err.Error()))
+			errs = append(errs, res.err)
+		}
+	}
+
+	wg.Wait()
+
+	if ctxErr != nil {
+		return ctxErr
+	}
+
+	return errors.Join(errs...)
+}
+
+// restoreBuffered restores one entry with its logs and events captured in res.
+func (m *Manager) restoreBuffered(ref EntryRef, res *entryResult) {
+	defer close(res.done)
+
+	if err := m.checkContext(); err != nil {
+		res.err = err
+		res.canceled = true
+
+		return
+	}
+
+	em := *m
+	em.logger = slog.New(&bufferHandler{buf: &res.logs, h: m.logger.Handler()})
+	em.events = EventSinkFunc(func(ev Event) {
+		res.events = append(res.events, ev)
+	})
+
+	res.err = em.restoreSubEntry(ref.App, ref.SubEntry, ref.Target)
+}
+
+// groupIndependent partitions entries into groups that can be restored
+// concurrently. Two entries share a group when a path one of them touches is
+// the same as, or an ancestor of, a path touched by the other. Groups are
+// ordered by their first entry and hold entry indexes in order.
+func (m *Manager) groupIndependent(entries []EntryRef) [][]int {
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+
+		return parent[i]
+	}
+
+	paths := make([][]string, len(entries))
+	for i, ref := range entries {
+		paths[i] = m.entryPaths(ref)
+	}
+
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			if pathsOverlap(paths[i], paths[j]) {
+				ri, rj := find(i), find(j)
+				if ri < rj {
+					parent[rj] = ri
+				} else {
+					parent[ri] = rj
+				}
+			}
+		}
+	}
+
+	var groups [][]int
+	index := make(map[int]int)
+	for i := range entries {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+
+	return groups
+}
+
+// entryPaths returns the target and backup paths an entry restore touches.
+func (m *Manager) entryPaths(ref EntryRef) []string {
+	backupPath := m.resolvePath(ref.SubEntry.Backup)
+
+	if ref.SubEntry.IsFolder() {
+		return []string{filepath.Clean(ref.Target), filepath.Clean(backupPath)}
+	}
+
+	paths := make([]string, 0, 2*len(ref.SubEntry.Files))
+	for _, file := range ref.SubEntry.Files {
+		paths = append(paths,
+			filepath.Join(ref.Target, file),
+			filepath.Join(backupPath, file))
+	}
+
+	return paths
+}
+
+// pathsOverlap reports whether any path in a equals or contains a path in b.
+func pathsOverlap(a, b []string) bool {
+	for _, p := range a {
+		for _, q := range b {
+			if isPathWithin(p, q) || isPathWithin(q, p) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isPathWithin reports whether path is dir or lies below it.
+func isPathWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// bufferedRecord is a log record with the handler it was addressed to.
+type bufferedRecord struct {
+	h slog.Handler
+	r slog.Record
+}
+
+// logBuffer collects the log records of one entry. It is written by a single
+// worker and replayed once the worker is done, so it needs no locking.
+type logBuffer struct {
+	records []bufferedRecord
+}
+
+// replay passes the buffered records on to their handlers.
+func (b *logBuffer) replay() {
+	for _, rec := range b.records {
+		_ = rec.h.Handle(context.Background(), rec.r) //nolint:errcheck // logging is best-effort
+	}
+}
+
+// bufferHandler is a slog.Handler that holds records back in a logBuffer
+// instead of writing them.
+type bufferHandler struct {
+	buf *logBuffer
+	h   slog.Handler
+}
+
+func (h *bufferHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *bufferHandler) Handle(_ context.Context, r slog.Record) error {
+	h.buf.records = append(h.buf.records, bufferedRecord{h: h.h, r: r.Clone()})
+
+	return nil
+}
+
+func (h *bufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &bufferHandler{buf: h.buf, h: h.h.WithAttrs(attrs)}
+}
+
+func (h *bufferHandler) WithGroup(name string) slog.Handler {
+	return &bufferHandler{buf: h.buf, h: h.h.WithGroup(name)}
+}
@@ -0,0 +1,180 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+)
+
+func TestGroupIndependent(t *testing.T) {
+	mgr := newJournalTestManager(t, "/repo", nil)
+
+	folder := func(backup, target string) EntryRef {
+		return EntryRef{SubEntry: config.SubEntry{Backup: backup}, Target: target}
+	}
+	files := func(backup, target string, names ...string) EntryRef {
+		return EntryRef{SubEntry: config.SubEntry{Backup: backup, Files: names}, Target: target}
+	}
+
+	tests := []struct {
+		name    string
+		entries []EntryRef
+		want    [][]int
+	}{
+		{
+			name: "independent folders",
+			entries: []EntryRef{
+				folder("./nvim", "/home/u/.config/nvim"),
+				folder("./kitty", "/home/u/.config/kitty"),
+			},
+			want: [][]int{{0}, {1}},
+		},
+		{
+			name: "nested target",
+			entries: []EntryRef{
+				folder("./config", "/home/u/.config"),
+				folder("./nvim", "/home/u/.config/nvim"),
+				folder("./kitty", "/home/u/.kitty"),
+			},
+			want: [][]int{{0, 1}, {2}},
+		},
+		{
+			name: "shared backup directory with distinct files",
+			entries: []EntryRef{
+				files("./shell", "/home/u", ".zshrc"),
+				files("./shell", "/home/u", ".bashrc"),
+			},
+			want: [][]int{{0}, {1}},
+		},
+		{
+			name: "same file",
+			entries: []EntryRef{
+				files("./zsh", "/home/u", ".zshrc"),
+				folder("./other", "/home/u/.other"),
+				files("./zsh-work", "/home/u", ".zshrc"),
+			},
+			want: [][]int{{0, 2}, {1}},
+		},
+		{
+			name: "transitive",
+			entries: []EntryRef{
+				folder("./a", "/home/u/a"),
+				folder("./b", "/home/u/b"),
+				folder("./ab", "/home/u"),
+			},
+			want: [][]int{{0, 1, 2}},
+		},
+		{
+			name: "sibling prefix is not a parent",
+			entries: []EntryRef{
+				folder("./nvim", "/home/u/.config/nvim"),
+				folder("./nvim-old", "/home/u/.config/nvim-old"),
+			},
+			want: [][]int{{0}, {1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mgr.groupIndependent(tt.entries); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupIndependent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// parallelTestApps returns applications with one folder entry each, plus a
+// failing files entry, all rooted in backupRoot and homeDir.
+func parallelTestApps(t *testing.T, backupRoot, homeDir string) []config.Application {
+	t.Helper()
+
+	var apps []config.Application
+	for i := range 6 {
+		name := fmt.Sprintf("app%d", i)
+		writeTestFile(t, filepath.Join(backupRoot, name, "config"), name)
+		writeTestFile(t, filepath.Join(homeDir, "."+name, "local"), "local")
+
+		apps = append(apps, config.Application{Name: name, Entries: []config.SubEntry{
+			{Name: "config", Backup: "./" + name, Targets: map[string]string{"linux": filepath.Join(homeDir, "."+name)}},
+		}})
+	}
+
+	apps = append(apps, config.Application{Name: "broken", Entries: []config.SubEntry{
+		{Name: "missing", Backup: "./broken", Files: []string{".missing"}, Targets: map[string]string{"linux": homeDir}},
+	}})
+
+	return apps
+}
+
+func TestRestore_ParallelMatchesSequential(t *testing.T) {
+	restore := func(jobs int) ([]Event, string, string, error) {
+		backupRoot := t.TempDir()
+		homeDir := t.TempDir()
+
+		mgr := newJournalTestManager(t, backupRoot, parallelTestApps(t, backupRoot, homeDir))
+		mgr.Jobs = jobs
+
+		var events []Event
+		err := mgr.WithEventSink(collectEvents(&events)).Restore()
+
+		return events, backupRoot, homeDir, err
+	}
+
+	seqEvents, _, _, seqErr := restore(1)
+	parEvents, backupRoot, homeDir, parErr := restore(4)
+
+	if seqErr == nil || parErr == nil {
+		t.Fatalf("Restore() errors = %v, %v; want the broken entry to fail in both", seqErr, parErr)
+	}
+	var pathErr *PathError
+	if !errors.As(parErr, &pathErr) || filepath.Base(pathErr.Path) != ".missing" {
+		t.Errorf("parallel error = %v, want a *PathError for .missing", parErr)
+	}
+
+	if got, want := eventNames(parEvents), eventNames(seqEvents); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parallel events = %v, want %v", got, want)
+	}
+	for i := range parEvents {
+		if got, want := parEvents[i].entryEvent(), seqEvents[i].entryEvent(); got != want {
+			t.Errorf("event %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	for i := range 6 {
+		name := fmt.Sprintf("app%d", i)
+		target := filepath.Join(homeDir, "."+name)
+		if !isSymlink(target) {
+			t.Errorf("%s is not a symlink", target)
+		}
+		assertFileContent(t, filepath.Join(backupRoot, name, "local"), "local")
+	}
+}
+
+func TestRestore_ParallelCanceled(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mgr := newJournalTestManager(t, backupRoot, parallelTestApps(t, backupRoot, homeDir)).WithContext(ctx)
+	mgr.Jobs = 4
+
+	var events []Event
+	err := mgr.WithEventSink(collectEvents(&events)).RestoreEntries(mgr.restoreRefs())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("RestoreEntries() error = %v, want context.Canceled", err)
+	}
+
+	if len(events) != 0 {
+		t.Errorf("events = %v, want none after cancellation", eventNames(events))
+	}
+	if isSymlink(filepath.Join(homeDir, ".app0")) {
+		t.Error("entry restored after cancellation")
+	}
+}
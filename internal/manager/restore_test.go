@@ -1,16 +1,17 @@
 package manager
 
 import (
-	"context"
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 func TestRestoreFolder(t *testing.T) {
@@ -629,7 +630,7 @@ func TestCreateSymlink_Success(t *testing.T) {
 
 	target := filepath.Join(tmpDir, "target")
 
-	err := createSymlink(context.Background(), source, target, false)
+	err := createSymlink(vfs.OS{}, fsOps{fs: vfs.OS{}}, source, target)
 	if err != nil {
 		t.Fatalf("createSymlink() error = %v", err)
 	}
@@ -651,7 +652,7 @@ func TestCreateSymlink_SourceNotExist(t *testing.T) {
 	source := filepath.Join(tmpDir, "nonexistent")
 	target := filepath.Join(tmpDir, "target")
 
-	err := createSymlink(context.Background(), source, target, false)
+	err := createSymlink(vfs.OS{}, fsOps{fs: vfs.OS{}}, source, target)
 	if err == nil {
 		t.Fatal("createSymlink() should fail when source doesn't exist")
 	}
@@ -673,7 +674,7 @@ func TestCreateSymlink_FileSource(t *testing.T) {
 
 	target := filepath.Join(tmpDir, "target.txt")
 
-	err := createSymlink(context.Background(), source, target, false)
+	err := createSymlink(vfs.OS{}, fsOps{fs: vfs.OS{}}, source, target)
 	if err != nil {
 		t.Fatalf("createSymlink() error = %v", err)
 	}
@@ -1820,3 +1821,133 @@ func TestRestoreFiles_OnlyMergesListedFiles(t *testing.T) {
 		t.Error("conflict file (config_target_*.txt) should be created for the merged file")
 	}
 }
+
+func TestRestore_DryRunSimulatesPlan(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	folderTarget := filepath.Join(homeDir, ".config", "app")
+	writeTestFile(t, filepath.Join(folderTarget, "app.conf"), "local")
+	writeTestFile(t, filepath.Join(homeDir, ".apprc"), "rc")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./app", Targets: map[string]string{"linux": folderTarget}},
+			{Name: "rc", Backup: "./rc", Files: []string{".apprc"}, Targets: map[string]string{"linux": homeDir}},
+		}},
+	})
+	mgr.DryRun = true
+
+	var adopted, linked []string
+	mgr = mgr.WithEventSink(EventSinkFunc(func(ev Event) {
+		switch e := ev.(type) {
+		case FileAdopted:
+			adopted = append(adopted, e.From)
+		case SymlinkCreated:
+			linked = append(linked, e.Target)
+		}
+	}))
+
+	// Without a backup, only simulating the adoption lets the link step run
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	wantAdopted := []string{folderTarget, filepath.Join(homeDir, ".apprc")}
+	if !reflect.DeepEqual(adopted, wantAdopted) {
+		t.Errorf("adopted %v, want %v", adopted, wantAdopted)
+	}
+	if !reflect.DeepEqual(linked, wantAdopted) {
+		t.Errorf("linked %v, want %v", linked, wantAdopted)
+	}
+
+	assertFileContent(t, filepath.Join(folderTarget, "app.conf"), "local")
+	assertFileContent(t, filepath.Join(homeDir, ".apprc"), "rc")
+	if isSymlink(folderTarget) || pathExists(filepath.Join(backupRoot, "app")) || pathExists(filepath.Join(backupRoot, "rc")) {
+		t.Error("dry run changed the filesystem")
+	}
+}
+
+func TestRestoreFolder_WithOverlayFS(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+
+	source := filepath.Join(tmpDir, "backup", "nvim")
+	target := filepath.Join(tmpDir, "home", "nvim")
+	writeTestFile(t, filepath.Join(source, "init.lua"), "lua")
+
+	overlay := vfs.NewOverlay(vfs.OS{})
+	mgr := New(&config.Config{BackupRoot: filepath.Join(tmpDir, "backup")}, &platform.Platform{OS: platform.OSLinux}).WithFS(overlay)
+
+	if err := mgr.RestoreFolder(config.SubEntry{Name: "nvim"}, source, target); err != nil {
+		t.Fatalf("RestoreFolder() error = %v", err)
+	}
+
+	if !symlinkPointsTo(overlay, target, source) {
+		t.Error("overlay does not show the symlink")
+	}
+	if data, err := overlay.ReadFile(filepath.Join(target, "init.lua")); err != nil || string(data) != "lua" {
+		t.Errorf("read through overlay symlink = %q, %v", data, err)
+	}
+	if pathExists(filepath.Join(tmpDir, "home")) {
+		t.Error("RestoreFolder() wrote to disk through the overlay")
+	}
+}
+
+func TestRestore_SelectsHostTarget(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(backupRoot, "app", "app.conf"), "conf")
+
+	generic := filepath.Join(homeDir, "generic")
+	perHost := filepath.Join(homeDir, "desktop")
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{{
+			Name:    "config",
+			Backup:  "./app",
+			Targets: map[string]string{"linux": generic, "host:desktop": perHost},
+		}}},
+	})
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if !symlinkPointsTo(mgr.fs, perHost, filepath.Join(backupRoot, "app")) {
+		t.Errorf("%s is not linked to the backup", perHost)
+	}
+	if pathExists(generic) {
+		t.Errorf("the plain linux target %s was restored on host desktop", generic)
+	}
+}
+
+func TestRestore_DarwinTargets(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(backupRoot, "app", "app.conf"), "conf")
+	writeTestFile(t, filepath.Join(backupRoot, "shell", "rc"), "rc")
+
+	macTarget := filepath.Join(homeDir, "Library", "app")
+	shellTarget := filepath.Join(homeDir, "shell")
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./app", Targets: map[string]string{"linux": filepath.Join(homeDir, "linux-app"), "darwin": macTarget}},
+			{Name: "shell", Backup: "./shell", Targets: map[string]string{"linux": shellTarget}},
+		}},
+	})
+	mgr.Platform = mgr.Platform.WithOS(platform.OSDarwin)
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if !symlinkPointsTo(mgr.fs, macTarget, filepath.Join(backupRoot, "app")) {
+		t.Errorf("darwin target %s is not linked", macTarget)
+	}
+	// Entries without a darwin key fall back to their linux target
+	if !symlinkPointsTo(mgr.fs, shellTarget, filepath.Join(backupRoot, "shell")) {
+		t.Errorf("linux fallback target %s is not linked", shellTarget)
+	}
+}
@@ -0,0 +1,266 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AntoineGS/tidydots/internal/state"
+	"github.com/AntoineGS/tidydots/internal/vfs"
+)
+
+// Reasons recorded for archived content.
+const (
+	// snapshotRemoved: restore deleted the target to replace it with a symlink
+	snapshotRemoved = "removed"
+	// snapshotOverwritten: a force re-render overwrote edits to a rendered file
+	snapshotOverwritten = "overwritten"
+	// snapshotReplaced: restoring from a snapshot replaced the current content
+	snapshotReplaced = "replaced"
+)
+
+// snapshotsDirName is the directory inside the state directory holding archived content.
+const snapshotsDirName = "snapshots"
+
+// snapshotID returns the snapshot that content archived now belongs to: the
+// current restore run, or a new standalone snapshot outside of a run.
+func (m *Manager) snapshotID() string {
+	if m.journal != nil {
+		return m.journal.runID
+	}
+
+	return newRunID()
+}
+
+// snapshot archives path before it is removed or overwritten and returns where
+// the archived copy lives. With move set, the content is moved into the
+// snapshot store (which also removes it from path); otherwise it is copied.
+func (m *Manager) snapshot(path string, sudo bool, reason string, move bool) (string, error) {
+	info, err := m.fs.Lstat(path)
+	if err != nil {
+		return "", NewPathError("snapshot", path, err)
+	}
+
+	id := m.snapshotID()
+
+	slot, err := claimSnapshotSlot(m.fs, filepath.Join(m.stateDir(), snapshotsDirName, id))
+	if err != nil {
+		return "", NewPathError("snapshot", path, fmt.Errorf("creating snapshot directory: %w", err))
+	}
+
+	stored := filepath.Join(slot, filepath.Base(path))
+
+	if move {
+		err = m.fileOps(sudo).Move(path, stored)
+	} else {
+		err = m.fileOps(sudo).Copy(path, stored)
+	}
+	if err != nil {
+		_ = m.fs.RemoveAll(slot) //nolint:errcheck // best-effort cleanup of partial snapshot
+		return "", NewPathError("snapshot", path, err)
+	}
+
+	m.logger.Info("archived to snapshot",
+		slog.String("path", path),
+		slog.String("snapshot", id))
+
+	if m.stateStore != nil && !m.DryRun {
+		f := state.SnapshotFile{
+			SnapshotID: id,
+			Host:       m.Platform.Hostname,
+			Path:       path,
+			Stored:     stored,
+			Reason:     reason,
+			IsDir:      info.IsDir(),
+		}
+		if err := m.stateStore.AddSnapshotFile(f); err != nil {
+			m.logger.Warn("could not record snapshot", slog.String("error", err.Error()))
+		}
+	}
+
+	m.ensureIgnored(stateDirName + "/")
+
+	if m.journal == nil && !m.DryRun {
+		m.pruneSnapshots()
+	}
+
+	return stored, nil
+}
+
+// claimSnapshotSlot creates the next free numbered directory inside dir. Using
+// Mkdir to claim it keeps concurrent snapshots from sharing a slot.
+func claimSnapshotSlot(fsys vfs.FS, dir string) (string, error) {
+	if err := fsys.MkdirAll(dir, DirPerms); err != nil {
+		return "", err
+	}
+
+	for n := 1; ; n++ {
+		slot := filepath.Join(dir, strconv.Itoa(n))
+
+		err := fsys.Mkdir(slot, DirPerms)
+		if err == nil {
+			return slot, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+	}
+}
+
+// Snapshots returns the snapshots taken on this host, newest first.
+func (m *Manager) Snapshots() ([]state.Snapshot, error) {
+	if m.stateStore == nil {
+		return nil, ErrNoStateStore
+	}
+
+	return m.stateStore.ListSnapshots(m.Platform.Hostname)
+}
+
+// SnapshotFiles returns the files and directories archived in a snapshot.
+func (m *Manager) SnapshotFiles(id string) ([]state.SnapshotFile, error) {
+	if m.stateStore == nil {
+		return nil, ErrNoStateStore
+	}
+
+	files, err := m.stateStore.ListSnapshotFiles(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, id)
+	}
+
+	return files, nil
+}
+
+// RestoreFromSnapshot copies a file or directory archived in snapshot id back
+// to dest, or to its original location when dest is empty. path is the
+// original absolute path, either an archived path itself or a path inside an
+// archived directory. An existing destination is only replaced when overwrite
+// is set, and is archived itself first. It returns the destination written.
+func (m *Manager) RestoreFromSnapshot(id, path, dest string, overwrite bool) (string, error) {
+	files, err := m.SnapshotFiles(id)
+	if err != nil {
+		return "", err
+	}
+
+	path = filepath.Clean(path)
+
+	src, ok := findInSnapshot(files, path)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotInSnapshot, path)
+	}
+
+	if dest == "" {
+		dest = path
+	}
+
+	if _, err := m.fs.Lstat(dest); err == nil {
+		if !overwrite {
+			return dest, NewPathError("restore snapshot", dest, ErrTargetExists)
+		}
+
+		m.logger.Info("replacing existing content", slog.String("path", dest))
+
+		if !m.DryRun {
+			if err := m.replaceForSnapshot(dest); err != nil {
+				return dest, err
+			}
+		}
+	}
+
+	m.logger.Info("restoring from snapshot",
+		slog.String("snapshot", id),
+		slog.String("from", src),
+		slog.String("to", dest))
+
+	if m.DryRun {
+		return dest, nil
+	}
+
+	if err := m.fs.MkdirAll(filepath.Dir(dest), DirPerms); err != nil {
+		return dest, NewPathError("restore snapshot", dest, fmt.Errorf("creating parent: %w", err))
+	}
+
+	if err := (fsOps{fs: m.fs}).Copy(src, dest); err != nil {
+		return dest, NewPathError("restore snapshot", dest, err)
+	}
+
+	return dest, nil
+}
+
+// findInSnapshot resolves an original path to its archived copy. The most
+// recently archived match wins.
+func findInSnapshot(files []state.SnapshotFile, path string) (string, bool) {
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+
+		if f.Path == path {
+			return f.Stored, true
+		}
+
+		if f.IsDir {
+			rel, err := filepath.Rel(f.Path, path)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return filepath.Join(f.Stored, rel), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// replaceForSnapshot clears dest before restoring over it. Symlinks are just
+// removed; real content is archived first so nothing is lost.
+func (m *Manager) replaceForSnapshot(dest string) error {
+	if m.isSymlink(dest) {
+		if err := m.fs.Remove(dest); err != nil {
+			return NewPathError("restore snapshot", dest, fmt.Errorf("removing symlink: %w", err))
+		}
+		return nil
+	}
+
+	_, err := m.snapshot(dest, false, snapshotReplaced, true)
+
+	return err
+}
+
+// deleteSnapshot removes a snapshot's archived content and its records.
+func (m *Manager) deleteSnapshot(id string) {
+	if err := m.fs.RemoveAll(filepath.Join(m.stateDir(), snapshotsDirName, id)); err != nil {
+		m.logger.Warn("could not remove snapshot", slog.String("snapshot", id), slog.String("error", err.Error()))
+		return
+	}
+
+	if m.stateStore != nil {
+		if err := m.stateStore.DeleteSnapshot(id); err != nil {
+			m.logger.Warn("could not delete snapshot record", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// pruneSnapshots drops snapshots beyond keepRuns. Snapshots of restore runs
+// are kept as long as their run is, since rollback needs them.
+func (m *Manager) pruneSnapshots() {
+	if m.stateStore == nil {
+		return
+	}
+
+	snapshots, err := m.stateStore.ListSnapshots(m.Platform.Hostname)
+	if err != nil || len(snapshots) <= keepRuns {
+		return
+	}
+
+	for _, snap := range snapshots[keepRuns:] {
+		run, err := m.stateStore.GetRun(snap.ID)
+		if err != nil || run != nil {
+			continue
+		}
+
+		m.deleteSnapshot(snap.ID)
+	}
+}
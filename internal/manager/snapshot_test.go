@@ -0,0 +1,183 @@
+package manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/state"
+	tmpl "github.com/AntoineGS/tidydots/internal/template"
+)
+
+func TestRestore_SnapshotsForceDeletedContent(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	nvimTarget := filepath.Join(homeDir, ".config", "nvim")
+	writeTestFile(t, filepath.Join(backupRoot, "nvim", "init.lua"), "-- repo")
+	writeTestFile(t, filepath.Join(nvimTarget, "init.lua"), "-- local")
+	writeTestFile(t, filepath.Join(nvimTarget, "lua", "opts.lua"), "-- opts")
+	writeTestFile(t, filepath.Join(backupRoot, "zsh", ".zshrc"), "# repo")
+	writeTestFile(t, filepath.Join(homeDir, ".zshrc"), "# local")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "nvim", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./nvim", Targets: map[string]string{"linux": nvimTarget}},
+		}},
+		{Name: "zsh", Entries: []config.SubEntry{
+			{Name: "zshrc", Backup: "./zsh", Files: []string{".zshrc"}, Targets: map[string]string{"linux": homeDir}},
+		}},
+	})
+	mgr.NoMerge = true
+	mgr.ForceDelete = true
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	snapshots, err := mgr.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Files != 2 {
+		t.Fatalf("Snapshots() = %+v, want one snapshot with 2 paths", snapshots)
+	}
+	id := snapshots[0].ID
+
+	// The target is now a symlink, so restoring in place needs overwrite
+	zshrc := filepath.Join(homeDir, ".zshrc")
+	if _, err := mgr.RestoreFromSnapshot(id, zshrc, "", false); !errors.Is(err, ErrTargetExists) {
+		t.Fatalf("RestoreFromSnapshot() error = %v, want ErrTargetExists", err)
+	}
+
+	if _, err := mgr.RestoreFromSnapshot(id, zshrc, "", true); err != nil {
+		t.Fatalf("RestoreFromSnapshot(overwrite) error = %v", err)
+	}
+	assertFileContent(t, zshrc, "# local")
+	assertFileContent(t, filepath.Join(backupRoot, "zsh", ".zshrc"), "# repo")
+
+	// A single file can be restored from an archived folder
+	dest := filepath.Join(t.TempDir(), "opts.lua")
+	written, err := mgr.RestoreFromSnapshot(id, filepath.Join(nvimTarget, "lua", "opts.lua"), dest, false)
+	if err != nil {
+		t.Fatalf("RestoreFromSnapshot(folder file) error = %v", err)
+	}
+	if written != dest {
+		t.Errorf("RestoreFromSnapshot() wrote %s, want %s", written, dest)
+	}
+	assertFileContent(t, dest, "-- opts")
+
+	if _, err := mgr.RestoreFromSnapshot(id, filepath.Join(homeDir, ".bashrc"), "", false); !errors.Is(err, ErrNotInSnapshot) {
+		t.Errorf("RestoreFromSnapshot(unknown path) error = %v, want ErrNotInSnapshot", err)
+	}
+	if _, err := mgr.RestoreFromSnapshot("missing", zshrc, "", false); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("RestoreFromSnapshot(unknown snapshot) error = %v, want ErrSnapshotNotFound", err)
+	}
+}
+
+func TestRollback_KeepsSnapshot(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(backupRoot, "zsh", ".zshrc"), "# repo")
+	writeTestFile(t, filepath.Join(homeDir, ".zshrc"), "# local")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "zsh", Entries: []config.SubEntry{
+			{Name: "zshrc", Backup: "./zsh", Files: []string{".zshrc"}, Targets: map[string]string{"linux": homeDir}},
+		}},
+	})
+	mgr.NoMerge = true
+	mgr.ForceDelete = true
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	runID, err := mgr.Rollback("")
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	files, err := mgr.SnapshotFiles(runID)
+	if err != nil {
+		t.Fatalf("SnapshotFiles() error = %v", err)
+	}
+	assertFileContent(t, files[0].Stored, "# local")
+}
+
+func TestRestore_SnapshotsForceRenderedEdits(t *testing.T) {
+	backupRoot := t.TempDir()
+	homeDir := t.TempDir()
+
+	tmplPath := filepath.Join(backupRoot, "app", "config.tmpl")
+	writeTestFile(t, tmplPath, "os={{ .OS }}")
+
+	mgr := newJournalTestManager(t, backupRoot, []config.Application{
+		{Name: "app", Entries: []config.SubEntry{
+			{Name: "config", Backup: "./app", Targets: map[string]string{"linux": filepath.Join(homeDir, "app")}},
+		}},
+	})
+
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	rendered := tmpl.RenderedPath(tmplPath)
+	if err := os.WriteFile(rendered, []byte("os=linux\nedited"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr.ForceRender = true
+	if err := mgr.Restore(); err != nil {
+		t.Fatalf("Restore(force render) error = %v", err)
+	}
+
+	assertFileContent(t, rendered, "os=linux")
+
+	snapshots, err := mgr.Snapshots()
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("Snapshots() = %+v, %v, want one snapshot", snapshots, err)
+	}
+
+	files, err := mgr.SnapshotFiles(snapshots[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Path != rendered {
+		t.Errorf("snapshot path = %s, want %s", files[0].Path, rendered)
+	}
+	assertFileContent(t, files[0].Stored, "os=linux\nedited")
+}
+
+func TestFindInSnapshot(t *testing.T) {
+	files := []state.SnapshotFile{
+		{Path: "/home/u/.zshrc", Stored: "/s/1/.zshrc"},
+		{Path: "/home/u/.config/nvim", Stored: "/s/2/nvim", IsDir: true},
+		{Path: "/home/u/.zshrc", Stored: "/s/3/.zshrc"},
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{"newest exact match", "/home/u/.zshrc", "/s/3/.zshrc", true},
+		{"archived folder", "/home/u/.config/nvim", "/s/2/nvim", true},
+		{"file in archived folder", "/home/u/.config/nvim/lua/opts.lua", "/s/2/nvim/lua/opts.lua", true},
+		{"sibling of archived folder", "/home/u/.config/nvim2", "", false},
+		{"unknown", "/home/u/.bashrc", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findInSnapshot(files, tt.path)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("findInSnapshot(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -1,21 +1,25 @@
 package manager
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"fmt"
 	"io/fs"
 	"log/slog"
-	"os"
 	"path/filepath"
 
 	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/ignore"
 	tmpl "github.com/AntoineGS/tidydots/internal/template"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 // RestoreFolderWithTemplates handles folders that contain .tmpl files.
 // It delegates folder-level operations (adoption, merge, folder symlink) to RestoreFolder,
 // then renders templates and creates relative symlinks inside the backup directory.
 func (m *Manager) RestoreFolderWithTemplates(subEntry config.SubEntry, source, target string) error {
+	m = m.dryRunView()
+
 	// Step 1: Delegate folder-level operations to RestoreFolder
 	// (handles adoption, merge, creates folder symlink target → source)
 	if err := m.RestoreFolder(subEntry, source, target); err != nil {
@@ -23,21 +27,30 @@ func (m *Manager) RestoreFolderWithTemplates(subEntry config.SubEntry, source, t
 	}
 
 	// Step 2: Render templates and create relative symlinks in backup dir
-	if !pathExists(source) {
+	if !m.pathExists(source) {
 		return nil
 	}
 
-	return m.renderTemplatesInBackup(source)
+	ign, err := m.ignoreMatcher(subEntry)
+	if err != nil {
+		return NewPathError("restore", source, err)
+	}
+
+	return m.renderTemplatesInBackup(source, ign)
 }
 
-// renderTemplatesInBackup walks the backup directory for .tmpl files and
-// renders each one, creating a relative symlink in the backup dir.
-func (m *Manager) renderTemplatesInBackup(backupDir string) error {
-	return filepath.WalkDir(backupDir, func(path string, d fs.DirEntry, err error) error {
+// renderTemplatesInBackup walks the backup directory for .tmpl files not
+// matched by ign and renders each one, creating a relative symlink in the backup dir.
+func (m *Manager) renderTemplatesInBackup(backupDir string, ign *ignore.Matcher) error {
+	return vfs.WalkDir(m.fs, backupDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
+		if skip, skipErr := skipIgnored(ign, backupDir, path, d); skip {
+			return skipErr
+		}
+
 		if d.IsDir() {
 			return nil
 		}
@@ -66,7 +79,7 @@ func (m *Manager) renderTemplatesInBackup(backupDir string) error {
 //nolint:gocyclo // complexity acceptable for template restore logic with merge paths
 func (m *Manager) renderTemplateAndLink(tmplAbsPath, relPath string) error {
 	// Read template source
-	tmplContent, err := os.ReadFile(tmplAbsPath) //nolint:gosec // path from config
+	tmplContent, err := m.fs.ReadFile(tmplAbsPath)
 	if err != nil {
 		return NewPathError("restore", tmplAbsPath, fmt.Errorf("reading template: %w", err))
 	}
@@ -82,7 +95,7 @@ func (m *Manager) renderTemplateAndLink(tmplAbsPath, relPath string) error {
 		record, lookupErr := m.stateStore.GetLatestRender(relPath)
 		if lookupErr != nil {
 			m.logger.Warn("failed to query render history", slog.String("error", lookupErr.Error()))
-		} else if record != nil && record.TemplateHash == hash && pathExists(renderedAbsPath) {
+		} else if record != nil && record.TemplateHash == hash && m.pathExists(renderedAbsPath) {
 			// Template unchanged and rendered file exists - just ensure relative symlink
 			m.logger.Debug("template unchanged, skipping re-render",
 				slog.String("template", relPath))
@@ -100,10 +113,6 @@ func (m *Manager) renderTemplateAndLink(tmplAbsPath, relPath string) error {
 		slog.String("template", relPath),
 		slog.String("rendered", renderedAbsPath))
 
-	if m.DryRun {
-		return nil
-	}
-
 	// Determine what to write
 	finalContent := rendered
 
@@ -118,8 +127,8 @@ func (m *Manager) renderTemplateAndLink(tmplAbsPath, relPath string) error {
 			base := string(record.PureRender)
 
 			var theirs string
-			if pathExists(renderedAbsPath) {
-				theirsBytes, readErr := os.ReadFile(renderedAbsPath) //nolint:gosec // generated file
+			if m.pathExists(renderedAbsPath) {
+				theirsBytes, readErr := m.fs.ReadFile(renderedAbsPath)
 				if readErr != nil {
 					m.logger.Warn("could not read current rendered file",
 						slog.String("path", renderedAbsPath),
@@ -137,7 +146,7 @@ func (m *Manager) renderTemplateAndLink(tmplAbsPath, relPath string) error {
 
 			if mergeResult.HasConflict {
 				conflictPath := tmpl.ConflictPath(tmplAbsPath)
-				if writeErr := os.WriteFile(conflictPath, []byte(mergeResult.Content), FilePerms); writeErr != nil {
+				if writeErr := m.fs.WriteFile(conflictPath, []byte(mergeResult.Content), FilePerms); writeErr != nil {
 					m.logger.Warn("could not write conflict file",
 						slog.String("path", conflictPath),
 						slog.String("error", writeErr.Error()))
@@ -148,30 +157,42 @@ func (m *Manager) renderTemplateAndLink(tmplAbsPath, relPath string) error {
 			}
 
 			finalContent = []byte(mergeResult.Content)
-		} else if pathExists(renderedAbsPath) {
+		} else if m.pathExists(renderedAbsPath) {
 			// First render but rendered file exists (orphaned) - back it up
 			bakPath := renderedAbsPath + ".bak"
 			m.logger.Warn("backing up orphaned rendered file",
 				slog.String("from", renderedAbsPath),
 				slog.String("to", bakPath))
-			if copyErr := copyFile(renderedAbsPath, bakPath); copyErr != nil {
+			if copyErr := copyFile(m.fs, renderedAbsPath, bakPath); copyErr != nil {
 				m.logger.Warn("could not backup rendered file",
 					slog.String("error", copyErr.Error()))
 			}
 		}
 	}
 
+	// A force re-render discards manual edits, so keep the edited file in a snapshot
+	if m.ForceRender && m.pathExists(renderedAbsPath) {
+		current, readErr := m.fs.ReadFile(renderedAbsPath)
+		if readErr != nil || !bytes.Equal(current, finalContent) {
+			if _, snapErr := m.snapshot(renderedAbsPath, false, snapshotOverwritten, false); snapErr != nil {
+				return snapErr
+			}
+		}
+	}
+
 	// Write the rendered content
-	if mkdirErr := os.MkdirAll(filepath.Dir(renderedAbsPath), DirPerms); mkdirErr != nil {
+	if mkdirErr := m.fs.MkdirAll(filepath.Dir(renderedAbsPath), DirPerms); mkdirErr != nil {
 		return NewPathError("restore", renderedAbsPath, fmt.Errorf("creating rendered dir: %w", mkdirErr))
 	}
 
-	if writeErr := os.WriteFile(renderedAbsPath, finalContent, FilePerms); writeErr != nil {
+	if writeErr := m.fs.WriteFile(renderedAbsPath, finalContent, FilePerms); writeErr != nil {
 		return NewPathError("restore", renderedAbsPath, fmt.Errorf("writing rendered file: %w", writeErr))
 	}
 
+	m.emit(TemplateRendered{EntryEvent: m.entryEvent(m.entryName), Template: tmplAbsPath, Rendered: renderedAbsPath})
+
 	// Store pure render in DB (always store the unmerged template output)
-	if m.stateStore != nil {
+	if m.stateStore != nil && !m.DryRun {
 		if saveErr := m.stateStore.SaveRender(relPath, rendered, hash, m.Platform.OS, m.Platform.Hostname); saveErr != nil {
 			m.logger.Warn("failed to save render record",
 				slog.String("template", relPath),
@@ -199,21 +220,19 @@ func (m *Manager) ensureRelativeSymlinkForTemplate(tmplAbsPath string) error {
 // Uses os.Symlink directly (no sudo needed for same-directory relative links).
 func (m *Manager) ensureRelativeSymlink(symlinkPath, target string) error {
 	// Check if already a correct relative symlink
-	if isSymlink(symlinkPath) {
-		existing, err := os.Readlink(symlinkPath)
+	if m.isSymlink(symlinkPath) {
+		existing, err := m.fs.Readlink(symlinkPath)
 		if err == nil && existing == target {
 			return nil
 		}
 	}
 
 	// Remove existing file or incorrect symlink
-	if pathExists(symlinkPath) || isSymlink(symlinkPath) {
+	if m.pathExists(symlinkPath) || m.isSymlink(symlinkPath) {
 		m.logger.Info("removing existing file/symlink for relative symlink",
 			slog.String("path", symlinkPath))
-		if !m.DryRun {
-			if err := os.Remove(symlinkPath); err != nil {
-				return NewPathError("restore", symlinkPath, fmt.Errorf("removing existing: %w", err))
-			}
+		if err := m.fs.Remove(symlinkPath); err != nil {
+			return NewPathError("restore", symlinkPath, fmt.Errorf("removing existing: %w", err))
 		}
 	}
 
@@ -221,9 +240,5 @@ func (m *Manager) ensureRelativeSymlink(symlinkPath, target string) error {
 		slog.String("link", symlinkPath),
 		slog.String("target", target))
 
-	if !m.DryRun {
-		return os.Symlink(target, symlinkPath)
-	}
-
-	return nil
+	return m.fs.Symlink(target, symlinkPath)
 }
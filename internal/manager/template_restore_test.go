@@ -10,6 +10,7 @@ import (
 	"github.com/AntoineGS/tidydots/internal/platform"
 	"github.com/AntoineGS/tidydots/internal/state"
 	tmpl "github.com/AntoineGS/tidydots/internal/template"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 const expectedHostnameRender = "Host=testhost"
@@ -626,7 +627,7 @@ func TestHasTemplateFiles(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(dir, "regular.txt"), []byte("x"), 0600); err != nil {
 		t.Fatal(err)
 	}
-	if hasTemplateFiles(dir) {
+	if hasTemplateFiles(vfs.OS{}, dir, nil) {
 		t.Error("should return false when no .tmpl files")
 	}
 
@@ -634,7 +635,7 @@ func TestHasTemplateFiles(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(dir, "config.tmpl"), []byte("x"), 0600); err != nil {
 		t.Fatal(err)
 	}
-	if !hasTemplateFiles(dir) {
+	if !hasTemplateFiles(vfs.OS{}, dir, nil) {
 		t.Error("should return true when .tmpl file exists")
 	}
 }
@@ -649,13 +650,13 @@ func TestHasTemplateFiles_Nested(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(subDir, "config.tmpl"), []byte("x"), 0600); err != nil {
 		t.Fatal(err)
 	}
-	if !hasTemplateFiles(dir) {
+	if !hasTemplateFiles(vfs.OS{}, dir, nil) {
 		t.Error("should detect .tmpl files in subdirectories")
 	}
 }
 
 func TestHasTemplateFiles_NonExistent(t *testing.T) {
-	if hasTemplateFiles("/nonexistent/path") {
+	if hasTemplateFiles(vfs.OS{}, "/nonexistent/path", nil) {
 		t.Error("should return false for non-existent directory")
 	}
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/AntoineGS/tidydots/internal/state"
 	tmpl "github.com/AntoineGS/tidydots/internal/template"
+	"github.com/AntoineGS/tidydots/internal/vfs"
 )
 
 // templateWalkFunc is called for each template file found during walking.
@@ -14,7 +15,7 @@ import (
 type templateWalkFunc func(path, relPath string, record *state.RenderRecord) error
 
 // walkTemplateFiles walks backupDir for .tmpl files, filtering out non-template,
-// rendered, and conflict files, and calls fn for each template file found.
+// rendered, conflict and ignored files, and calls fn for each template file found.
 // It handles the common nil stateStore check and hasTemplateFiles guard.
 // Returns nil if stateStore is nil or the directory has no template files.
 func (m *Manager) walkTemplateFiles(backupDir string, fn templateWalkFunc) error {
@@ -22,15 +23,21 @@ func (m *Manager) walkTemplateFiles(backupDir string, fn templateWalkFunc) error
 		return nil
 	}
 
-	if !hasTemplateFiles(backupDir) {
+	ign := m.ignoreMatcherForBackup(backupDir)
+
+	if !hasTemplateFiles(m.fs, backupDir, ign) {
 		return nil
 	}
 
-	return filepath.WalkDir(backupDir, func(path string, d fs.DirEntry, err error) error {
+	return vfs.WalkDir(m.fs, backupDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return filepath.SkipDir
 		}
 
+		if skip, skipErr := skipIgnored(ign, backupDir, path, d); skip {
+			return skipErr
+		}
+
 		if d.IsDir() {
 			return nil
 		}
@@ -3,43 +3,434 @@ package packages
 import (
 	"bytes"
 	"context"
-	"fmt"
+	"encoding/json"
 	"log/slog"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
 )
 
 // bulkListFunc runs a single command to list all installed packages and returns
-// a set of lowercase package IDs. Used by managers where per-package queries are
-// slow or unreliable under concurrency (e.g. winget).
-type bulkListFunc func(ctx context.Context) map[string]bool
+// their lowercase package IDs mapped to the installed version, or to "" when
+// the manager does not report one. Used by managers where per-package queries
+// are slow or unreliable under concurrency (e.g. winget).
+type bulkListFunc func(ctx context.Context) map[string]string
+
+// outdatedListFunc runs a single command to list the installed packages that
+// have a newer version available, and returns their lowercase package IDs
+// mapped to that version, or to "" when the manager does not report it.
+type outdatedListFunc func(ctx context.Context) map[string]string
 
 // managerCmd defines the install and check commands for a package manager.
-// The placeholder "{pkg}" in args is replaced with the actual package name.
+// The placeholder "{pkg}" in args is replaced with the actual package name,
+// also inside a larger argument such as "nixpkgs#{pkg}".
 type managerCmd struct {
-	install  []string     // command args for install, e.g. {"sudo", "pacman", "-S", "--noconfirm", "{pkg}"}
-	check    []string     // command args for checking install status, e.g. {"pacman", "-Q", "{pkg}"}
-	bulkList bulkListFunc // if set, IsInstalled uses a single bulk query instead of per-package checks
+	install   []string     // command args for install, e.g. {"sudo", "pacman", "-S", "--noconfirm", "{pkg}"}
+	uninstall []string     // command args for uninstall, e.g. {"sudo", "pacman", "-R", "--noconfirm", "{pkg}"}; nil if unsupported
+	upgrade   []string     // command args for upgrade, e.g. {"brew", "upgrade", "{pkg}"}; nil if install also upgrades
+	check     []string     // command args for checking install status, e.g. {"apk", "info", "-e", "{pkg}"}
+	bulkList  bulkListFunc // if set, IsInstalled uses a single bulk query instead of per-package checks
+
+	// outdated, if set, lists the packages with an available upgrade.
+	outdated outdatedListFunc
+
+	// explicit, if set, lists the packages installed on request, for
+	// "tidydots packages import".
+	explicit explicitListFunc
+
+	// installName and checkName, if set, rewrite the configured package name
+	// for the install command and the installed check, e.g. to add or strip
+	// a version suffix.
+	installName func(string) string
+	checkName   func(string) string
+
+	// optionArgs, if set, returns the flags for a package's install options,
+	// inserted before the package name.
+	optionArgs func(ManagerValue) []string
+
+	// pin, if set, returns the install arguments selecting an exact version
+	// of a package: flags followed by the package argument, e.g.
+	// {"ripgrep=14.1.0"} or {"--version", "14.1.0", "ripgrep"}.
+	pin func(pkgName, version string) []string
+
+	// single marks managers that install one package per invocation, so
+	// InstallAll never batches them.
+	single bool
+
+	// systemUpgrade, if set, is the full system upgrade of a manager that
+	// does not support upgrading single packages, such as pacman, where
+	// partial upgrades can break the system. Upgrade refuses such packages
+	// and names this command instead.
+	systemUpgrade []string
 }
 
+// installArgs returns the install command for a package, selecting its
+// version when it is pinned to one.
+func (mc managerCmd) installArgs(val ManagerValue) []string {
+	pkgName := val.PackageName
+	if mc.installName != nil {
+		pkgName = mc.installName(pkgName)
+	}
+
+	var opts []string
+	if pinned := mc.pinArgs(val); pinned != nil {
+		last := len(pinned) - 1
+		pkgName, opts = pinned[last], pinned[:last]
+	}
+
+	args := expandArgs(mc.install, pkgName)
+
+	if mc.optionArgs != nil {
+		opts = append(opts, mc.optionArgs(val)...)
+	}
+
+	if len(opts) > 0 {
+		last := len(args) - 1
+		args = append(append(args[:last:last], opts...), args[last])
+	}
+
+	return args
+}
+
+// pinArgs returns the install arguments selecting the exact version a
+// package is pinned to, or nil when it is not pinned or the manager cannot
+// install a given version.
+func (mc managerCmd) pinArgs(val ManagerValue) []string {
+	if mc.pin == nil {
+		return nil
+	}
+
+	version, ok := pinnedVersion(val)
+	if !ok {
+		return nil
+	}
+
+	return mc.pin(val.PackageName, version)
+}
+
+// batchArgs returns one install command for several packages: the command
+// of the first, with every package name in place of its name. Packages can
+// share a command when their install options match.
+func (mc managerCmd) batchArgs(vals []ManagerValue) []string {
+	args := mc.installArgs(vals[0])
+	last := len(args) - 1
+
+	names := make([]string, 0, len(vals))
+	for _, val := range vals {
+		names = append(names, mc.installArgs(val)[last])
+	}
+
+	return append(args[:last:last], names...)
+}
+
+// batchKey identifies the packages of a manager that can share an install
+// command, or returns "" when val must be installed on its own.
+func (mc managerCmd) batchKey(mgr PackageManager, val ManagerValue) string {
+	if mc.single {
+		return ""
+	}
+
+	var opts []string
+	if mc.optionArgs != nil {
+		opts = mc.optionArgs(val)
+	}
+
+	// snap only accepts confinement and channel flags for a single snap
+	if mgr == Snap && len(opts) > 0 {
+		return ""
+	}
+
+	// Version flags apply to every package of a command
+	if len(mc.pinArgs(val)) > 1 {
+		return ""
+	}
+
+	return strings.Join(append([]string{string(mgr)}, opts...), " ")
+}
+
+// checkedName returns the name pkgName is looked up under when checking
+// whether it is installed.
+func (mc managerCmd) checkedName(pkgName string) string {
+	if mc.checkName != nil {
+		return mc.checkName(pkgName)
+	}
+
+	return pkgName
+}
+
+// Managers without an upgrade command (cargo, go and npm) upgrade an installed
+// package when installing it again. Pacman and its AUR helpers only upgrade
+// the whole system. Managers without a pin function cannot install a given
+// version of a package.
 var managerCmds = map[PackageManager]managerCmd{
-	Pacman: {install: []string{"sudo", "pacman", "-S", "--noconfirm", "{pkg}"}, check: []string{"pacman", "-Q", "{pkg}"}},
-	Yay:    {install: []string{"yay", "-S", "--noconfirm", "{pkg}"}, check: []string{"pacman", "-Q", "{pkg}"}},
-	Paru:   {install: []string{"paru", "-S", "--noconfirm", "{pkg}"}, check: []string{"pacman", "-Q", "{pkg}"}},
-	Apt:    {install: []string{"sudo", "apt-get", "install", "-y", "{pkg}"}, check: []string{"dpkg", "-s", "{pkg}"}},
-	Dnf:    {install: []string{"sudo", "dnf", "install", "-y", "{pkg}"}, check: []string{"rpm", "-q", "{pkg}"}},
-	Brew:   {install: []string{"brew", "install", "{pkg}"}, check: []string{"brew", "list", "{pkg}"}},
-	Winget: {install: []string{"winget", "install", "--accept-package-agreements", "--accept-source-agreements", "{pkg}"}, bulkList: wingetBulkList},
-	Scoop:  {install: []string{"scoop", "install", "{pkg}"}, check: []string{"scoop", "info", "{pkg}"}},
-	Choco:  {install: []string{"choco", "install", "-y", "{pkg}"}, check: []string{"choco", "list", "--local-only", "{pkg}"}},
+	Pacman: {install: []string{"sudo", "pacman", "-S", "--noconfirm", "{pkg}"}, uninstall: []string{"sudo", "pacman", "-R", "--noconfirm", "{pkg}"}, bulkList: pacmanBulkList, outdated: pacmanOutdatedList("pacman"), explicit: pacmanExplicitList("pacman"), systemUpgrade: []string{"sudo", "pacman", "-Syu"}},
+	Yay:    {install: []string{"yay", "-S", "--noconfirm", "{pkg}"}, uninstall: []string{"yay", "-R", "--noconfirm", "{pkg}"}, bulkList: pacmanBulkList, outdated: pacmanOutdatedList("yay"), explicit: pacmanExplicitList("yay"), systemUpgrade: []string{"yay", "-Syu"}},
+	Paru:   {install: []string{"paru", "-S", "--noconfirm", "{pkg}"}, uninstall: []string{"paru", "-R", "--noconfirm", "{pkg}"}, bulkList: pacmanBulkList, outdated: pacmanOutdatedList("paru"), explicit: pacmanExplicitList("paru"), systemUpgrade: []string{"paru", "-Syu"}},
+	Apt:    {install: []string{"sudo", "apt-get", "install", "-y", "{pkg}"}, uninstall: []string{"sudo", "apt-get", "remove", "-y", "{pkg}"}, upgrade: []string{"sudo", "apt-get", "install", "--only-upgrade", "-y", "{pkg}"}, bulkList: dpkgBulkList, outdated: aptOutdatedList, pin: pinEquals, explicit: aptExplicitList},
+	Dnf:    {install: []string{"sudo", "dnf", "install", "-y", "{pkg}"}, uninstall: []string{"sudo", "dnf", "remove", "-y", "{pkg}"}, upgrade: []string{"sudo", "dnf", "upgrade", "-y", "{pkg}"}, bulkList: rpmBulkList, outdated: dnfOutdatedList, pin: pinDash},
+	Zypper: {install: []string{"sudo", "zypper", "--non-interactive", "install", "{pkg}"}, uninstall: []string{"sudo", "zypper", "--non-interactive", "remove", "{pkg}"}, upgrade: []string{"sudo", "zypper", "--non-interactive", "update", "{pkg}"}, bulkList: rpmBulkList, outdated: zypperOutdatedList, pin: pinEquals},
+	Apk:    {install: []string{"sudo", "apk", "add", "{pkg}"}, uninstall: []string{"sudo", "apk", "del", "{pkg}"}, upgrade: []string{"sudo", "apk", "add", "-u", "{pkg}"}, check: []string{"apk", "info", "-e", "{pkg}"}, bulkList: apkBulkList, pin: pinEquals},
+	Xbps:   {install: []string{"sudo", "xbps-install", "-y", "{pkg}"}, uninstall: []string{"sudo", "xbps-remove", "-y", "{pkg}"}, upgrade: []string{"sudo", "xbps-install", "-uy", "{pkg}"}, check: []string{"xbps-query", "{pkg}"}, bulkList: xbpsBulkList},
+	Emerge: {install: []string{"sudo", "emerge", "--noreplace", "{pkg}"}, uninstall: []string{"sudo", "emerge", "--depclean", "{pkg}"}, upgrade: []string{"sudo", "emerge", "--update", "{pkg}"}, bulkList: emergeBulkList, pin: pinEmerge},
+	Nix:    {install: []string{"nix", "profile", "install", "nixpkgs#{pkg}"}, uninstall: []string{"nix", "profile", "remove", "{pkg}"}, upgrade: []string{"nix", "profile", "upgrade", "{pkg}"}, bulkList: nixBulkList},
+
+	// Flatpak asks polkit for system installs itself, so it runs without sudo.
+	Flatpak: {install: []string{"flatpak", "install", "-y", "--noninteractive", "{pkg}"}, uninstall: []string{"flatpak", "uninstall", "-y", "--noninteractive", "{pkg}"}, upgrade: []string{"flatpak", "update", "-y", "--noninteractive", "{pkg}"}, bulkList: flatpakBulkList, outdated: flatpakOutdatedList, optionArgs: flatpakOptionArgs},
+	Snap:    {install: []string{"sudo", "snap", "install", "{pkg}"}, uninstall: []string{"sudo", "snap", "remove", "{pkg}"}, upgrade: []string{"sudo", "snap", "refresh", "{pkg}"}, bulkList: snapBulkList, outdated: snapOutdatedList, optionArgs: snapOptionArgs},
+
+	Brew:   {install: []string{"brew", "install", "{pkg}"}, uninstall: []string{"brew", "uninstall", "{pkg}"}, upgrade: []string{"brew", "upgrade", "{pkg}"}, bulkList: brewBulkList, outdated: brewOutdatedList, checkName: stripTapPrefix, explicit: brewExplicitList},
+	Port:   {install: []string{"sudo", "port", "-N", "install", "{pkg}"}, uninstall: []string{"sudo", "port", "-N", "uninstall", "{pkg}"}, upgrade: []string{"sudo", "port", "-N", "upgrade", "{pkg}"}, bulkList: portBulkList, outdated: portOutdatedList},
+	Winget: {install: []string{"winget", "install", "--accept-package-agreements", "--accept-source-agreements", "{pkg}"}, uninstall: []string{"winget", "uninstall", "--accept-source-agreements", "{pkg}"}, upgrade: []string{"winget", "upgrade", "--accept-package-agreements", "--accept-source-agreements", "{pkg}"}, bulkList: wingetBulkList, outdated: wingetOutdatedList, single: true, pin: pinVersionFlag, explicit: wingetExplicitList},
+	Scoop:  {install: []string{"scoop", "install", "{pkg}"}, uninstall: []string{"scoop", "uninstall", "{pkg}"}, upgrade: []string{"scoop", "update", "{pkg}"}, bulkList: scoopBulkList, outdated: scoopOutdatedList, checkName: stripTapPrefix, pin: pinAt, explicit: scoopExplicitList},
+	Choco:  {install: []string{"choco", "install", "-y", "{pkg}"}, uninstall: []string{"choco", "uninstall", "-y", "{pkg}"}, upgrade: []string{"choco", "upgrade", "-y", "{pkg}"}, bulkList: chocoBulkList, outdated: chocoOutdatedList, pin: pinVersionFlag},
+
+	// Language package managers install into the user's home, without sudo.
+	// "go install" has no counterpart to remove a binary, so go cannot uninstall.
+	Cargo: {install: []string{"cargo", "install", "{pkg}"}, uninstall: []string{"cargo", "uninstall", "{pkg}"}, bulkList: cargoBulkList, pin: pinAt},
+	Go:    {install: []string{"go", "install", "{pkg}"}, bulkList: goBulkList, installName: goInstallName, checkName: stripGoVersion, single: true, pin: pinGo},
+	Pipx:  {install: []string{"pipx", "install", "{pkg}"}, uninstall: []string{"pipx", "uninstall", "{pkg}"}, upgrade: []string{"pipx", "upgrade", "{pkg}"}, bulkList: pipxBulkList, checkName: normalizePythonName, pin: pinPython},
+	Npm:   {install: []string{"npm", "install", "-g", "{pkg}"}, uninstall: []string{"npm", "uninstall", "-g", "{pkg}"}, bulkList: npmBulkList, outdated: npmOutdatedList, checkName: stripNpmVersion, pin: pinNpm},
+	Uv:    {install: []string{"uv", "tool", "install", "{pkg}"}, uninstall: []string{"uv", "tool", "uninstall", "{pkg}"}, upgrade: []string{"uv", "tool", "upgrade", "{pkg}"}, bulkList: uvBulkList, checkName: normalizePythonName, single: true, pin: pinPython},
+}
+
+// portBulkList runs "port -q installed" once and returns the installed port
+// names. "port installed <name>" exits successfully even when the port is
+// missing, so per-package checks cannot be used.
+func portBulkList(ctx context.Context) map[string]string {
+	slog.Debug("running port bulk list")
+
+	out, err := exec.CommandContext(ctx, "port", "-q", "installed").Output()
+	if err != nil {
+		slog.Debug("port bulk list failed", slog.String("error", err.Error()))
+		return make(map[string]string)
+	}
+
+	return parsePortInstalledOutput(string(out))
+}
+
+// parsePortInstalledOutput extracts ports and their versions from
+// "port -q installed" output, where each line reads
+// "  name @version_revision+variants (active)".
+func parsePortInstalledOutput(output string) map[string]string {
+	names := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		version := ""
+		if len(fields) > 1 {
+			version, _, _ = strings.Cut(strings.TrimPrefix(fields[1], "@"), "+")
+		}
+
+		names[strings.ToLower(fields[0])] = version
+	}
+
+	return names
+}
+
+// flatpakOptionArgs returns the scope flag and remote for a flatpak install.
+// The remote is positional and must directly precede the application ID.
+func flatpakOptionArgs(val ManagerValue) []string {
+	if val.Flatpak == nil {
+		return nil
+	}
+
+	var args []string
+	if val.Flatpak.Scope != "" {
+		args = append(args, "--"+val.Flatpak.Scope)
+	}
+	if val.Flatpak.Remote != "" {
+		args = append(args, val.Flatpak.Remote)
+	}
+
+	return args
+}
+
+// snapOptionArgs returns the confinement and channel flags for a snap install.
+func snapOptionArgs(val ManagerValue) []string {
+	if val.Snap == nil {
+		return nil
+	}
+
+	var args []string
+	if val.Snap.Classic {
+		args = append(args, "--classic")
+	}
+	if val.Snap.Channel != "" {
+		args = append(args, "--channel="+val.Snap.Channel)
+	}
+
+	return args
+}
+
+// flatpakBulkList runs "flatpak list --app --columns=application,version"
+// once and returns the installed application IDs, from both the user and
+// system installations.
+func flatpakBulkList(ctx context.Context) map[string]string {
+	slog.Debug("running flatpak bulk list")
+
+	out, err := exec.CommandContext(ctx, "flatpak", "list", "--app", "--columns=application,version").Output()
+	if err != nil {
+		slog.Debug("flatpak bulk list failed", slog.String("error", err.Error()))
+		return make(map[string]string)
+	}
+
+	return parseNameVersionColumns(string(out), false)
+}
+
+// snapBulkList runs "snap list" once and returns the installed snap names.
+func snapBulkList(ctx context.Context) map[string]string {
+	slog.Debug("running snap bulk list")
+
+	out, err := exec.CommandContext(ctx, "snap", "list").Output()
+	if err != nil {
+		slog.Debug("snap bulk list failed", slog.String("error", err.Error()))
+		return make(map[string]string)
+	}
+
+	return parseNameVersionColumns(string(out), true)
+}
+
+// parseNameVersionColumns maps the lowercased first field of every non-empty
+// line to its second field (the version, or "" when missing), skipping the
+// first line when the output has a header row.
+func parseNameVersionColumns(output string, header bool) map[string]string {
+	names := make(map[string]string)
+
+	lines := strings.Split(output, "\n")
+	if header && len(lines) > 0 {
+		lines = lines[1:]
+	}
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 0:
+		case 1:
+			names[strings.ToLower(fields[0])] = ""
+		default:
+			names[strings.ToLower(fields[0])] = fields[1]
+		}
+	}
+
+	return names
+}
+
+// gentooPkgDB is the directory where portage records installed packages.
+var gentooPkgDB = "/var/db/pkg"
+
+// gentooVersion matches the version suffix of an installed package directory,
+// e.g. "-0.9.5-r1" in "neovim-0.9.5-r1".
+var gentooVersion = regexp.MustCompile(`-[0-9][0-9.]*[a-z]?(_(alpha|beta|pre|rc|p)[0-9]*)*(-r[0-9]+)?$`)
+
+// emergeBulkList reads portage's installed package database once. Packages are
+// recorded both as "category/name" and as plain "name", since either form can
+// be passed to emerge. Querying it directly avoids depending on portage-utils.
+func emergeBulkList(_ context.Context) map[string]string {
+	names := make(map[string]string)
+
+	categories, err := os.ReadDir(gentooPkgDB)
+	if err != nil {
+		slog.Debug("emerge bulk list failed", slog.String("error", err.Error()))
+		return names
+	}
+
+	for _, category := range categories {
+		if !category.IsDir() {
+			continue
+		}
+
+		pkgs, err := os.ReadDir(filepath.Join(gentooPkgDB, category.Name()))
+		if err != nil {
+			continue
+		}
+
+		for _, pkg := range pkgs {
+			version := gentooVersion.FindString(pkg.Name())
+			name := strings.TrimSuffix(pkg.Name(), version)
+			version = strings.TrimPrefix(version, "-")
+			names[strings.ToLower(name)] = version
+			names[strings.ToLower(category.Name()+"/"+name)] = version
+		}
+	}
+
+	return names
+}
+
+// nixBulkList runs "nix profile list --json" once and returns the names of the
+// packages in the user's profile.
+func nixBulkList(ctx context.Context) map[string]string {
+	slog.Debug("running nix bulk list")
+
+	out, err := exec.CommandContext(ctx, "nix", "profile", "list", "--json").Output()
+	if err != nil {
+		slog.Debug("nix bulk list failed", slog.String("error", err.Error()))
+		return make(map[string]string)
+	}
+
+	return parseNixProfileList(out)
+}
+
+// parseNixProfileList extracts package names from "nix profile list --json",
+// which does not report versions.
+// Recent nix versions key elements by name; older ones return a list. Either
+// way the last segment of each attribute path ("legacyPackages.x86_64-linux.ripgrep")
+// is the nixpkgs attribute passed to install.
+func parseNixProfileList(data []byte) map[string]string {
+	names := make(map[string]string)
+
+	var profile struct {
+		Elements json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		slog.Debug("nix bulk list: invalid JSON", slog.String("error", err.Error()))
+		return names
+	}
+
+	type element struct {
+		AttrPath string `json:"attrPath"`
+	}
+
+	addAttr := func(attrPath string) {
+		if attrPath == "" {
+			return
+		}
+		parts := strings.Split(attrPath, ".")
+		names[strings.ToLower(parts[len(parts)-1])] = ""
+	}
+
+	var byName map[string]element
+	if err := json.Unmarshal(profile.Elements, &byName); err == nil {
+		for name, e := range byName {
+			names[strings.ToLower(name)] = ""
+			addAttr(e.AttrPath)
+		}
+
+		return names
+	}
+
+	var list []element
+	if err := json.Unmarshal(profile.Elements, &list); err == nil {
+		for _, e := range list {
+			addAttr(e.AttrPath)
+		}
+	}
+
+	return names
 }
 
 // wingetBulkList runs "winget list" once and parses the output to build a set of
 // installed package IDs. This avoids N slow serial "winget list --id" calls and
 // the concurrency bugs (0x8a150001) that winget has with parallel queries.
-func wingetBulkList(ctx context.Context) map[string]bool {
+func wingetBulkList(ctx context.Context) map[string]string {
 	slog.Debug("running winget bulk list")
 
 	cmd := exec.CommandContext(ctx, "winget", "list", "--disable-interactivity", "--accept-source-agreements")
@@ -52,17 +443,23 @@ func wingetBulkList(ctx context.Context) map[string]bool {
 		slog.Debug("winget bulk list failed",
 			slog.String("error", err.Error()),
 			slog.String("stderr", strings.TrimSpace(stderr.String())))
-		return make(map[string]bool)
+		return make(map[string]string)
 	}
 
 	return parseWingetListOutput(stdout.String())
 }
 
-// parseWingetListOutput extracts package IDs from winget list output.
+// parseWingetListOutput extracts package IDs and versions from winget list output.
 // The output has a header row with column names separated by dashes, then data rows.
-// The Id column position is detected from the header.
-func parseWingetListOutput(output string) map[string]bool {
-	ids := make(map[string]bool)
+// The Id and Version column positions are detected from the header.
+func parseWingetListOutput(output string) map[string]string {
+	return parseWingetTable(output, "Version")
+}
+
+// parseWingetTable extracts package IDs from a winget table, mapped to the
+// value of column ("Version" or "Available").
+func parseWingetTable(output, column string) map[string]string {
+	ids := make(map[string]string)
 	lines := cleanWingetOutput(output)
 
 	// Find the header separator line (all dashes) to locate column positions
@@ -90,9 +487,17 @@ func parseWingetListOutput(output string) map[string]bool {
 
 	// Find the next column after Id (Version) to determine Id column end
 	idEnd := len(header)
-	versionIdx := strings.Index(header, "Version")
-	if versionIdx > idStart {
-		idEnd = versionIdx
+	if i := strings.Index(header, "Version"); i > idStart {
+		idEnd = i
+	}
+
+	// The value column ends where the next one (Available or Source) starts
+	versionIdx := strings.Index(header, column)
+	versionEnd := len(header)
+	for _, next := range []string{"Available", "Source"} {
+		if i := strings.Index(header, next); i > versionIdx && i < versionEnd {
+			versionEnd = i
+		}
 	}
 
 	// Parse data rows
@@ -107,9 +512,16 @@ func parseWingetListOutput(output string) map[string]bool {
 		}
 
 		id := strings.TrimSpace(line[idStart:end])
-		if id != "" {
-			ids[strings.ToLower(id)] = true
+		if id == "" {
+			continue
 		}
+
+		version := ""
+		if versionIdx > idStart && len(line) > versionIdx {
+			version = strings.TrimSpace(line[versionIdx:min(versionEnd, len(line))])
+		}
+
+		ids[strings.ToLower(id)] = version
 	}
 
 	slog.Debug("winget bulk list complete",
@@ -145,26 +557,66 @@ func cleanWingetOutput(output string) []string {
 func expandArgs(args []string, pkgName string) []string {
 	result := make([]string, len(args))
 	for i, arg := range args {
-		if arg == "{pkg}" {
-			result[i] = pkgName
-		} else {
-			result[i] = arg
-		}
+		result[i] = strings.ReplaceAll(arg, "{pkg}", pkgName)
 	}
 
 	return result
 }
 
+// BuildBatchCommand creates one *exec.Cmd installing all pkgs with the
+// package manager method, like BuildCommand does for a single package.
+// Returns nil unless every package has a value for the manager and they can
+// share a command (see Manager.InstallAll).
+func BuildBatchCommand(ctx context.Context, pkgs []Package, method string) *exec.Cmd {
+	pm := PackageManager(method)
+
+	if _, ok := managerCmds[pm]; !ok || len(pkgs) == 0 {
+		return nil
+	}
+
+	vals := make([]ManagerValue, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		val, ok := pkg.Managers[pm]
+		if !ok {
+			return nil
+		}
+
+		vals = append(vals, val)
+	}
+
+	return batchCommand(ctx, pm, vals)
+}
+
+// batchCommand creates one *exec.Cmd installing the package manager values
+// with manager pm, or returns nil when they cannot share a command.
+func batchCommand(ctx context.Context, pm PackageManager, vals []ManagerValue) *exec.Cmd {
+	mc := managerCmds[pm]
+
+	for _, val := range vals {
+		if key := mc.batchKey(pm, val); key == "" || key != mc.batchKey(pm, vals[0]) {
+			return nil
+		}
+	}
+
+	args := mc.batchArgs(vals)
+
+	return exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // args from trusted lookup table
+}
+
 // BuildCommand creates an *exec.Cmd for installing a package using the given method.
 // It is a pure command builder — the caller controls execution, stdio wiring, and dry-run logic.
-// Returns nil if no command can be built for the given method.
-func BuildCommand(ctx context.Context, pkg Package, method, osType string) *exec.Cmd { //nolint:gocyclo // switch over package manager types is inherently branchy
+// Git clone destinations are selected for machine; everything else only
+// depends on its OS. Returns nil if no command can be built for the given method,
+// and for URL installs, whose file is fetched with Download before running
+// BuildURLInstallCommand.
+func BuildCommand(ctx context.Context, pkg Package, method string, machine config.Machine) *exec.Cmd { //nolint:gocyclo // switch over package manager types is inherently branchy
 	pm := PackageManager(method)
+	osType := machine.OS
 
 	// Package managers (pacman, yay, apt, etc.)
 	if mc, ok := managerCmds[pm]; ok {
 		if val, exists := pkg.Managers[pm]; exists {
-			args := expandArgs(mc.install, val.PackageName)
+			args := mc.installArgs(val)
 			return exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // args from trusted lookup table
 		}
 	}
@@ -175,7 +627,7 @@ func BuildCommand(ctx context.Context, pkg Package, method, osType string) *exec
 		if !ok || !gitVal.IsGit() {
 			return nil
 		}
-		target := gitVal.Git.Targets[osType]
+		target := gitVal.Git.TargetFor(machine)
 		if target == "" {
 			return nil
 		}
@@ -195,7 +647,7 @@ func BuildCommand(ctx context.Context, pkg Package, method, osType string) *exec
 		if !ok || !installerVal.IsInstaller() {
 			return nil
 		}
-		command, hasCmd := installerVal.Installer.Command[osType]
+		command, hasCmd := config.ForOS(installerVal.Installer.Command, osType)
 		if !hasCmd {
 			return nil
 		}
@@ -205,7 +657,7 @@ func BuildCommand(ctx context.Context, pkg Package, method, osType string) *exec
 		return exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // intentional install command from user config
 
 	case MethodCustom:
-		command, ok := pkg.Custom[osType]
+		command, ok := config.ForOS(pkg.Custom, osType)
 		if !ok {
 			return nil
 		}
@@ -214,29 +666,6 @@ func BuildCommand(ctx context.Context, pkg Package, method, osType string) *exec
 		}
 		return exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // intentional command from user config
 
-	case MethodURL:
-		urlInstall, ok := pkg.URL[osType]
-		if !ok {
-			return nil
-		}
-		if osType == platform.OSWindows {
-			script := fmt.Sprintf(`
-				$tmpFile = [System.IO.Path]::GetTempFileName()
-				Invoke-WebRequest -Uri '%s' -OutFile $tmpFile
-				$command = '%s' -replace '\{file\}', $tmpFile
-				Invoke-Expression $command
-				Remove-Item $tmpFile -ErrorAction SilentlyContinue
-			`, urlInstall.URL, urlInstall.Command)
-			return exec.CommandContext(ctx, "powershell", "-Command", script) //nolint:gosec // intentional command from user config
-		}
-		script := fmt.Sprintf(`
-			tmpfile=$(mktemp)
-			trap "rm -f $tmpfile" EXIT
-			curl -fsSL -o "$tmpfile" '%s' && \
-			chmod +x "$tmpfile" && \
-			%s
-		`, urlInstall.URL, strings.ReplaceAll(urlInstall.Command, "{file}", "$tmpfile"))
-		return exec.CommandContext(ctx, "sh", "-c", script) //nolint:gosec // intentional command from user config
 	}
 
 	return nil
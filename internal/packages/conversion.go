@@ -36,12 +36,13 @@ func convertPackage(pkg *config.EntryPackage) (map[PackageManager]ManagerValue,
 		// Convert config.InstallerPackage to packages.InstallerConfig
 		if k == "installer" && v.IsInstaller() {
 			managers[Installer] = ManagerValue{Installer: &InstallerConfig{
-				Command: v.Installer.Command,
-				Binary:  v.Installer.Binary,
+				Command:   v.Installer.Command,
+				Binary:    v.Installer.Binary,
+				Uninstall: v.Installer.Uninstall,
 			}}
 			continue
 		}
-		managers[PackageManager(k)] = ManagerValue{PackageName: v.PackageName}
+		managers[PackageManager(k)] = ManagerValue{PackageName: v.PackageName, Version: v.Version, Flatpak: v.Flatpak, Snap: v.Snap}
 	}
 
 	urlInstalls := make(map[string]URLInstall)
@@ -49,6 +50,7 @@ func convertPackage(pkg *config.EntryPackage) (map[PackageManager]ManagerValue,
 		urlInstalls[k] = URLInstall{
 			URL:     v.URL,
 			Command: v.Command,
+			SHA256:  v.SHA256,
 		}
 	}
 
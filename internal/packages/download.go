@@ -0,0 +1,217 @@
+package packages
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/AntoineGS/tidydots/internal/platform"
+)
+
+// DownloadProgress receives the bytes of a download received so far and its
+// size, or -1 when the server does not send one.
+type DownloadProgress func(received, total int64)
+
+// httpClient downloads URL installs. Its transport honours HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY.
+var httpClient = &http.Client{Transport: http.DefaultTransport}
+
+// DownloadCacheDir returns the directory downloads with a checksum are kept
+// in, under the user's cache directory.
+func DownloadCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "tidydots", "downloads"), nil
+}
+
+// Download fetches the file of a URL install and returns its path, and a
+// function removing it once installed. When the install has a SHA-256
+// checksum, the file must match it, and it is kept in cacheDir under its
+// checksum, so later installs skip the download. Without a checksum, or with
+// an empty cacheDir, the file goes to a temporary directory. progress may be
+// nil.
+func Download(ctx context.Context, spec URLInstall, cacheDir string, progress DownloadProgress) (string, func(), error) {
+	noop := func() {}
+	want := strings.ToLower(spec.SHA256)
+	name := downloadName(spec.URL)
+
+	if want != "" && cacheDir != "" {
+		dir := filepath.Join(cacheDir, want)
+		cached := filepath.Join(dir, name)
+
+		if got, err := fileSHA256(cached); err == nil && got == want {
+			return cached, noop, nil
+		}
+
+		if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // cache of public downloads
+			return "", noop, fmt.Errorf("creating cache directory: %w", err)
+		}
+
+		if err := downloadTo(ctx, spec.URL, cached, want, progress); err != nil {
+			return "", noop, err
+		}
+
+		return cached, noop, nil
+	}
+
+	// Create temp directory to avoid TOCTOU race on the file path
+	tmpDir, err := os.MkdirTemp("", "tidydots-*")
+	if err != nil {
+		return "", noop, fmt.Errorf("creating temp directory: %w", err)
+	}
+
+	cleanup := func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			// Log but don't fail on cleanup errors
+			fmt.Printf("[WARN] Failed to remove temp directory %s: %v\n", tmpDir, err)
+		}
+	}
+
+	dest := filepath.Join(tmpDir, name)
+	if err := downloadTo(ctx, spec.URL, dest, want, progress); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+
+	return dest, cleanup, nil
+}
+
+// downloadTo downloads rawURL to dest, checking its SHA-256 checksum when
+// want is set, and makes it executable. The file is written next to dest and
+// renamed into place once complete, so dest never holds a partial or
+// mismatching download.
+func downloadTo(ctx context.Context, rawURL, dest, want string, progress DownloadProgress) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s: %s", rawURL, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".download-*")
+	if err != nil {
+		return fmt.Errorf("creating download file: %w", err)
+	}
+
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	hash := sha256.New()
+	body := io.Reader(resp.Body)
+
+	if progress != nil {
+		body = &progressReader{r: resp.Body, total: resp.ContentLength, progress: progress}
+	}
+
+	_, copyErr := io.Copy(io.MultiWriter(tmp, hash), body)
+	if closeErr := tmp.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+
+	if copyErr != nil {
+		return fmt.Errorf("downloading %s: %w", rawURL, copyErr)
+	}
+
+	if got := hex.EncodeToString(hash.Sum(nil)); want != "" && got != want {
+		return fmt.Errorf("checksum mismatch for %s: got sha256 %s, want %s", rawURL, got, want)
+	}
+
+	if err := os.Chmod(tmpPath, ExecPerms); err != nil { //nolint:gosec // installer scripts need to be executable
+		return fmt.Errorf("making download executable: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, dest); err != nil {
+		return fmt.Errorf("saving download: %w", err)
+	}
+
+	return nil
+}
+
+// downloadName returns the file name of a download: the last element of the
+// URL path, so installers keep their extension, or "installer". Names that
+// could leave the download directory, such as ".." or names holding a
+// backslash (a separator on Windows), also fall back to "installer".
+func downloadName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "installer"
+	}
+
+	switch name := path.Base(u.Path); {
+	case name == "." || name == ".." || name == "/" || name == "":
+		return "installer"
+	case strings.ContainsAny(name, `/\`):
+		return "installer"
+	default:
+		return name
+	}
+}
+
+// fileSHA256 returns the hex SHA-256 checksum of a file.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // path in the download cache
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// progressReader reports the bytes read through it.
+type progressReader struct {
+	r        io.Reader
+	received int64
+	total    int64
+	progress DownloadProgress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.received += int64(n)
+		p.progress(p.received, p.total)
+	}
+
+	return n, err
+}
+
+// BuildURLInstallCommand creates an *exec.Cmd running the command of a URL
+// install on its downloaded file, which replaces the {file} placeholder. Like
+// BuildCommand, it leaves execution to the caller.
+// SECURITY NOTE: This intentionally executes commands from the user's
+// configuration file on downloaded content.
+func BuildURLInstallCommand(ctx context.Context, command, file, osType string) *exec.Cmd {
+	command = strings.ReplaceAll(command, "{file}", file)
+
+	if osType == platform.OSWindows {
+		return exec.CommandContext(ctx, "powershell", "-Command", command) //nolint:gosec // intentional install command
+	}
+
+	return exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // intentional install command
+}
@@ -0,0 +1,350 @@
+package packages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+)
+
+// explicitListFunc lists the packages installed on request with a manager,
+// leaving out the ones pulled in as dependencies, by the names the manager
+// installs them with.
+type explicitListFunc func(ctx context.Context) ([]string, error)
+
+// ImportManagers returns the package managers that can list the packages
+// installed on request, sorted.
+func ImportManagers() []string {
+	var managers []string
+
+	for mgr, mc := range managerCmds {
+		if mc.explicit != nil {
+			managers = append(managers, string(mgr))
+		}
+	}
+
+	sort.Strings(managers)
+
+	return managers
+}
+
+// CanImport returns true if the manager can list the packages installed on
+// request.
+func CanImport(manager string) bool {
+	mc, ok := managerCmds[PackageManager(manager)]
+	return ok && mc.explicit != nil
+}
+
+// ExplicitPackages returns the packages installed on request with a
+// manager, sorted and without duplicates.
+func ExplicitPackages(ctx context.Context, manager string) ([]string, error) {
+	mc, ok := managerCmds[PackageManager(manager)]
+	if !ok || mc.explicit == nil {
+		return nil, fmt.Errorf("%s cannot list installed packages", manager)
+	}
+
+	names, err := mc.explicit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+
+	unique := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			unique = append(unique, name)
+		}
+	}
+
+	return unique, nil
+}
+
+// runExplicitList runs a command printing one package per line and returns
+// the package names.
+func runExplicitList(ctx context.Context, args ...string) ([]string, error) {
+	slog.Debug("running " + args[0] + " explicit list")
+
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output() //nolint:gosec // args from trusted callers
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %w", strings.Join(args, " "), err)
+	}
+
+	return parseNameLines(string(out)), nil
+}
+
+// parseNameLines returns the first field of each non-empty line.
+func parseNameLines(output string) []string {
+	var names []string
+
+	for _, line := range strings.Split(output, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+
+	return names
+}
+
+// pacmanExplicitList lists the explicitly installed packages of pacman or an
+// AUR helper. pacman only lists the packages of its repositories ("-n"),
+// since it cannot install AUR packages.
+func pacmanExplicitList(bin string) explicitListFunc {
+	return func(ctx context.Context) ([]string, error) {
+		if bin == "pacman" {
+			return runExplicitList(ctx, bin, "-Qqen")
+		}
+
+		return runExplicitList(ctx, bin, "-Qqe")
+	}
+}
+
+// aptExplicitList lists the packages marked as manually installed.
+func aptExplicitList(ctx context.Context) ([]string, error) {
+	return runExplicitList(ctx, "apt-mark", "showmanual")
+}
+
+// brewExplicitList lists the formulae installed on request that no other
+// formula depends on, and the casks.
+func brewExplicitList(ctx context.Context) ([]string, error) {
+	formulae, err := runExplicitList(ctx, "brew", "leaves", "--installed-on-request")
+	if err != nil {
+		return nil, err
+	}
+
+	casks, err := runExplicitList(ctx, "brew", "list", "--cask", "-1")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(formulae, casks...), nil
+}
+
+// wingetExplicitList exports the installed packages that winget can install
+// from its sources, which leaves out system components.
+func wingetExplicitList(ctx context.Context) ([]string, error) {
+	tmpDir, err := os.MkdirTemp("", "tidydots-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	path := filepath.Join(tmpDir, "winget-export.json")
+
+	cmd := exec.CommandContext(ctx, "winget", "export", "-o", path, "--disable-interactivity", "--accept-source-agreements") //nolint:gosec // path in our temp directory
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("running winget export: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // path in our temp directory
+	if err != nil {
+		return nil, fmt.Errorf("reading winget export: %w", err)
+	}
+
+	return parseWingetExport(data)
+}
+
+// parseWingetExport extracts the package identifiers of "winget export".
+func parseWingetExport(data []byte) ([]string, error) {
+	var export struct {
+		Sources []struct {
+			Packages []struct {
+				PackageIdentifier string `json:"PackageIdentifier"`
+			} `json:"Packages"`
+		} `json:"Sources"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("parsing winget export: %w", err)
+	}
+
+	var names []string
+
+	for _, source := range export.Sources {
+		for _, pkg := range source.Packages {
+			names = append(names, pkg.PackageIdentifier)
+		}
+	}
+
+	return names, nil
+}
+
+// scoopExplicitList lists the installed apps with "scoop export".
+func scoopExplicitList(ctx context.Context) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "scoop", "export").Output()
+	if err != nil {
+		return nil, fmt.Errorf("running scoop export: %w", err)
+	}
+
+	return parseScoopExport(string(out)), nil
+}
+
+// parseScoopExport extracts the apps of "scoop export": JSON with an "apps"
+// list in recent versions, or lines reading "name (v:version) [bucket]"
+// before. Apps from a bucket other than "main" keep it as a prefix, so they
+// install from the same bucket.
+func parseScoopExport(output string) []string {
+	var export struct {
+		Apps []struct {
+			Name   string `json:"Name"`
+			Source string `json:"Source"`
+		} `json:"apps"`
+	}
+
+	if err := json.Unmarshal([]byte(output), &export); err != nil {
+		return parseNameLines(strings.ReplaceAll(output, "\r\n", "\n"))
+	}
+
+	var names []string
+
+	for _, app := range export.Apps {
+		switch app.Source {
+		case "", "main":
+			names = append(names, app.Name)
+		default:
+			names = append(names, app.Source+"/"+app.Name)
+		}
+	}
+
+	return names
+}
+
+// ImportCandidate is an installed package that no application configures
+// for its manager.
+type ImportCandidate struct {
+	AppName     string // application the package is added to
+	PackageName string // name the manager installs the package with
+	Merge       bool   // AppName is an existing application
+}
+
+// importAppName returns the application name for an imported package: the
+// name without its tap or bucket for brew and scoop, and the part after the
+// publisher, in lowercase, for winget IDs such as "BurntSushi.ripgrep.MSVC".
+func importAppName(manager, pkgName string) string {
+	switch PackageManager(manager) {
+	case Brew, Scoop:
+		return stripTapPrefix(pkgName)
+	case Winget:
+		if parts := strings.Split(pkgName, "."); len(parts) > 1 && parts[1] != "" {
+			return strings.ToLower(parts[1])
+		}
+	}
+
+	return pkgName
+}
+
+// PlanImport returns the installed packages that no application configures
+// for manager, in order. A package joins the application of the same name
+// (ignoring case) when it has no value for manager; otherwise it gets a new
+// application, named after the package. When that name is in use, the full
+// package name is tried, then the name followed by the manager and, if
+// needed, a number, so no package is left out.
+func PlanImport(apps []config.Application, manager string, installed []string) []ImportCandidate {
+	mc := managerCmds[PackageManager(manager)]
+
+	configured := make(map[string]bool)
+	appIndex := make(map[string]int)
+
+	for i, app := range apps {
+		appIndex[strings.ToLower(app.Name)] = i
+
+		if app.Package == nil {
+			continue
+		}
+
+		if val, ok := app.Package.Managers[manager]; ok && val.PackageName != "" {
+			configured[strings.ToLower(mc.checkedName(val.PackageName))] = true
+		}
+	}
+
+	var candidates []ImportCandidate
+
+	taken := make(map[string]bool)
+
+	for _, pkgName := range installed {
+		if configured[strings.ToLower(mc.checkedName(pkgName))] {
+			continue
+		}
+
+		name := importAppName(manager, pkgName)
+		key := strings.ToLower(name)
+
+		if i, ok := appIndex[key]; ok && !taken[key] {
+			if pkg := apps[i].Package; pkg == nil || pkg.Managers[manager].PackageName == "" {
+				taken[key] = true
+				candidates = append(candidates, ImportCandidate{AppName: apps[i].Name, PackageName: pkgName, Merge: true})
+
+				continue
+			}
+		}
+
+		free := func(n string) bool {
+			_, exists := appIndex[strings.ToLower(n)]
+			return !exists && !taken[strings.ToLower(n)]
+		}
+
+		appName := ""
+		for _, n := range []string{name, pkgName, name + "-" + manager} {
+			if free(n) {
+				appName = n
+				break
+			}
+		}
+
+		for i := 2; appName == ""; i++ {
+			if n := fmt.Sprintf("%s-%s-%d", name, manager, i); free(n) {
+				appName = n
+			}
+		}
+
+		taken[strings.ToLower(appName)] = true
+		candidates = append(candidates, ImportCandidate{AppName: appName, PackageName: pkgName})
+	}
+
+	return candidates
+}
+
+// ApplyImport adds the candidates to cfg: their package is set for manager
+// on the application they merge into, or on a new application appended to
+// the configuration.
+func ApplyImport(cfg *config.Config, manager string, candidates []ImportCandidate) {
+	for _, c := range candidates {
+		value := config.ManagerValue{PackageName: c.PackageName}
+
+		if c.Merge {
+			for i := range cfg.Applications {
+				app := &cfg.Applications[i]
+				if app.Name != c.AppName {
+					continue
+				}
+
+				if app.Package == nil {
+					app.Package = &config.EntryPackage{}
+				}
+
+				if app.Package.Managers == nil {
+					app.Package.Managers = make(map[string]config.ManagerValue)
+				}
+
+				app.Package.Managers[manager] = value
+
+				break
+			}
+
+			continue
+		}
+
+		cfg.Applications = append(cfg.Applications, config.Application{
+			Name: c.AppName,
+			Package: &config.EntryPackage{
+				Managers: map[string]config.ManagerValue{manager: value},
+			},
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
 )
 
@@ -36,26 +37,25 @@ func (m *Manager) Install(pkg Package) InstallResult {
 	}
 
 	// Try package managers
-	if len(pkg.Managers) > 0 {
-		for _, mgr := range m.Available {
-			// Skip git and installer managers (already handled above)
-			if mgr == Git || mgr == Installer {
-				continue
-			}
-
-			if val, ok := pkg.Managers[mgr]; ok {
-				result.Method = string(mgr)
-				success, msg := m.installWithManager(mgr, val.PackageName)
-				result.Success = success
-				result.Message = msg
+	if mgr, val, ok := m.managerFor(pkg); ok {
+		result.Method = string(mgr)
+		success, msg := m.installWithManager(mgr, val)
+		result.Success = success
+		result.Message = msg
 
-				return result
+		if success {
+			if val.Version != "" && !m.DryRun {
+				ResetInstalledCache()
 			}
+
+			result.Message = m.withVersionNote(msg, mgr, val)
 		}
+
+		return result
 	}
 
 	// Try custom command
-	if cmd, ok := pkg.Custom[m.OS]; ok {
+	if cmd, ok := config.ForOS(pkg.Custom, m.OS); ok {
 		result.Method = MethodCustom
 		success, msg := m.runCustomCommand(cmd)
 		result.Success = success
@@ -65,7 +65,7 @@ func (m *Manager) Install(pkg Package) InstallResult {
 	}
 
 	// Try URL install
-	if urlInstall, ok := pkg.URL[m.OS]; ok {
+	if urlInstall, ok := config.ForOS(pkg.URL, m.OS); ok {
 		result.Method = MethodURL
 		success, msg := m.installFromURL(urlInstall)
 		result.Success = success
@@ -80,19 +80,109 @@ func (m *Manager) Install(pkg Package) InstallResult {
 	return result
 }
 
-// InstallAll installs all packages in the provided slice sequentially.
-// It returns a slice of InstallResult, one for each package, indicating
-// the success or failure of each installation.
+// InstallAll installs all packages in the provided slice. Packages installed
+// with the same package manager (and the same install options) are installed
+// with a single command, so sudo prompts and dependency resolution happen
+// once per manager. When a batch fails, its packages are installed one by one
+// to attribute the failure. It returns a slice of InstallResult, one for each
+// package in the order given, indicating the success or failure of each
+// installation.
 func (m *Manager) InstallAll(packages []Package) []InstallResult {
-	results := make([]InstallResult, 0, len(packages))
-	for _, pkg := range packages {
-		results = append(results, m.Install(pkg))
+	results := make([]InstallResult, len(packages))
+
+	// Group batchable packages by manager and options, keeping first-seen order
+	batches := make(map[string][]int)
+	batchOf := make([]string, len(packages))
+
+	for i, pkg := range packages {
+		mgr, val, ok := m.managerFor(pkg)
+		if !ok || pkg.Managers[Git].IsGit() || pkg.Managers[Installer].IsInstaller() {
+			continue
+		}
+
+		if key := managerCmds[mgr].batchKey(mgr, val); key != "" {
+			batchOf[i] = key
+			batches[key] = append(batches[key], i)
+		}
+	}
+
+	for i, pkg := range packages {
+		key := batchOf[i]
+		switch {
+		case key == "" || len(batches[key]) == 1:
+			results[i] = m.Install(pkg)
+		case batches[key][0] == i:
+			m.installBatch(packages, batches[key], results)
+		}
 	}
 
 	return results
 }
 
-func (m *Manager) installWithManager(mgr PackageManager, pkgName string) (bool, string) {
+// managerFor returns the first available package manager, in detection
+// order, that pkg has a value for. The value carries the locked version of
+// the package, if any.
+func (m *Manager) managerFor(pkg Package) (PackageManager, ManagerValue, bool) {
+	for _, mgr := range m.Available {
+		// Skip git and installer managers (handled separately)
+		if mgr == Git || mgr == Installer {
+			continue
+		}
+
+		if val, ok := pkg.Managers[mgr]; ok {
+			return mgr, m.withVersion(pkg.Name, mgr, val), true
+		}
+	}
+
+	return "", ManagerValue{}, false
+}
+
+// installBatch installs the packages at indices with one command and stores
+// their results. On failure each package is retried on its own.
+func (m *Manager) installBatch(packages []Package, indices []int, results []InstallResult) {
+	mgr, _, _ := m.managerFor(packages[indices[0]])
+
+	vals := make([]ManagerValue, 0, len(indices))
+	versioned := false
+
+	for _, i := range indices {
+		_, val, _ := m.managerFor(packages[i])
+		vals = append(vals, val)
+		versioned = versioned || val.Version != ""
+	}
+
+	success, msg := false, "no batch command"
+	if cmd := batchCommand(m.ctx, mgr, vals); cmd != nil {
+		success, msg = m.runManagerCommand(mgr, cmd)
+	}
+
+	if !success {
+		if m.Verbose {
+			fmt.Printf("Batch install via %s failed (%s), installing packages one by one\n", mgr, msg)
+		}
+
+		for _, i := range indices {
+			results[i] = m.Install(packages[i])
+		}
+
+		return
+	}
+
+	if versioned && !m.DryRun {
+		ResetInstalledCache()
+	}
+
+	for n, i := range indices {
+		results[i] = InstallResult{
+			Package: packages[i].Name,
+			Method:  string(mgr),
+			Success: success,
+			Message: m.withVersionNote(msg, mgr, vals[n]),
+		}
+	}
+}
+
+func (m *Manager) installWithManager(mgr PackageManager, val ManagerValue) (bool, string) {
 	mc, ok := managerCmds[mgr]
 	if !ok {
 		if mgr == Git {
@@ -106,9 +196,15 @@ func (m *Manager) installWithManager(mgr PackageManager, pkgName string) (bool,
 		return false, fmt.Sprintf("Unknown package manager: %s", mgr)
 	}
 
-	args := expandArgs(mc.install, pkgName)
+	args := mc.installArgs(val)
 	cmd := exec.CommandContext(m.ctx, args[0], args[1:]...) //nolint:gosec // args from trusted lookup table
 
+	return m.runManagerCommand(mgr, cmd)
+}
+
+// runManagerCommand runs a package manager install command attached to the
+// terminal, so sudo can prompt for a password.
+func (m *Manager) runManagerCommand(mgr PackageManager, cmd *exec.Cmd) (bool, string) {
 	if m.DryRun {
 		return true, fmt.Sprintf("Would run: %s", strings.Join(cmd.Args, " "))
 	}
@@ -126,9 +222,9 @@ func (m *Manager) installWithManager(mgr PackageManager, pkgName string) (bool,
 
 // installGitPackage clones or updates a git repository.
 func (m *Manager) installGitPackage(gitCfg GitConfig) (bool, string) {
-	// Get target path for current OS
-	targetPath, ok := gitCfg.Targets[m.OS]
-	if !ok {
+	// Get target path for this machine
+	targetPath := gitCfg.TargetFor(m.machine)
+	if targetPath == "" {
 		return false, fmt.Sprintf("No git target path defined for OS: %s", m.OS)
 	}
 
@@ -207,7 +303,7 @@ func (m *Manager) gitPull(repoPath string, sudo bool) (bool, string) {
 // user's configuration file. Users should only use configurations they trust,
 // as malicious configs could execute harmful commands.
 func (m *Manager) installInstallerPackage(cfg InstallerConfig) (bool, string) {
-	command, ok := cfg.Command[m.OS]
+	command, ok := config.ForOS(cfg.Command, m.OS)
 	if !ok {
 		return false, fmt.Sprintf("No installer command defined for OS: %s", m.OS)
 	}
@@ -265,60 +361,23 @@ func (m *Manager) runCustomCommand(command string) (bool, string) {
 // SECURITY NOTE: This intentionally downloads and executes content from URLs
 // specified in the user's configuration file. Users should only use configurations
 // they trust, as malicious configs could download and execute harmful code.
+// Setting a sha256 checksum guards against the download changing.
 func (m *Manager) installFromURL(urlInstall URLInstall) (bool, string) {
 	if m.DryRun {
 		return true, fmt.Sprintf("Would download %s and run: %s", urlInstall.URL, urlInstall.Command)
 	}
 
-	// Create temp directory to avoid TOCTOU race on the file path
-	tmpDir, err := os.MkdirTemp("", "tidydots-*")
-	if err != nil {
-		return false, fmt.Sprintf("Failed to create temp directory: %v", err)
-	}
-
-	defer func() {
-		if err := os.RemoveAll(tmpDir); err != nil {
-			// Log but don't fail on cleanup errors
-			fmt.Printf("[WARN] Failed to remove temp directory %s: %v\n", tmpDir, err)
-		}
-	}()
-
-	tmpPath := filepath.Join(tmpDir, "installer")
-
-	// Download file
-	var downloadCmd *exec.Cmd
-
-	if m.OS == platform.OSWindows {
-		// Escape single quotes in URL and path for PowerShell
-		escapedURL := strings.ReplaceAll(urlInstall.URL, "'", "''")
-		escapedPath := strings.ReplaceAll(tmpPath, "'", "''")
-		downloadCmd = exec.CommandContext(m.ctx, "powershell", "-Command", //nolint:gosec // intentional download command
-			fmt.Sprintf("Invoke-WebRequest -Uri '%s' -OutFile '%s'", escapedURL, escapedPath))
-	} else {
-		downloadCmd = exec.CommandContext(m.ctx, "curl", "-fsSL", "-o", tmpPath, urlInstall.URL) //nolint:gosec // intentional download command
+	if m.Verbose {
+		fmt.Printf("Downloading %s\n", urlInstall.URL)
 	}
 
-	if err := downloadCmd.Run(); err != nil {
+	file, cleanup, err := Download(m.ctx, urlInstall, m.CacheDir, nil)
+	if err != nil {
 		return false, fmt.Sprintf("Download failed: %v", err)
 	}
+	defer cleanup()
 
-	// Make executable on Unix
-	if m.OS != platform.OSWindows {
-		if err := os.Chmod(tmpPath, ExecPerms); err != nil { //nolint:gosec // installer scripts need to be executable
-			return false, fmt.Sprintf("Failed to make executable: %v", err)
-		}
-	}
-
-	// Run install command
-	command := strings.ReplaceAll(urlInstall.Command, "{file}", tmpPath)
-
-	var installCmd *exec.Cmd
-	if m.OS == platform.OSWindows {
-		installCmd = exec.CommandContext(m.ctx, "powershell", "-Command", command) //nolint:gosec // intentional install command
-	} else {
-		installCmd = exec.CommandContext(m.ctx, "sh", "-c", command) //nolint:gosec // intentional install command
-	}
-
+	installCmd := BuildURLInstallCommand(m.ctx, urlInstall.Command, file, m.OS)
 	installCmd.Stdout = os.Stdout
 	installCmd.Stderr = os.Stderr
 	installCmd.Stdin = os.Stdin
@@ -0,0 +1,285 @@
+package packages
+
+import (
+	"bufio"
+	"context"
+	"debug/buildinfo"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// cargoBulkList runs "cargo install --list" once and returns the installed
+// crates with their versions.
+func cargoBulkList(ctx context.Context) map[string]string {
+	slog.Debug("running cargo bulk list")
+
+	out, err := exec.CommandContext(ctx, "cargo", "install", "--list").Output()
+	if err != nil {
+		slog.Debug("cargo bulk list failed", slog.String("error", err.Error()))
+		return make(map[string]string)
+	}
+
+	return parseCargoInstallList(string(out))
+}
+
+// parseCargoInstallList extracts crates and versions from "cargo install --list"
+// output, where each crate reads "name v1.2.3:" (or "name v1.2.3 (source):")
+// followed by its indented binaries.
+func parseCargoInstallList(output string) map[string]string {
+	names := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		version := ""
+		if len(fields) > 1 {
+			version = strings.TrimPrefix(strings.TrimSuffix(fields[1], ":"), "v")
+		}
+
+		names[strings.ToLower(strings.TrimSuffix(fields[0], ":"))] = version
+	}
+
+	return names
+}
+
+// goInstallName adds "@latest" to a package path without a version, which
+// "go install" requires outside a module.
+func goInstallName(pkgName string) string {
+	if strings.Contains(pkgName, "@") {
+		return pkgName
+	}
+
+	return pkgName + "@latest"
+}
+
+// stripGoVersion removes the "@version" suffix of a package path.
+func stripGoVersion(pkgName string) string {
+	name, _, _ := strings.Cut(pkgName, "@")
+	return name
+}
+
+// goBulkList reads the build information of every binary in the directory
+// "go install" writes to, and returns their package and module paths with
+// the module version.
+func goBulkList(ctx context.Context) map[string]string {
+	names := make(map[string]string)
+
+	dir, err := goBinDir(ctx)
+	if err != nil {
+		slog.Debug("go bulk list failed", slog.String("error", err.Error()))
+		return names
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		slog.Debug("go bulk list failed", slog.String("error", err.Error()))
+		return names
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		info, err := buildinfo.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue // not a Go binary
+		}
+
+		names[strings.ToLower(info.Path)] = info.Main.Version
+		if info.Main.Path != "" {
+			names[strings.ToLower(info.Main.Path)] = info.Main.Version
+		}
+	}
+
+	return names
+}
+
+// goBinDir returns GOBIN, or the bin directory of the first GOPATH entry.
+func goBinDir(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "env", "GOBIN", "GOPATH").Output()
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) != "" {
+		return strings.TrimSpace(lines[0]), nil
+	}
+
+	var gopaths []string
+	if len(lines) > 1 {
+		gopaths = filepath.SplitList(strings.TrimSpace(lines[1]))
+	}
+
+	if len(gopaths) == 0 || gopaths[0] == "" {
+		return "", errors.New("go env: GOBIN and GOPATH are empty")
+	}
+
+	return filepath.Join(gopaths[0], "bin"), nil
+}
+
+// normalizePythonName normalizes a Python package requirement to the name its
+// tools list it under: extras and version specifiers are dropped, and the name
+// is lowercased with runs of "-", "_" and "." replaced by "-" (PEP 503).
+func normalizePythonName(pkgName string) string {
+	if i := strings.IndexAny(pkgName, "[<>=!~ ;@"); i >= 0 {
+		pkgName = pkgName[:i]
+	}
+
+	var b strings.Builder
+
+	sep := false
+	for _, r := range strings.ToLower(pkgName) {
+		if r == '-' || r == '_' || r == '.' {
+			sep = true
+			continue
+		}
+
+		if sep && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		sep = false
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// pipxBulkList runs "pipx list --json" once and returns the installed
+// packages with their versions.
+func pipxBulkList(ctx context.Context) map[string]string {
+	slog.Debug("running pipx bulk list")
+
+	out, err := exec.CommandContext(ctx, "pipx", "list", "--json").Output()
+	if err != nil {
+		slog.Debug("pipx bulk list failed", slog.String("error", err.Error()))
+		return make(map[string]string)
+	}
+
+	return parsePipxList(out)
+}
+
+// parsePipxList extracts packages and versions from "pipx list --json", which
+// keys virtual environments by package name.
+func parsePipxList(data []byte) map[string]string {
+	names := make(map[string]string)
+
+	var list struct {
+		Venvs map[string]struct {
+			Metadata struct {
+				MainPackage struct {
+					PackageVersion string `json:"package_version"`
+				} `json:"main_package"`
+			} `json:"metadata"`
+		} `json:"venvs"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		slog.Debug("pipx bulk list: invalid JSON", slog.String("error", err.Error()))
+		return names
+	}
+
+	for name, venv := range list.Venvs {
+		names[normalizePythonName(name)] = venv.Metadata.MainPackage.PackageVersion
+	}
+
+	return names
+}
+
+// stripNpmVersion removes the "@version" suffix of a package name, keeping
+// the "@" of a scoped package such as "@scope/name@1.2.3".
+func stripNpmVersion(pkgName string) string {
+	if i := strings.LastIndex(pkgName, "@"); i > 0 {
+		return pkgName[:i]
+	}
+
+	return pkgName
+}
+
+// npmBulkList runs "npm ls -g --depth=0 --json" once and returns the
+// globally installed packages with their versions.
+func npmBulkList(ctx context.Context) map[string]string {
+	slog.Debug("running npm bulk list")
+
+	// npm ls exits non-zero on problems such as peer dependency warnings but
+	// still prints the tree, so the output is parsed regardless.
+	out, err := exec.CommandContext(ctx, "npm", "ls", "-g", "--depth=0", "--json").Output()
+	if err != nil && len(out) == 0 {
+		slog.Debug("npm bulk list failed", slog.String("error", err.Error()))
+		return make(map[string]string)
+	}
+
+	return parseNpmList(out)
+}
+
+// parseNpmList extracts packages and versions from "npm ls --json".
+func parseNpmList(data []byte) map[string]string {
+	names := make(map[string]string)
+
+	var list struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		slog.Debug("npm bulk list: invalid JSON", slog.String("error", err.Error()))
+		return names
+	}
+
+	for name, dep := range list.Dependencies {
+		names[strings.ToLower(name)] = dep.Version
+	}
+
+	return names
+}
+
+// uvBulkList runs "uv tool list" once and returns the installed tools with
+// their versions.
+func uvBulkList(ctx context.Context) map[string]string {
+	slog.Debug("running uv bulk list")
+
+	out, err := exec.CommandContext(ctx, "uv", "tool", "list").Output()
+	if err != nil {
+		slog.Debug("uv bulk list failed", slog.String("error", err.Error()))
+		return make(map[string]string)
+	}
+
+	return parseUvToolList(string(out))
+}
+
+// parseUvToolList extracts tools and versions from "uv tool list" output, where each
+// tool reads "name v1.2.3" followed by its executables as "- binary" lines.
+func parseUvToolList(output string) map[string]string {
+	names := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		version := ""
+		if len(fields) > 1 {
+			version = strings.TrimPrefix(fields[1], "v")
+		}
+
+		names[normalizePythonName(fields[0])] = version
+	}
+
+	return names
+}
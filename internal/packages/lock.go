@@ -0,0 +1,108 @@
+package packages
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockFileName is the name of the lockfile kept next to tidydots.yaml.
+const LockFileName = "tidydots.lock"
+
+// Lock records the installed version of packages, by package name and
+// package manager, so other machines can install the same versions.
+type Lock struct {
+	Packages map[string]map[string]string `yaml:"packages"`
+}
+
+// LoadLock reads a lockfile. A missing file yields an empty lock.
+func LoadLock(path string) (*Lock, error) {
+	lock := &Lock{Packages: make(map[string]map[string]string)}
+
+	data, err := os.ReadFile(path) //nolint:gosec // lockfile path from the configuration directory
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading lockfile: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("parsing lockfile %s: %w", path, err)
+	}
+
+	if lock.Packages == nil {
+		lock.Packages = make(map[string]map[string]string)
+	}
+
+	return lock, nil
+}
+
+// Save writes the lockfile.
+func (l *Lock) Save(path string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("marshaling lockfile: %w", err)
+	}
+
+	header := "# Generated by \"tidydots lock\". Installed with \"tidydots install --locked\".\n"
+
+	if err := os.WriteFile(path, append([]byte(header), data...), 0o644); err != nil { //nolint:gosec // tracked in the repository
+		return fmt.Errorf("writing lockfile: %w", err)
+	}
+
+	return nil
+}
+
+// Version returns the locked version of a package for a package manager. It
+// is safe to call on a nil Lock.
+func (l *Lock) Version(pkgName, manager string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+
+	version, ok := l.Packages[pkgName][manager]
+
+	return version, ok && version != ""
+}
+
+// WithLock returns a new Manager that installs the versions recorded in lock
+// instead of the configured version constraints.
+func (m *Manager) WithLock(lock *Lock) *Manager {
+	m2 := *m
+	m2.lock = lock
+
+	return &m2
+}
+
+// UpdateLock records in lock the installed version of the packages installed
+// with a package manager that reports versions. Versions recorded for other
+// managers are kept, so one lockfile serves several machines. It returns the
+// versions recorded, in order.
+func (m *Manager) UpdateLock(lock *Lock, packages []Package) []PackageVersion {
+	var locked []PackageVersion
+
+	for _, pkg := range packages {
+		mgr, val, ok := m.installedManager(pkg)
+		if !ok {
+			continue
+		}
+
+		version, ok := InstalledVersion(m.ctx, val.PackageName, string(mgr))
+		if !ok || version == "" {
+			continue
+		}
+
+		if lock.Packages[pkg.Name] == nil {
+			lock.Packages[pkg.Name] = make(map[string]string)
+		}
+
+		lock.Packages[pkg.Name][string(mgr)] = version
+		locked = append(locked, PackageVersion{Name: pkg.Name, Method: string(mgr), Installed: version})
+	}
+
+	return locked
+}
@@ -3,6 +3,7 @@ package packages
 import (
 	"context"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
 )
 
@@ -15,26 +16,34 @@ type Manager struct {
 	ctx          context.Context
 	Config       *Config
 	OS           string
+	machine      config.Machine // selects git clone destinations
 	Preferred    PackageManager
 	Available    []PackageManager
 	availableSet map[PackageManager]bool
 	DryRun       bool
 	Verbose      bool
+	lock         *Lock // locked versions replacing version constraints; nil if unused
+
+	// CacheDir keeps URL downloads that have a checksum; "" disables caching.
+	CacheDir string
 }
 
 // NewManager creates a new package Manager with the given configuration.
 // It detects available package managers on the system and selects a preferred
 // manager based on the configuration priority, default manager setting, or
 // OS-specific defaults. The osType parameter specifies the target OS (linux/windows),
-// and dryRun/verbose control the execution mode.
+// and dryRun/verbose control the execution mode. URL downloads with a checksum
+// are cached in DownloadCacheDir.
 func NewManager(cfg *Config, osType string, dryRun, verbose bool) *Manager {
 	m := &Manager{
 		ctx:     context.Background(),
 		Config:  cfg,
 		OS:      osType,
+		machine: config.Machine{OS: osType},
 		DryRun:  dryRun,
 		Verbose: verbose,
 	}
+	m.CacheDir, _ = DownloadCacheDir()
 	m.detectAvailableManagers()
 	m.selectPreferredManager()
 
@@ -48,6 +57,14 @@ func (m *Manager) WithContext(ctx context.Context) *Manager {
 	return &m2
 }
 
+// WithMachine returns a new Manager that selects git clone destinations for
+// mc, so distro, host and wsl target keys apply.
+func (m *Manager) WithMachine(mc config.Machine) *Manager {
+	m2 := *m
+	m2.machine = mc
+	return &m2
+}
+
 func (m *Manager) detectAvailableManagers() {
 	m.availableSet = make(map[PackageManager]bool)
 	for _, mgr := range platform.DetectAvailableManagers() {
@@ -75,24 +92,27 @@ func (m *Manager) selectPreferredManager() {
 	}
 
 	// Auto-select based on OS
-	if m.OS == platform.OSWindows {
-		for _, mgr := range []PackageManager{Winget, Scoop, Choco} {
-			if m.HasManager(mgr) {
-				m.Preferred = mgr
-				return
-			}
-		}
-	} else {
-		// Linux/macOS priority
-		for _, mgr := range []PackageManager{Yay, Paru, Pacman, Apt, Dnf, Brew} {
-			if m.HasManager(mgr) {
-				m.Preferred = mgr
-				return
-			}
+	for _, mgr := range defaultPriority(m.OS) {
+		if m.HasManager(mgr) {
+			m.Preferred = mgr
+			return
 		}
 	}
 }
 
+// defaultPriority returns the order in which managers are preferred on an OS
+// when the configuration sets none.
+func defaultPriority(osType string) []PackageManager {
+	switch osType {
+	case platform.OSWindows:
+		return []PackageManager{Winget, Scoop, Choco}
+	case platform.OSDarwin:
+		return []PackageManager{Brew, Port}
+	default:
+		return []PackageManager{Yay, Paru, Pacman, Apt, Dnf, Zypper, Apk, Xbps, Emerge, Nix, Brew}
+	}
+}
+
 // HasManager checks if a package manager is available on the system.
 // It returns true if the specified manager was detected during initialization.
 func (m *Manager) HasManager(mgr PackageManager) bool {
@@ -0,0 +1,330 @@
+package packages
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os/exec"
+	"strings"
+)
+
+// runOutdatedList runs a command listing upgradable packages and returns its
+// output, or false when the command fails. Some managers exit with a non-zero
+// status when upgrades are available (e.g. "npm outdated"), so the output is
+// used whenever there is any.
+func runOutdatedList(ctx context.Context, manager string, args ...string) (string, bool) {
+	slog.Debug("running " + manager + " outdated list")
+
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output() //nolint:gosec // args from trusted callers
+	if err != nil && len(out) == 0 {
+		slog.Debug(manager+" outdated list failed", slog.String("error", err.Error()))
+		return "", false
+	}
+
+	return string(out), true
+}
+
+// pacmanOutdatedList returns the outdated list of pacman or an AUR helper,
+// whose "-Qu" output is identical. AUR helpers also report AUR packages.
+// Upgrades are looked up in the local sync database, so they only show once
+// it has been refreshed.
+func pacmanOutdatedList(bin string) outdatedListFunc {
+	return func(ctx context.Context) map[string]string {
+		out, ok := runOutdatedList(ctx, bin, bin, "-Qu")
+		if !ok {
+			return make(map[string]string)
+		}
+
+		return parseArrowUpdates(out, "->")
+	}
+}
+
+// parseArrowUpdates extracts upgrades from lines reading
+// "name installed <arrow> available", as printed by "pacman -Qu" ("->") and
+// "port outdated" ("<").
+func parseArrowUpdates(output, arrow string) map[string]string {
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[2] != arrow {
+			continue
+		}
+
+		versions[strings.ToLower(fields[0])] = fields[3]
+	}
+
+	return versions
+}
+
+// aptOutdatedList runs "apt list --upgradable" once. Like pacman, it reports
+// the upgrades known since the last "apt update".
+func aptOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "apt", "apt", "list", "--upgradable")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseAptUpgradable(out)
+}
+
+// parseAptUpgradable extracts upgrades from lines reading
+// "name/suite version arch [upgradable from: installed]".
+func parseAptUpgradable(output string) map[string]string {
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		name, _, ok := strings.Cut(fields[0], "/")
+		if !ok {
+			continue
+		}
+
+		versions[strings.ToLower(name)] = fields[1]
+	}
+
+	return versions
+}
+
+// dnfOutdatedList runs "dnf list --upgrades" once.
+func dnfOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "dnf", "dnf", "list", "--upgrades", "-q")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseDnfUpgrades(out)
+}
+
+// parseDnfUpgrades extracts upgrades from lines reading
+// "name.arch version repository", skipping the heading.
+func parseDnfUpgrades(output string) map[string]string {
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+
+		i := strings.LastIndex(fields[0], ".")
+		if i <= 0 {
+			continue
+		}
+
+		versions[strings.ToLower(fields[0][:i])] = fields[1]
+	}
+
+	return versions
+}
+
+// zypperOutdatedList runs "zypper list-updates" once.
+func zypperOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "zypper", "zypper", "-q", "list-updates")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseZypperUpdates(out)
+}
+
+// parseZypperUpdates extracts upgrades from the rows of the
+// "S | Repository | Name | Current Version | Available Version | Arch" table.
+func parseZypperUpdates(output string) map[string]string {
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		cols := strings.Split(line, "|")
+		if len(cols) < 5 || strings.TrimSpace(cols[0]) != "v" {
+			continue
+		}
+
+		versions[strings.ToLower(strings.TrimSpace(cols[2]))] = strings.TrimSpace(cols[4])
+	}
+
+	return versions
+}
+
+// flatpakOutdatedList lists the applications with updates on their remotes.
+func flatpakOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "flatpak", "flatpak", "remote-ls", "--updates", "--app", "--columns=application,version")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseNameVersionColumns(out, false)
+}
+
+// snapOutdatedList runs "snap refresh --list" once. When every snap is up to
+// date it prints nothing on stdout.
+func snapOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "snap", "snap", "refresh", "--list")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseNameVersionColumns(out, true)
+}
+
+// brewOutdatedList runs "brew outdated --json=v2" once, for formulae and
+// casks.
+func brewOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "brew", "brew", "outdated", "--json=v2")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseBrewOutdated([]byte(out))
+}
+
+// parseBrewOutdated extracts upgrades from "brew outdated --json=v2".
+func parseBrewOutdated(data []byte) map[string]string {
+	versions := make(map[string]string)
+
+	type outdated struct {
+		Name           string `json:"name"`
+		CurrentVersion string `json:"current_version"`
+	}
+
+	var list struct {
+		Formulae []outdated `json:"formulae"`
+		Casks    []outdated `json:"casks"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		slog.Debug("brew outdated list: invalid JSON", slog.String("error", err.Error()))
+		return versions
+	}
+
+	for _, pkg := range append(list.Formulae, list.Casks...) {
+		versions[strings.ToLower(pkg.Name)] = pkg.CurrentVersion
+	}
+
+	return versions
+}
+
+// portOutdatedList runs "port outdated" once.
+func portOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "port", "port", "-q", "outdated")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseArrowUpdates(out, "<")
+}
+
+// wingetOutdatedList runs "winget upgrade" once, which lists the installed
+// packages with an Available column.
+func wingetOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "winget", "winget", "upgrade", "--disable-interactivity", "--accept-source-agreements")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	versions := parseWingetTable(out, "Available")
+
+	// Skip the summary line below the table
+	for id, version := range versions {
+		if version == "" {
+			delete(versions, id)
+		}
+	}
+
+	return versions
+}
+
+// scoopOutdatedList runs "scoop status" once.
+func scoopOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "scoop", "scoop", "status")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseScoopStatus(out)
+}
+
+// parseScoopStatus extracts upgrades from the "Name Installed Version
+// Latest Version ..." table of "scoop status".
+func parseScoopStatus(output string) map[string]string {
+	versions := make(map[string]string)
+
+	inTable := false
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if !inTable {
+			inTable = strings.Trim(fields[0], "-") == ""
+			continue
+		}
+
+		if len(fields) > 2 {
+			versions[strings.ToLower(fields[0])] = fields[2]
+		}
+	}
+
+	return versions
+}
+
+// chocoOutdatedList runs "choco outdated" once.
+func chocoOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "choco", "choco", "outdated", "--limit-output")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseChocoOutdated(out)
+}
+
+// parseChocoOutdated extracts upgrades from "choco outdated --limit-output",
+// where each line reads "name|installed|available|pinned".
+func parseChocoOutdated(output string) map[string]string {
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		cols := strings.Split(strings.TrimSpace(line), "|")
+		if len(cols) < 3 || cols[0] == "" {
+			continue
+		}
+
+		versions[strings.ToLower(cols[0])] = cols[2]
+	}
+
+	return versions
+}
+
+// npmOutdatedList runs "npm outdated -g --json" once. npm exits with status 1
+// when packages are outdated.
+func npmOutdatedList(ctx context.Context) map[string]string {
+	out, ok := runOutdatedList(ctx, "npm", "npm", "outdated", "-g", "--json")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseNpmOutdated([]byte(out))
+}
+
+// parseNpmOutdated extracts upgrades from "npm outdated --json", reporting
+// the latest version of each package.
+func parseNpmOutdated(data []byte) map[string]string {
+	versions := make(map[string]string)
+
+	var list map[string]struct {
+		Latest string `json:"latest"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		slog.Debug("npm outdated list: invalid JSON", slog.String("error", err.Error()))
+		return versions
+	}
+
+	for name, pkg := range list {
+		versions[strings.ToLower(name)] = pkg.Latest
+	}
+
+	return versions
+}
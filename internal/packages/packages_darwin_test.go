@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"testing"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
 	"github.com/AntoineGS/tidydots/internal/testutil"
 )
@@ -21,7 +22,7 @@ func TestBuildCommand_DarwinBrew(t *testing.T) {
 		},
 	}
 
-	cmd := BuildCommand(context.Background(), pkg, string(Brew), "linux") // tidydots maps macOS to "linux"
+	cmd := BuildCommand(context.Background(), pkg, string(Brew), config.Machine{OS: platform.OSDarwin})
 	if cmd == nil {
 		t.Fatal("BuildCommand() returned nil")
 	}
@@ -38,7 +39,7 @@ func TestBuildCommand_DarwinCustomUsesShell(t *testing.T) {
 		Custom: map[string]string{"linux": "brew install --cask firefox"},
 	}
 
-	cmd := BuildCommand(context.Background(), pkg, MethodCustom, "linux") // tidydots maps macOS to "linux"
+	cmd := BuildCommand(context.Background(), pkg, MethodCustom, config.Machine{OS: platform.OSDarwin})
 	if cmd == nil {
 		t.Fatal("BuildCommand() returned nil")
 	}
@@ -52,14 +53,14 @@ func TestBuildCommand_DarwinURLUsesCurl(t *testing.T) {
 	pkg := Package{
 		Name: "url-tool",
 		URL: map[string]URLInstall{
-			"linux": { // tidydots maps macOS to "linux"
+			"linux": { // macOS falls back to linux keys
 				URL:     "https://example.com/install.sh",
 				Command: "{file}",
 			},
 		},
 	}
 
-	cmd := BuildCommand(context.Background(), pkg, MethodURL, "linux")
+	cmd := BuildCommand(context.Background(), pkg, MethodURL, config.Machine{OS: platform.OSDarwin})
 	if cmd == nil {
 		t.Fatal("BuildCommand() returned nil")
 	}
@@ -83,7 +84,7 @@ func TestDetectManagers_DarwinWithMocks(t *testing.T) {
 
 	// Reset cache so detection runs fresh
 	platform.ResetAvailableManagersCache()
-	platform.SetDetectionHints("linux", false) // tidydots maps macOS to "linux"
+	platform.SetDetectionHints(platform.OSDarwin, false)
 
 	managers := platform.DetectAvailableManagers()
 
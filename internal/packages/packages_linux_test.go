@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"testing"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
 	"github.com/AntoineGS/tidydots/internal/testutil"
 )
@@ -63,7 +64,7 @@ func TestBuildCommand_LinuxManagers(t *testing.T) {
 				},
 			}
 
-			cmd := BuildCommand(context.Background(), pkg, string(tt.manager), "linux")
+			cmd := BuildCommand(context.Background(), pkg, string(tt.manager), config.Machine{OS: "linux"})
 			if cmd == nil {
 				t.Fatal("BuildCommand() returned nil")
 			}
@@ -81,7 +82,7 @@ func TestBuildCommand_LinuxCustomUsesShell(t *testing.T) {
 		Custom: map[string]string{"linux": "make install"},
 	}
 
-	cmd := BuildCommand(context.Background(), pkg, MethodCustom, "linux")
+	cmd := BuildCommand(context.Background(), pkg, MethodCustom, config.Machine{OS: "linux"})
 	if cmd == nil {
 		t.Fatal("BuildCommand() returned nil")
 	}
@@ -90,29 +91,13 @@ func TestBuildCommand_LinuxCustomUsesShell(t *testing.T) {
 	assertArgs(t, cmd, []string{"sh", "-c", "make install"})
 }
 
-func TestBuildCommand_LinuxURLUsesCurl(t *testing.T) {
+func TestBuildURLInstallCommand_LinuxUsesSh(t *testing.T) {
 	t.Parallel()
 
-	pkg := Package{
-		Name: "url-tool",
-		URL: map[string]URLInstall{
-			"linux": {
-				URL:     "https://example.com/install.sh",
-				Command: "{file}",
-			},
-		},
-	}
-
-	cmd := BuildCommand(context.Background(), pkg, MethodURL, "linux")
-	if cmd == nil {
-		t.Fatal("BuildCommand() returned nil")
-	}
+	cmd := BuildURLInstallCommand(context.Background(), "sh {file} --yes", "/tmp/install.sh", "linux")
 
-	// Linux URL install should use sh -c with curl
-	args := cmd.Args
-	if args[0] != "sh" || args[1] != "-c" {
-		t.Errorf("expected sh -c, got %v", args[:2])
-	}
+	// Linux URL installs run their command with sh -c on the downloaded file
+	assertArgs(t, cmd, []string{"sh", "-c", "sh /tmp/install.sh --yes"})
 }
 
 func TestDetectManagers_LinuxWithMocks(t *testing.T) {
@@ -2,11 +2,19 @@ package packages
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/AntoineGS/tidydots/internal/config"
@@ -232,6 +240,38 @@ func TestSelectPreferredManager(t *testing.T) {
 			osType:          "linux",
 			wantPreferred:   Brew,
 		},
+		{
+			name:            "auto-select linux (zypper before nix and brew)",
+			available:       []PackageManager{Brew, Nix, Zypper},
+			defaultManager:  "",
+			managerPriority: nil,
+			osType:          "linux",
+			wantPreferred:   Zypper,
+		},
+		{
+			name:            "auto-select linux (nix before brew)",
+			available:       []PackageManager{Brew, Nix},
+			defaultManager:  "",
+			managerPriority: nil,
+			osType:          "linux",
+			wantPreferred:   Nix,
+		},
+		{
+			name:            "auto-select darwin (brew first)",
+			available:       []PackageManager{Port, Brew},
+			defaultManager:  "",
+			managerPriority: nil,
+			osType:          "darwin",
+			wantPreferred:   Brew,
+		},
+		{
+			name:            "auto-select darwin (port when brew not available)",
+			available:       []PackageManager{Port},
+			defaultManager:  "",
+			managerPriority: nil,
+			osType:          "darwin",
+			wantPreferred:   Port,
+		},
 		{
 			name:            "auto-select windows (winget first)",
 			available:       []PackageManager{Winget, Scoop, Choco},
@@ -1527,6 +1567,46 @@ managers:
 	}
 }
 
+func TestPackage_UnmarshalYAML_FlatpakAndSnap(t *testing.T) {
+	yamlData := `
+name: "code"
+managers:
+  flatpak:
+    name: "com.visualstudio.code"
+    remote: "flathub"
+  snap: "code"
+`
+
+	var pkg Package
+	if err := yaml.Unmarshal([]byte(yamlData), &pkg); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	flatpak := pkg.Managers[Flatpak]
+	if flatpak.PackageName != "com.visualstudio.code" || flatpak.Flatpak == nil || flatpak.Flatpak.Remote != "flathub" {
+		t.Errorf("flatpak = %+v, want com.visualstudio.code from flathub", flatpak)
+	}
+
+	snap := pkg.Managers[Snap]
+	if snap.PackageName != "code" || snap.Snap != nil {
+		t.Errorf("snap = %+v, want plain name code", snap)
+	}
+}
+
+func TestParseNameVersionColumns(t *testing.T) {
+	t.Parallel()
+
+	flatpak := parseNameVersionColumns("org.gimp.GIMP\t2.10.38\ncom.visualstudio.code\t\n", false)
+	if !reflect.DeepEqual(flatpak, map[string]string{"org.gimp.gimp": "2.10.38", "com.visualstudio.code": ""}) {
+		t.Errorf("flatpak list = %v, want gimp 2.10.38 and code without version", flatpak)
+	}
+
+	snap := parseNameVersionColumns("Name    Version  Rev    Tracking       Publisher  Notes\ncore22  20240111 1122   latest/stable  canonical  base\ncode    1.90     163    latest/stable  vscode     classic\n", true)
+	if !reflect.DeepEqual(snap, map[string]string{"core22": "20240111", "code": "1.90"}) {
+		t.Errorf("snap list = %v, want core22 and code", snap)
+	}
+}
+
 func TestPackage_UnmarshalYAML_InstallerWithoutBinary(t *testing.T) {
 	yamlData := `
 name: "no-binary-pkg"
@@ -1815,6 +1895,173 @@ func TestManager_InstallGitPackage_WithSudo(t *testing.T) {
 	}
 }
 
+func TestParsePortInstalledOutput(t *testing.T) {
+	t.Parallel()
+
+	output := "  git @2.45.1_0+credential_osxkeychain+diff_highlight (active)\n  ripgrep @14.1.0_0 (active)\n\n"
+
+	names := parsePortInstalledOutput(output)
+	if !reflect.DeepEqual(names, map[string]string{"git": "2.45.1_0", "ripgrep": "14.1.0_0"}) {
+		t.Errorf("parsePortInstalledOutput() = %v, want git and ripgrep", names)
+	}
+}
+
+func TestBuildCommand_DarwinFallsBackToLinuxKeys(t *testing.T) {
+	t.Parallel()
+
+	pkg := Package{
+		Name:   "tool",
+		Custom: map[string]string{"linux": "echo linux"},
+	}
+
+	darwin := config.Machine{OS: "darwin"}
+	if cmd := BuildCommand(context.Background(), pkg, MethodCustom, darwin); cmd == nil || cmd.Args[2] != "echo linux" {
+		t.Fatalf("BuildCommand(darwin) = %v, want the linux custom command", cmd)
+	}
+
+	pkg.Custom["darwin"] = "echo darwin"
+	if cmd := BuildCommand(context.Background(), pkg, MethodCustom, darwin); cmd == nil || cmd.Args[2] != "echo darwin" {
+		t.Errorf("BuildCommand(darwin) = %v, want the darwin custom command", cmd)
+	}
+}
+
+func TestParseNixProfileList(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "elements keyed by name",
+			data: `{"version":3,"elements":{"ripgrep":{"attrPath":"legacyPackages.x86_64-linux.ripgrep"},"fd-find":{"attrPath":"legacyPackages.x86_64-linux.fd"}}}`,
+			want: []string{"ripgrep", "fd-find", "fd"},
+		},
+		{
+			name: "elements as a list",
+			data: `{"version":2,"elements":[{"attrPath":"legacyPackages.x86_64-linux.bat"}]}`,
+			want: []string{"bat"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			names := parseNixProfileList([]byte(tt.data))
+			if len(names) != len(tt.want) {
+				t.Errorf("parseNixProfileList() = %v, want %v", names, tt.want)
+			}
+			for _, name := range tt.want {
+				if _, ok := names[name]; !ok {
+					t.Errorf("parseNixProfileList() missing %q in %v", name, names)
+				}
+			}
+		})
+	}
+
+	if names := parseNixProfileList([]byte("not json")); len(names) != 0 {
+		t.Errorf("parseNixProfileList(invalid) = %v, want empty", names)
+	}
+}
+
+func TestEmergeBulkList(t *testing.T) {
+	db := t.TempDir()
+	for _, dir := range []string{"app-editors/neovim-0.9.5-r1", "media-fonts/font-adobe-100dpi-1.0.3", "dev-vcs/git-2.45.2"} {
+		if err := os.MkdirAll(filepath.Join(db, dir), 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old := gentooPkgDB
+	gentooPkgDB = db
+	t.Cleanup(func() { gentooPkgDB = old })
+
+	names := emergeBulkList(context.Background())
+	want := map[string]string{
+		"neovim":                        "0.9.5-r1",
+		"app-editors/neovim":            "0.9.5-r1",
+		"font-adobe-100dpi":             "1.0.3",
+		"media-fonts/font-adobe-100dpi": "1.0.3",
+		"git":                           "2.45.2",
+		"dev-vcs/git":                   "2.45.2",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("emergeBulkList() = %v, want %v", names, want)
+	}
+}
+
+func TestLanguageManagerListParsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		parse func(string) map[string]string
+		want  map[string]string
+		name  string
+		input string
+	}{
+		{
+			name:  "cargo install --list",
+			parse: parseCargoInstallList,
+			input: "bat v0.24.0:\n    bat\nripgrep v14.1.0 (https://github.com/BurntSushi/ripgrep#abc):\n    rg\n",
+			want:  map[string]string{"bat": "0.24.0", "ripgrep": "14.1.0"},
+		},
+		{
+			name:  "pipx list --json",
+			parse: func(s string) map[string]string { return parsePipxList([]byte(s)) },
+			input: `{"pipx_spec_version":"0.1","venvs":{"black":{"metadata":{"main_package":{"package_version":"24.4.2"}}},"Python_Dotenv":{}}}`,
+			want:  map[string]string{"black": "24.4.2", "python-dotenv": ""},
+		},
+		{
+			name:  "npm ls -g --json",
+			parse: func(s string) map[string]string { return parseNpmList([]byte(s)) },
+			input: `{"name":"lib","dependencies":{"typescript":{"version":"5.4.5"},"@biomejs/biome":{"version":"1.8.0"}}}`,
+			want:  map[string]string{"typescript": "5.4.5", "@biomejs/biome": "1.8.0"},
+		},
+		{
+			name:  "uv tool list",
+			parse: parseUvToolList,
+			input: "ruff v0.5.0\n- ruff\nhttpie v3.2.2\n- http\n- https\n",
+			want:  map[string]string{"ruff": "0.5.0", "httpie": "3.2.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.parse(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckedName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		manager PackageManager
+		pkgName string
+		want    string
+	}{
+		{Go, "golang.org/x/tools/gopls@latest", "golang.org/x/tools/gopls"},
+		{Npm, "@biomejs/biome@1.8.0", "@biomejs/biome"},
+		{Npm, "@biomejs/biome", "@biomejs/biome"},
+		{Npm, "typescript@5", "typescript"},
+		{Pipx, "Black[d]>=24", "black"},
+		{Uv, "python_dotenv==1.0", "python-dotenv"},
+		{Cargo, "ripgrep", "ripgrep"},
+	}
+
+	for _, tt := range tests {
+		if got := managerCmds[tt.manager].checkedName(tt.pkgName); got != tt.want {
+			t.Errorf("checkedName(%s, %q) = %q, want %q", tt.manager, tt.pkgName, got, tt.want)
+		}
+	}
+}
+
 func TestParseWingetListOutput(t *testing.T) {
 	t.Parallel()
 
@@ -1829,10 +2076,10 @@ zoxide                                     ajeetdsouza.zoxide
 `
 		ids := parseWingetListOutput(output)
 
-		expected := []string{"7zip.7zip", "git.git", "starship.starship", "ajeetdsouza.zoxide"}
-		for _, id := range expected {
-			if !ids[id] {
-				t.Errorf("expected %q in installed IDs", id)
+		expected := map[string]string{"7zip.7zip": "26.00", "git.git": "2.53.0", "starship.starship": "1.17.1", "ajeetdsouza.zoxide": "0.9.4"}
+		for id, version := range expected {
+			if got, ok := ids[id]; !ok || got != version {
+				t.Errorf("ids[%q] = %q, %v, want version %q", id, got, ok, version)
 			}
 		}
 	})
@@ -1845,7 +2092,7 @@ Git        Git.Git               2.53.0  winget
 `
 		ids := parseWingetListOutput(output)
 
-		if !ids["git.git"] {
+		if _, ok := ids["git.git"]; !ok {
 			t.Error("expected case-insensitive ID lookup to work")
 		}
 	})
@@ -1878,7 +2125,7 @@ Git        Git.Git               2.53.0  winget
 `
 		ids := parseWingetListOutput(output)
 
-		if !ids["git.git"] {
+		if _, ok := ids["git.git"]; !ok {
 			t.Error("expected git.git to be found despite progress spinner lines")
 		}
 	})
@@ -1895,10 +2142,10 @@ Git        Git.Git               2.53.0  winget
 
 		ids := parseWingetListOutput(output)
 
-		if !ids["git.git"] {
+		if ids["git.git"] != "2.53.0" {
 			t.Error("expected git.git to be found with \\r spinner prefix")
 		}
-		if !ids["ajeetdsouza.zoxide"] {
+		if _, ok := ids["ajeetdsouza.zoxide"]; !ok {
 			t.Error("expected ajeetdsouza.zoxide to be found with \\r spinner prefix")
 		}
 	})
@@ -1943,6 +2190,146 @@ func TestBuildCommand(t *testing.T) {
 			osType:   "linux",
 			wantArgs: []string{"sudo", "apt-get", "install", "-y", "curl"},
 		},
+		{
+			name: "zypper",
+			pkg: Package{
+				Name:     "git",
+				Managers: map[PackageManager]ManagerValue{Zypper: {PackageName: "git"}},
+			},
+			method:   "zypper",
+			osType:   "linux",
+			wantArgs: []string{"sudo", "zypper", "--non-interactive", "install", "git"},
+		},
+		{
+			name: "apk",
+			pkg: Package{
+				Name:     "ripgrep",
+				Managers: map[PackageManager]ManagerValue{Apk: {PackageName: "ripgrep"}},
+			},
+			method:   "apk",
+			osType:   "linux",
+			wantArgs: []string{"sudo", "apk", "add", "ripgrep"},
+		},
+		{
+			name: "xbps",
+			pkg: Package{
+				Name:     "neovim",
+				Managers: map[PackageManager]ManagerValue{Xbps: {PackageName: "neovim"}},
+			},
+			method:   "xbps",
+			osType:   "linux",
+			wantArgs: []string{"sudo", "xbps-install", "-y", "neovim"},
+		},
+		{
+			name: "emerge",
+			pkg: Package{
+				Name:     "app-editors/neovim",
+				Managers: map[PackageManager]ManagerValue{Emerge: {PackageName: "app-editors/neovim"}},
+			},
+			method:   "emerge",
+			osType:   "linux",
+			wantArgs: []string{"sudo", "emerge", "--noreplace", "app-editors/neovim"},
+		},
+		{
+			name: "nix",
+			pkg: Package{
+				Name:     "ripgrep",
+				Managers: map[PackageManager]ManagerValue{Nix: {PackageName: "ripgrep"}},
+			},
+			method:   "nix",
+			osType:   "linux",
+			wantArgs: []string{"nix", "profile", "install", "nixpkgs#ripgrep"},
+		},
+		{
+			name: "cargo",
+			pkg: Package{
+				Name:     "ripgrep",
+				Managers: map[PackageManager]ManagerValue{Cargo: {PackageName: "ripgrep"}},
+			},
+			method:   "cargo",
+			osType:   "linux",
+			wantArgs: []string{"cargo", "install", "ripgrep"},
+		},
+		{
+			name: "go adds @latest",
+			pkg: Package{
+				Name:     "golang.org/x/tools/gopls",
+				Managers: map[PackageManager]ManagerValue{Go: {PackageName: "golang.org/x/tools/gopls"}},
+			},
+			method:   "go",
+			osType:   "linux",
+			wantArgs: []string{"go", "install", "golang.org/x/tools/gopls@latest"},
+		},
+		{
+			name: "go keeps version",
+			pkg: Package{
+				Name:     "mvdan.cc/gofumpt@v0.7.0",
+				Managers: map[PackageManager]ManagerValue{Go: {PackageName: "mvdan.cc/gofumpt@v0.7.0"}},
+			},
+			method:   "go",
+			osType:   "linux",
+			wantArgs: []string{"go", "install", "mvdan.cc/gofumpt@v0.7.0"},
+		},
+		{
+			name: "pipx",
+			pkg: Package{
+				Name:     "black",
+				Managers: map[PackageManager]ManagerValue{Pipx: {PackageName: "black"}},
+			},
+			method:   "pipx",
+			osType:   "linux",
+			wantArgs: []string{"pipx", "install", "black"},
+		},
+		{
+			name: "npm",
+			pkg: Package{
+				Name:     "@biomejs/biome",
+				Managers: map[PackageManager]ManagerValue{Npm: {PackageName: "@biomejs/biome"}},
+			},
+			method:   "npm",
+			osType:   "linux",
+			wantArgs: []string{"npm", "install", "-g", "@biomejs/biome"},
+		},
+		{
+			name: "uv",
+			pkg: Package{
+				Name:     "ruff",
+				Managers: map[PackageManager]ManagerValue{Uv: {PackageName: "ruff"}},
+			},
+			method:   "uv",
+			osType:   "linux",
+			wantArgs: []string{"uv", "tool", "install", "ruff"},
+		},
+		{
+			name: "flatpak",
+			pkg: Package{
+				Name:     "app",
+				Managers: map[PackageManager]ManagerValue{Flatpak: {PackageName: "org.gimp.GIMP"}},
+			},
+			method:   "flatpak",
+			osType:   "linux",
+			wantArgs: []string{"flatpak", "install", "-y", "--noninteractive", "org.gimp.GIMP"},
+		},
+		{
+			name: "flatpak with remote and scope",
+			pkg: Package{
+				Name:     "app",
+				Managers: map[PackageManager]ManagerValue{Flatpak: {PackageName: "org.gimp.GIMP", Flatpak: &config.FlatpakOptions{Remote: "flathub", Scope: "user"}}},
+			},
+			method:   "flatpak",
+			osType:   "linux",
+			wantArgs: []string{"flatpak", "install", "-y", "--noninteractive", "--user", "flathub", "org.gimp.GIMP"},
+		},
+		{
+			name: "snap classic on a channel",
+			pkg: Package{
+				Name:     "app",
+				Managers: map[PackageManager]ManagerValue{Snap: {PackageName: "code", Snap: &config.SnapOptions{Classic: true, Channel: "latest/edge"}}},
+			},
+			method:   "snap",
+			osType:   "linux",
+			wantArgs: []string{"sudo", "snap", "install", "--classic", "--channel=latest/edge", "code"},
+		},
 		{
 			name: "winget",
 			pkg: Package{
@@ -2068,15 +2455,16 @@ func TestBuildCommand(t *testing.T) {
 			wantArgs: []string{"powershell", "-Command", "msbuild /t:install"},
 		},
 		{
-			name: "url linux",
+			name: "url is downloaded first",
 			pkg: Package{
 				Name: "url-tool",
 				URL: map[string]URLInstall{
 					"linux": {URL: "https://example.com/tool", Command: "chmod +x {file} && {file}"},
 				},
 			},
-			method: "url",
-			osType: "linux",
+			method:  "url",
+			osType:  "linux",
+			wantNil: true,
 		},
 		{
 			name: "unknown method",
@@ -2098,7 +2486,7 @@ func TestBuildCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cmd := BuildCommand(context.Background(), tt.pkg, tt.method, tt.osType)
+			cmd := BuildCommand(context.Background(), tt.pkg, tt.method, config.Machine{OS: tt.osType})
 
 			if tt.wantNil {
 				if cmd != nil {
@@ -2111,20 +2499,6 @@ func TestBuildCommand(t *testing.T) {
 				t.Fatal("BuildCommand() = nil, want non-nil")
 			}
 
-			// For url method, just verify it's a shell command (args vary with temp paths)
-			if tt.method == "url" {
-				if tt.osType == "windows" {
-					if cmd.Args[0] != "powershell" {
-						t.Errorf("expected powershell, got %s", cmd.Args[0])
-					}
-				} else {
-					if cmd.Args[0] != "sh" {
-						t.Errorf("expected sh, got %s", cmd.Args[0])
-					}
-				}
-				return
-			}
-
 			if len(cmd.Args) != len(tt.wantArgs) {
 				t.Errorf("BuildCommand() args length = %d, want %d\n  got:  %v\n  want: %v",
 					len(cmd.Args), len(tt.wantArgs), cmd.Args, tt.wantArgs)
@@ -2140,3 +2514,1069 @@ func TestBuildCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildBatchCommand(t *testing.T) {
+	pkg := func(mgr PackageManager, val ManagerValue) Package {
+		return Package{Name: val.PackageName, Managers: map[PackageManager]ManagerValue{mgr: val}}
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		pkgs     []Package
+		wantArgs []string
+	}{
+		{
+			name:   "pacman",
+			method: "pacman",
+			pkgs: []Package{
+				pkg(Pacman, ManagerValue{PackageName: "vim"}),
+				pkg(Pacman, ManagerValue{PackageName: "git"}),
+			},
+			wantArgs: []string{"sudo", "pacman", "-S", "--noconfirm", "vim", "git"},
+		},
+		{
+			name:   "npm keeps versions",
+			method: "npm",
+			pkgs: []Package{
+				pkg(Npm, ManagerValue{PackageName: "typescript@5"}),
+				pkg(Npm, ManagerValue{PackageName: "@biomejs/biome"}),
+			},
+			wantArgs: []string{"npm", "install", "-g", "typescript@5", "@biomejs/biome"},
+		},
+		{
+			name:   "flatpak with same options",
+			method: "flatpak",
+			pkgs: []Package{
+				pkg(Flatpak, ManagerValue{PackageName: "org.a.A", Flatpak: &config.FlatpakOptions{Remote: "flathub"}}),
+				pkg(Flatpak, ManagerValue{PackageName: "org.b.B", Flatpak: &config.FlatpakOptions{Remote: "flathub"}}),
+			},
+			wantArgs: []string{"flatpak", "install", "-y", "--noninteractive", "flathub", "org.a.A", "org.b.B"},
+		},
+		{
+			name:   "flatpak with different remotes",
+			method: "flatpak",
+			pkgs: []Package{
+				pkg(Flatpak, ManagerValue{PackageName: "org.a.A", Flatpak: &config.FlatpakOptions{Remote: "flathub"}}),
+				pkg(Flatpak, ManagerValue{PackageName: "org.b.B", Flatpak: &config.FlatpakOptions{Remote: "fedora"}}),
+			},
+		},
+		{
+			name:   "snap with options",
+			method: "snap",
+			pkgs: []Package{
+				pkg(Snap, ManagerValue{PackageName: "code", Snap: &config.SnapOptions{Classic: true}}),
+				pkg(Snap, ManagerValue{PackageName: "helm", Snap: &config.SnapOptions{Classic: true}}),
+			},
+		},
+		{
+			name:   "winget installs one package per command",
+			method: "winget",
+			pkgs: []Package{
+				pkg(Winget, ManagerValue{PackageName: "Git.Git"}),
+				pkg(Winget, ManagerValue{PackageName: "Neovim.Neovim"}),
+			},
+		},
+		{
+			name:   "package without the manager",
+			method: "pacman",
+			pkgs: []Package{
+				pkg(Pacman, ManagerValue{PackageName: "vim"}),
+				pkg(Apt, ManagerValue{PackageName: "git"}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := BuildBatchCommand(context.Background(), tt.pkgs, tt.method)
+
+			if tt.wantArgs == nil {
+				if cmd != nil {
+					t.Fatalf("BuildBatchCommand() = %v, want nil", cmd.Args)
+				}
+
+				return
+			}
+
+			if cmd == nil {
+				t.Fatal("BuildBatchCommand() = nil")
+			}
+
+			if !reflect.DeepEqual(cmd.Args, tt.wantArgs) {
+				t.Errorf("BuildBatchCommand() args = %v, want %v", cmd.Args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestInstallAll_DryRunBatches(t *testing.T) {
+	m := &Manager{
+		ctx:          context.Background(),
+		Config:       &Config{},
+		OS:           "linux",
+		DryRun:       true,
+		Available:    []PackageManager{Pacman, Cargo},
+		availableSet: toAvailableSet([]PackageManager{Pacman, Cargo}),
+	}
+
+	results := m.InstallAll([]Package{
+		{Name: "vim", Managers: map[PackageManager]ManagerValue{Pacman: {PackageName: "vim"}}},
+		{Name: "ripgrep", Managers: map[PackageManager]ManagerValue{Cargo: {PackageName: "ripgrep"}}},
+		{Name: "git", Managers: map[PackageManager]ManagerValue{Pacman: {PackageName: "git"}}},
+	})
+
+	want := []InstallResult{
+		{Package: "vim", Method: "pacman", Success: true, Message: "Would run: sudo pacman -S --noconfirm vim git"},
+		{Package: "ripgrep", Method: "cargo", Success: true, Message: "Would run: cargo install ripgrep"},
+		{Package: "git", Method: "pacman", Success: true, Message: "Would run: sudo pacman -S --noconfirm vim git"},
+	}
+
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("InstallAll() = %+v, want %+v", results, want)
+	}
+}
+
+func TestSystemManagerListParsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		parse func(string) map[string]string
+		want  map[string]string
+		name  string
+		input string
+	}{
+		{
+			name:  "pacman -Q",
+			parse: func(s string) map[string]string { return parseNameVersionColumns(s, false) },
+			input: "git 2.45.2-1\nneovim 0.10.0-3\n",
+			want:  map[string]string{"git": "2.45.2-1", "neovim": "0.10.0-3"},
+		},
+		{
+			name:  "dpkg-query",
+			parse: parseDpkgQueryOutput,
+			input: "installed curl 8.5.0-2ubuntu10.1\nconfig-files vim 2:9.1.0016-1ubuntu7\ninstalled git 1:2.43.0-1ubuntu7\n",
+			want:  map[string]string{"curl": "8.5.0-2ubuntu10.1", "git": "1:2.43.0-1ubuntu7"},
+		},
+		{
+			name:  "apk info -v",
+			parse: parseApkInfo,
+			input: "musl-1.2.4_git20230717-r4\npy3-setuptools-70.3.0-r0\nWARNING: opening /var/cache: No such file\nbusybox-1.36.1-r15\n",
+			want:  map[string]string{"musl": "1.2.4_git20230717-r4", "py3-setuptools": "70.3.0-r0", "busybox": "1.36.1-r15"},
+		},
+		{
+			name:  "xbps-query -l",
+			parse: parseXbpsQueryList,
+			input: "ii bash-5.2.21_1        GNU Bourne Again Shell\nii xorg-server-xwayland-23.2.4_1 Nested X server\nhr old-tool-1.0_1 Half removed\n",
+			want:  map[string]string{"bash": "5.2.21_1", "xorg-server-xwayland": "23.2.4_1"},
+		},
+		{
+			name:  "brew list --versions",
+			parse: func(s string) map[string]string { return parseNameVersionColumns(s, false) },
+			input: "python@3.12 3.12.4 3.12.3\nripgrep 14.1.0\n",
+			want:  map[string]string{"python@3.12": "3.12.4", "ripgrep": "14.1.0"},
+		},
+		{
+			name:  "scoop list",
+			parse: parseScoopList,
+			input: "Installed apps:\r\n\r\nName    Version Source Updated             Info\r\n----    ------- ------ -------             ----\r\n7zip    23.01   main   2024-05-14 10:00:00\r\nNeovim  0.10.0  main   2024-05-16 09:12:30\r\n",
+			want:  map[string]string{"7zip": "23.01", "neovim": "0.10.0"},
+		},
+		{
+			name:  "choco list --limit-output",
+			parse: parseChocoList,
+			input: "chocolatey|2.2.2\r\ngit|2.45.1\r\n",
+			want:  map[string]string{"chocolatey": "2.2.2", "git": "2.45.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.parse(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstalledVersion(t *testing.T) {
+	entry := &bulkCacheEntry{ids: map[string]string{"neovim": "0.10.0", "firefox": ""}}
+	entry.once.Do(func() {})
+	installedCache.Store(string(Brew), entry)
+	t.Cleanup(ResetInstalledCache)
+
+	tests := []struct {
+		pkgName     string
+		manager     string
+		wantVersion string
+		wantOK      bool
+	}{
+		{"neovim", "brew", "0.10.0", true},
+		{"homebrew/cask/Firefox", "brew", "", true},
+		{"ripgrep", "brew", "", false},
+		{"neovim", "apk", "", false}, // no bulk list
+	}
+
+	for _, tt := range tests {
+		version, ok := InstalledVersion(context.Background(), tt.pkgName, tt.manager)
+		if version != tt.wantVersion || ok != tt.wantOK {
+			t.Errorf("InstalledVersion(%q, %q) = %q, %v, want %q, %v", tt.pkgName, tt.manager, version, ok, tt.wantVersion, tt.wantOK)
+		}
+	}
+
+	if !IsInstalled(context.Background(), "neovim", "brew") {
+		t.Error("IsInstalled(neovim, brew) = false, want true from the cached bulk list")
+	}
+}
+
+func TestBuildUninstallCommand(t *testing.T) {
+	linux := config.Machine{OS: platform.OSLinux}
+	tmpDir := t.TempDir()
+	clone := filepath.Join(tmpDir, "repo")
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	pkg := func(mgr PackageManager, val ManagerValue) Package {
+		return Package{Name: "pkg", Managers: map[PackageManager]ManagerValue{mgr: val}}
+	}
+	gitPkg := func(target string, sudo bool) Package {
+		return pkg(Git, ManagerValue{Git: &GitConfig{
+			URL:     "https://github.com/test/repo.git",
+			Targets: map[string]string{platform.OSLinux: target},
+			Sudo:    sudo,
+		}})
+	}
+
+	tests := []struct {
+		name     string
+		pkg      Package
+		method   string
+		wantArgs []string
+	}{
+		{
+			name:     "pacman",
+			pkg:      pkg(Pacman, ManagerValue{PackageName: "vim"}),
+			method:   "pacman",
+			wantArgs: []string{"sudo", "pacman", "-R", "--noconfirm", "vim"},
+		},
+		{
+			name:     "npm strips the version",
+			pkg:      pkg(Npm, ManagerValue{PackageName: "typescript@5"}),
+			method:   "npm",
+			wantArgs: []string{"npm", "uninstall", "-g", "typescript"},
+		},
+		{
+			name:     "brew strips the tap",
+			pkg:      pkg(Brew, ManagerValue{PackageName: "homebrew/cask/firefox"}),
+			method:   "brew",
+			wantArgs: []string{"brew", "uninstall", "firefox"},
+		},
+		{
+			name:   "go cannot uninstall",
+			pkg:    pkg(Go, ManagerValue{PackageName: "golang.org/x/tools/gopls@latest"}),
+			method: "go",
+		},
+		{
+			name:   "manager not configured",
+			pkg:    pkg(Pacman, ManagerValue{PackageName: "vim"}),
+			method: "apt",
+		},
+		{
+			name:     "git removes the clone",
+			pkg:      gitPkg(clone, false),
+			method:   "git",
+			wantArgs: []string{"rm", "-rf", "--", clone},
+		},
+		{
+			name:     "git with sudo",
+			pkg:      gitPkg(clone, true),
+			method:   "git",
+			wantArgs: []string{"sudo", "rm", "-rf", "--", clone},
+		},
+		{
+			name:     "git expands the home directory",
+			pkg:      gitPkg("~/.local/share/repo", false),
+			method:   "git",
+			wantArgs: []string{"rm", "-rf", "--", filepath.Join(home, ".local/share/repo")},
+		},
+		{
+			name:   "git never removes the home directory",
+			pkg:    gitPkg("~", false),
+			method: "git",
+		},
+		{
+			name:   "git never removes the root directory",
+			pkg:    gitPkg("/", false),
+			method: "git",
+		},
+		{
+			name: "installer uninstall command",
+			pkg: pkg(Installer, ManagerValue{Installer: &InstallerConfig{
+				Command:   map[string]string{"linux": "curl -fsSL https://example.com/install.sh | sh"},
+				Uninstall: map[string]string{"linux": "rm -f ~/.local/bin/mytool"},
+			}}),
+			method:   "installer",
+			wantArgs: []string{"sh", "-c", "rm -f ~/.local/bin/mytool"},
+		},
+		{
+			name: "installer without uninstall command",
+			pkg: pkg(Installer, ManagerValue{Installer: &InstallerConfig{
+				Command: map[string]string{"linux": "curl -fsSL https://example.com/install.sh | sh"},
+			}}),
+			method: "installer",
+		},
+		{
+			name:   "custom cannot uninstall",
+			pkg:    Package{Name: "pkg", Custom: map[string]string{"linux": "make install"}},
+			method: "custom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := BuildUninstallCommand(context.Background(), tt.pkg, tt.method, linux)
+
+			if tt.wantArgs == nil {
+				if cmd != nil {
+					t.Errorf("BuildUninstallCommand() = %v, want nil", cmd.Args)
+				}
+				return
+			}
+
+			if cmd == nil {
+				t.Fatal("BuildUninstallCommand() = nil, want a command")
+			}
+
+			if !reflect.DeepEqual(cmd.Args, tt.wantArgs) {
+				t.Errorf("BuildUninstallCommand() args = %v, want %v", cmd.Args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestManager_Uninstall_DryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	clone := filepath.Join(tmpDir, "repo")
+	if err := os.MkdirAll(filepath.Join(clone, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	gitPkg := func(target string) Package {
+		return Package{
+			Name: "test-repo",
+			Managers: map[PackageManager]ManagerValue{
+				Git: {Git: &GitConfig{
+					URL:     "https://github.com/test/repo.git",
+					Targets: map[string]string{platform.OSLinux: target},
+				}},
+			},
+		}
+	}
+
+	mgr := NewManager(&Config{}, platform.OSLinux, true, false) // dry-run = true
+
+	result := mgr.Uninstall(gitPkg(clone))
+	if !result.Success || result.Method != "git" {
+		t.Errorf("Uninstall() = %+v, want a successful git uninstall", result)
+	}
+
+	if !strings.Contains(result.Message, "Would run") {
+		t.Errorf("Expected 'Would run' in dry-run message, got: %s", result.Message)
+	}
+
+	if _, err := os.Stat(clone); err != nil {
+		t.Errorf("Expected the clone to remain in dry-run mode: %v", err)
+	}
+
+	result = mgr.Uninstall(gitPkg(filepath.Join(tmpDir, "missing")))
+	if result.Success || result.Method != "none" {
+		t.Errorf("Uninstall() of a missing clone = %+v, want method none and no success", result)
+	}
+}
+
+func TestOutdatedListParsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		parse func(string) map[string]string
+		want  map[string]string
+		name  string
+		input string
+	}{
+		{
+			name:  "pacman -Qu",
+			parse: func(s string) map[string]string { return parseArrowUpdates(s, "->") },
+			input: "neovim 0.9.5-1 -> 0.10.0-1\nlinux 6.9.1.arch1-1 -> 6.9.2.arch1-1 [ignored]\n",
+			want:  map[string]string{"neovim": "0.10.0-1", "linux": "6.9.2.arch1-1"},
+		},
+		{
+			name:  "port outdated",
+			parse: func(s string) map[string]string { return parseArrowUpdates(s, "<") },
+			input: "The following installed ports are outdated:\nripgrep                        14.0.3_0 < 14.1.0_0\n",
+			want:  map[string]string{"ripgrep": "14.1.0_0"},
+		},
+		{
+			name:  "apt list --upgradable",
+			parse: parseAptUpgradable,
+			input: "Listing...\ncurl/noble-updates 8.5.0-2ubuntu10.2 amd64 [upgradable from: 8.5.0-2ubuntu10.1]\n",
+			want:  map[string]string{"curl": "8.5.0-2ubuntu10.2"},
+		},
+		{
+			name:  "dnf list --upgrades",
+			parse: parseDnfUpgrades,
+			input: "Available Upgrades\nneovim.x86_64    0.10.0-1.fc40    updates\npython3-pip.noarch 23.3.2-2.fc40 updates\n",
+			want:  map[string]string{"neovim": "0.10.0-1.fc40", "python3-pip": "23.3.2-2.fc40"},
+		},
+		{
+			name:  "zypper list-updates",
+			parse: parseZypperUpdates,
+			input: "S | Repository | Name   | Current Version | Available Version | Arch\n--+------------+--------+-----------------+-------------------+-------\nv | Update     | neovim | 0.9.5-1.1       | 0.10.0-1.1        | x86_64\n",
+			want:  map[string]string{"neovim": "0.10.0-1.1"},
+		},
+		{
+			name:  "brew outdated --json=v2",
+			parse: func(s string) map[string]string { return parseBrewOutdated([]byte(s)) },
+			input: `{"formulae":[{"name":"neovim","installed_versions":["0.9.5"],"current_version":"0.10.0"}],"casks":[{"name":"firefox","installed_versions":["126.0"],"current_version":"127.0"}]}`,
+			want:  map[string]string{"neovim": "0.10.0", "firefox": "127.0"},
+		},
+		{
+			name:  "scoop status",
+			parse: parseScoopStatus,
+			input: "Name   Installed Version Latest Version Missing Dependencies Info\r\n----   ----------------- -------------- -------------------- ----\r\nneovim 0.9.5             0.10.0\r\n",
+			want:  map[string]string{"neovim": "0.10.0"},
+		},
+		{
+			name:  "choco outdated --limit-output",
+			parse: parseChocoOutdated,
+			input: "git|2.45.1|2.45.2|false\r\n",
+			want:  map[string]string{"git": "2.45.2"},
+		},
+		{
+			name:  "npm outdated --json",
+			parse: func(s string) map[string]string { return parseNpmOutdated([]byte(s)) },
+			input: `{"typescript":{"current":"5.3.3","wanted":"5.4.5","latest":"5.4.5","location":"/usr/lib/node_modules/typescript"}}`,
+			want:  map[string]string{"typescript": "5.4.5"},
+		},
+		{
+			name:  "winget upgrade",
+			parse: func(s string) map[string]string { return parseWingetTable(s, "Available") },
+			input: "Name   Id            Version Available Source\r\n--------------------------------------------\r\nNeovim Neovim.Neovim 0.9.5   0.10.0    winget\r\n",
+			want:  map[string]string{"neovim.neovim": "0.10.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.parse(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildUpgradeCommand(t *testing.T) {
+	pkg := func(mgr PackageManager, name string) Package {
+		return Package{Name: name, Managers: map[PackageManager]ManagerValue{mgr: {PackageName: name}}}
+	}
+
+	tests := []struct {
+		name     string
+		pkg      Package
+		method   string
+		wantArgs []string
+	}{
+		{
+			name:     "apt only upgrades",
+			pkg:      pkg(Apt, "curl"),
+			method:   "apt",
+			wantArgs: []string{"sudo", "apt-get", "install", "--only-upgrade", "-y", "curl"},
+		},
+		{
+			name:     "brew strips the tap",
+			pkg:      pkg(Brew, "homebrew/cask/firefox"),
+			method:   "brew",
+			wantArgs: []string{"brew", "upgrade", "firefox"},
+		},
+		{
+			name:     "cargo installs again",
+			pkg:      pkg(Cargo, "ripgrep"),
+			method:   "cargo",
+			wantArgs: []string{"cargo", "install", "ripgrep"},
+		},
+		{
+			name:   "pacman only upgrades the whole system",
+			pkg:    pkg(Pacman, "neovim"),
+			method: "pacman",
+		},
+		{
+			name:     "npm keeps the pinned version",
+			pkg:      pkg(Npm, "typescript@5"),
+			method:   "npm",
+			wantArgs: []string{"npm", "install", "-g", "typescript@5"},
+		},
+		{
+			name:   "git is pulled instead",
+			pkg:    pkg(Pacman, "neovim"),
+			method: "git",
+		},
+		{
+			name:   "manager not configured",
+			pkg:    pkg(Pacman, "neovim"),
+			method: "apt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := BuildUpgradeCommand(context.Background(), tt.pkg, tt.method)
+
+			if tt.wantArgs == nil {
+				if cmd != nil {
+					t.Errorf("BuildUpgradeCommand() = %v, want nil", cmd.Args)
+				}
+				return
+			}
+
+			if cmd == nil {
+				t.Fatal("BuildUpgradeCommand() = nil, want a command")
+			}
+
+			if !reflect.DeepEqual(cmd.Args, tt.wantArgs) {
+				t.Errorf("BuildUpgradeCommand() args = %v, want %v", cmd.Args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestManager_Versions_And_Upgrade(t *testing.T) {
+	seed := func(cache *sync.Map, ids map[string]string) {
+		entry := &bulkCacheEntry{ids: ids}
+		entry.once.Do(func() {})
+		cache.Store(string(Brew), entry)
+	}
+	seed(&installedCache, map[string]string{"neovim": "0.9.5", "ripgrep": "14.1.0"})
+	seed(&outdatedCache, map[string]string{"neovim": "0.10.0"})
+	t.Cleanup(ResetInstalledCache)
+
+	pkg := func(name string) Package {
+		return Package{Name: name, Managers: map[PackageManager]ManagerValue{Brew: {PackageName: name}}}
+	}
+
+	mgr := NewManager(&Config{}, platform.OSDarwin, true, false) // dry-run = true
+	mgr.Available = []PackageManager{Brew}
+
+	versions := mgr.Versions([]Package{pkg("neovim"), pkg("ripgrep"), pkg("fzf")})
+	want := []PackageVersion{
+		{Name: "neovim", Method: "brew", Installed: "0.9.5", Available: "0.10.0", Outdated: true, Checked: true},
+		{Name: "ripgrep", Method: "brew", Installed: "14.1.0", Checked: true},
+	}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("Versions() = %+v, want %+v", versions, want)
+	}
+
+	if got := versions[0].Change(); got != "0.9.5 → 0.10.0" {
+		t.Errorf("Change() = %q, want %q", got, "0.9.5 → 0.10.0")
+	}
+
+	if got := (PackageVersion{Available: "1.0"}).Change(); got != "? → 1.0" {
+		t.Errorf("Change() = %q, want %q", got, "? → 1.0")
+	}
+
+	results := mgr.UpgradeAll([]Package{pkg("neovim"), pkg("ripgrep"), pkg("fzf")})
+
+	if !results[0].Success || results[0].Message != "Would run: brew upgrade neovim" {
+		t.Errorf("Upgrade(neovim) = %+v, want a dry-run brew upgrade", results[0])
+	}
+
+	if !results[1].Success || results[1].Message != "Already up to date" {
+		t.Errorf("Upgrade(ripgrep) = %+v, want already up to date", results[1])
+	}
+
+	if results[2].Success || results[2].Method != "none" {
+		t.Errorf("Upgrade(fzf) = %+v, want not installed", results[2])
+	}
+}
+
+func TestBuildCommand_VersionConstraint(t *testing.T) {
+	tests := []struct {
+		name     string
+		mgr      PackageManager
+		pkgName  string
+		version  string
+		wantArgs []string
+	}{
+		{"apt exact", Apt, "ripgrep", "14.1.0-1", []string{"sudo", "apt-get", "install", "-y", "ripgrep=14.1.0-1"}},
+		{"apt prefix is not pinned", Apt, "ripgrep", "14.1.*", []string{"sudo", "apt-get", "install", "-y", "ripgrep"}},
+		{"dnf exact", Dnf, "ripgrep", "14.1.0", []string{"sudo", "dnf", "install", "-y", "ripgrep-14.1.0"}},
+		{"emerge exact", Emerge, "sys-apps/ripgrep", "14.1.0", []string{"sudo", "emerge", "--noreplace", "=sys-apps/ripgrep-14.1.0"}},
+		{"go replaces the version", Go, "golang.org/x/tools/gopls@latest", "0.16.1", []string{"go", "install", "golang.org/x/tools/gopls@v0.16.1"}},
+		{"npm replaces the version", Npm, "typescript@5", "5.4.5", []string{"npm", "install", "-g", "typescript@5.4.5"}},
+		{"pipx exact", Pipx, "black", "24.4.2", []string{"pipx", "install", "black==24.4.2"}},
+		{"winget version flag", Winget, "BurntSushi.ripgrep.MSVC", "14.1.0", []string{"winget", "install", "--accept-package-agreements", "--accept-source-agreements", "--version", "14.1.0", "BurntSushi.ripgrep.MSVC"}},
+		{"brew cannot pin", Brew, "ripgrep", "14.1.0", []string{"brew", "install", "ripgrep"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := Package{Name: "app", Managers: map[PackageManager]ManagerValue{tt.mgr: {PackageName: tt.pkgName, Version: tt.version}}}
+
+			cmd := BuildCommand(context.Background(), pkg, string(tt.mgr), config.Machine{OS: platform.OSLinux})
+			if cmd == nil {
+				t.Fatal("BuildCommand() = nil")
+			}
+
+			if !reflect.DeepEqual(cmd.Args, tt.wantArgs) {
+				t.Errorf("BuildCommand() args = %v, want %v", cmd.Args, tt.wantArgs)
+			}
+		})
+	}
+
+	t.Run("version flags are not batched", func(t *testing.T) {
+		pkgs := []Package{
+			{Name: "git", Managers: map[PackageManager]ManagerValue{Choco: {PackageName: "git", Version: "2.45.1"}}},
+			{Name: "fzf", Managers: map[PackageManager]ManagerValue{Choco: {PackageName: "fzf"}}},
+		}
+
+		if cmd := BuildBatchCommand(context.Background(), pkgs, string(Choco)); cmd != nil {
+			t.Errorf("BuildBatchCommand() args = %v, want nil", cmd.Args)
+		}
+	})
+}
+
+func TestLock(t *testing.T) {
+	entry := &bulkCacheEntry{ids: map[string]string{"ripgrep": "14.1.0", "neovim": "0.10.0"}}
+	entry.once.Do(func() {})
+	installedCache.Store(string(Brew), entry)
+	t.Cleanup(ResetInstalledCache)
+
+	path := filepath.Join(t.TempDir(), LockFileName)
+
+	lock, err := LoadLock(path)
+	if err != nil {
+		t.Fatalf("LoadLock() of a missing file error = %v", err)
+	}
+
+	lock.Packages["ripgrep"] = map[string]string{"apt": "14.1.0-1"}
+
+	mgr := NewManager(&Config{}, platform.OSDarwin, false, false)
+	mgr.Available = []PackageManager{Brew}
+
+	brew := func(name string) Package {
+		return Package{Name: name, Managers: map[PackageManager]ManagerValue{Brew: {PackageName: name}}}
+	}
+
+	got := mgr.UpdateLock(lock, []Package{brew("ripgrep"), brew("neovim"), brew("fzf")})
+	want := []PackageVersion{
+		{Name: "ripgrep", Method: "brew", Installed: "14.1.0"},
+		{Name: "neovim", Method: "brew", Installed: "0.10.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UpdateLock() = %+v, want %+v", got, want)
+	}
+
+	if err := lock.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadLock(path)
+	if err != nil {
+		t.Fatalf("LoadLock() error = %v", err)
+	}
+
+	wantPackages := map[string]map[string]string{
+		"ripgrep": {"apt": "14.1.0-1", "brew": "14.1.0"},
+		"neovim":  {"brew": "0.10.0"},
+	}
+	if !reflect.DeepEqual(loaded.Packages, wantPackages) {
+		t.Errorf("LoadLock() packages = %v, want %v", loaded.Packages, wantPackages)
+	}
+}
+
+func TestManager_InstallLocked_DryRun(t *testing.T) {
+	lock := &Lock{Packages: map[string]map[string]string{
+		"ripgrep": {"apt": "14.1.0-1", "brew": "14.1.0"},
+		"neovim":  {"brew": "0.10.0"},
+	}}
+
+	pkg := func(name string, mgr PackageManager) Package {
+		return Package{Name: name, Managers: map[PackageManager]ManagerValue{mgr: {PackageName: name}}}
+	}
+
+	mgr := NewManager(&Config{}, platform.OSLinux, true, false).WithLock(lock) // dry-run = true
+
+	mgr.Available = []PackageManager{Apt}
+	results := mgr.InstallAll([]Package{pkg("ripgrep", Apt), pkg("fd-find", Apt)})
+
+	for _, r := range results {
+		if want := "Would run: sudo apt-get install -y ripgrep=14.1.0-1 fd-find"; r.Message != want {
+			t.Errorf("InstallAll(%s) message = %q, want %q", r.Package, r.Message, want)
+		}
+	}
+
+	mgr.Available = []PackageManager{Brew}
+	result := mgr.Install(pkg("neovim", Brew))
+
+	if want := "Would run: brew install neovim (brew cannot install version 0.10.0)"; !result.Success || result.Message != want {
+		t.Errorf("Install(neovim) = %+v, want message %q", result, want)
+	}
+}
+
+func TestManager_Upgrade_VersionConstraint(t *testing.T) {
+	seed := func(cache *sync.Map, ids map[string]string) {
+		entry := &bulkCacheEntry{ids: ids}
+		entry.once.Do(func() {})
+		cache.Store(string(Brew), entry)
+	}
+	seed(&installedCache, map[string]string{"neovim": "0.9.5", "ripgrep": "13.0.0", "fzf": "0.52.0"})
+	seed(&outdatedCache, map[string]string{"neovim": "0.10.0", "ripgrep": "14.1.0", "fzf": "0.53.0"})
+	t.Cleanup(ResetInstalledCache)
+
+	pkg := func(name, version string) Package {
+		return Package{Name: name, Managers: map[PackageManager]ManagerValue{Brew: {PackageName: name, Version: version}}}
+	}
+
+	mgr := NewManager(&Config{}, platform.OSDarwin, true, false) // dry-run = true
+	mgr.Available = []PackageManager{Brew}
+
+	pkgs := []Package{pkg("neovim", "0.9.5"), pkg("ripgrep", "13.*"), pkg("fzf", ">=0.50")}
+
+	for i, v := range mgr.Versions(pkgs) {
+		if wantHeld := i < 2; v.Held != wantHeld {
+			t.Errorf("Versions() %s held = %v, want %v", v.Name, v.Held, wantHeld)
+		}
+	}
+
+	results := mgr.UpgradeAll(pkgs)
+	wantMessages := []string{
+		"Held by version constraint 0.9.5",
+		"Held by version constraint 13.*",
+		"Would run: brew upgrade fzf",
+	}
+
+	for i, r := range results {
+		if !r.Success || r.Message != wantMessages[i] {
+			t.Errorf("Upgrade(%s) = %+v, want message %q", r.Package, r, wantMessages[i])
+		}
+	}
+}
+
+func TestManager_Upgrade_ConstraintPinsVersion(t *testing.T) {
+	seed := func(cache *sync.Map, manager PackageManager, ids map[string]string) {
+		entry := &bulkCacheEntry{ids: ids}
+		entry.once.Do(func() {})
+		cache.Store(string(manager), entry)
+	}
+	seed(&installedCache, Apt, map[string]string{"ripgrep": "13.0.0"})
+	seed(&outdatedCache, Apt, map[string]string{"ripgrep": "13.0.1"})
+	seed(&installedCache, Pipx, map[string]string{"black": "24.1.0"})
+	t.Cleanup(ResetInstalledCache)
+
+	mgr := NewManager(&Config{}, platform.OSLinux, true, false) // dry-run = true
+	mgr.Available = []PackageManager{Apt, Pipx}
+
+	tests := []struct {
+		name    string
+		manager PackageManager
+		version string
+		want    string
+	}{
+		{"ripgrep", Apt, "13.*", "Would run: sudo apt-get install -y ripgrep=13.0.1"},
+		{"ripgrep", Apt, "", "Would run: sudo apt-get install --only-upgrade -y ripgrep"},
+		{"black", Pipx, "24.*", "Cannot verify version constraint 24.*: pipx does not report the available version"},
+		{"black", Pipx, "", "Would run: pipx upgrade black"},
+	}
+
+	for _, tt := range tests {
+		pkg := Package{Name: tt.name, Managers: map[PackageManager]ManagerValue{tt.manager: {PackageName: tt.name, Version: tt.version}}}
+
+		if r := mgr.Upgrade(pkg); !r.Success || r.Message != tt.want {
+			t.Errorf("Upgrade(%s %q) = %+v, want message %q", tt.name, tt.version, r, tt.want)
+		}
+	}
+}
+
+func TestManager_Upgrade_PacmanRefusesPartialUpgrade(t *testing.T) {
+	seed := func(cache *sync.Map, ids map[string]string) {
+		entry := &bulkCacheEntry{ids: ids}
+		entry.once.Do(func() {})
+		cache.Store(string(Pacman), entry)
+	}
+	seed(&installedCache, map[string]string{"neovim": "0.9.5", "fd": "9.0.0"})
+	seed(&outdatedCache, map[string]string{"neovim": "0.10.0"})
+	t.Cleanup(ResetInstalledCache)
+
+	mgr := NewManager(&Config{}, platform.OSLinux, true, false) // dry-run = true
+	mgr.Available = []PackageManager{Pacman}
+
+	tests := []struct {
+		name        string
+		wantSuccess bool
+		want        string
+	}{
+		{"neovim", false, "pacman cannot upgrade single packages: run 'sudo pacman -Syu' to upgrade the whole system"},
+		{"fd", true, "Already up to date"},
+	}
+
+	for _, tt := range tests {
+		pkg := Package{Name: tt.name, Managers: map[PackageManager]ManagerValue{Pacman: {PackageName: tt.name}}}
+
+		if r := mgr.Upgrade(pkg); r.Success != tt.wantSuccess || r.Message != tt.want {
+			t.Errorf("Upgrade(%s) = %+v, want success %v and message %q", tt.name, r, tt.wantSuccess, tt.want)
+		}
+	}
+}
+
+func TestExplicitListParsers(t *testing.T) {
+	if got, want := parseNameLines("ripgrep\n\nneovim\n  fd  \n"), []string{"ripgrep", "neovim", "fd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNameLines() = %v, want %v", got, want)
+	}
+
+	wingetExport := `{
+  "$schema": "https://aka.ms/winget-packages.schema.2.0.json",
+  "Sources": [
+    {
+      "Packages": [
+        {"PackageIdentifier": "Git.Git"},
+        {"PackageIdentifier": "BurntSushi.ripgrep.MSVC"}
+      ],
+      "SourceDetails": {"Name": "winget"}
+    }
+  ]
+}`
+
+	got, err := parseWingetExport([]byte(wingetExport))
+	if err != nil {
+		t.Fatalf("parseWingetExport() error = %v", err)
+	}
+
+	if want := []string{"Git.Git", "BurntSushi.ripgrep.MSVC"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseWingetExport() = %v, want %v", got, want)
+	}
+
+	scoopJSON := `{"buckets":[{"Name":"main"}],"apps":[{"Name":"git","Source":"main"},{"Name":"vscode","Source":"extras"}]}`
+	if got, want := parseScoopExport(scoopJSON), []string{"git", "extras/vscode"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseScoopExport(JSON) = %v, want %v", got, want)
+	}
+
+	scoopText := "git (v:2.45.1) [main]\r\n7zip (v:24.06) [main]\r\n"
+	if got, want := parseScoopExport(scoopText), []string{"git", "7zip"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseScoopExport(text) = %v, want %v", got, want)
+	}
+}
+
+func TestPlanImport_ApplyImport(t *testing.T) {
+	cfg := &config.Config{Applications: []config.Application{
+		{Name: "neovim"},
+		{Name: "Ripgrep", Package: &config.EntryPackage{Managers: map[string]config.ManagerValue{"winget": {PackageName: "BurntSushi.ripgrep.GNU"}}}},
+		{Name: "firefox", Package: &config.EntryPackage{Managers: map[string]config.ManagerValue{"brew": {PackageName: "homebrew/cask/firefox"}}}},
+		{Name: "bat", Package: &config.EntryPackage{Managers: map[string]config.ManagerValue{"brew": {PackageName: "bat-extras"}}}},
+		{Name: "bat-brew"},
+	}}
+
+	brew := PlanImport(cfg.Applications, "brew", []string{"firefox", "neovim", "fd", "bat"})
+	wantBrew := []ImportCandidate{
+		{AppName: "neovim", PackageName: "neovim", Merge: true},
+		{AppName: "fd", PackageName: "fd"},
+		{AppName: "bat-brew-2", PackageName: "bat"},
+	}
+	if !reflect.DeepEqual(brew, wantBrew) {
+		t.Errorf("PlanImport(brew) = %+v, want %+v", brew, wantBrew)
+	}
+
+	winget := PlanImport(cfg.Applications, "winget", []string{"BurntSushi.ripgrep.GNU", "Git.Git", "Neovim.Neovim", "Microsoft.Git"})
+	wantWinget := []ImportCandidate{
+		{AppName: "git", PackageName: "Git.Git"},
+		{AppName: "neovim", PackageName: "Neovim.Neovim", Merge: true},
+		{AppName: "Microsoft.Git", PackageName: "Microsoft.Git"},
+	}
+	if !reflect.DeepEqual(winget, wantWinget) {
+		t.Errorf("PlanImport(winget) = %+v, want %+v", winget, wantWinget)
+	}
+
+	ApplyImport(cfg, "brew", brew)
+
+	if got := cfg.Applications[0].Package.Managers["brew"].PackageName; got != "neovim" {
+		t.Errorf("merged neovim brew package = %q, want neovim", got)
+	}
+
+	if n := len(cfg.Applications); n != 7 {
+		t.Fatalf("len(Applications) = %d, want 7", n)
+	}
+
+	if app := cfg.Applications[5]; app.Name != "fd" || app.Package.Managers["brew"].PackageName != "fd" {
+		t.Errorf("new application = %+v, want fd installed with brew", app)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	body := []byte("#!/bin/sh\necho installed\n")
+	sum := sha256.Sum256(body)
+	checksum := hex.EncodeToString(sum[:])
+
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.URL.Path != "/releases/tool.sh" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	url := srv.URL + "/releases/tool.sh"
+
+	t.Run("checksum match is cached", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		requests.Store(0)
+
+		var received, total int64
+
+		path, cleanup, err := Download(context.Background(), URLInstall{URL: url, SHA256: strings.ToUpper(checksum)}, cacheDir, func(r, tot int64) {
+			received, total = r, tot
+		})
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+		cleanup()
+
+		if want := filepath.Join(cacheDir, checksum, "tool.sh"); path != want {
+			t.Errorf("Download() path = %q, want %q", path, want)
+		}
+
+		if received != int64(len(body)) || total != int64(len(body)) {
+			t.Errorf("progress = %d of %d, want %d of %d", received, total, len(body), len(body))
+		}
+
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0o100 == 0 {
+			t.Errorf("downloaded file = %v, %v, want an executable file", info, err)
+		}
+
+		if _, _, err := Download(context.Background(), URLInstall{URL: url, SHA256: checksum}, cacheDir, nil); err != nil {
+			t.Fatalf("second Download() error = %v", err)
+		}
+
+		if n := requests.Load(); n != 1 {
+			t.Errorf("server requests = %d, want 1 (second download from the cache)", n)
+		}
+	})
+
+	t.Run("checksum mismatch aborts", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		wrong := strings.Repeat("0", 64)
+
+		_, _, err := Download(context.Background(), URLInstall{URL: url, SHA256: wrong}, cacheDir, nil)
+		if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Fatalf("Download() error = %v, want a checksum mismatch", err)
+		}
+
+		entries, _ := os.ReadDir(filepath.Join(cacheDir, wrong))
+		if len(entries) != 0 {
+			t.Errorf("cache holds %d files after a mismatch, want none", len(entries))
+		}
+	})
+
+	t.Run("without checksum downloads to a temp dir", func(t *testing.T) {
+		cacheDir := t.TempDir()
+
+		path, cleanup, err := Download(context.Background(), URLInstall{URL: url}, cacheDir, nil)
+		if err != nil {
+			t.Fatalf("Download() error = %v", err)
+		}
+
+		if strings.HasPrefix(path, cacheDir) {
+			t.Errorf("Download() path = %q, want outside the cache", path)
+		}
+
+		if got, err := os.ReadFile(path); err != nil || string(got) != string(body) {
+			t.Errorf("downloaded content = %q, %v, want %q", got, err, body)
+		}
+
+		cleanup()
+
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("file still exists after cleanup: %v", err)
+		}
+	})
+
+	t.Run("http error", func(t *testing.T) {
+		_, _, err := Download(context.Background(), URLInstall{URL: srv.URL + "/missing"}, t.TempDir(), nil)
+		if err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("Download() error = %v, want a 404 error", err)
+		}
+	})
+}
+
+func TestDownloadName(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/releases/tool-1.2.3.tar.gz", "tool-1.2.3.tar.gz"},
+		{"https://example.com/install.sh?version=2", "install.sh"},
+		{"https://example.com/", "installer"},
+		{"https://example.com", "installer"},
+		{"https://example.com/releases/..", "installer"},
+		{"https://example.com/releases/%2e%2e", "installer"},
+		{"https://example.com/releases/..%5C..%5Cevil.exe", "installer"},
+		{"https://example.com/releases/tool%5Cevil.exe", "installer"},
+		{"https://example.com/releases/tool%2F..%2Fevil.exe", "evil.exe"},
+		{"://bad", "installer"},
+	}
+
+	for _, tt := range tests {
+		if got := downloadName(tt.url); got != tt.want {
+			t.Errorf("downloadName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestManager_InstallFromURL(t *testing.T) {
+	if runtime.GOOS == platform.OSWindows {
+		t.Skip("install command uses sh")
+	}
+
+	body := []byte("payload")
+	sum := sha256.Sum256(body)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "installed")
+
+	mgr := NewManager(&Config{}, platform.OSLinux, false, false)
+	mgr.Available = nil
+	mgr.CacheDir = t.TempDir()
+
+	pkg := func(checksum string) Package {
+		return Package{Name: "tool", URL: map[string]URLInstall{
+			"linux": {URL: srv.URL + "/tool", Command: "cp {file} " + out, SHA256: checksum},
+		}}
+	}
+
+	result := mgr.Install(pkg(hex.EncodeToString(sum[:])))
+	if !result.Success || result.Message != "Installed via URL" {
+		t.Fatalf("Install() = %+v, want installed via URL", result)
+	}
+
+	if got, err := os.ReadFile(out); err != nil || string(got) != string(body) {
+		t.Errorf("install command output = %q, %v, want %q", got, err, body)
+	}
+
+	result = mgr.Install(pkg(strings.Repeat("f", 64)))
+	if result.Success || !strings.Contains(result.Message, "checksum mismatch") {
+		t.Errorf("Install() with a wrong checksum = %+v, want a checksum mismatch", result)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"testing"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
 	"github.com/AntoineGS/tidydots/internal/testutil"
 )
@@ -51,7 +52,7 @@ func TestBuildCommand_WindowsManagers(t *testing.T) {
 				},
 			}
 
-			cmd := BuildCommand(context.Background(), pkg, string(tt.manager), "windows")
+			cmd := BuildCommand(context.Background(), pkg, string(tt.manager), config.Machine{OS: "windows"})
 			if cmd == nil {
 				t.Fatal("BuildCommand() returned nil")
 			}
@@ -69,7 +70,7 @@ func TestBuildCommand_WindowsCustomUsesPowerShell(t *testing.T) {
 		Custom: map[string]string{"windows": "msbuild /t:install"},
 	}
 
-	cmd := BuildCommand(context.Background(), pkg, MethodCustom, "windows")
+	cmd := BuildCommand(context.Background(), pkg, MethodCustom, config.Machine{OS: "windows"})
 	if cmd == nil {
 		t.Fatal("BuildCommand() returned nil")
 	}
@@ -78,29 +79,13 @@ func TestBuildCommand_WindowsCustomUsesPowerShell(t *testing.T) {
 	assertArgs(t, cmd, []string{"powershell", "-Command", "msbuild /t:install"})
 }
 
-func TestBuildCommand_WindowsURLUsesInvokeWebRequest(t *testing.T) {
+func TestBuildURLInstallCommand_WindowsUsesPowershell(t *testing.T) {
 	t.Parallel()
 
-	pkg := Package{
-		Name: "url-tool",
-		URL: map[string]URLInstall{
-			"windows": {
-				URL:     "https://example.com/setup.exe",
-				Command: "{file}",
-			},
-		},
-	}
-
-	cmd := BuildCommand(context.Background(), pkg, MethodURL, "windows")
-	if cmd == nil {
-		t.Fatal("BuildCommand() returned nil")
-	}
+	cmd := BuildURLInstallCommand(context.Background(), "Start-Process {file} -Wait", `C:\Temp\setup.exe`, "windows")
 
-	// Windows URL install should use powershell with Invoke-WebRequest
-	args := cmd.Args
-	if args[0] != "powershell" || args[1] != "-Command" {
-		t.Errorf("expected powershell -Command, got %v", args[:2])
-	}
+	// Windows URL installs run their command with powershell -Command on the downloaded file
+	assertArgs(t, cmd, []string{"powershell", "-Command", `Start-Process C:\Temp\setup.exe -Wait`})
 }
 
 func TestDetectManagers_WindowsWithMocks(t *testing.T) {
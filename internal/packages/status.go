@@ -8,17 +8,22 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
 )
 
-// installedCache holds the lazily-populated set of installed package IDs for
-// managers that support bulk listing (see managerCmd.bulkList).
+// installedCache holds the lazily-populated installed package IDs and versions
+// for managers that support bulk listing (see managerCmd.bulkList).
 // The cache is populated once per manager on the first IsInstalled call.
 var installedCache sync.Map // map[string]*bulkCacheEntry
 
+// outdatedCache holds the lazily-populated upgradable package IDs and their
+// available versions, for managers that can list them (see managerCmd.outdated).
+var outdatedCache sync.Map // map[string]*bulkCacheEntry
+
 type bulkCacheEntry struct {
-	once       sync.Once
-	installedIDs map[string]bool // lowercase ID → true
+	once sync.Once
+	ids  map[string]string // lowercase ID → version ("" when unknown)
 }
 
 // IsInstalled checks if a package is installed on the system.
@@ -35,6 +40,8 @@ func IsInstalled(ctx context.Context, pkgName string, manager string) bool {
 	}
 
 	// Managers with bulk list support: run one command, cache all installed IDs
+	pkgName = mc.checkedName(pkgName)
+
 	if mc.bulkList != nil {
 		return isInstalledBulk(ctx, pkgName, manager, mc)
 	}
@@ -42,16 +49,64 @@ func IsInstalled(ctx context.Context, pkgName string, manager string) bool {
 	return isInstalledSingle(ctx, pkgName, manager, mc)
 }
 
-// isInstalledBulk checks installation via cached bulk list output.
-func isInstalledBulk(ctx context.Context, pkgName, manager string, mc managerCmd) bool {
-	val, _ := installedCache.LoadOrStore(manager, &bulkCacheEntry{})
+// InstalledVersion returns the version of an installed package, as reported
+// by its manager's bulk list (see IsInstalled). It returns false when the
+// package is not installed or the manager has no bulk list; the version is
+// empty when the manager does not report one.
+func InstalledVersion(ctx context.Context, pkgName string, manager string) (string, bool) {
+	mc, ok := managerCmds[PackageManager(manager)]
+	if !ok || mc.bulkList == nil {
+		return "", false
+	}
+
+	version, found := bulkInstalled(ctx, manager, mc)[strings.ToLower(mc.checkedName(pkgName))]
+
+	return version, found
+}
+
+// AvailableVersion returns the newer version of an installed package, as
+// reported by its manager's outdated list. It returns false when the package
+// is up to date or the manager cannot list upgrades; the version is empty
+// when the manager does not report it.
+func AvailableVersion(ctx context.Context, pkgName string, manager string) (string, bool) {
+	mc, ok := managerCmds[PackageManager(manager)]
+	if !ok || mc.outdated == nil {
+		return "", false
+	}
+
+	version, found := cachedList(ctx, &outdatedCache, manager, mc.outdated)[strings.ToLower(mc.checkedName(pkgName))]
+
+	return version, found
+}
+
+// CanListOutdated returns true if the manager can report the packages that
+// have an upgrade available.
+func CanListOutdated(manager string) bool {
+	return managerCmds[PackageManager(manager)].outdated != nil
+}
+
+// bulkInstalled returns the cached bulk list of a manager, running it on
+// first use.
+func bulkInstalled(ctx context.Context, manager string, mc managerCmd) map[string]string {
+	return cachedList(ctx, &installedCache, manager, mc.bulkList)
+}
+
+// cachedList returns the list of a manager stored in cache, running list on
+// first use.
+func cachedList(ctx context.Context, cache *sync.Map, manager string, list func(context.Context) map[string]string) map[string]string {
+	val, _ := cache.LoadOrStore(manager, &bulkCacheEntry{})
 	entry := val.(*bulkCacheEntry)
 
 	entry.once.Do(func() {
-		entry.installedIDs = mc.bulkList(ctx)
+		entry.ids = list(ctx)
 	})
 
-	found := entry.installedIDs[strings.ToLower(pkgName)]
+	return entry.ids
+}
+
+// isInstalledBulk checks installation via cached bulk list output.
+func isInstalledBulk(ctx context.Context, pkgName, manager string, mc managerCmd) bool {
+	_, found := bulkInstalled(ctx, manager, mc)[strings.ToLower(pkgName)]
 	if found {
 		slog.Debug("package detected as installed (bulk cache)",
 			slog.String("package", pkgName),
@@ -93,10 +148,12 @@ func isInstalledSingle(ctx context.Context, pkgName, manager string, mc managerC
 	return true
 }
 
-// ResetInstalledCache clears the bulk installed cache, causing the next
-// IsInstalled call to re-query. Useful for tests and after install operations.
+// ResetInstalledCache clears the bulk installed and outdated caches, causing
+// the next IsInstalled or AvailableVersion call to re-query. Useful for tests
+// and after install operations.
 func ResetInstalledCache() {
 	installedCache = sync.Map{}
+	outdatedCache = sync.Map{}
 }
 
 // IsInstallerInstalled checks if an installer package is installed by looking up
@@ -121,16 +178,16 @@ func (m *Manager) CanInstall(pkg Package) bool {
 	}
 	// Check installer (always available when configured with a command for current OS)
 	if val, ok := pkg.Managers[Installer]; ok && val.IsInstaller() {
-		if _, hasCmd := val.Installer.Command[m.OS]; hasCmd {
+		if _, hasCmd := config.ForOS(val.Installer.Command, m.OS); hasCmd {
 			return true
 		}
 	}
 	// Check custom
-	if _, ok := pkg.Custom[m.OS]; ok {
+	if _, ok := config.ForOS(pkg.Custom, m.OS); ok {
 		return true
 	}
 	// Check URL
-	if _, ok := pkg.URL[m.OS]; ok {
+	if _, ok := config.ForOS(pkg.URL, m.OS); ok {
 		return true
 	}
 
@@ -150,16 +207,16 @@ func (m *Manager) GetInstallMethod(pkg Package) string {
 
 	// Check installer (always available when configured with a command for current OS)
 	if val, ok := pkg.Managers[Installer]; ok && val.IsInstaller() {
-		if _, hasCmd := val.Installer.Command[m.OS]; hasCmd {
+		if _, hasCmd := config.ForOS(val.Installer.Command, m.OS); hasCmd {
 			return string(Installer)
 		}
 	}
 
-	if _, ok := pkg.Custom[m.OS]; ok {
+	if _, ok := config.ForOS(pkg.Custom, m.OS); ok {
 		return MethodCustom
 	}
 
-	if _, ok := pkg.URL[m.OS]; ok {
+	if _, ok := config.ForOS(pkg.URL, m.OS); ok {
 		return MethodURL
 	}
 
@@ -0,0 +1,239 @@
+package packages
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"strings"
+)
+
+// runBulkList runs a list command for manager and returns its output, or
+// false when the command fails (e.g. the manager is not installed).
+func runBulkList(ctx context.Context, manager string, args ...string) (string, bool) {
+	slog.Debug("running " + manager + " bulk list")
+
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output() //nolint:gosec // args from trusted callers
+	if err != nil {
+		slog.Debug(manager+" bulk list failed", slog.String("error", err.Error()))
+		return "", false
+	}
+
+	return string(out), true
+}
+
+// pacmanBulkList runs "pacman -Q" once and returns the installed packages with
+// their versions. It also covers packages installed by AUR helpers. Unlike
+// "pacman -Qq", the output keeps the version column.
+func pacmanBulkList(ctx context.Context) map[string]string {
+	out, ok := runBulkList(ctx, "pacman", "pacman", "-Q")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseNameVersionColumns(out, false)
+}
+
+// dpkgBulkList runs dpkg-query once and returns the installed packages with
+// their versions. Packages that were removed but keep their configuration
+// files are still known to dpkg, so the status column is checked.
+func dpkgBulkList(ctx context.Context) map[string]string {
+	out, ok := runBulkList(ctx, "dpkg", "dpkg-query", "-W", "-f=${db:Status-Status} ${Package} ${Version}\n")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseDpkgQueryOutput(out)
+}
+
+// parseDpkgQueryOutput extracts installed packages from lines reading
+// "status name version".
+func parseDpkgQueryOutput(output string) map[string]string {
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "installed" {
+			continue
+		}
+
+		versions[strings.ToLower(fields[1])] = fields[2]
+	}
+
+	return versions
+}
+
+// rpmBulkList runs "rpm -qa" once and returns the installed packages with
+// their versions, for dnf and zypper.
+func rpmBulkList(ctx context.Context) map[string]string {
+	out, ok := runBulkList(ctx, "rpm", "rpm", "-qa", "--queryformat", "%{NAME} %{VERSION}-%{RELEASE}\n")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseNameVersionColumns(out, false)
+}
+
+// apkBulkList runs "apk info -v" once and returns the installed packages with
+// their versions.
+func apkBulkList(ctx context.Context) map[string]string {
+	out, ok := runBulkList(ctx, "apk", "apk", "info", "-v")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseApkInfo(out)
+}
+
+// parseApkInfo extracts packages from lines reading "name-version-rN". The
+// name may contain dashes but the version does not, so the version starts
+// after the second-to-last dash.
+func parseApkInfo(output string) map[string]string {
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		pkg, release := splitLastDash(strings.TrimSpace(line))
+		if !strings.HasPrefix(release, "r") {
+			continue
+		}
+
+		if name, version := splitLastDash(pkg); name != "" && version != "" {
+			versions[strings.ToLower(name)] = version + "-" + release
+		}
+	}
+
+	return versions
+}
+
+// xbpsBulkList runs "xbps-query -l" once and returns the installed packages
+// with their versions.
+func xbpsBulkList(ctx context.Context) map[string]string {
+	out, ok := runBulkList(ctx, "xbps", "xbps-query", "-l")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseXbpsQueryList(out)
+}
+
+// parseXbpsQueryList extracts installed packages from lines reading
+// "state name-version_revision description". Only packages in the "ii"
+// (installed) state are kept.
+func parseXbpsQueryList(output string) map[string]string {
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "ii" {
+			continue
+		}
+
+		if name, version := splitLastDash(fields[1]); name != "" && version != "" {
+			versions[strings.ToLower(name)] = version
+		}
+	}
+
+	return versions
+}
+
+// splitLastDash splits s around its last dash, returning s and "" when it
+// has none.
+func splitLastDash(s string) (string, string) {
+	i := strings.LastIndex(s, "-")
+	if i < 0 {
+		return s, ""
+	}
+
+	return s[:i], s[i+1:]
+}
+
+// brewBulkList lists installed formulae and casks once with their versions.
+// When several versions of a formula are kept, the first one listed is used.
+func brewBulkList(ctx context.Context) map[string]string {
+	versions := make(map[string]string)
+
+	for _, kind := range []string{"--formula", "--cask"} {
+		out, ok := runBulkList(ctx, "brew", "brew", "list", kind, "--versions")
+		if !ok {
+			continue
+		}
+
+		for name, version := range parseNameVersionColumns(out, false) {
+			versions[name] = version
+		}
+	}
+
+	return versions
+}
+
+// stripTapPrefix removes the tap or bucket of a package name, such as
+// "homebrew/cask/firefox" or "extras/vscode", which brew and scoop list
+// packages without.
+func stripTapPrefix(pkgName string) string {
+	if i := strings.LastIndex(pkgName, "/"); i >= 0 {
+		return pkgName[i+1:]
+	}
+
+	return pkgName
+}
+
+// scoopBulkList runs "scoop list" once and returns the installed apps with
+// their versions.
+func scoopBulkList(ctx context.Context) map[string]string {
+	out, ok := runBulkList(ctx, "scoop", "scoop", "list")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseScoopList(out)
+}
+
+// parseScoopList extracts apps from "scoop list" output: a "Name Version ..."
+// table whose rows follow a separator line of dashes.
+func parseScoopList(output string) map[string]string {
+	versions := make(map[string]string)
+
+	inTable := false
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if !inTable {
+			inTable = strings.Trim(fields[0], "-") == ""
+			continue
+		}
+
+		if len(fields) > 1 {
+			versions[strings.ToLower(fields[0])] = fields[1]
+		}
+	}
+
+	return versions
+}
+
+// chocoBulkList runs "choco list" once and returns the installed packages with
+// their versions.
+func chocoBulkList(ctx context.Context) map[string]string {
+	out, ok := runBulkList(ctx, "choco", "choco", "list", "--local-only", "--limit-output")
+	if !ok {
+		return make(map[string]string)
+	}
+
+	return parseChocoList(out)
+}
+
+// parseChocoList extracts packages from "choco list --limit-output", where
+// each line reads "name|version".
+func parseChocoList(output string) map[string]string {
+	versions := make(map[string]string)
+
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		name, version, ok := strings.Cut(strings.TrimSpace(line), "|")
+		if ok && name != "" {
+			versions[strings.ToLower(name)] = version
+		}
+	}
+
+	return versions
+}
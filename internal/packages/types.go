@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,7 +19,9 @@ const (
 // PackageManager represents a supported package manager identifier.
 // It is used to specify which package manager should be used for installing
 // a package, such as pacman, apt, brew, winget, etc. The supported values
-// are defined as constants (Pacman, Yay, Paru, Apt, Dnf, Brew, Winget, Scoop, Choco).
+// are defined as constants (Pacman, Yay, Paru, Apt, Dnf, Zypper, Apk, Xbps,
+// Emerge, Nix, Flatpak, Snap, Brew, Port, Winget, Scoop, Choco, Cargo, Go,
+// Pipx, Npm, Uv).
 type PackageManager string
 
 // Supported package manager identifiers.
@@ -33,14 +36,40 @@ const (
 	Apt PackageManager = "apt"
 	// Dnf is the Fedora package manager
 	Dnf PackageManager = "dnf"
-	// Brew is the macOS package manager
+	// Zypper is the openSUSE package manager
+	Zypper PackageManager = "zypper"
+	// Apk is the Alpine Linux package manager
+	Apk PackageManager = "apk"
+	// Xbps is the Void Linux package manager (xbps-install)
+	Xbps PackageManager = "xbps"
+	// Emerge is the Gentoo package manager
+	Emerge PackageManager = "emerge"
+	// Nix installs packages from nixpkgs into the user's nix profile
+	Nix PackageManager = "nix"
+	// Flatpak installs sandboxed desktop applications from a flatpak remote
+	Flatpak PackageManager = "flatpak"
+	// Snap installs snap packages from the Snap Store
+	Snap PackageManager = "snap"
+	// Brew is the Homebrew package manager for macOS and Linux
 	Brew PackageManager = "brew"
+	// Port is the MacPorts package manager for macOS
+	Port PackageManager = "port"
 	// Winget is the Windows package manager
 	Winget PackageManager = "winget"
 	// Scoop is a Windows package manager
 	Scoop PackageManager = "scoop"
 	// Choco is the Chocolatey Windows package manager
 	Choco PackageManager = "choco"
+	// Cargo installs Rust crates with "cargo install"
+	Cargo PackageManager = "cargo"
+	// Go installs Go programs with "go install"
+	Go PackageManager = "go"
+	// Pipx installs Python applications into isolated environments
+	Pipx PackageManager = "pipx"
+	// Npm installs Node.js packages globally
+	Npm PackageManager = "npm"
+	// Uv installs Python tools with "uv tool install"
+	Uv PackageManager = "uv"
 	// Git is the git package manager for repository clones
 	Git PackageManager = "git"
 	// Installer is the installer package manager for shell command-based installation
@@ -64,20 +93,33 @@ type GitConfig struct {
 	Sudo    bool              `yaml:"sudo,omitempty"`
 }
 
+// TargetFor returns the clone destination for a machine, using the most
+// specific matching key (see config.SelectTarget).
+func (g GitConfig) TargetFor(mc config.Machine) string {
+	return config.SelectTarget(g.Targets, mc)
+}
+
 // InstallerConfig represents installer-specific package configuration.
-// It contains OS-specific shell commands and an optional binary name for install checks.
+// It contains OS-specific shell commands, an optional binary name for install
+// checks and optional OS-specific shell commands to uninstall.
 type InstallerConfig struct {
-	Command map[string]string `yaml:"command"`
-	Binary  string            `yaml:"binary,omitempty"`
+	Command   map[string]string `yaml:"command"`
+	Binary    string            `yaml:"binary,omitempty"`
+	Uninstall map[string]string `yaml:"uninstall,omitempty"`
 }
 
 // ManagerValue represents a typed value for a package manager entry.
 // It holds either a package name string (for traditional managers like pacman, apt),
 // a GitConfig (for git repositories), or an InstallerConfig (for shell command-based installation).
+// Flatpak and snap packages may also carry install options alongside their name,
+// and named packages a version constraint.
 type ManagerValue struct {
 	PackageName string
+	Version     string
 	Git         *GitConfig
 	Installer   *InstallerConfig
+	Flatpak     *config.FlatpakOptions
+	Snap        *config.SnapOptions
 }
 
 // IsGit returns true if this manager value represents a git package configuration.
@@ -147,6 +189,13 @@ func (p *Package) UnmarshalYAML(node *yaml.Node) error {
 			}
 			p.Managers[pm] = ManagerValue{Installer: &installerCfg}
 
+		case Flatpak, Snap:
+			mv, err := decodeOptionsManager(pm, valueNode)
+			if err != nil {
+				return err
+			}
+			p.Managers[pm] = mv
+
 		default:
 			// Traditional managers are strings
 			var pkgName string
@@ -160,13 +209,50 @@ func (p *Package) UnmarshalYAML(node *yaml.Node) error {
 	return nil
 }
 
+// decodeOptionsManager decodes a flatpak or snap manager value, given either
+// as a package name or as an object with a name and install options.
+func decodeOptionsManager(pm PackageManager, node yaml.Node) (ManagerValue, error) {
+	if node.Kind != yaml.MappingNode {
+		var pkgName string
+		if err := node.Decode(&pkgName); err != nil {
+			return ManagerValue{}, fmt.Errorf("failed to decode manager %s: %w", pm, err)
+		}
+		return ManagerValue{PackageName: pkgName}, nil
+	}
+
+	var named struct {
+		Name string `yaml:"name"`
+	}
+	if err := node.Decode(&named); err != nil {
+		return ManagerValue{}, fmt.Errorf("failed to decode %s config: %w", pm, err)
+	}
+
+	mv := ManagerValue{PackageName: named.Name}
+
+	if pm == Flatpak {
+		mv.Flatpak = &config.FlatpakOptions{}
+		if err := node.Decode(mv.Flatpak); err != nil {
+			return ManagerValue{}, fmt.Errorf("failed to decode %s config: %w", pm, err)
+		}
+	} else {
+		mv.Snap = &config.SnapOptions{}
+		if err := node.Decode(mv.Snap); err != nil {
+			return ManagerValue{}, fmt.Errorf("failed to decode %s config: %w", pm, err)
+		}
+	}
+
+	return mv, nil
+}
+
 // URLInstall represents installation from a URL with download and command execution.
 // The URL field specifies where to download the installer file, and the Command
 // field specifies the shell command to run after download. Use {file} as a
-// placeholder in Command to reference the downloaded file path.
+// placeholder in Command to reference the downloaded file path. SHA256, if
+// set, must match the download, which is then cached (see Download).
 type URLInstall struct {
 	URL     string `yaml:"url"`
-	Command string `yaml:"command"` // Command to run after download, use {file} as placeholder
+	Command string `yaml:"command"`          // Command to run after download, use {file} as placeholder
+	SHA256  string `yaml:"sha256,omitempty"` // Checksum the download must match, in hex; "" skips the check
 }
 
 // Config holds the packages configuration including the list of packages
@@ -183,7 +269,8 @@ type Config struct {
 // InstallResult represents the result of a package installation attempt.
 // It contains the package name, whether the installation succeeded, a message
 // describing the outcome, and the method used (e.g., "pacman", "custom", "url").
-// This is returned by Install and InstallAll methods to report installation status.
+// This is returned by Install and InstallAll methods to report installation status,
+// and by Uninstall and UninstallAll for removals.
 type InstallResult struct {
 	Package string
 	Message string
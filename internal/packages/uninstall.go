@@ -0,0 +1,221 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/platform"
+)
+
+// uninstallArgs returns the uninstall command for a package, or nil when the
+// manager cannot uninstall. The package is removed under the name it is
+// listed as installed, without version or tap.
+func (mc managerCmd) uninstallArgs(val ManagerValue) []string {
+	if mc.uninstall == nil {
+		return nil
+	}
+
+	return expandArgs(mc.uninstall, mc.checkedName(val.PackageName))
+}
+
+// IsGitCloned returns true if target (which may start with "~") holds a git
+// clone, as created by installing a git package.
+func IsGitCloned(target string) bool {
+	if target == "" {
+		return false
+	}
+
+	info, err := os.Stat(filepath.Join(config.ExpandPath(target, nil), ".git"))
+
+	return err == nil && info.IsDir()
+}
+
+// isProtectedDir reports whether path is the home directory or a filesystem
+// root, which a git target must never resolve to before being deleted.
+func isProtectedDir(path string) bool {
+	path = filepath.Clean(path)
+	if path == filepath.Dir(path) {
+		return true
+	}
+
+	home, err := os.UserHomeDir()
+
+	return err == nil && path == filepath.Clean(home)
+}
+
+// BuildUninstallCommand creates an *exec.Cmd removing a package installed with
+// the given method: the manager's uninstall command, the installer's
+// uninstall command for the machine's OS, or a recursive delete of a git
+// clone. Like BuildCommand, it leaves execution to the caller. Returns nil if
+// the method cannot uninstall the package (custom and URL installs, go, or an
+// installer without an uninstall command).
+func BuildUninstallCommand(ctx context.Context, pkg Package, method string, machine config.Machine) *exec.Cmd {
+	pm := PackageManager(method)
+
+	if mc, ok := managerCmds[pm]; ok {
+		val, exists := pkg.Managers[pm]
+		if !exists {
+			return nil
+		}
+
+		args := mc.uninstallArgs(val)
+		if args == nil {
+			return nil
+		}
+
+		return exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // args from trusted lookup table
+	}
+
+	switch pm {
+	case Git:
+		gitVal, ok := pkg.Managers[Git]
+		if !ok || !gitVal.IsGit() {
+			return nil
+		}
+
+		target := config.ExpandPath(gitVal.Git.TargetFor(machine), nil)
+		if target == "" || isProtectedDir(target) {
+			return nil
+		}
+
+		if machine.OS == platform.OSWindows {
+			script := fmt.Sprintf("Remove-Item -LiteralPath '%s' -Recurse -Force", strings.ReplaceAll(target, "'", "''"))
+			return exec.CommandContext(ctx, "powershell", "-Command", script) //nolint:gosec // intentional command from user config
+		}
+
+		if gitVal.Git.Sudo {
+			return exec.CommandContext(ctx, "sudo", "rm", "-rf", "--", target) //nolint:gosec // intentional command from user config
+		}
+
+		return exec.CommandContext(ctx, "rm", "-rf", "--", target) //nolint:gosec // intentional command from user config
+
+	case Installer:
+		installerVal, ok := pkg.Managers[Installer]
+		if !ok || !installerVal.IsInstaller() {
+			return nil
+		}
+
+		command, hasCmd := config.ForOS(installerVal.Installer.Uninstall, machine.OS)
+		if !hasCmd {
+			return nil
+		}
+
+		if machine.OS == platform.OSWindows {
+			return exec.CommandContext(ctx, "powershell", "-Command", command) //nolint:gosec // intentional uninstall command from user config
+		}
+
+		return exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // intentional uninstall command from user config
+	}
+
+	return nil
+}
+
+// UninstallMethod returns the method that installed a package, and would
+// remove it: "git" when its clone exists, "installer" when its binary is
+// found in PATH (or no binary is configured), or the first available package
+// manager reporting it as installed. It returns "none" when the package is not
+// installed by any of them; custom and URL installs are never detected.
+func (m *Manager) UninstallMethod(pkg Package) string {
+	if val, ok := pkg.Managers[Git]; ok && val.IsGit() {
+		if IsGitCloned(val.Git.TargetFor(m.machine)) {
+			return string(Git)
+		}
+
+		return "none"
+	}
+
+	if val, ok := pkg.Managers[Installer]; ok && val.IsInstaller() {
+		if val.Installer.Binary == "" || IsInstallerInstalled(val.Installer.Binary) {
+			return string(Installer)
+		}
+
+		return "none"
+	}
+
+	if mgr, _, ok := m.installedManager(pkg); ok {
+		return string(mgr)
+	}
+
+	return "none"
+}
+
+// installedManager returns the first available package manager, in detection
+// order, that reports pkg as installed.
+func (m *Manager) installedManager(pkg Package) (PackageManager, ManagerValue, bool) {
+	for _, mgr := range m.Available {
+		if mgr == Git || mgr == Installer {
+			continue
+		}
+
+		if val, ok := pkg.Managers[mgr]; ok && IsInstalled(m.ctx, val.PackageName, string(mgr)) {
+			return mgr, val, true
+		}
+	}
+
+	return "", ManagerValue{}, false
+}
+
+// Uninstall removes a single package with the method that installed it (see
+// UninstallMethod). It returns an InstallResult describing the removal.
+func (m *Manager) Uninstall(pkg Package) InstallResult {
+	method := m.UninstallMethod(pkg)
+	result := InstallResult{Package: pkg.Name, Method: method}
+
+	if method == "none" {
+		result.Message = "Not installed by any configured method"
+		return result
+	}
+
+	cmd := BuildUninstallCommand(m.ctx, pkg, method, m.machine)
+	if cmd == nil {
+		switch method {
+		case string(Installer):
+			result.Message = fmt.Sprintf("No uninstall command defined for OS: %s", m.OS)
+		default:
+			result.Message = fmt.Sprintf("%s cannot uninstall packages", method)
+		}
+
+		return result
+	}
+
+	if m.DryRun {
+		result.Success = true
+		result.Message = fmt.Sprintf("Would run: %s", strings.Join(cmd.Args, " "))
+
+		return result
+	}
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	if err := cmd.Run(); err != nil {
+		result.Message = fmt.Sprintf("Uninstall failed: %v", err)
+		return result
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("Uninstalled via %s", method)
+
+	return result
+}
+
+// UninstallAll uninstalls the packages one by one and returns their results
+// in order. The installed package cache is cleared afterwards.
+func (m *Manager) UninstallAll(packages []Package) []InstallResult {
+	results := make([]InstallResult, 0, len(packages))
+	for _, pkg := range packages {
+		results = append(results, m.Uninstall(pkg))
+	}
+
+	if !m.DryRun {
+		ResetInstalledCache()
+	}
+
+	return results
+}
@@ -0,0 +1,255 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+)
+
+// PackageVersion describes the installed version of a package and the newer
+// version offered by the package manager that installed it.
+type PackageVersion struct {
+	Name      string
+	Method    string
+	Installed string // "" when the manager does not report it
+	Available string // newer version; "" when up to date or not reported
+	Outdated  bool   // a newer version is available
+	Checked   bool   // false when the manager cannot list upgrades
+	Held      bool   // the version constraint rules out the newer version
+}
+
+// Change describes the upgrade as "installed → available", with "?" for the
+// versions the manager does not report.
+func (v PackageVersion) Change() string {
+	orUnknown := func(version string) string {
+		if version == "" {
+			return "?"
+		}
+
+		return version
+	}
+
+	return orUnknown(v.Installed) + " → " + orUnknown(v.Available)
+}
+
+// upgradeArgs returns the upgrade command for a package: the manager's
+// upgrade command, or its install command when installing again upgrades.
+func (mc managerCmd) upgradeArgs(val ManagerValue) []string {
+	if mc.upgrade == nil {
+		return mc.installArgs(val)
+	}
+
+	return expandArgs(mc.upgrade, mc.checkedName(val.PackageName))
+}
+
+// constrainedUpgradeArgs returns the command upgrading a package with a
+// version constraint to version, a newer version the constraint allows: an
+// install of that version when the manager can select one, or its upgrade
+// command otherwise, which installs the same newest version.
+func (mc managerCmd) constrainedUpgradeArgs(val ManagerValue, version string) []string {
+	if mc.pin == nil {
+		return mc.upgradeArgs(val)
+	}
+
+	val.Version = version
+
+	return mc.installArgs(val)
+}
+
+// BuildUpgradeCommand creates an *exec.Cmd upgrading a package with the given
+// package manager method. Like BuildCommand, it leaves execution to the
+// caller. Returns nil for git, installer, custom and URL methods, and for
+// managers that only upgrade the whole system.
+func BuildUpgradeCommand(ctx context.Context, pkg Package, method string) *exec.Cmd {
+	pm := PackageManager(method)
+
+	mc, ok := managerCmds[pm]
+	if !ok || mc.systemUpgrade != nil {
+		return nil
+	}
+
+	val, exists := pkg.Managers[pm]
+	if !exists {
+		return nil
+	}
+
+	args := mc.upgradeArgs(val)
+
+	return exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // args from trusted lookup table
+}
+
+// Versions returns the installed and available versions of the packages
+// installed with a package manager, in order. Packages that are not
+// installed, or were installed with git, an installer, a custom command or a
+// URL, are left out.
+func (m *Manager) Versions(packages []Package) []PackageVersion {
+	var versions []PackageVersion
+
+	for _, pkg := range packages {
+		mgr, val, ok := m.installedManager(pkg)
+		if !ok {
+			continue
+		}
+
+		v := PackageVersion{
+			Name:    pkg.Name,
+			Method:  string(mgr),
+			Checked: CanListOutdated(string(mgr)),
+		}
+		v.Installed, _ = InstalledVersion(m.ctx, val.PackageName, string(mgr))
+
+		if v.Checked {
+			v.Available, v.Outdated = AvailableVersion(m.ctx, val.PackageName, string(mgr))
+			v.Held = v.Outdated && heldBy(val, v.Available) != ""
+		}
+
+		versions = append(versions, v)
+	}
+
+	return versions
+}
+
+// heldBy returns the version constraint of a package when it rules out
+// upgrading to version, or "" when the upgrade is allowed. Packages pinned to
+// an exact version are always held.
+func heldBy(val ManagerValue, version string) string {
+	c, err := config.ParseVersionConstraint(val.Version)
+	if err != nil || val.Version == "" {
+		return ""
+	}
+
+	if _, exact := c.ExactVersion(); exact || (version != "" && !c.Allows(version)) {
+		return c.String()
+	}
+
+	return ""
+}
+
+// UpgradeMethod returns the method that would upgrade a package: "git" when
+// its clone exists, or the first available package manager reporting it as
+// installed. It returns "none" otherwise; installer, custom and URL packages
+// cannot be upgraded.
+func (m *Manager) UpgradeMethod(pkg Package) string {
+	if val, ok := pkg.Managers[Git]; ok && val.IsGit() {
+		if IsGitCloned(val.Git.TargetFor(m.machine)) {
+			return string(Git)
+		}
+
+		return "none"
+	}
+
+	if _, ok := pkg.Managers[Installer]; ok {
+		return "none"
+	}
+
+	if mgr, _, ok := m.installedManager(pkg); ok {
+		return string(mgr)
+	}
+
+	return "none"
+}
+
+// Upgrade upgrades a single package with the method that installed it (see
+// UpgradeMethod). Git packages are pulled. Packages whose manager reports them
+// as up to date, or whose version constraint rules out the upgrade, are not
+// touched. Neither are packages with a version constraint whose manager does
+// not report the available version, since the upgrade could break the
+// constraint; the others are upgraded to the version that was checked. It
+// returns an InstallResult describing the upgrade.
+func (m *Manager) Upgrade(pkg Package) InstallResult {
+	method := m.UpgradeMethod(pkg)
+	result := InstallResult{Package: pkg.Name, Method: method}
+
+	switch method {
+	case "none":
+		result.Message = "Not installed by git or a package manager"
+		return result
+	case string(Git):
+		result.Success, result.Message = m.installGitPackage(*pkg.Managers[Git].Git)
+		return result
+	}
+
+	val := pkg.Managers[PackageManager(method)]
+	available := ""
+
+	if CanListOutdated(method) {
+		var outdated bool
+		if available, outdated = AvailableVersion(m.ctx, val.PackageName, method); !outdated {
+			result.Success = true
+			result.Message = "Already up to date"
+
+			return result
+		}
+	}
+
+	if constraint := heldBy(val, available); constraint != "" {
+		result.Success = true
+		result.Message = fmt.Sprintf("Held by version constraint %s", constraint)
+
+		return result
+	}
+
+	if val.Version != "" && available == "" {
+		result.Success = true
+		result.Message = fmt.Sprintf("Cannot verify version constraint %s: %s does not report the available version", val.Version, method)
+
+		return result
+	}
+
+	// Partial upgrades are unsupported on Arch, so only a full upgrade is offered
+	if full := managerCmds[PackageManager(method)].systemUpgrade; full != nil {
+		result.Message = fmt.Sprintf("%s cannot upgrade single packages: run '%s' to upgrade the whole system", method, strings.Join(full, " "))
+		return result
+	}
+
+	cmd := BuildUpgradeCommand(m.ctx, pkg, method)
+	if cmd == nil {
+		result.Message = fmt.Sprintf("%s cannot upgrade packages", method)
+		return result
+	}
+
+	if val.Version != "" {
+		args := managerCmds[PackageManager(method)].constrainedUpgradeArgs(val, available)
+		cmd = exec.CommandContext(m.ctx, args[0], args[1:]...) //nolint:gosec // args from trusted lookup table
+	}
+
+	if m.DryRun {
+		result.Success = true
+		result.Message = fmt.Sprintf("Would run: %s", strings.Join(cmd.Args, " "))
+
+		return result
+	}
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	if err := cmd.Run(); err != nil {
+		result.Message = fmt.Sprintf("Upgrade failed: %v", err)
+		return result
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("Upgraded via %s", method)
+
+	return result
+}
+
+// UpgradeAll upgrades the packages one by one and returns their results in
+// order. The installed package cache is cleared afterwards.
+func (m *Manager) UpgradeAll(packages []Package) []InstallResult {
+	results := make([]InstallResult, 0, len(packages))
+	for _, pkg := range packages {
+		results = append(results, m.Upgrade(pkg))
+	}
+
+	if !m.DryRun {
+		ResetInstalledCache()
+	}
+
+	return results
+}
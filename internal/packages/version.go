@@ -0,0 +1,110 @@
+package packages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AntoineGS/tidydots/internal/config"
+)
+
+// pinnedVersion returns the version a package is pinned to by an exact
+// version constraint.
+func pinnedVersion(val ManagerValue) (string, bool) {
+	c, err := config.ParseVersionConstraint(val.Version)
+	if err != nil {
+		return "", false
+	}
+
+	return c.ExactVersion()
+}
+
+// pinEquals selects a version as "name=version" (apt, zypper, apk).
+func pinEquals(pkgName, version string) []string {
+	return []string{pkgName + "=" + version}
+}
+
+// pinDash selects a version as "name-version" (dnf).
+func pinDash(pkgName, version string) []string {
+	return []string{pkgName + "-" + version}
+}
+
+// pinEmerge selects a version as "=category/name-version".
+func pinEmerge(pkgName, version string) []string {
+	return []string{"=" + pkgName + "-" + version}
+}
+
+// pinAt selects a version as "name@version" (cargo, scoop).
+func pinAt(pkgName, version string) []string {
+	return []string{pkgName + "@" + version}
+}
+
+// pinGo replaces the version suffix of a package path, adding the "v" that
+// module versions start with.
+func pinGo(pkgName, version string) []string {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+
+	return []string{stripGoVersion(pkgName) + "@" + version}
+}
+
+// pinNpm replaces the version suffix of an npm package.
+func pinNpm(pkgName, version string) []string {
+	return []string{stripNpmVersion(pkgName) + "@" + version}
+}
+
+// pinPython selects a version with a "name==version" requirement (pipx, uv).
+func pinPython(pkgName, version string) []string {
+	return []string{pkgName + "==" + version}
+}
+
+// pinVersionFlag selects a version with a "--version" flag (winget, choco).
+func pinVersionFlag(pkgName, version string) []string {
+	return []string{"--version", version, pkgName}
+}
+
+// withVersion returns val with the version recorded in the lockfile for its
+// package and manager, if any, in place of its version constraint.
+func (m *Manager) withVersion(pkgName string, mgr PackageManager, val ManagerValue) ManagerValue {
+	if version, ok := m.lock.Version(pkgName, string(mgr)); ok {
+		val.Version = version
+	}
+
+	return val
+}
+
+// versionNote describes a package whose version constraint was not met by an
+// install: before installing, when the manager cannot select the pinned
+// version; afterwards, when the installed version does not satisfy it. It
+// returns "" otherwise, or when the manager does not report versions.
+func (m *Manager) versionNote(mgr PackageManager, val ManagerValue) string {
+	c, err := config.ParseVersionConstraint(val.Version)
+	if err != nil || val.Version == "" {
+		return ""
+	}
+
+	if m.DryRun {
+		if version, ok := c.ExactVersion(); ok && managerCmds[mgr].pin == nil {
+			return fmt.Sprintf("%s cannot install version %s", mgr, version)
+		}
+
+		return ""
+	}
+
+	installed, ok := InstalledVersion(m.ctx, val.PackageName, string(mgr))
+	if !ok || installed == "" || c.Allows(installed) {
+		return ""
+	}
+
+	return fmt.Sprintf("installed version %s does not match %s", installed, c)
+}
+
+// withVersionNote appends the version note of a package to an install
+// message.
+func (m *Manager) withVersionNote(msg string, mgr PackageManager, val ManagerValue) string {
+	if note := m.versionNote(mgr, val); note != "" {
+		return fmt.Sprintf("%s (%s)", msg, note)
+	}
+
+	return msg
+}
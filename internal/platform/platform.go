@@ -19,8 +19,25 @@ const (
 	OSLinux = "linux"
 	// OSWindows represents Windows operating systems
 	OSWindows = "windows"
+	// OSDarwin represents macOS
+	OSDarwin = "darwin"
 )
 
+// SupportedOS lists the operating system identifiers accepted by --os and as
+// target keys.
+var SupportedOS = []string{OSLinux, OSWindows, OSDarwin}
+
+// IsSupportedOS reports whether osType is one of SupportedOS.
+func IsSupportedOS(osType string) bool {
+	for _, s := range SupportedOS {
+		if s == osType {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Platform holds detected platform information including the operating system,
 // Linux distribution, hostname, current user, and privilege status.
 type Platform struct {
@@ -110,8 +127,11 @@ func detectUser() string {
 }
 
 func detectOS() string {
-	if runtime.GOOS == "windows" {
+	switch runtime.GOOS {
+	case "windows":
 		return OSWindows
+	case "darwin":
+		return OSDarwin
 	}
 
 	// Also check OS environment variable (for cross-platform scripts)
@@ -125,10 +145,14 @@ func detectOS() string {
 
 // detectDisplay checks whether a display server is available.
 // On Linux, it checks for DISPLAY (X11) or WAYLAND_DISPLAY (Wayland).
-// On Windows, it always returns true.
+// On Windows it always returns true, and on macOS it returns true unless the
+// session is a remote SSH login.
 func detectDisplay(osType string) bool {
-	if osType == OSWindows {
+	switch osType {
+	case OSWindows:
 		return true
+	case OSDarwin:
+		return os.Getenv("SSH_CONNECTION") == "" || os.Getenv("DISPLAY") != ""
 	}
 
 	if os.Getenv("DISPLAY") != "" {
@@ -231,12 +255,23 @@ func IsCommandAvailable(cmd string) bool {
 }
 
 // KnownPackageManagers is the list of supported package managers across all platforms.
-// Includes Arch Linux (yay, paru, pacman), Debian/Fedora/macOS (apt, dnf, brew),
-// Windows (winget, scoop, choco) package managers, and git for repository cloning.
+// Includes Arch Linux (yay, paru, pacman), Debian/Fedora (apt, dnf), openSUSE
+// (zypper), Alpine (apk), Void (xbps), Gentoo (emerge), Nix (nix), the Linux
+// application stores (flatpak, snap), Homebrew
+// (brew, on macOS and Linux), MacPorts (port), Windows (winget, scoop, choco)
+// package managers, the cross-platform language package managers (cargo, go,
+// pipx, npm, uv), and git for repository cloning. Detection reports managers
+// in this order, so system managers are tried before language ones.
 var KnownPackageManagers = []string{
 	"yay", "paru", "pacman", // Arch Linux
-	"apt", "dnf", "brew", // Debian/Fedora/macOS
+	"apt", "dnf", // Debian/Fedora
+	"zypper", "apk", "xbps", // openSUSE/Alpine/Void
+	"emerge", "nix", // Gentoo/Nix
+	"flatpak", "snap", // Linux application stores
+	"brew",                     // macOS and Linux
+	"port",                     // macOS
 	"winget", "scoop", "choco", // Windows
+	"cargo", "go", "pipx", "npm", "uv", // Language package managers, on every OS
 	"git", // Git for repository cloning
 }
 
@@ -316,10 +351,31 @@ var managersForOS = map[string]map[string]bool{
 	OSLinux: {
 		"yay": true, "paru": true, "pacman": true,
 		"apt": true, "dnf": true, "brew": true,
+		"zypper": true, "apk": true, "xbps": true,
+		"emerge": true, "nix": true,
+		"flatpak": true, "snap": true,
 	},
 	OSWindows: {
 		"winget": true, "scoop": true, "choco": true,
 	},
+	OSDarwin: {
+		"brew": true, "port": true,
+	},
+}
+
+// managerBinaries maps managers whose command is not named after them to the
+// binary detected in PATH.
+var managerBinaries = map[string]string{
+	"xbps": "xbps-install",
+}
+
+// ManagerBinary returns the executable looked up in PATH to detect a manager.
+func ManagerBinary(manager string) string {
+	if bin, ok := managerBinaries[manager]; ok {
+		return bin
+	}
+
+	return manager
 }
 
 // isManagerValidForOS returns true if the manager is valid for the given OS,
@@ -370,11 +426,11 @@ func DetectAvailableManagers() []string {
 			}
 
 			if detectedWSL && len(windowsDriveMounts) > 0 {
-				if lookPathSkipWindowsDrives(mgr, windowsDriveMounts) {
+				if lookPathSkipWindowsDrives(ManagerBinary(mgr), windowsDriveMounts) {
 					available = append(available, mgr)
 				}
 			} else {
-				if IsCommandAvailable(mgr) {
+				if IsCommandAvailable(ManagerBinary(mgr)) {
 					available = append(available, mgr)
 				}
 			}
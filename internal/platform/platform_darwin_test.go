@@ -9,10 +9,9 @@ import (
 func TestDetectOS_Darwin(t *testing.T) {
 	t.Parallel()
 
-	// tidydots maps macOS to "linux" (non-Windows = linux)
 	got := detectOS()
-	if got != OSLinux {
-		t.Errorf("detectOS() = %q on macOS, want %q (tidydots maps non-Windows to linux)", got, OSLinux)
+	if got != OSDarwin {
+		t.Errorf("detectOS() = %q on macOS, want %q", got, OSDarwin)
 	}
 }
 
@@ -39,8 +38,8 @@ func TestDetectWSL_Darwin(t *testing.T) {
 func TestDetect_Darwin(t *testing.T) {
 	p := Detect()
 
-	if p.OS != OSLinux {
-		t.Errorf("Detect().OS = %q on macOS, want %q", p.OS, OSLinux)
+	if p.OS != OSDarwin {
+		t.Errorf("Detect().OS = %q on macOS, want %q", p.OS, OSDarwin)
 	}
 
 	if p.IsWSL {
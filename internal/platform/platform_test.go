@@ -19,6 +19,11 @@ func TestDetectOS(t *testing.T) {
 	if runtime.GOOS == "windows" && os != OSWindows {
 		t.Errorf("detectOS() = %q, want %q", os, OSWindows)
 	}
+
+	// On macOS, should return "darwin"
+	if runtime.GOOS == "darwin" && os != OSDarwin {
+		t.Errorf("detectOS() = %q, want %q", os, OSDarwin)
+	}
 }
 
 func TestDetect(t *testing.T) {
@@ -29,8 +34,8 @@ func TestDetect(t *testing.T) {
 		t.Fatal("Detect() returned nil")
 	}
 
-	if p.OS != OSLinux && p.OS != OSWindows {
-		t.Errorf("OS = %q, want %q or %q", p.OS, OSLinux, OSWindows)
+	if !IsSupportedOS(p.OS) {
+		t.Errorf("OS = %q, want one of %v", p.OS, SupportedOS)
 	}
 
 	if p.EnvVars == nil {
@@ -62,6 +67,10 @@ func TestWithOS(t *testing.T) {
 	if !newP.IsArchLinux() {
 		t.Error("WithOS() Distro not preserved")
 	}
+
+	if mac := p.WithOS(OSDarwin); mac.OS != OSDarwin || !mac.IsArchLinux() {
+		t.Errorf("WithOS(darwin) = %+v", mac)
+	}
 }
 
 func TestGetBasename(t *testing.T) {
@@ -129,6 +138,7 @@ func TestDetectDisplay(t *testing.T) {
 		osType         string
 		display        string
 		waylandDisplay string
+		sshConnection  string
 		want           bool
 	}{
 		{
@@ -160,6 +170,24 @@ func TestDetectDisplay(t *testing.T) {
 			osType: OSLinux,
 			want:   false,
 		},
+		{
+			name:   "darwin local session",
+			osType: OSDarwin,
+			want:   true,
+		},
+		{
+			name:          "darwin over ssh",
+			osType:        OSDarwin,
+			sshConnection: "10.0.0.2 50000 10.0.0.1 22",
+			want:          false,
+		},
+		{
+			name:          "darwin over ssh with X forwarding",
+			osType:        OSDarwin,
+			sshConnection: "10.0.0.2 50000 10.0.0.1 22",
+			display:       "localhost:10.0",
+			want:          true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -167,6 +195,7 @@ func TestDetectDisplay(t *testing.T) {
 			// Clear both env vars first
 			t.Setenv("DISPLAY", tt.display)
 			t.Setenv("WAYLAND_DISPLAY", tt.waylandDisplay)
+			t.Setenv("SSH_CONNECTION", tt.sshConnection)
 
 			got := detectDisplay(tt.osType)
 			if got != tt.want {
@@ -219,6 +248,17 @@ func TestIsManagerValidForOS(t *testing.T) {
 		{"choco on linux", "choco", OSLinux, false},
 		{"brew on linux", "brew", OSLinux, true},
 		{"brew on windows", "brew", OSWindows, false},
+		{"brew on darwin", "brew", OSDarwin, true},
+		{"port on darwin", "port", OSDarwin, true},
+		{"port on linux", "port", OSLinux, false},
+		{"apt on darwin", "apt", OSDarwin, false},
+		{"zypper on linux", "zypper", OSLinux, true},
+		{"xbps on windows", "xbps", OSWindows, false},
+		{"nix on darwin", "nix", OSDarwin, false},
+		{"cargo on linux", "cargo", OSLinux, true},
+		{"npm on windows", "npm", OSWindows, true},
+		{"uv on darwin", "uv", OSDarwin, true},
+		{"winget on darwin", "winget", OSDarwin, false},
 		{"git on linux", "git", OSLinux, true},
 		{"git on windows", "git", OSWindows, true},
 		{"unknown manager on linux", "unknown", OSLinux, true},
@@ -236,6 +276,17 @@ func TestIsManagerValidForOS(t *testing.T) {
 	}
 }
 
+func TestManagerBinary(t *testing.T) {
+	t.Parallel()
+
+	if got := ManagerBinary("xbps"); got != "xbps-install" {
+		t.Errorf("ManagerBinary(xbps) = %q, want xbps-install", got)
+	}
+	if got := ManagerBinary("zypper"); got != "zypper" {
+		t.Errorf("ManagerBinary(zypper) = %q, want zypper", got)
+	}
+}
+
 func TestIsUnderWindowsDrive(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,219 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Restore run statuses.
+const (
+	RunRunning    = "running"
+	RunCompleted  = "completed"
+	RunFailed     = "failed"
+	RunRolledBack = "rolled_back"
+)
+
+// RestoreRun is one invocation of restore whose steps are journaled.
+type RestoreRun struct {
+	StartedAt  time.Time
+	FinishedAt time.Time // zero while the run is in progress
+	ID         string
+	Host       string
+	Status     string
+}
+
+// JournalOp is a single destructive restore step with the data needed to undo it.
+// The meaning of Path and Aux depends on Kind and is defined by the caller.
+type JournalOp struct {
+	CreatedAt   time.Time
+	RunID       string
+	Application string
+	Entry       string
+	Kind        string
+	Path        string
+	Aux         string
+	ID          int64
+	Seq         int
+	Sudo        bool
+	Undone      bool
+}
+
+// BeginRun records the start of a restore run.
+func (s *Store) BeginRun(id, host string) error {
+	_, err := s.exec(context.Background(), `
+		INSERT INTO restore_runs (id, host, status) VALUES (?, ?, ?)
+	`, id, host, RunRunning)
+	if err != nil {
+		return fmt.Errorf("beginning run: %w", err)
+	}
+
+	return nil
+}
+
+// FinishRun sets the final status of a restore run.
+func (s *Store) FinishRun(id, status string) error {
+	_, err := s.exec(context.Background(), `
+		UPDATE restore_runs SET status = ?, finished_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, status, id)
+	if err != nil {
+		return fmt.Errorf("finishing run: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRun removes a restore run and its journal.
+func (s *Store) DeleteRun(id string) error {
+	ctx := context.Background()
+
+	if _, err := s.exec(ctx, `DELETE FROM journal_ops WHERE run_id = ?`, id); err != nil {
+		return fmt.Errorf("deleting journal: %w", err)
+	}
+
+	if _, err := s.exec(ctx, `DELETE FROM restore_runs WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("deleting run: %w", err)
+	}
+
+	return nil
+}
+
+// GetRun returns the restore run with the given ID, or nil if it does not exist.
+func (s *Store) GetRun(id string) (*RestoreRun, error) {
+	row := s.db.QueryRowContext(context.Background(), `
+		SELECT id, host, status, started_at, finished_at FROM restore_runs WHERE id = ?
+	`, id)
+
+	r, err := scanRun(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil //nolint:nilnil // nil means "not found", distinct from error
+	}
+
+	return r, err
+}
+
+// ListRuns returns the restore runs recorded for a host, newest first.
+// A limit of zero or less returns all runs.
+func (s *Store) ListRuns(host string, limit int) ([]RestoreRun, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT id, host, status, started_at, finished_at
+		FROM restore_runs
+		WHERE host = ?
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?
+	`, host, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }() //nolint:errcheck,gosec // defer close is best-effort
+
+	var runs []RestoreRun
+	for rows.Next() {
+		r, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, *r)
+	}
+
+	return runs, rows.Err()
+}
+
+// AddJournalOp appends a step to a run's journal and returns its ID.
+func (s *Store) AddJournalOp(op JournalOp) (int64, error) {
+	res, err := s.exec(context.Background(), `
+		INSERT INTO journal_ops (run_id, seq, application, entry, kind, path, aux, sudo)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, op.RunID, op.Seq, op.Application, op.Entry, op.Kind, op.Path, op.Aux, op.Sudo)
+	if err != nil {
+		return 0, fmt.Errorf("adding journal op: %w", err)
+	}
+
+	return res.LastInsertId()
+}
+
+// ListJournalOps returns the steps of a run in the order they were performed.
+func (s *Store) ListJournalOps(runID string) ([]JournalOp, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT id, run_id, seq, application, entry, kind, path, aux, sudo, undone, created_at
+		FROM journal_ops
+		WHERE run_id = ?
+		ORDER BY seq
+	`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("querying journal: %w", err)
+	}
+	defer func() { _ = rows.Close() }() //nolint:errcheck,gosec // defer close is best-effort
+
+	var ops []JournalOp
+	for rows.Next() {
+		var op JournalOp
+		var createdAt string
+
+		if err := rows.Scan(&op.ID, &op.RunID, &op.Seq, &op.Application, &op.Entry, &op.Kind,
+			&op.Path, &op.Aux, &op.Sudo, &op.Undone, &createdAt); err != nil {
+			return nil, fmt.Errorf("scanning journal op: %w", err)
+		}
+
+		op.CreatedAt, err = parseTime(createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("parsing created_at: %w", err)
+		}
+
+		ops = append(ops, op)
+	}
+
+	return ops, rows.Err()
+}
+
+// MarkJournalOpUndone flags a step as reverted so it is not undone twice.
+func (s *Store) MarkJournalOpUndone(id int64) error {
+	_, err := s.exec(context.Background(), `
+		UPDATE journal_ops SET undone = 1 WHERE id = ?
+	`, id)
+	if err != nil {
+		return fmt.Errorf("marking journal op undone: %w", err)
+	}
+
+	return nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRun(row rowScanner) (*RestoreRun, error) {
+	var r RestoreRun
+	var startedAt string
+	var finishedAt sql.NullString
+
+	if err := row.Scan(&r.ID, &r.Host, &r.Status, &startedAt, &finishedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scanning run: %w", err)
+	}
+
+	var err error
+	r.StartedAt, err = parseTime(startedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parsing started_at: %w", err)
+	}
+
+	if finishedAt.Valid {
+		r.FinishedAt, err = parseTime(finishedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("parsing finished_at: %w", err)
+		}
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,108 @@
+package state
+
+import (
+	"testing"
+)
+
+func TestRestoreRuns(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, id := range []string{"run-1", "run-2", "run-3"} {
+		if err := store.BeginRun(id, "desktop"); err != nil {
+			t.Fatalf("BeginRun(%s) error = %v", id, err)
+		}
+	}
+	if err := store.BeginRun("other", "laptop"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.FinishRun("run-1", RunCompleted); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := store.ListRuns("desktop", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("ListRuns() = %d runs, want 3", len(runs))
+	}
+	if runs[0].ID != "run-3" {
+		t.Errorf("ListRuns()[0] = %s, want newest run-3", runs[0].ID)
+	}
+
+	limited, err := store.ListRuns("desktop", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limited) != 2 {
+		t.Errorf("ListRuns(limit 2) = %d runs, want 2", len(limited))
+	}
+
+	run, err := store.GetRun("run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != RunCompleted || run.FinishedAt.IsZero() {
+		t.Errorf("GetRun() = %+v, want completed with finish time", run)
+	}
+
+	missing, err := store.GetRun("nope")
+	if err != nil || missing != nil {
+		t.Errorf("GetRun(missing) = %v, %v, want nil, nil", missing, err)
+	}
+}
+
+func TestJournalOps(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.BeginRun("run-1", "desktop"); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := []JournalOp{
+		{RunID: "run-1", Seq: 1, Application: "zsh", Entry: "zshrc", Kind: "moved", Path: "/home/a", Aux: "/repo/a"},
+		{RunID: "run-1", Seq: 2, Application: "zsh", Entry: "zshrc", Kind: "symlink_created", Path: "/home/a", Aux: "/repo/a", Sudo: true},
+	}
+
+	var firstID int64
+	for i, op := range ops {
+		id, err := store.AddJournalOp(op)
+		if err != nil {
+			t.Fatalf("AddJournalOp() error = %v", err)
+		}
+		if i == 0 {
+			firstID = id
+		}
+	}
+
+	if err := store.MarkJournalOpUndone(firstID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.ListJournalOps("run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ListJournalOps() = %d ops, want 2", len(got))
+	}
+	if got[0].Kind != "moved" || !got[0].Undone {
+		t.Errorf("op 1 = %+v, want undone moved step", got[0])
+	}
+	if got[1].Seq != 2 || !got[1].Sudo || got[1].Undone {
+		t.Errorf("op 2 = %+v, want pending sudo step", got[1])
+	}
+
+	if err := store.DeleteRun("run-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = store.ListJournalOps("run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ListJournalOps() after DeleteRun = %d ops, want 0", len(got))
+	}
+}
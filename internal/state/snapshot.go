@@ -0,0 +1,122 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Snapshot groups the content archived by one restore run, or by one
+// standalone restore operation.
+type Snapshot struct {
+	CreatedAt time.Time
+	ID        string
+	Host      string
+	Files     int
+}
+
+// SnapshotFile is one file or directory archived before restore removed or
+// overwrote it. Stored is where the archived copy lives.
+type SnapshotFile struct {
+	CreatedAt  time.Time
+	SnapshotID string
+	Host       string
+	Path       string
+	Stored     string
+	Reason     string
+	ID         int64
+	IsDir      bool
+}
+
+// AddSnapshotFile records an archived file or directory.
+func (s *Store) AddSnapshotFile(f SnapshotFile) error {
+	_, err := s.exec(context.Background(), `
+		INSERT INTO snapshot_files (snapshot_id, host, path, stored, reason, is_dir)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, f.SnapshotID, f.Host, f.Path, f.Stored, f.Reason, f.IsDir)
+	if err != nil {
+		return fmt.Errorf("adding snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// ListSnapshots returns the snapshots recorded for a host, newest first.
+func (s *Store) ListSnapshots(host string) ([]Snapshot, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT snapshot_id, host, MIN(created_at), COUNT(*)
+		FROM snapshot_files
+		WHERE host = ?
+		GROUP BY snapshot_id, host
+		ORDER BY MIN(created_at) DESC, snapshot_id DESC
+	`, host)
+	if err != nil {
+		return nil, fmt.Errorf("querying snapshots: %w", err)
+	}
+	defer func() { _ = rows.Close() }() //nolint:errcheck,gosec // defer close is best-effort
+
+	var snapshots []Snapshot
+	for rows.Next() {
+		var snap Snapshot
+		var createdAt string
+
+		if err := rows.Scan(&snap.ID, &snap.Host, &createdAt, &snap.Files); err != nil {
+			return nil, fmt.Errorf("scanning snapshot: %w", err)
+		}
+
+		snap.CreatedAt, err = parseTime(createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("parsing created_at: %w", err)
+		}
+
+		snapshots = append(snapshots, snap)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// ListSnapshotFiles returns the files archived in a snapshot, oldest first.
+func (s *Store) ListSnapshotFiles(snapshotID string) ([]SnapshotFile, error) {
+	rows, err := s.db.QueryContext(context.Background(), `
+		SELECT id, snapshot_id, host, path, stored, reason, is_dir, created_at
+		FROM snapshot_files
+		WHERE snapshot_id = ?
+		ORDER BY id
+	`, snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("querying snapshot files: %w", err)
+	}
+	defer func() { _ = rows.Close() }() //nolint:errcheck,gosec // defer close is best-effort
+
+	var files []SnapshotFile
+	for rows.Next() {
+		var f SnapshotFile
+		var createdAt string
+
+		if err := rows.Scan(&f.ID, &f.SnapshotID, &f.Host, &f.Path, &f.Stored, &f.Reason,
+			&f.IsDir, &createdAt); err != nil {
+			return nil, fmt.Errorf("scanning snapshot file: %w", err)
+		}
+
+		f.CreatedAt, err = parseTime(createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("parsing created_at: %w", err)
+		}
+
+		files = append(files, f)
+	}
+
+	return files, rows.Err()
+}
+
+// DeleteSnapshot forgets every file recorded in a snapshot.
+func (s *Store) DeleteSnapshot(snapshotID string) error {
+	_, err := s.exec(context.Background(), `
+		DELETE FROM snapshot_files WHERE snapshot_id = ?
+	`, snapshotID)
+	if err != nil {
+		return fmt.Errorf("deleting snapshot: %w", err)
+	}
+
+	return nil
+}
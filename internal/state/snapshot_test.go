@@ -0,0 +1,58 @@
+package state
+
+import (
+	"testing"
+)
+
+func TestSnapshots(t *testing.T) {
+	store := newTestStore(t)
+
+	files := []SnapshotFile{
+		{SnapshotID: "snap-1", Host: "desktop", Path: "/home/u/.zshrc", Stored: "/s/snap-1/1/.zshrc", Reason: "removed"},
+		{SnapshotID: "snap-1", Host: "desktop", Path: "/home/u/.config/nvim", Stored: "/s/snap-1/2/nvim", Reason: "removed", IsDir: true},
+		{SnapshotID: "snap-2", Host: "laptop", Path: "/home/u/.zshrc", Stored: "/s/snap-2/1/.zshrc", Reason: "overwritten"},
+	}
+
+	for _, f := range files {
+		if err := store.AddSnapshotFile(f); err != nil {
+			t.Fatalf("AddSnapshotFile() error = %v", err)
+		}
+	}
+
+	snapshots, err := store.ListSnapshots("desktop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].ID != "snap-1" || snapshots[0].Files != 2 {
+		t.Fatalf("ListSnapshots() = %+v, want snap-1 with 2 files", snapshots)
+	}
+	if snapshots[0].CreatedAt.IsZero() {
+		t.Error("ListSnapshots() CreatedAt is zero")
+	}
+
+	got, err := store.ListSnapshotFiles("snap-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ListSnapshotFiles() = %d files, want 2", len(got))
+	}
+	if got[0].Path != "/home/u/.zshrc" || got[0].IsDir {
+		t.Errorf("file 1 = %+v, want .zshrc file", got[0])
+	}
+	if !got[1].IsDir || got[1].Stored != "/s/snap-1/2/nvim" {
+		t.Errorf("file 2 = %+v, want nvim directory", got[1])
+	}
+
+	if err := store.DeleteSnapshot("snap-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = store.ListSnapshotFiles("snap-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ListSnapshotFiles() after DeleteSnapshot = %d files, want 0", len(got))
+	}
+}
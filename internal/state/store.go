@@ -1,4 +1,5 @@
-// Package state manages template render history in a SQLite database.
+// Package state manages template render history, the inventory of managed
+// paths, the restore journal and snapshots in a SQLite database.
 package state
 
 import (
@@ -8,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite" // Pure-Go SQLite driver
@@ -24,9 +26,32 @@ type RenderRecord struct {
 	PlatformHost string
 }
 
+// Managed path modes recorded by restore.
+const (
+	// ModeFolder records a target directory linked to a backup folder
+	ModeFolder = "folder"
+	// ModeFile records a single target file linked to a backup file
+	ModeFile = "file"
+)
+
+// ManagedPath records a symlink created by restore, so it can be found again
+// after its entry is removed from the configuration.
+type ManagedPath struct {
+	RecordedAt  time.Time
+	TargetPath  string
+	SourcePath  string
+	Application string
+	Entry       string
+	Mode        string
+	Host        string
+	ID          int64
+}
+
 // Store manages the SQLite database for template render history.
+// It is safe for concurrent use; writes are serialized.
 type Store struct {
-	db *sql.DB
+	db      *sql.DB
+	writeMu sync.Mutex
 }
 
 // Open opens or creates the SQLite database at the given path and runs migrations.
@@ -35,7 +60,8 @@ func Open(dbPath string) (*Store, error) {
 		return nil, fmt.Errorf("creating database directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	// Wait for locks instead of failing when another connection is writing
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("opening database: %w", err)
 	}
@@ -56,6 +82,15 @@ func Open(dbPath string) (*Store, error) {
 	return s, nil
 }
 
+// exec runs a write statement. Writes are serialized so concurrent restores
+// do not compete for SQLite's single writer lock.
+func (s *Store) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return s.db.ExecContext(ctx, query, args...)
+}
+
 // Close closes the database connection.
 func (s *Store) Close() error {
 	return s.db.Close()
@@ -98,7 +133,7 @@ func (s *Store) GetLatestRender(templatePath string) (*RenderRecord, error) {
 // SaveRender stores a new render record for the given template.
 func (s *Store) SaveRender(templatePath string, pureRender []byte, templateHash, platformOS, hostname string) error {
 	ctx := context.Background()
-	_, err := s.db.ExecContext(ctx, `
+	_, err := s.exec(ctx, `
 		INSERT INTO template_renders (template_path, pure_render, template_hash, platform_os, platform_host)
 		VALUES (?, ?, ?, ?, ?)
 	`, templatePath, pureRender, templateHash, platformOS, hostname)
@@ -158,7 +193,7 @@ func (s *Store) GetRenderByID(id int64) (*RenderRecord, error) {
 // PruneHistory keeps only the N most recent renders per template, deleting older ones.
 func (s *Store) PruneHistory(templatePath string, keepN int) error {
 	ctx := context.Background()
-	_, err := s.db.ExecContext(ctx, `
+	_, err := s.exec(ctx, `
 		DELETE FROM template_renders
 		WHERE template_path = ?
 		AND id NOT IN (
@@ -178,7 +213,7 @@ func (s *Store) PruneHistory(templatePath string, keepN int) error {
 // RemoveTemplate deletes all render records for the given template.
 func (s *Store) RemoveTemplate(templatePath string) error {
 	ctx := context.Background()
-	_, err := s.db.ExecContext(ctx, `
+	_, err := s.exec(ctx, `
 		DELETE FROM template_renders WHERE template_path = ?
 	`, templatePath)
 	if err != nil {
@@ -188,12 +223,84 @@ func (s *Store) RemoveTemplate(templatePath string) error {
 	return nil
 }
 
+// RecordManagedPath stores or refreshes the record for a managed symlink.
+// Records are unique per target path and host.
+func (s *Store) RecordManagedPath(p ManagedPath) error {
+	ctx := context.Background()
+	_, err := s.exec(ctx, `
+		INSERT INTO managed_paths (target_path, source_path, application, entry, mode, host)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(target_path, host) DO UPDATE SET
+			source_path = excluded.source_path,
+			application = excluded.application,
+			entry       = excluded.entry,
+			mode        = excluded.mode,
+			recorded_at = CURRENT_TIMESTAMP
+	`, p.TargetPath, p.SourcePath, p.Application, p.Entry, p.Mode, p.Host)
+	if err != nil {
+		return fmt.Errorf("recording managed path: %w", err)
+	}
+
+	return nil
+}
+
+// ListManagedPaths returns all managed paths recorded for the given host,
+// ordered by target path.
+func (s *Store) ListManagedPaths(host string) ([]ManagedPath, error) {
+	ctx := context.Background()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, target_path, source_path, application, entry, mode, host, recorded_at
+		FROM managed_paths
+		WHERE host = ?
+		ORDER BY target_path
+	`, host)
+	if err != nil {
+		return nil, fmt.Errorf("querying managed paths: %w", err)
+	}
+	defer func() { _ = rows.Close() }() //nolint:errcheck,gosec // defer close is best-effort
+
+	var paths []ManagedPath
+	for rows.Next() {
+		var p ManagedPath
+		var recordedAt string
+
+		if err := rows.Scan(&p.ID, &p.TargetPath, &p.SourcePath, &p.Application, &p.Entry, &p.Mode, &p.Host, &recordedAt); err != nil {
+			return nil, fmt.Errorf("scanning managed path: %w", err)
+		}
+
+		p.RecordedAt, err = parseTime(recordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parsing recorded_at: %w", err)
+		}
+
+		paths = append(paths, p)
+	}
+
+	return paths, rows.Err()
+}
+
+// RemoveManagedPath deletes the record for a target path on the given host.
+func (s *Store) RemoveManagedPath(targetPath, host string) error {
+	ctx := context.Background()
+	_, err := s.exec(ctx, `
+		DELETE FROM managed_paths WHERE target_path = ? AND host = ?
+	`, targetPath, host)
+	if err != nil {
+		return fmt.Errorf("removing managed path: %w", err)
+	}
+
+	return nil
+}
+
 // migrate runs schema migrations.
 func (s *Store) migrate() error {
 	currentVersion := s.getSchemaVersion()
 
 	migrations := []func(*sql.Tx) error{
 		migrateV1,
+		migrateV2,
+		migrateV3,
+		migrateV4,
 	}
 
 	ctx := context.Background()
@@ -287,3 +394,93 @@ func migrateV1(tx *sql.Tx) error {
 
 	return nil
 }
+
+// migrateV2 adds the managed_paths inventory written by restore.
+func migrateV2(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS managed_paths (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			target_path     TEXT NOT NULL,
+			source_path     TEXT NOT NULL,
+			application     TEXT NOT NULL,
+			entry           TEXT NOT NULL,
+			mode            TEXT NOT NULL,
+			host            TEXT NOT NULL,
+			recorded_at     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(target_path, host)
+		)`,
+	}
+
+	ctx := context.Background()
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("executing %q: %w", stmt[:40], err)
+		}
+	}
+
+	return nil
+}
+
+// migrateV3 adds the restore run journal used for rollback.
+func migrateV3(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS restore_runs (
+			id              TEXT PRIMARY KEY,
+			host            TEXT NOT NULL,
+			status          TEXT NOT NULL,
+			started_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			finished_at     DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS journal_ops (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			run_id          TEXT NOT NULL REFERENCES restore_runs(id) ON DELETE CASCADE,
+			seq             INTEGER NOT NULL,
+			application     TEXT NOT NULL,
+			entry           TEXT NOT NULL,
+			kind            TEXT NOT NULL,
+			path            TEXT NOT NULL,
+			aux             TEXT NOT NULL DEFAULT '',
+			sudo            INTEGER NOT NULL DEFAULT 0,
+			undone          INTEGER NOT NULL DEFAULT 0,
+			created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_journal_ops_run
+			ON journal_ops(run_id, seq)`,
+	}
+
+	ctx := context.Background()
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("executing %q: %w", stmt[:40], err)
+		}
+	}
+
+	return nil
+}
+
+// migrateV4 adds the snapshot store of target content removed or overwritten by restore.
+func migrateV4(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS snapshot_files (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			snapshot_id     TEXT NOT NULL,
+			host            TEXT NOT NULL,
+			path            TEXT NOT NULL,
+			stored          TEXT NOT NULL,
+			reason          TEXT NOT NULL,
+			is_dir          INTEGER NOT NULL DEFAULT 0,
+			created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_snapshot_files_snapshot
+			ON snapshot_files(snapshot_id)`,
+	}
+
+	ctx := context.Background()
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("executing %q: %w", stmt[:40], err)
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"database/sql"
 	"path/filepath"
 	"testing"
 )
@@ -27,14 +28,14 @@ func TestOpen_CreatesDBAndSchema(t *testing.T) {
 	}
 	defer func() { _ = store.Close() }() //nolint:errcheck // cleanup is best-effort
 
-	// Should have schema_version table with version 1
+	// Should have schema_version table with the latest version
 	var version int
 	ctx := context.Background()
 	if err := store.db.QueryRowContext(ctx, `SELECT version FROM schema_version`).Scan(&version); err != nil {
 		t.Fatalf("failed to read schema version: %v", err)
 	}
-	if version != 1 {
-		t.Errorf("schema version = %d, want 1", version)
+	if version != 4 {
+		t.Errorf("schema version = %d, want 4", version)
 	}
 }
 
@@ -59,8 +60,8 @@ func TestOpen_Idempotent(t *testing.T) {
 	if err := store2.db.QueryRowContext(ctx, `SELECT version FROM schema_version`).Scan(&version); err != nil {
 		t.Fatalf("failed to read schema version: %v", err)
 	}
-	if version != 1 {
-		t.Errorf("schema version = %d, want 1", version)
+	if version != 4 {
+		t.Errorf("schema version = %d, want 4", version)
 	}
 }
 
@@ -266,18 +267,18 @@ func TestRemoveTemplate_DoesNotAffectOthers(t *testing.T) {
 	}
 }
 
-func TestSchemaMigration_Version0To1(t *testing.T) {
+func TestSchemaMigration_Version0ToLatest(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), ".tidydots.db")
 
-	// Open creates schema from scratch (version 0 -> 1)
+	// Open creates schema from scratch (version 0 -> 4)
 	store, err := Open(dbPath)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	version := store.getSchemaVersion()
-	if version != 1 {
-		t.Errorf("expected version 1, got %d", version)
+	if version != 4 {
+		t.Errorf("expected version 4, got %d", version)
 	}
 
 	_ = store.Close() //nolint:errcheck // cleanup is best-effort
@@ -290,7 +291,109 @@ func TestSchemaMigration_Version0To1(t *testing.T) {
 	defer func() { _ = store2.Close() }() //nolint:errcheck // cleanup is best-effort
 
 	version = store2.getSchemaVersion()
-	if version != 1 {
-		t.Errorf("expected version 1 after re-open, got %d", version)
+	if version != 4 {
+		t.Errorf("expected version 4 after re-open, got %d", version)
+	}
+}
+
+func TestSchemaMigration_Version1ToLatest(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), ".tidydots.db")
+
+	// Build a version 1 database by hand
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateV1(tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_version (version) VALUES (1)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`INSERT INTO template_renders (template_path, pure_render, template_hash, platform_os, platform_host)
+		VALUES ('a.tmpl', 'x', 'h', 'linux', 'host')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close() //nolint:errcheck // cleanup is best-effort
+
+	store, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }() //nolint:errcheck // cleanup is best-effort
+
+	if version := store.getSchemaVersion(); version != 4 {
+		t.Errorf("expected version 4 after upgrade, got %d", version)
+	}
+
+	// Existing renders survive and the new table is usable
+	if rec, err := store.GetLatestRender("a.tmpl"); err != nil || rec == nil {
+		t.Errorf("GetLatestRender() after upgrade = %v, %v", rec, err)
+	}
+	if err := store.RecordManagedPath(ManagedPath{TargetPath: "/t", SourcePath: "/s", Mode: ModeFile, Host: "host"}); err != nil {
+		t.Errorf("RecordManagedPath() after upgrade error = %v", err)
+	}
+}
+
+func TestManagedPaths(t *testing.T) {
+	store := newTestStore(t)
+
+	records := []ManagedPath{
+		{TargetPath: "/home/me/.zshrc", SourcePath: "/dots/zsh/.zshrc", Application: "zsh", Entry: "zshrc", Mode: ModeFile, Host: "desktop"},
+		{TargetPath: "/home/me/.config/nvim", SourcePath: "/dots/nvim", Application: "nvim", Entry: "config", Mode: ModeFolder, Host: "desktop"},
+		{TargetPath: "/home/me/.zshrc", SourcePath: "/dots/zsh/.zshrc", Application: "zsh", Entry: "zshrc", Mode: ModeFile, Host: "laptop"},
+	}
+	for _, r := range records {
+		if err := store.RecordManagedPath(r); err != nil {
+			t.Fatalf("RecordManagedPath() error = %v", err)
+		}
+	}
+
+	// Recording the same target again updates instead of duplicating
+	updated := records[0]
+	updated.SourcePath = "/dots/shell/.zshrc"
+	if err := store.RecordManagedPath(updated); err != nil {
+		t.Fatalf("RecordManagedPath() update error = %v", err)
+	}
+
+	paths, err := store.ListManagedPaths("desktop")
+	if err != nil {
+		t.Fatalf("ListManagedPaths() error = %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("ListManagedPaths() returned %d paths, want 4", len(paths))
+	}
+	if paths[0].TargetPath != "/home/me/.config/nvim" || paths[0].Mode != ModeFolder {
+		t.Errorf("paths[0] = %+v, want nvim folder record", paths[0])
+	}
+	if paths[1].SourcePath != "/dots/shell/.zshrc" {
+		t.Errorf("paths[1].SourcePath = %q, want updated source", paths[1].SourcePath)
+	}
+	if paths[1].RecordedAt.IsZero() {
+		t.Error("RecordedAt should be set")
+	}
+
+	if err := store.RemoveManagedPath("/home/me/.zshrc", "desktop"); err != nil {
+		t.Fatalf("RemoveManagedPath() error = %v", err)
+	}
+
+	paths, err = store.ListManagedPaths("desktop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 {
+		t.Errorf("ListManagedPaths() after remove returned %d paths, want 1", len(paths))
+	}
+
+	// Other hosts are untouched
+	if others, _ := store.ListManagedPaths("laptop"); len(others) != 1 {
+		t.Errorf("laptop records = %d, want 1", len(others))
 	}
 }
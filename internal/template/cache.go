@@ -0,0 +1,142 @@
+package template
+
+import (
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+// impureFuncs lists template functions whose output is not determined by the
+// template text and context alone. Render results that call them are never cached.
+var impureFuncs = map[string]bool{
+	"shuffle": true, // random order
+	"set":     true, // mutates its map argument, which may be the context
+	"unset":   true,
+}
+
+// cacheKey identifies a template by name and text. The name is part of the key
+// because it appears in error messages.
+type cacheKey struct {
+	name string
+	text string
+}
+
+// cachedResult is a memoized render outcome.
+type cachedResult struct {
+	err    error
+	output string
+}
+
+// renderCache memoizes parsed templates and pure render results for a single
+// Engine. Because an Engine's context is fixed at construction, template text
+// alone determines the result. It is safe for concurrent use.
+type renderCache struct {
+	parsed  map[cacheKey]*template.Template
+	results map[cacheKey]cachedResult
+	mu      sync.RWMutex
+}
+
+func newRenderCache() *renderCache {
+	return &renderCache{
+		parsed:  make(map[cacheKey]*template.Template),
+		results: make(map[cacheKey]cachedResult),
+	}
+}
+
+func (c *renderCache) getResult(key cacheKey) (cachedResult, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	r, ok := c.results[key]
+
+	return r, ok
+}
+
+func (c *renderCache) putResult(key cacheKey, r cachedResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.results[key] = r
+}
+
+func (c *renderCache) getParsed(key cacheKey) (*template.Template, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	t, ok := c.parsed[key]
+
+	return t, ok
+}
+
+func (c *renderCache) putParsed(key cacheKey, t *template.Template) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.parsed[key] = t
+}
+
+// isPure reports whether a parsed template only calls functions whose output
+// depends on their arguments, so its render result can be reused.
+func isPure(t *template.Template) bool {
+	for _, tt := range t.Templates() {
+		if tt.Tree != nil && !isPureNode(tt.Tree.Root) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isPureNode(node parse.Node) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case *parse.ListNode:
+		if n == nil {
+			return true
+		}
+		for _, child := range n.Nodes {
+			if !isPureNode(child) {
+				return false
+			}
+		}
+		return true
+	case *parse.ActionNode:
+		return isPureNode(n.Pipe)
+	case *parse.IfNode:
+		return isPureBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		return isPureBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return isPureBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		return isPureNode(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return true
+		}
+		for _, cmd := range n.Cmds {
+			if !isPureNode(cmd) {
+				return false
+			}
+		}
+		return true
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if !isPureNode(arg) {
+				return false
+			}
+		}
+		return true
+	case *parse.ChainNode:
+		return isPureNode(n.Node)
+	case *parse.IdentifierNode:
+		return !impureFuncs[n.Ident]
+	default:
+		return true
+	}
+}
+
+func isPureBranch(n *parse.BranchNode) bool {
+	return isPureNode(n.Pipe) && isPureNode(n.List) && isPureNode(n.ElseList)
+}
@@ -0,0 +1,150 @@
+package template
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRenderString_CachesPureResults(t *testing.T) {
+	ctx := &Context{OS: "linux", Hostname: "desktop", Env: map[string]string{}}
+	engine := NewEngine(ctx)
+
+	tmplStr := `{{ eq .Hostname "desktop" }}`
+
+	for range 3 {
+		got, err := engine.RenderString("when", tmplStr)
+		if err != nil {
+			t.Fatalf("RenderString() error = %v", err)
+		}
+		if got != "true" {
+			t.Errorf("RenderString() = %q, want %q", got, "true")
+		}
+	}
+
+	key := cacheKey{name: "when", text: tmplStr}
+	if _, ok := engine.cache.getResult(key); !ok {
+		t.Error("pure template result was not cached")
+	}
+	if _, ok := engine.cache.getParsed(key); !ok {
+		t.Error("parsed template was not cached")
+	}
+}
+
+func TestRenderString_CachesErrors(t *testing.T) {
+	engine := NewEngine(&Context{OS: "linux", Env: map[string]string{}})
+
+	for _, tmplStr := range []string{"{{ .Invalid", "{{ .Hostnme }}"} {
+		_, first := engine.RenderString("path", tmplStr)
+		_, second := engine.RenderString("path", tmplStr)
+
+		if first == nil || second == nil {
+			t.Fatalf("RenderString(%q) errors = (%v, %v), want both non-nil", tmplStr, first, second)
+		}
+		if first.Error() != second.Error() {
+			t.Errorf("cached error %q differs from original %q", second, first)
+		}
+	}
+}
+
+func TestRenderString_DoesNotCacheImpureResults(t *testing.T) {
+	engine := NewEngine(&Context{OS: "linux", Env: map[string]string{}})
+
+	tmplStr := `{{ shuffle "abcdef" }}`
+	if _, err := engine.RenderString("shuffle", tmplStr); err != nil {
+		t.Fatalf("RenderString() error = %v", err)
+	}
+
+	key := cacheKey{name: "shuffle", text: tmplStr}
+	if _, ok := engine.cache.getResult(key); ok {
+		t.Error("result of impure template should not be cached")
+	}
+	if _, ok := engine.cache.getParsed(key); !ok {
+		t.Error("parsed impure template should still be cached")
+	}
+}
+
+func TestWithStrict_UsesSeparateCache(t *testing.T) {
+	engine := NewEngine(&Context{OS: "linux", Env: map[string]string{}})
+
+	tmplStr := "{{ .Env.MISSING }}"
+	if _, err := engine.RenderString("path", tmplStr); err != nil {
+		t.Fatalf("lenient RenderString() error = %v", err)
+	}
+
+	if _, err := engine.WithStrict(true).RenderString("path", tmplStr); err == nil {
+		t.Error("strict engine reused the lenient cached result")
+	}
+}
+
+func TestIsPure(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		want bool
+	}{
+		{"field access", "{{ .OS }}", true},
+		{"pure functions", `{{ toUpper .User | trim }}`, true},
+		{"if branch", `{{ if eq .OS "linux" }}a{{ else }}b{{ end }}`, true},
+		{"shuffle", `{{ shuffle "ab" }}`, false},
+		{"set in if body", `{{ if true }}{{ set .Env "A" "b" }}{{ end }}`, false},
+		{"unset in else", `{{ if false }}x{{ else }}{{ unset .Env "A" }}{{ end }}`, false},
+		{"impure in range", `{{ range (list 1) }}{{ shuffle "a" }}{{ end }}`, false},
+		{"impure in define", `{{ define "x" }}{{ shuffle "a" }}{{ end }}{{ template "x" }}`, false},
+	}
+
+	engine := NewEngine(&Context{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := engine.newTemplate(tt.name).Parse(tt.tmpl)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := isPure(parsed); got != tt.want {
+				t.Errorf("isPure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderString_ConcurrentUse(t *testing.T) {
+	engine := NewEngine(&Context{OS: "linux", Hostname: "desktop", Env: map[string]string{}})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+
+	for i := range 64 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			tmplStr := fmt.Sprintf("{{ .Hostname }}-%d", i%4)
+			got, err := engine.RenderString("path", tmplStr)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if want := fmt.Sprintf("desktop-%d", i%4); got != want {
+				errs <- fmt.Errorf("RenderString() = %q, want %q", got, want)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func BenchmarkRenderString_Cached(b *testing.B) {
+	engine := NewEngine(&Context{OS: "linux", Hostname: "desktop", Env: map[string]string{}})
+	tmplStr := `{{ and (eq .OS "linux") (ne .Hostname "server") }}`
+
+	b.ResetTimer()
+	for b.Loop() {
+		_, _ = engine.RenderString("when", tmplStr)
+	}
+}
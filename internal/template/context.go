@@ -17,6 +17,7 @@ type Context struct {
 	HasDisplay bool
 	IsWSL      bool
 	Env        map[string]string
+	Data       map[string]string
 }
 
 // NewContextFromPlatform creates a Context from platform detection results,
@@ -44,5 +45,6 @@ func NewContextFromPlatform(p *platform.Platform) *Context {
 		HasDisplay: p.HasDisplay,
 		IsWSL:      p.IsWSL,
 		Env:        env,
+		Data:       make(map[string]string),
 	}
 }
@@ -24,9 +24,13 @@ const (
 )
 
 // Engine renders Go templates with platform-aware context and sprout functions.
+// Parsed templates and pure render results are cached per engine, so the
+// Context must not be modified after the engine is created.
 type Engine struct {
 	ctx     *Context
 	funcMap template.FuncMap
+	cache   *renderCache
+	strict  bool
 }
 
 // NewEngine creates a template engine with sprout functions and the given context.
@@ -46,31 +50,75 @@ func NewEngine(ctx *Context) *Engine {
 	return &Engine{
 		ctx:     ctx,
 		funcMap: handler.Build(),
+		cache:   newRenderCache(),
 	}
 }
 
+// WithStrict returns a copy of the Engine that fails on missing map keys
+// (missingkey=error) instead of rendering "<no value>".
+func (e *Engine) WithStrict(strict bool) *Engine {
+	e2 := *e
+	e2.strict = strict
+	e2.cache = newRenderCache() // results differ between strict and lenient engines
+
+	return &e2
+}
+
+// newTemplate creates a named template with the engine's functions and options.
+func (e *Engine) newTemplate(name string) *template.Template {
+	t := template.New(name).Funcs(e.funcMap)
+	if e.strict {
+		t = t.Option("missingkey=error")
+	}
+
+	return t
+}
+
 // RenderString renders a template string. Returns input unchanged if no {{ delimiters are present.
+// Parsed templates are cached, and so are results of templates that only use pure functions.
 func (e *Engine) RenderString(name, tmplStr string) (string, error) {
 	if !strings.Contains(tmplStr, "{{") {
 		return tmplStr, nil
 	}
 
-	tmpl, err := template.New(name).Funcs(e.funcMap).Parse(tmplStr)
-	if err != nil {
-		return "", fmt.Errorf("parsing template %q: %w", name, err)
+	key := cacheKey{name: name, text: tmplStr}
+	if r, ok := e.cache.getResult(key); ok {
+		return r.output, r.err
+	}
+
+	tmpl, ok := e.cache.getParsed(key)
+	if !ok {
+		var err error
+
+		tmpl, err = e.newTemplate(name).Parse(tmplStr)
+		if err != nil {
+			err = fmt.Errorf("parsing template %q: %w", name, err)
+			e.cache.putResult(key, cachedResult{err: err})
+
+			return "", err
+		}
+
+		e.cache.putParsed(key, tmpl)
 	}
 
 	var buf bytes.Buffer
+	result := cachedResult{}
 	if err := tmpl.Execute(&buf, e.ctx); err != nil {
-		return "", fmt.Errorf("executing template %q: %w", name, err)
+		result.err = fmt.Errorf("executing template %q: %w", name, err)
+	} else {
+		result.output = buf.String()
+	}
+
+	if isPure(tmpl) {
+		e.cache.putResult(key, result)
 	}
 
-	return buf.String(), nil
+	return result.output, result.err
 }
 
 // RenderBytes renders a template from byte content.
 func (e *Engine) RenderBytes(name string, content []byte) ([]byte, error) {
-	tmpl, err := template.New(name).Funcs(e.funcMap).Parse(string(content))
+	tmpl, err := e.newTemplate(name).Parse(string(content))
 	if err != nil {
 		return nil, fmt.Errorf("parsing template %q: %w", name, err)
 	}
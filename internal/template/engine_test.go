@@ -169,6 +169,46 @@ func TestRenderBytes_Error(t *testing.T) {
 	}
 }
 
+func TestRenderString_Strict(t *testing.T) {
+	ctx := &Context{
+		OS:   "linux",
+		Env:  map[string]string{"HOME": "/home/me"},
+		Data: map[string]string{"theme": "dark"},
+	}
+	lenient := NewEngine(ctx)
+	strict := lenient.WithStrict(true)
+
+	tests := []struct {
+		name       string
+		template   string
+		want       string
+		wantStrict bool // strict engine should fail
+	}{
+		{name: "present env key", template: "{{ .Env.HOME }}", want: "/home/me"},
+		{name: "present data key", template: "{{ .Data.theme }}", want: "dark"},
+		{name: "missing env key", template: "{{ .Env.MISSING }}", want: "<no value>", wantStrict: true},
+		{name: "missing data key", template: "{{ .Data.font }}", want: "<no value>", wantStrict: true},
+		{name: "index missing key", template: `{{ index .Env "MISSING" }}`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lenient.RenderString(tt.name, tt.template)
+			if err != nil {
+				t.Fatalf("lenient RenderString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("lenient RenderString() = %q, want %q", got, tt.want)
+			}
+
+			_, err = strict.RenderString(tt.name, tt.template)
+			if (err != nil) != tt.wantStrict {
+				t.Errorf("strict RenderString() error = %v, wantErr %v", err, tt.wantStrict)
+			}
+		})
+	}
+}
+
 func TestIsTemplateFile(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/manager"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -101,28 +102,50 @@ func (m Model) renderSubEntryInlineDetail(_ *SubEntryItem, _ int) string {
 	return ""
 }
 
-// performRestoreSubEntry performs restore on a SubEntry
-func (m Model) performRestoreSubEntry(subEntry config.SubEntry, target string) (bool, string) {
-	if !subEntry.IsConfig() {
-		return false, "Not a config entry"
-	}
+// restoreSubEntries restores sub-entries through the manager as one run and
+// turns the events it reports into one result per item, in order. Result names
+// are the sub-entry names.
+func (m Model) restoreSubEntries(items []*SubEntryItem) []ResultItem {
+	results := make([]ResultItem, len(items))
+	refs := make([]manager.EntryRef, 0, len(items))
+	indexes := make([]int, 0, len(items))
 
-	backupPath := m.resolvePath(subEntry.Backup)
+	for i, item := range items {
+		results[i].Name = item.SubEntry.Name
 
-	var err error
-	if subEntry.IsFolder() {
-		if m.Manager.HasTemplateFiles(backupPath) {
-			err = m.Manager.RestoreFolderWithTemplates(subEntry, backupPath, target)
-		} else {
-			err = m.Manager.RestoreFolder(subEntry, backupPath, target)
+		if !item.SubEntry.IsConfig() {
+			results[i].Message = "Not a config entry"
+			continue
 		}
-	} else {
-		err = m.Manager.RestoreFiles(subEntry, backupPath, target)
+
+		refs = append(refs, manager.EntryRef{App: item.AppName, SubEntry: item.SubEntry, Target: item.Target})
+		indexes = append(indexes, i)
 	}
 
-	if err != nil {
-		return false, fmt.Sprintf("Failed: %v", err)
+	if len(refs) == 0 {
+		return results
+	}
+
+	// Entries are restored in order and each reports exactly one start event
+	current := -1
+	sink := manager.EventSinkFunc(func(ev manager.Event) {
+		switch e := ev.(type) {
+		case manager.EntryStarted:
+			current++
+		case manager.EntryDone:
+			results[indexes[current]].Success = true
+			results[indexes[current]].Message = fmt.Sprintf("Restored: %s → %s", e.Target, e.Backup)
+		case manager.EntryFailed:
+			results[indexes[current]].Message = fmt.Sprintf("Failed: %v", e.Err)
+		}
+	})
+
+	if err := m.Manager.WithEventSink(sink).RestoreEntries(refs); err != nil && current < len(refs)-1 {
+		// Cancelled before every entry ran
+		for _, idx := range indexes[current+1:] {
+			results[idx].Message = fmt.Sprintf("Failed: %v", err)
+		}
 	}
 
-	return true, fmt.Sprintf("Restored: %s → %s", target, backupPath)
+	return results
 }
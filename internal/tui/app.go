@@ -21,6 +21,12 @@ func Run(cfg *config.Config, plat *platform.Platform, dryRun bool, configPath st
 	}
 	defer func() { _ = mgr.Close() }()
 
+	if appCfg, err := config.LoadAppConfig(); err == nil {
+		if err := mgr.InitCipher(appCfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not initialize encryption: %v\n", err)
+		}
+	}
+
 	return RunWithManager(cfg, plat, mgr, configPath)
 }
 
@@ -82,35 +82,25 @@ func (m Model) executeBatchRestore() tea.Cmd {
 		}
 	}
 
-	// Execute restore operations sequentially
+	// Restore all items as one run through the manager
 	return func() tea.Msg {
-		results := make([]ResultItem, 0, len(items))
+		subItems := make([]*SubEntryItem, len(items))
+		for i, item := range items {
+			subItems[i] = &m.Applications[item.appIdx].SubItems[item.subIdx]
+		}
+
+		results := m.restoreSubEntries(subItems)
 		successCount := 0
 		failCount := 0
 
-		for _, item := range items {
-			// Get sub-entry data
-			subItem := &m.Applications[item.appIdx].SubItems[item.subIdx]
-
-			// Perform restore
-			success, message := m.performRestoreSubEntry(subItem.SubEntry, subItem.Target)
+		for i := range results {
+			results[i].Name = items[i].name
 
-			// Record result
-			results = append(results, ResultItem{
-				Name:    item.name,
-				Success: success,
-				Message: message,
-			})
-
-			if success {
+			if results[i].Success {
 				successCount++
 			} else {
 				failCount++
 			}
-
-			// Send progress update (not final message)
-			// Note: In a real implementation, we would send BatchOperationMsg here
-			// For now, we'll just collect results and send BatchCompleteMsg at the end
 		}
 
 		return BatchCompleteMsg{
@@ -171,6 +161,51 @@ type initBatchInstallMsg struct {
 	packages []PackageItem
 }
 
+// executeBatchUninstall uninstalls the packages of all selected apps that are
+// installed, one at a time.
+func (m Model) executeBatchUninstall() tea.Cmd {
+	pkgs := m.selectedInstalledPackages()
+
+	return func() tea.Msg {
+		return initBatchUninstallMsg{packages: pkgs}
+	}
+}
+
+// selectedInstalledPackages returns the packages of the selected apps that
+// are installed, in table order.
+func (m Model) selectedInstalledPackages() []PackageItem {
+	appIndices := make([]int, 0, len(m.selectedApps))
+	for appIdx := range m.selectedApps {
+		appIndices = append(appIndices, appIdx)
+	}
+	sort.Ints(appIndices)
+
+	var pkgs []PackageItem
+
+	for _, appIdx := range appIndices {
+		if appIdx < 0 || appIdx >= len(m.Applications) {
+			continue
+		}
+
+		app := m.Applications[appIdx]
+		if app.PkgInstalled != nil && *app.PkgInstalled && app.Application.HasPackage() {
+			pkgs = append(pkgs, PackageItem{
+				Name:     app.Application.Name,
+				Package:  app.Application.Package,
+				Method:   app.PkgMethod,
+				Selected: true,
+			})
+		}
+	}
+
+	return pkgs
+}
+
+// initBatchUninstallMsg is an internal message to start uninstalling packages.
+type initBatchUninstallMsg struct {
+	packages []PackageItem
+}
+
 // executeBatchDelete executes delete operations for all selected items.
 // Returns a command that processes deletions in reverse order to avoid index shifting.
 func (m Model) executeBatchDelete() tea.Cmd {
@@ -26,6 +26,8 @@ const (
 	PlaceholderInstallerLinux   = "e.g., curl ... | sh"
 	PlaceholderInstallerWindows = "e.g., winget install ..."
 	PlaceholderInstallerBinary  = "e.g., cargo"
+	PlaceholderFlatpakRemote    = "e.g., flathub"
+	PlaceholderSnapChannel      = "e.g., latest/stable"
 	IndentSpaces                = "    "
 	CheckboxUnchecked           = "[ ]"
 	CheckboxChecked             = "[✓]"
@@ -35,6 +37,8 @@ const (
 const (
 	TypeGit       = "git"
 	TypeInstaller = "installer"
+	TypeFlatpak   = "flatpak"
+	TypeSnap      = "snap"
 	TypeFolder    = "folder"
 	TypeNone      = "none"
 )
@@ -43,6 +47,7 @@ const (
 const (
 	StatusInstalled = "Installed"
 	StatusMissing   = "Missing"
+	StatusUpdate    = "Update"
 	StatusFiltered  = "Filtered"
 	StatusOutdated  = "Outdated"
 	StatusModified  = "Modified"
@@ -87,3 +92,10 @@ const (
 	InstallerFieldBinary  = 2
 	InstallerFieldCount   = 3
 )
+
+// Flatpak and snap option field indices within their sub-sections
+const (
+	OptionFieldText   = 0 // flatpak remote, snap channel
+	OptionFieldToggle = 1 // flatpak scope, snap classic confinement
+	OptionFieldCount  = 2
+)
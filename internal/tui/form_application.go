@@ -46,6 +46,7 @@ func (m *Model) initApplicationFormNew() {
 
 	gitURLInput, gitBranchInput, gitLinuxInput, gitWindowsInput := newGitTextInputs()
 	installerLinuxInput, installerWindowsInput, installerBinaryInput := newInstallerTextInputs()
+	flatpakRemoteInput, snapChannelInput := newOptionTextInputs()
 
 	m.applicationForm = &ApplicationForm{
 		nameInput:             nameInput,
@@ -73,6 +74,9 @@ func (m *Model) initApplicationFormNew() {
 		installerBinaryInput:  installerBinaryInput,
 		installerFieldCursor:  -1,
 		hasInstallerPackage:   false,
+		flatpakRemoteInput:    flatpakRemoteInput,
+		snapChannelInput:      snapChannelInput,
+		optionFieldCursor:     -1,
 	}
 
 	m.activeForm = FormApplication
@@ -121,6 +125,7 @@ func (m *Model) initApplicationFormEdit(appIdx int) {
 
 	gitURLInput, gitBranchInput, gitLinuxInput, gitWindowsInput := newGitTextInputs()
 	installerLinuxInput, installerWindowsInput, installerBinaryInput := newInstallerTextInputs()
+	flatpakRemoteInput, snapChannelInput := newOptionTextInputs()
 
 	// Load package managers (only string-based managers, skip git and installer)
 	packageManagers := make(map[string]string)
@@ -197,7 +202,11 @@ func (m *Model) initApplicationFormEdit(appIdx int) {
 		installerBinaryInput:  installerBinaryInput,
 		installerFieldCursor:  -1,
 		hasInstallerPackage:   hasInstallerPackage,
+		flatpakRemoteInput:    flatpakRemoteInput,
+		snapChannelInput:      snapChannelInput,
+		optionFieldCursor:     -1,
 	}
+	m.applicationForm.loadPackageOptions(app.Package)
 
 	m.activeForm = FormApplication
 	m.Screen = ScreenAddForm
@@ -239,6 +248,11 @@ func (m Model) updateApplicationForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.updateApplicationInstallerFieldInput(msg)
 	}
 
+	// Handle editing a flatpak or snap option text field
+	if m.applicationForm.editingOptionField {
+		return m.updateApplicationOptionFieldInput(msg)
+	}
+
 	// Handle editing a text field
 	if m.applicationForm.editingField {
 		return m.updateApplicationFieldInput(msg)
@@ -262,6 +276,9 @@ func (m Model) updateApplicationForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.applicationForm.packagesCursor == len(displayPackageManagers)+1 && m.applicationForm.installerFieldCursor >= 0 {
 			return m.updateApplicationInstallerFields(msg)
 		}
+		if m.applicationForm.optionFieldCursor >= 0 && m.optionManager() != "" {
+			return m.updateApplicationOptionFields(msg)
+		}
 		return m.updateApplicationPackagesList(msg)
 	}
 
@@ -294,6 +311,7 @@ func (m Model) updateApplicationForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.applicationForm.packagesCursor = len(displayPackageManagers) + 1
 			m.applicationForm.gitFieldCursor = -1
 			m.applicationForm.installerFieldCursor = -1
+			m.applicationForm.optionFieldCursor = -1
 		}
 		m.updateApplicationFormFocus()
 		return m, nil
@@ -315,6 +333,7 @@ func (m Model) updateApplicationForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.applicationForm.packagesCursor = len(displayPackageManagers) + 1
 			m.applicationForm.gitFieldCursor = -1
 			m.applicationForm.installerFieldCursor = -1
+			m.applicationForm.optionFieldCursor = -1
 		}
 		m.updateApplicationFormFocus()
 		return m, nil
@@ -422,6 +441,7 @@ func (m Model) updateApplicationPackagesList(msg tea.KeyMsg) (tea.Model, tea.Cmd
 			// Reset field cursors when moving between items
 			m.applicationForm.gitFieldCursor = -1
 			m.applicationForm.installerFieldCursor = -1
+			m.applicationForm.optionFieldCursor = -1
 		} else {
 			// Move to previous field
 			m.applicationForm.focusIndex--
@@ -431,6 +451,9 @@ func (m Model) updateApplicationPackagesList(msg tea.KeyMsg) (tea.Model, tea.Cmd
 
 	case key.Matches(msg, FormNavKeys.Down):
 		switch {
+		case m.optionManager() != "" && m.applicationForm.optionFieldCursor == -1:
+			// Enter the flatpak or snap option sub-fields
+			m.applicationForm.optionFieldCursor = 0
 		case m.applicationForm.packagesCursor < maxCursor:
 			// Moving to next item - handle git sub-field entry
 			if m.applicationForm.packagesCursor == gitItemIdx && m.applicationForm.hasGitPackage && m.applicationForm.gitFieldCursor == -1 {
@@ -440,6 +463,7 @@ func (m Model) updateApplicationPackagesList(msg tea.KeyMsg) (tea.Model, tea.Cmd
 			m.applicationForm.packagesCursor++
 			m.applicationForm.gitFieldCursor = -1
 			m.applicationForm.installerFieldCursor = -1
+			m.applicationForm.optionFieldCursor = -1
 		case m.applicationForm.packagesCursor == installerItemIdx && m.applicationForm.hasInstallerPackage && m.applicationForm.installerFieldCursor == -1:
 			// Enter installer sub-fields (handled separately since installer is the last item, so packagesCursor == maxCursor)
 			m.applicationForm.installerFieldCursor = 0
@@ -452,6 +476,7 @@ func (m Model) updateApplicationPackagesList(msg tea.KeyMsg) (tea.Model, tea.Cmd
 			m.applicationForm.packagesCursor = 0
 			m.applicationForm.gitFieldCursor = -1
 			m.applicationForm.installerFieldCursor = -1
+			m.applicationForm.optionFieldCursor = -1
 			m.updateApplicationFormFocus()
 		}
 		return m, nil
@@ -464,6 +489,7 @@ func (m Model) updateApplicationPackagesList(msg tea.KeyMsg) (tea.Model, tea.Cmd
 		m.applicationForm.packagesCursor = 0
 		m.applicationForm.gitFieldCursor = -1
 		m.applicationForm.installerFieldCursor = -1
+		m.applicationForm.optionFieldCursor = -1
 		m.updateApplicationFormFocus()
 		return m, nil
 
@@ -471,6 +497,7 @@ func (m Model) updateApplicationPackagesList(msg tea.KeyMsg) (tea.Model, tea.Cmd
 		m.applicationForm.focusIndex--
 		m.applicationForm.gitFieldCursor = -1
 		m.applicationForm.installerFieldCursor = -1
+		m.applicationForm.optionFieldCursor = -1
 		m.updateApplicationFormFocus()
 		return m, nil
 
@@ -550,6 +577,7 @@ func (m Model) handlePackagesListDelete(gitItemIdx, installerItemIdx int) (tea.M
 	if m.applicationForm.packagesCursor == installerItemIdx && m.applicationForm.installerFieldCursor == -1 {
 		m.applicationForm.hasInstallerPackage = false
 		m.applicationForm.installerFieldCursor = -1
+		m.applicationForm.optionFieldCursor = -1
 		m.applicationForm.installerLinuxInput.SetValue("")
 		m.applicationForm.installerWindowsInput.SetValue("")
 		m.applicationForm.installerBinaryInput.SetValue("")
@@ -562,6 +590,7 @@ func (m Model) handlePackagesListDelete(gitItemIdx, installerItemIdx int) (tea.M
 	}
 	manager := displayPackageManagers[m.applicationForm.packagesCursor]
 	delete(m.applicationForm.packageManagers, manager)
+	m.clearPackageOptions(manager)
 	m.applicationForm.err = ""
 	return m, nil
 }
@@ -601,6 +630,7 @@ func (m Model) updateApplicationPackageInput(msg tea.KeyMsg) (tea.Model, tea.Cmd
 		} else {
 			// Clear if empty
 			delete(m.applicationForm.packageManagers, manager)
+			m.clearPackageOptions(manager)
 		}
 
 		m.applicationForm.editingPackage = false
@@ -686,6 +716,18 @@ func (m Model) viewApplicationForm() string {
 		m.applicationForm.packagesCursor,
 		m.applicationForm.editingPackage,
 		m.applicationForm.packageNameInput,
+		func(i int, manager string) string {
+			return renderPackageOptionsSection(
+				manager,
+				ft == appFieldPackages && m.applicationForm.packagesCursor == i && m.applicationForm.optionFieldCursor >= 0,
+				m.applicationForm.optionFieldCursor,
+				m.applicationForm.editingOptionField,
+				m.applicationForm.flatpakRemoteInput,
+				m.applicationForm.flatpakScope,
+				m.applicationForm.snapChannelInput,
+				m.applicationForm.snapClassic,
+			)
+		},
 	))
 	onGitItem := ft == appFieldPackages && m.applicationForm.packagesCursor == len(displayPackageManagers)
 	b.WriteString(renderGitPackageSection(
@@ -783,7 +825,7 @@ func (m Model) renderApplicationFormHelp() string {
 
 	ft := m.getApplicationFieldType()
 
-	if m.applicationForm.editingGitField || m.applicationForm.editingInstallerField {
+	if m.applicationForm.editingGitField || m.applicationForm.editingInstallerField || m.applicationForm.editingOptionField {
 		return RenderHelpFromBindings(m.width,
 			TextEditKeys.Confirm,
 			TextEditKeys.SaveForm,
@@ -841,6 +883,13 @@ func (m Model) renderApplicationFormHelp() string {
 			}
 			return RenderHelpFromBindings(m.width, FormNavKeys.Edit, FormNavKeys.Save)
 		}
+		// Flatpak and snap option states
+		if m.applicationForm.optionFieldCursor >= 0 && m.optionManager() != "" {
+			if m.applicationForm.optionFieldCursor == OptionFieldToggle {
+				return RenderHelpFromBindings(m.width, FormNavKeys.Toggle, FormNavKeys.Save)
+			}
+			return RenderHelpFromBindings(m.width, FormNavKeys.Edit, FormNavKeys.Save)
+		}
 		// Bounds check for packagesCursor
 		if m.applicationForm.packagesCursor >= 0 && m.applicationForm.packagesCursor < len(displayPackageManagers) {
 			manager := displayPackageManagers[m.applicationForm.packagesCursor]
@@ -889,6 +938,13 @@ func (m *Model) saveApplicationForm() error {
 	// Build when expression and package
 	when := strings.TrimSpace(m.applicationForm.whenInput.Value())
 	pkg := buildPackageSpec(m.applicationForm.packageManagers)
+	pkg = mergePackageOptions(
+		pkg,
+		m.applicationForm.flatpakRemoteInput,
+		m.applicationForm.flatpakScope,
+		m.applicationForm.snapChannelInput,
+		m.applicationForm.snapClassic,
+	)
 
 	// Merge git package data
 	pkg = mergeGitPackage(
@@ -1036,6 +1092,7 @@ func NewApplicationForm(app config.Application, isEdit bool) *ApplicationForm {
 
 	gitURLInput, gitBranchInput, gitLinuxInput, gitWindowsInput := newGitTextInputs()
 	installerLinuxInput, installerWindowsInput, installerBinaryInput := newInstallerTextInputs()
+	flatpakRemoteInput, snapChannelInput := newOptionTextInputs()
 
 	// Load package managers (only string-based managers, skip git and installer)
 	packageManagers := make(map[string]string)
@@ -1086,7 +1143,7 @@ func NewApplicationForm(app config.Application, isEdit bool) *ApplicationForm {
 		}
 	}
 
-	return &ApplicationForm{
+	form := &ApplicationForm{
 		nameInput:             nameInput,
 		descriptionInput:      descriptionInput,
 		whenInput:             whenInput,
@@ -1104,7 +1161,13 @@ func NewApplicationForm(app config.Application, isEdit bool) *ApplicationForm {
 		installerBinaryInput:  installerBinaryInput,
 		installerFieldCursor:  -1,
 		hasInstallerPackage:   hasInstallerPackage,
+		flatpakRemoteInput:    flatpakRemoteInput,
+		snapChannelInput:      snapChannelInput,
+		optionFieldCursor:     -1,
 	}
+	form.loadPackageOptions(app.Package)
+
+	return form
 }
 
 // Validate checks if the ApplicationForm has valid data
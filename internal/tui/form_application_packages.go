@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -289,3 +290,184 @@ func (m *Model) getInstallerFieldInput() *textinput.Model {
 		return nil
 	}
 }
+
+// optionManager returns the manager at packagesCursor when it has option
+// sub-fields to show, that is a flatpak or snap row with a package name.
+func (m *Model) optionManager() string {
+	if m.applicationForm == nil || m.applicationForm.packagesCursor < 0 || m.applicationForm.packagesCursor >= len(displayPackageManagers) {
+		return ""
+	}
+
+	manager := displayPackageManagers[m.applicationForm.packagesCursor]
+	if !hasPackageOptions(manager) || m.applicationForm.packageManagers[manager] == "" {
+		return ""
+	}
+
+	return manager
+}
+
+// updateApplicationOptionFields handles navigation within flatpak and snap option sub-fields (optionFieldCursor >= 0)
+func (m Model) updateApplicationOptionFields(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.applicationForm == nil {
+		return m, nil
+	}
+
+	if m, cmd, handled := m.handleCommonKeys(msg); handled {
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(msg, FormNavKeys.Cancel):
+		m.activeForm = FormNone
+		m.applicationForm = nil
+		m.Screen = ScreenResults
+		return m, nil
+
+	case key.Matches(msg, FormNavKeys.Up):
+		// Back to the manager row when leaving the first sub-field
+		m.applicationForm.optionFieldCursor--
+		return m, nil
+
+	case key.Matches(msg, FormNavKeys.Down):
+		if m.applicationForm.optionFieldCursor < OptionFieldCount-1 {
+			m.applicationForm.optionFieldCursor++
+		} else {
+			// Move to the next manager row
+			m.applicationForm.packagesCursor++
+			m.applicationForm.optionFieldCursor = -1
+		}
+		return m, nil
+
+	case key.Matches(msg, FormNavKeys.Edit):
+		if m.applicationForm.optionFieldCursor == OptionFieldToggle {
+			m.toggleOptionField()
+			return m, nil
+		}
+		input := m.getOptionFieldInput()
+		if input != nil {
+			m.applicationForm.editingOptionField = true
+			m.applicationForm.originalValue = input.Value()
+			input.Focus()
+			input.SetCursor(len(input.Value()))
+		}
+		return m, nil
+
+	case key.Matches(msg, FormNavKeys.Toggle):
+		if m.applicationForm.optionFieldCursor == OptionFieldToggle {
+			m.toggleOptionField()
+		}
+		return m, nil
+
+	case key.Matches(msg, FormNavKeys.TabNext):
+		m.applicationForm.focusIndex++
+		if m.applicationForm.focusIndex > 3 {
+			m.applicationForm.focusIndex = 0
+		}
+		m.applicationForm.packagesCursor = 0
+		m.applicationForm.optionFieldCursor = -1
+		m.updateApplicationFormFocus()
+		return m, nil
+
+	case key.Matches(msg, FormNavKeys.TabPrev):
+		m.applicationForm.focusIndex--
+		m.applicationForm.optionFieldCursor = -1
+		m.updateApplicationFormFocus()
+		return m, nil
+
+	case key.Matches(msg, FormNavKeys.Save):
+		if err := m.saveApplicationForm(); err != nil {
+			m.applicationForm.err = err.Error()
+			return m, nil
+		}
+		m.activeForm = FormNone
+		m.applicationForm = nil
+		m.Screen = ScreenResults
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// updateApplicationOptionFieldInput handles text input when editing a flatpak remote or snap channel
+func (m Model) updateApplicationOptionFieldInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.applicationForm == nil {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+
+	if m, cmd, handled := m.handleTextEditKeys(msg); handled {
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(msg, TextEditKeys.Cancel):
+		// Restore original value and exit edit mode
+		input := m.getOptionFieldInput()
+		if input != nil {
+			input.SetValue(m.applicationForm.originalValue)
+		}
+		m.applicationForm.editingOptionField = false
+		return m, nil
+
+	case key.Matches(msg, TextEditKeys.Confirm) || key.Matches(msg, TextEditKeys.SaveForm):
+		// Save current value and exit edit mode
+		m.applicationForm.editingOptionField = false
+		return m, nil
+	}
+
+	// Pass to the focused text input
+	input := m.getOptionFieldInput()
+	if input != nil {
+		*input, cmd = input.Update(msg)
+	}
+
+	m.applicationForm.err = ""
+	return m, cmd
+}
+
+// getOptionFieldInput returns a pointer to the option text input of the current manager row
+func (m *Model) getOptionFieldInput() *textinput.Model {
+	if m.applicationForm == nil || m.applicationForm.optionFieldCursor != OptionFieldText {
+		return nil
+	}
+
+	switch m.optionManager() {
+	case TypeFlatpak:
+		return &m.applicationForm.flatpakRemoteInput
+	case TypeSnap:
+		return &m.applicationForm.snapChannelInput
+	default:
+		return nil
+	}
+}
+
+// toggleOptionField cycles the flatpak scope or flips snap classic confinement
+func (m *Model) toggleOptionField() {
+	switch m.optionManager() {
+	case TypeFlatpak:
+		switch m.applicationForm.flatpakScope {
+		case "":
+			m.applicationForm.flatpakScope = config.FlatpakScopeUser
+		case config.FlatpakScopeUser:
+			m.applicationForm.flatpakScope = config.FlatpakScopeSystem
+		default:
+			m.applicationForm.flatpakScope = ""
+		}
+	case TypeSnap:
+		m.applicationForm.snapClassic = !m.applicationForm.snapClassic
+	}
+}
+
+// clearPackageOptions resets the option fields of a manager whose package name was removed
+func (m *Model) clearPackageOptions(manager string) {
+	switch manager {
+	case TypeFlatpak:
+		m.applicationForm.flatpakRemoteInput.SetValue("")
+		m.applicationForm.flatpakScope = ""
+	case TypeSnap:
+		m.applicationForm.snapChannelInput.SetValue("")
+		m.applicationForm.snapClassic = false
+	}
+	m.applicationForm.optionFieldCursor = -1
+}
@@ -66,18 +66,57 @@ func newInstallerTextInputs() (installerLinuxInput, installerWindowsInput, insta
 	return installerLinuxInput, installerWindowsInput, installerBinaryInput
 }
 
+// newOptionTextInputs creates the flatpak remote and snap channel text inputs
+func newOptionTextInputs() (flatpakRemoteInput, snapChannelInput textinput.Model) {
+	flatpakRemoteInput = textinput.New()
+	flatpakRemoteInput.Placeholder = PlaceholderFlatpakRemote
+	flatpakRemoteInput.CharLimit = 128
+	flatpakRemoteInput.Width = 40
+
+	snapChannelInput = textinput.New()
+	snapChannelInput.Placeholder = PlaceholderSnapChannel
+	snapChannelInput.CharLimit = 128
+	snapChannelInput.Width = 40
+
+	return flatpakRemoteInput, snapChannelInput
+}
+
+// hasPackageOptions returns true for managers whose rows have option sub-fields
+func hasPackageOptions(manager string) bool {
+	return manager == TypeFlatpak || manager == TypeSnap
+}
+
+// loadPackageOptions fills the flatpak and snap option fields from a package
+func (f *ApplicationForm) loadPackageOptions(pkg *config.EntryPackage) {
+	if pkg == nil {
+		return
+	}
+
+	if v := pkg.Managers[TypeFlatpak]; v.Flatpak != nil {
+		f.flatpakRemoteInput.SetValue(v.Flatpak.Remote)
+		f.flatpakScope = v.Flatpak.Scope
+	}
+
+	if v := pkg.Managers[TypeSnap]; v.Snap != nil {
+		f.snapChannelInput.SetValue(v.Snap.Channel)
+		f.snapClassic = v.Snap.Classic
+	}
+}
+
 // renderPackagesSection renders the packages list with editing state
 // focused indicates if the packages section is currently focused
 // packageManagers is the map of manager -> package name
 // packagesCursor is the current cursor position within the list
 // editingPackage indicates if currently editing a package name
 // packageNameInput is the text input for editing package name
+// options renders the option sub-fields under a manager row, if any
 func renderPackagesSection(
 	focused bool,
 	packageManagers map[string]string,
 	packagesCursor int,
 	editingPackage bool,
 	packageNameInput textinput.Model,
+	options func(i int, manager string) string,
 ) string {
 	var b strings.Builder
 
@@ -104,6 +143,56 @@ func renderPackagesSection(
 				b.WriteString(fmt.Sprintf("%s%-8s %s\n", prefix, manager+":", MutedTextStyle.Render("(not set)")))
 			}
 		}
+
+		if options != nil && pkgName != "" {
+			b.WriteString(options(i, manager))
+		}
+	}
+
+	return b.String()
+}
+
+// renderPackageOptionsSection renders the option sub-fields of a flatpak or snap row
+// onSubFields indicates if the cursor is within this row's sub-fields
+func renderPackageOptionsSection(
+	manager string,
+	onSubFields bool,
+	optionFieldCursor int,
+	editingOptionField bool,
+	flatpakRemoteInput textinput.Model,
+	flatpakScope string,
+	snapChannelInput textinput.Model,
+	snapClassic bool,
+) string {
+	var b strings.Builder
+	subPrefix := IndentSpaces + "  "
+	editingText := editingOptionField && optionFieldCursor == OptionFieldText
+
+	var toggleLabel, toggleText string
+
+	switch manager {
+	case TypeFlatpak:
+		b.WriteString(renderGitField("Remote:  ", flatpakRemoteInput, onSubFields, optionFieldCursor == OptionFieldText, editingText))
+		toggleLabel = "Scope:   "
+		toggleText = flatpakScope
+		if toggleText == "" {
+			toggleText = "(default)"
+		}
+	case TypeSnap:
+		b.WriteString(renderGitField("Channel: ", snapChannelInput, onSubFields, optionFieldCursor == OptionFieldText, editingText))
+		toggleLabel = "Classic: "
+		toggleText = CheckboxUnchecked + " No"
+		if snapClassic {
+			toggleText = CheckboxChecked + " Yes"
+		}
+	default:
+		return ""
+	}
+
+	if onSubFields && optionFieldCursor == OptionFieldToggle {
+		b.WriteString(fmt.Sprintf("%s%s%s\n", subPrefix, toggleLabel, SelectedMenuItemStyle.Render(toggleText)))
+	} else {
+		b.WriteString(fmt.Sprintf("%s%s%s\n", subPrefix, toggleLabel, toggleText))
 	}
 
 	return b.String()
@@ -277,6 +366,37 @@ func buildPackageSpec(managers map[string]string) *config.EntryPackage {
 	}
 }
 
+// mergePackageOptions adds the flatpak and snap options to their managers in pkg
+func mergePackageOptions(
+	pkg *config.EntryPackage,
+	flatpakRemoteInput textinput.Model,
+	flatpakScope string,
+	snapChannelInput textinput.Model,
+	snapClassic bool,
+) *config.EntryPackage {
+	if pkg == nil {
+		return nil
+	}
+
+	if v, ok := pkg.Managers[TypeFlatpak]; ok {
+		opts := config.FlatpakOptions{Remote: strings.TrimSpace(flatpakRemoteInput.Value()), Scope: flatpakScope}
+		if !opts.IsZero() {
+			v.Flatpak = &opts
+			pkg.Managers[TypeFlatpak] = v
+		}
+	}
+
+	if v, ok := pkg.Managers[TypeSnap]; ok {
+		opts := config.SnapOptions{Channel: strings.TrimSpace(snapChannelInput.Value()), Classic: snapClassic}
+		if !opts.IsZero() {
+			v.Snap = &opts
+			pkg.Managers[TypeSnap] = v
+		}
+	}
+
+	return pkg
+}
+
 // mergeGitPackage merges git package data into an existing EntryPackage
 func mergeGitPackage(
 	pkg *config.EntryPackage,
@@ -322,6 +442,20 @@ func mergeGitPackage(
 	return pkg
 }
 
+// keepSelectorTargets copies the target keys the forms cannot edit, such as
+// distro, host and wsl keys, from existing into targets.
+func keepSelectorTargets(targets, existing map[string]string) {
+	for key, target := range existing {
+		if key == OSLinux || key == OSWindows {
+			continue
+		}
+
+		if _, ok := targets[key]; !ok {
+			targets[key] = target
+		}
+	}
+}
+
 // mergeInstallerPackage merges installer package data into an existing EntryPackage
 func mergeInstallerPackage(
 	pkg *config.EntryPackage,
@@ -398,6 +532,25 @@ func (m *Model) saveEditedApplication(appIdx int, name, description, when string
 		}
 	}
 
+	// Keep git target keys, installer uninstall commands and version
+	// constraints the form does not edit
+	if pkg != nil && app.Package != nil {
+		for mgr, val := range pkg.Managers {
+			if old, ok := app.Package.Managers[mgr]; ok && old.PackageName == val.PackageName {
+				val.Version = old.Version
+				pkg.Managers[mgr] = val
+			}
+		}
+
+		if git, old := pkg.Managers[TypeGit], app.Package.Managers[TypeGit]; git.IsGit() && old.IsGit() {
+			keepSelectorTargets(git.Git.Targets, old.Git.Targets)
+		}
+
+		if inst, old := pkg.Managers[TypeInstaller], app.Package.Managers[TypeInstaller]; inst.IsInstaller() && old.IsInstaller() {
+			inst.Installer.Uninstall = old.Installer.Uninstall
+		}
+	}
+
 	// Update Application metadata
 	app.Name = name
 	app.Description = description
@@ -1167,6 +1167,16 @@ func (m *Model) updateSubEntry(appIdx, subIdx int, subEntry config.SubEntry) err
 		}
 	}
 
+	// Keep the fields the form does not edit
+	existing := app.Entries[subIdx]
+	subEntry.Encrypted = existing.Encrypted
+	keepSelectorTargets(subEntry.Targets, existing.Targets)
+	subEntry.Permissions = existing.Permissions
+	subEntry.FilePermissions = existing.FilePermissions
+	if subEntry.IsFolder() {
+		subEntry.Ignore = existing.Ignore
+	}
+
 	// Update SubEntry
 	app.Entries[subIdx] = subEntry
 
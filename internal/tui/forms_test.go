@@ -1,9 +1,11 @@
 package tui
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/charmbracelet/bubbles/textinput"
 )
 
 func TestApplicationForm_Validation(t *testing.T) {
@@ -570,6 +572,71 @@ func TestSaveApplicationForm_InstallerPackageMerge(t *testing.T) {
 	})
 }
 
+func TestApplicationForm_PackageOptions(t *testing.T) {
+	app := config.Application{
+		Name: "code",
+		Package: &config.EntryPackage{
+			Managers: map[string]config.ManagerValue{
+				"flatpak": {PackageName: "com.visualstudio.code", Flatpak: &config.FlatpakOptions{Remote: "flathub", Scope: "user"}},
+				"snap":    {PackageName: "code", Snap: &config.SnapOptions{Classic: true}},
+			},
+		},
+	}
+	form := NewApplicationForm(app, true)
+
+	if form.flatpakRemoteInput.Value() != "flathub" || form.flatpakScope != "user" {
+		t.Errorf("flatpak options = %q, %q, want flathub, user", form.flatpakRemoteInput.Value(), form.flatpakScope)
+	}
+	if !form.snapClassic {
+		t.Error("snapClassic = false, want true")
+	}
+	if form.packageManagers["flatpak"] != "com.visualstudio.code" || form.packageManagers["snap"] != "code" {
+		t.Errorf("packageManagers = %v", form.packageManagers)
+	}
+
+	form.snapChannelInput.SetValue("latest/edge")
+	pkg := mergePackageOptions(buildPackageSpec(form.packageManagers),
+		form.flatpakRemoteInput, form.flatpakScope, form.snapChannelInput, form.snapClassic)
+
+	if got := pkg.Managers["flatpak"].Flatpak; got == nil || *got != (config.FlatpakOptions{Remote: "flathub", Scope: "user"}) {
+		t.Errorf("merged flatpak options = %+v", got)
+	}
+	if got := pkg.Managers["snap"].Snap; got == nil || *got != (config.SnapOptions{Channel: "latest/edge", Classic: true}) {
+		t.Errorf("merged snap options = %+v", got)
+	}
+
+	// Managers without options stay plain names
+	plain := mergePackageOptions(buildPackageSpec(map[string]string{"flatpak": "org.gimp.GIMP"}),
+		textinput.New(), "", textinput.New(), false)
+	if plain.Managers["flatpak"].Flatpak != nil {
+		t.Errorf("flatpak options = %+v, want nil", plain.Managers["flatpak"].Flatpak)
+	}
+}
+
+func TestApplicationForm_FlatpakScopeToggle(t *testing.T) {
+	m := Model{applicationForm: NewApplicationForm(config.Application{Name: "gimp"}, false)}
+	m.applicationForm.packageManagers["flatpak"] = "org.gimp.GIMP"
+	for i, manager := range displayPackageManagers {
+		if manager == "flatpak" {
+			m.applicationForm.packagesCursor = i
+		}
+	}
+	m.applicationForm.optionFieldCursor = OptionFieldToggle
+
+	for _, want := range []string{"user", "system", ""} {
+		m.toggleOptionField()
+		if m.applicationForm.flatpakScope != want {
+			t.Errorf("flatpakScope = %q, want %q", m.applicationForm.flatpakScope, want)
+		}
+	}
+
+	view := renderPackageOptionsSection("flatpak", true, OptionFieldToggle, false,
+		m.applicationForm.flatpakRemoteInput, "system", m.applicationForm.snapChannelInput, false)
+	if !strings.Contains(view, "Remote:") || !strings.Contains(view, "system") {
+		t.Errorf("renderPackageOptionsSection() = %q, want remote and scope", view)
+	}
+}
+
 func TestBuildPackageSpec(t *testing.T) {
 	t.Run("empty_managers", func(t *testing.T) {
 		result := buildPackageSpec(map[string]string{})
@@ -37,6 +37,7 @@ type ListKeyMap struct {
 	Delete       key.Binding
 	Restore      key.Binding
 	Install      key.Binding
+	Uninstall    key.Binding
 	Toggle       key.Binding
 	ShowDetail   key.Binding
 	NewOperation key.Binding
@@ -105,6 +106,10 @@ var ListKeys = ListKeyMap{
 		key.WithKeys("i"),
 		key.WithHelp("i", "install"),
 	),
+	Uninstall: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "uninstall"),
+	),
 	Toggle: key.NewBinding(
 		key.WithKeys("tab", " "),
 		key.WithHelp("tab", "toggle"),
@@ -125,11 +130,12 @@ var ListKeys = ListKeyMap{
 
 // MultiSelectKeyMap defines keybindings for the multi-select mode.
 type MultiSelectKeyMap struct {
-	Toggle  key.Binding
-	Clear   key.Binding
-	Restore key.Binding
-	Install key.Binding
-	Delete  key.Binding
+	Toggle    key.Binding
+	Clear     key.Binding
+	Restore   key.Binding
+	Install   key.Binding
+	Uninstall key.Binding
+	Delete    key.Binding
 }
 
 // MultiSelectKeys are the keybindings for multi-select mode.
@@ -149,6 +155,10 @@ var MultiSelectKeys = MultiSelectKeyMap{
 		key.WithKeys("i"),
 		key.WithHelp("i", "install"),
 	),
+	Uninstall: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "uninstall"),
+	),
 	Delete: key.NewBinding(
 		key.WithKeys("d"),
 		key.WithHelp("d", "delete"),
@@ -1,11 +1,15 @@
 package tui
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 
 	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/encryption"
+	"github.com/AntoineGS/tidydots/internal/ignore"
 	"github.com/AntoineGS/tidydots/internal/manager"
+	"github.com/AntoineGS/tidydots/internal/packages"
 	"github.com/AntoineGS/tidydots/internal/platform"
 	tmpl "github.com/AntoineGS/tidydots/internal/template"
 	"github.com/charmbracelet/bubbles/filepicker"
@@ -44,6 +48,8 @@ const (
 	OpInstallPackages
 	// OpDelete is the delete entries operation
 	OpDelete
+	// OpUninstallPackages is the uninstall packages operation
+	OpUninstallPackages
 )
 
 func (o Operation) String() string {
@@ -56,6 +62,8 @@ func (o Operation) String() string {
 		return "Install Packages"
 	case OpDelete:
 		return "Delete"
+	case OpUninstallPackages:
+		return "Uninstall Packages"
 	}
 
 	return "Unknown"
@@ -150,6 +158,14 @@ type ApplicationForm struct {
 	installerFieldCursor  int  // -1 = on installer label/button, 0-2 = on sub-fields
 	editingInstallerField bool // true when editing an installer text field
 	hasInstallerPackage   bool // true when installer package is configured/expanded
+
+	// Flatpak and snap option fields, shown under their manager rows once a package name is set
+	flatpakRemoteInput textinput.Model
+	snapChannelInput   textinput.Model
+	flatpakScope       string // "", "user" or "system"
+	snapClassic        bool   // install the snap with classic confinement
+	optionFieldCursor  int    // -1 = on the manager row, 0-1 = on its option sub-fields
+	editingOptionField bool   // true when editing an option text field
 }
 
 // SubEntryForm holds state for editing SubEntry data
@@ -196,6 +212,7 @@ type Model struct {
 	searchText               string
 	ConfigPath               string
 	pendingPackages          []PackageItem
+	templateErrors           []error // Template diagnostics for when/backup/target fields
 	results                  []ResultItem
 	Applications             []ApplicationItem
 	searchInput              textinput.Model
@@ -207,6 +224,7 @@ type Model struct {
 	height                   int
 	width                    int
 	currentPackageIndex      int
+	unbatchedUntil           int // pending packages before this index install one by one, after their batch failed
 	Operation                Operation
 	scrollOffset             int
 	Screen                   Screen
@@ -216,6 +234,7 @@ type Model struct {
 	searching                bool
 	confirmingDeleteSubEntry bool
 	confirmingDeleteApp      bool
+	confirmingUninstall      bool // true when asking to uninstall the package of the app at the cursor
 	confirmingFilterToggle   bool // true when showing filter toggle confirmation
 	filterToggleHiddenCount  int  // count of selections that would be hidden
 	showingDetail            bool
@@ -245,6 +264,9 @@ type Model struct {
 	batchCurrentIndex int            // Current item index (0-based)
 	batchSuccessCount int            // Count of successful operations
 	batchFailCount    int            // Count of failed operations
+	downloadItem      string         // Package whose URL install is downloading; "" when none
+	downloadReceived  int64          // Bytes of the download received so far
+	downloadTotal     int64          // Size of the download, or -1 when unknown
 }
 
 // PackageItem represents a package to be installed, including its name,
@@ -261,6 +283,8 @@ type ApplicationItem struct {
 	Application  config.Application
 	PkgInstalled *bool
 	PkgMethod    string
+	PkgOutdated  bool   // True if the package manager offers a newer version
+	PkgVersions  string // "installed → available" when PkgOutdated
 	SubItems     []SubEntryItem
 	Selected     bool
 	Expanded     bool
@@ -290,7 +314,7 @@ type ResultItem struct {
 func NewModel(cfg *config.Config, plat *platform.Platform, dryRun bool) Model {
 	// Create template engine for when expression evaluation
 	tmplCtx := tmpl.NewContextFromPlatform(plat)
-	renderer := tmpl.NewEngine(tmplCtx)
+	renderer := tmpl.NewEngine(tmplCtx).WithStrict(cfg.Strict)
 
 	// Initialize search input
 	searchInput := textinput.New()
@@ -322,6 +346,8 @@ func NewModel(cfg *config.Config, plat *platform.Platform, dryRun bool) Model {
 		multiSelectActive:  false,
 	}
 
+	m.templateErrors = cfg.CheckTemplates(renderer, config.NewMachine(m.Platform))
+
 	// Initialize applications for hierarchical view
 	m.initApplicationItems()
 
@@ -345,6 +371,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case pkgCheckResultMsg:
 		return m.handlePkgCheckResult(msg)
 
+	case pkgUpdateResultMsg:
+		return m.handlePkgUpdateResult(msg)
+
 	case stateCheckResultMsg:
 		return m.handleStateCheckResult(msg)
 
@@ -409,20 +438,84 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		m.currentPackageIndex++
 
-		// Check if there are more packages to install
-		if m.currentPackageIndex < len(m.pendingPackages) {
-			return m, m.installNextPackage()
+		return m.continuePackageInstall()
+
+	case PackageUninstallMsg:
+		m.results = append(m.results, ResultItem{
+			Name:    msg.Package.Name,
+			Success: msg.Success,
+			Message: msg.Message,
+		})
+
+		if msg.Success && !m.DryRun {
+			installed := false
+
+			for i := range m.Applications {
+				if m.Applications[i].Application.Name == msg.Package.Name && m.Applications[i].PkgInstalled != nil {
+					m.Applications[i].PkgInstalled = &installed
+					m.Applications[i].PkgOutdated = false
+
+					break
+				}
+			}
 		}
 
-		// All done - return to List view
-		m.processing = false
-		m.pendingPackages = nil
+		m.currentPackageIndex++
+
+		return m.continuePackageUninstall()
+
+	case initBatchUninstallMsg:
+		m.Operation = OpUninstallPackages
+		m.pendingPackages = msg.packages
 		m.currentPackageIndex = 0
-		m.Operation = OpList
-		m.Screen = ScreenResults
-		m.rebuildTable()
 
-		return m, nil
+		if len(m.pendingPackages) > 0 {
+			return m, m.uninstallNextPackage()
+		}
+
+		return m, func() tea.Msg {
+			return BatchCompleteMsg{
+				Results:      []ResultItem{},
+				SuccessCount: 0,
+				FailCount:    0,
+			}
+		}
+
+	case downloadProgressMsg:
+		m.downloadItem = msg.name
+		m.downloadReceived, m.downloadTotal = msg.received, msg.total
+		return m, waitForDownload(msg.events)
+
+	case downloadDoneMsg:
+		return m.handleDownloadDone(msg)
+
+	case PackageBatchInstallMsg:
+		if !msg.Success {
+			// Install the batch one by one to attribute the failure
+			m.unbatchedUntil = m.currentPackageIndex + len(msg.Packages)
+			return m, m.installNextPackage()
+		}
+
+		installed := true
+		for _, pkg := range msg.Packages {
+			m.results = append(m.results, ResultItem{
+				Name:    pkg.Name,
+				Success: true,
+				Message: msg.Message,
+			})
+
+			for i := range m.Applications {
+				if m.Applications[i].Application.Name == pkg.Name && m.Applications[i].PkgInstalled != nil {
+					m.Applications[i].PkgInstalled = &installed
+
+					break
+				}
+			}
+		}
+
+		m.currentPackageIndex += len(msg.Packages)
+
+		return m.continuePackageInstall()
 
 	case OperationCompleteMsg:
 		m.processing = false
@@ -475,9 +568,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case initBatchInstallMsg:
-		// Initialize batch package installation
-		m.pendingPackages = msg.packages
+		// Initialize batch package installation, grouped so each manager runs once
+		m.pendingPackages = groupPackagesByMethod(msg.packages)
 		m.currentPackageIndex = 0
+		m.unbatchedUntil = 0
 
 		// Start installing first package
 		if len(m.pendingPackages) > 0 {
@@ -636,9 +730,45 @@ type PackageInstallMsg struct {
 	Success bool
 }
 
+// PackageUninstallMsg is sent after each individual package uninstall completes
+type PackageUninstallMsg struct {
+	Err     error
+	Message string
+	Package PackageItem
+	Success bool
+}
+
+// downloadProgressMsg reports the progress of the download of a URL install.
+// Its channel delivers the next progress report or the downloadDoneMsg.
+type downloadProgressMsg struct {
+	events   <-chan tea.Msg
+	name     string
+	received int64
+	total    int64
+}
+
+// downloadDoneMsg is sent when the download of a URL install finishes
+type downloadDoneMsg struct {
+	err     error
+	cleanup func()
+	command string
+	file    string
+	pkg     PackageItem
+}
+
+// PackageBatchInstallMsg is sent after one command installing several packages
+// with the same manager completes
+type PackageBatchInstallMsg struct {
+	Err      error
+	Message  string
+	Packages []PackageItem
+	Success  bool
+}
+
 // detectConfigState determines the state of a config entry given its paths and file list.
-// This is the shared logic used by both detectPathState and detectSubEntryState.
-func detectConfigState(backupPath, targetPath string, isFolder bool, files []string) PathState {
+// This is the shared logic used by both detectSubEntryState and detectSubEntryStateStatic.
+// Folder content matched by ign is left out, as restore leaves it out.
+func detectConfigState(backupPath, targetPath string, isFolder bool, files []string, ign *ignore.Matcher) PathState {
 	if isFolder {
 		if info, err := os.Lstat(targetPath); err == nil {
 			if info.Mode()&os.ModeSymlink != 0 {
@@ -646,8 +776,8 @@ func detectConfigState(backupPath, targetPath string, isFolder bool, files []str
 			}
 		}
 
-		backupExists := pathExists(backupPath)
-		targetExists := pathExists(targetPath)
+		backupExists := folderExists(backupPath, ign)
+		targetExists := folderExists(targetPath, ign)
 
 		if backupExists {
 			return StateReady
@@ -670,7 +800,8 @@ func detectConfigState(backupPath, targetPath string, isFolder bool, files []str
 		srcFile := filepath.Join(backupPath, file)
 		dstFile := filepath.Join(targetPath, file)
 
-		if !pathExists(srcFile) {
+		// Encrypted files only have their .enc ciphertext until first decrypted
+		if !pathExists(srcFile) && !pathExists(encryption.EncryptedPath(srcFile)) {
 			continue
 		}
 
@@ -712,6 +843,22 @@ func (m Model) handlePkgCheckResult(msg pkgCheckResultMsg) (tea.Model, tea.Cmd)
 		}
 	}
 	m.initTableModel()
+
+	// Look for an upgrade once the package is known to be installed
+	if msg.installed && packages.CanListOutdated(msg.method) {
+		return m, m.checkPackageUpdateCmd(msg.appIndex, msg.method)
+	}
+
+	return m, nil
+}
+
+// handlePkgUpdateResult processes the result of a single async package upgrade check.
+func (m Model) handlePkgUpdateResult(msg pkgUpdateResultMsg) (tea.Model, tea.Cmd) {
+	if msg.appIndex < len(m.Applications) {
+		m.Applications[msg.appIndex].PkgOutdated = msg.outdated
+		m.Applications[msg.appIndex].PkgVersions = msg.versions
+	}
+	m.initTableModel()
 	return m, nil
 }
 
@@ -729,6 +876,44 @@ func pathExists(path string) bool {
 	return err == nil
 }
 
+// folderExists reports whether dir exists with content ign does not leave
+// out. A folder holding nothing but ignored paths counts as missing.
+func folderExists(dir string, ign *ignore.Matcher) bool {
+	if !pathExists(dir) {
+		return false
+	}
+
+	if ign.Empty() {
+		return true
+	}
+
+	empty := true
+	kept := false
+
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return nil
+		}
+
+		empty = false
+
+		rel, relErr := filepath.Rel(dir, path)
+		if relErr == nil && ign.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		kept = true
+
+		return fs.SkipAll
+	})
+
+	return kept || empty
+}
+
 // handleMouseEvent processes mouse events for the TUI.
 func (m Model) handleMouseEvent(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	// Only handle mouse events on the list table screen
@@ -738,7 +923,7 @@ func (m Model) handleMouseEvent(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 
 	// Don't handle mouse during modal states
 	if m.searching || m.confirmingDeleteApp || m.confirmingDeleteSubEntry ||
-		m.confirmingFilterToggle || m.showingDetail {
+		m.confirmingUninstall || m.confirmingFilterToggle || m.showingDetail {
 		return m, nil
 	}
 
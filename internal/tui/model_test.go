@@ -1,11 +1,13 @@
 package tui
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/manager"
 	"github.com/AntoineGS/tidydots/internal/platform"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -157,6 +159,7 @@ func TestOperationString(t *testing.T) {
 		{"Restore", OpRestore},
 		{"List", OpList},
 		{"Install Packages", OpInstallPackages},
+		{"Uninstall Packages", OpUninstallPackages},
 	}
 
 	for _, tt := range tests {
@@ -899,3 +902,46 @@ func TestNewModel_FilterEnabledByDefault(t *testing.T) {
 		t.Error("Expected filterEnabled to be true by default")
 	}
 }
+
+func TestDetectSubEntryState_IgnoredContent(t *testing.T) {
+	backupRoot := t.TempDir()
+	target := filepath.Join(t.TempDir(), "nvim")
+
+	for path, content := range map[string]string{
+		filepath.Join(backupRoot, ".tidydotsignore"): "lazy-lock.json\n",
+		filepath.Join(target, "lazy-lock.json"):      "{}",
+		filepath.Join(target, "logs", "debug.log"):   "log",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{Version: 3, BackupRoot: backupRoot}
+	plat := &platform.Platform{OS: platform.OSLinux, EnvVars: map[string]string{}}
+	mgr := manager.New(cfg, plat)
+
+	item := SubEntryItem{
+		SubEntry: config.SubEntry{Name: "config", Backup: "./nvim", Ignore: []string{"logs/"}},
+		Target:   target,
+	}
+
+	if got := detectSubEntryStateStatic(item, plat, cfg, nil); got != StateAdopt {
+		t.Errorf("state without ignore rules = %v, want %v", got, StateAdopt)
+	}
+
+	if got := detectSubEntryStateStatic(item, plat, cfg, mgr); got != StateMissing {
+		t.Errorf("state with only ignored content = %v, want %v", got, StateMissing)
+	}
+
+	if err := os.WriteFile(filepath.Join(target, "init.lua"), []byte("-- nvim"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := detectSubEntryStateStatic(item, plat, cfg, mgr); got != StateAdopt {
+		t.Errorf("state with managed content = %v, want %v", got, StateAdopt)
+	}
+}
@@ -9,11 +9,19 @@ import (
 )
 
 // isPackageInstalledFromPackage checks if a package is installed using the packages package
-func isPackageInstalledFromPackage(pkg *config.EntryPackage, method, entryName string) bool {
+func isPackageInstalledFromPackage(pkg *config.EntryPackage, method, entryName string, machine config.Machine) bool {
 	if pkg == nil {
 		return false
 	}
 
+	// Handle git packages by looking for their clone
+	if method == TypeGit {
+		if val, ok := pkg.Managers[method]; ok && val.IsGit() {
+			return packages.IsGitCloned(val.Git.TargetFor(machine))
+		}
+		return false
+	}
+
 	// Handle installer packages via binary PATH lookup
 	if method == TypeInstaller {
 		if val, ok := pkg.Managers[method]; ok && val.IsInstaller() {
@@ -37,6 +45,47 @@ func isPackageInstalledFromPackage(pkg *config.EntryPackage, method, entryName s
 	return packages.IsInstalled(context.Background(), pkgName, method)
 }
 
+// uninstallMethodFromPackage returns the method that installed a package, as
+// the CLI resolves it (see packages.Manager.UninstallMethod), rather than the
+// method it would be installed with. It returns TypeNone when no configured
+// method reports the package as installed.
+func uninstallMethodFromPackage(name string, pkg *config.EntryPackage, machine config.Machine) string {
+	converted := packages.FromPackageSpec(name, pkg)
+	if converted == nil {
+		return TypeNone
+	}
+
+	pkgMgr := packages.NewManager(&packages.Config{}, machine.OS, false, false).WithMachine(machine)
+
+	return pkgMgr.UninstallMethod(*converted)
+}
+
+// packageUpdateFromPackage reports whether the manager of an installed
+// package offers a newer version, and describes it as "installed → available"
+// with "?" for versions the manager does not report.
+func packageUpdateFromPackage(pkg *config.EntryPackage, method string) (bool, string) {
+	if pkg == nil {
+		return false, ""
+	}
+
+	val, ok := pkg.Managers[method]
+	if !ok || val.IsGit() || val.IsInstaller() {
+		return false, ""
+	}
+
+	ctx := context.Background()
+
+	available, outdated := packages.AvailableVersion(ctx, val.PackageName, method)
+	if !outdated {
+		return false, ""
+	}
+
+	installed, _ := packages.InstalledVersion(ctx, val.PackageName, method)
+	v := packages.PackageVersion{Installed: installed, Available: available}
+
+	return true, v.Change()
+}
+
 // getPackageInstallMethodFromPackage determines how a package would be installed
 func getPackageInstallMethodFromPackage(pkg *config.EntryPackage, osType string) string {
 	if pkg == nil {
@@ -52,16 +101,16 @@ func getPackageInstallMethodFromPackage(pkg *config.EntryPackage, osType string)
 	}
 	// Check installer (always available when configured with a command for current OS)
 	if val, ok := pkg.Managers[TypeInstaller]; ok && val.IsInstaller() {
-		if _, hasCmd := val.Installer.Command[osType]; hasCmd {
+		if _, hasCmd := config.ForOS(val.Installer.Command, osType); hasCmd {
 			return TypeInstaller
 		}
 	}
 	// Check custom
-	if _, ok := pkg.Custom[osType]; ok {
+	if _, ok := config.ForOS(pkg.Custom, osType); ok {
 		return "custom"
 	}
 	// Check URL
-	if _, ok := pkg.URL[osType]; ok {
+	if _, ok := config.ForOS(pkg.URL, osType); ok {
 		return "url"
 	}
 
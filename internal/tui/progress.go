@@ -21,6 +21,13 @@ type pkgCheckResultMsg struct {
 	installed bool
 }
 
+// pkgUpdateResultMsg is sent when a single package upgrade check completes.
+type pkgUpdateResultMsg struct {
+	appIndex int
+	outdated bool
+	versions string
+}
+
 // stateCheckResultMsg is sent when a single sub-entry state check completes.
 type stateCheckResultMsg struct {
 	appIndex int
@@ -42,7 +49,7 @@ func (m *Model) initApplicationItems() {
 		subItems := make([]SubEntryItem, 0, len(app.Entries))
 
 		for _, subEntry := range app.Entries {
-			target := subEntry.GetTarget(m.Platform.OS)
+			target := subEntry.TargetFor(config.NewMachine(m.Platform))
 			if target == "" {
 				continue
 			}
@@ -95,6 +102,8 @@ func (m *Model) reinitPreservingState(editedAppName string) {
 		subStates    map[string]PathState // subEntry name -> state
 		pkgMethod    string
 		pkgInstalled *bool
+		pkgOutdated  bool
+		pkgVersions  string
 		expanded     bool
 	}
 
@@ -109,6 +118,8 @@ func (m *Model) reinitPreservingState(editedAppName string) {
 			subStates:    subStates,
 			pkgMethod:    app.PkgMethod,
 			pkgInstalled: app.PkgInstalled,
+			pkgOutdated:  app.PkgOutdated,
+			pkgVersions:  app.PkgVersions,
 			expanded:     app.Expanded,
 		}
 	}
@@ -131,6 +142,8 @@ func (m *Model) reinitPreservingState(editedAppName string) {
 		m.Applications[i].Expanded = prev.expanded
 		m.Applications[i].PkgMethod = prev.pkgMethod
 		m.Applications[i].PkgInstalled = prev.pkgInstalled
+		m.Applications[i].PkgOutdated = prev.pkgOutdated
+		m.Applications[i].PkgVersions = prev.pkgVersions
 
 		if app.Application.Name == editedAppName {
 			// For the edited app, synchronously refresh sub-entry states
@@ -221,6 +234,13 @@ func (m Model) viewProgress() string {
 		b.WriteString("\n")
 	}
 
+	if m.downloadItem != "" {
+		b.WriteString("\n")
+		b.WriteString(PathNameStyle.Render("Downloading " + m.downloadItem + ": "))
+		b.WriteString(formatDownloadProgress(m.downloadReceived, m.downloadTotal))
+		b.WriteString("\n")
+	}
+
 	return BaseStyle.Render(b.String())
 }
 
@@ -313,6 +333,39 @@ func (m Model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// Handle uninstall confirmation
+	if m.Operation == OpList && m.confirmingUninstall {
+		switch {
+		case key.Matches(msg, ConfirmKeys.Yes):
+			m.confirmingUninstall = false
+
+			appIdx, subIdx := m.getApplicationAtCursorFromTable()
+			if appIdx < 0 || subIdx >= 0 {
+				return m, nil
+			}
+
+			app := m.Applications[appIdx]
+			m.Operation = OpUninstallPackages
+			m.currentPackageIndex = 0
+			m.results = nil
+			m.pendingPackages = []PackageItem{{
+				Name:     app.Application.Name,
+				Package:  app.Application.Package,
+				Method:   app.PkgMethod,
+				Selected: true,
+			}}
+			m.Screen = ScreenProgress
+
+			return m, m.uninstallNextPackage()
+		case key.Matches(msg, ConfirmKeys.No):
+			m.confirmingUninstall = false
+
+			return m, nil
+		}
+
+		return m, nil
+	}
+
 	// Handle diff picker separately
 	if m.Operation == OpList && m.showingDiffPicker {
 		return m.updateDiffPicker(msg)
@@ -351,7 +404,7 @@ func (m Model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	// Helper to check if we're in a clean list state (no modals/search)
-	listClean := m.Operation == OpList && !m.searching && !m.confirmingDeleteApp && !m.confirmingDeleteSubEntry && !m.showingDetail
+	listClean := m.Operation == OpList && !m.searching && !m.confirmingDeleteApp && !m.confirmingDeleteSubEntry && !m.confirmingUninstall && !m.showingDetail
 
 	switch {
 	case key.Matches(msg, ListKeys.Search):
@@ -600,6 +653,27 @@ func (m Model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		return m, nil
+	case key.Matches(msg, ListKeys.Uninstall):
+		// Ask for uninstall confirmation (only in List view)
+		if m.Operation == OpList {
+			if m.multiSelectActive {
+				// Show summary screen for batch uninstall
+				m.summaryOperation = OpUninstallPackages
+				m.Screen = ScreenSummary
+				return m, nil
+			}
+
+			// Only app rows whose package is installed
+			appIdx, subIdx := m.getApplicationAtCursorFromTable()
+			if appIdx >= 0 && subIdx < 0 {
+				app := m.Applications[appIdx]
+				if app.PkgInstalled != nil && *app.PkgInstalled {
+					m.confirmingUninstall = true
+				}
+			}
+		}
+
 		return m, nil
 	case key.Matches(msg, ListKeys.Restore):
 		// Restore selected SubEntry (only in List view for SubEntry rows)
@@ -617,33 +691,25 @@ func (m Model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if appIdx >= 0 && subIdx >= 0 {
 				// Restore single sub-entry
 				subItem := &m.Applications[appIdx].SubItems[subIdx]
-				success, message := m.performRestoreSubEntry(subItem.SubEntry, subItem.Target)
-				if success {
+				m.results = m.restoreSubEntries([]*SubEntryItem{subItem})
+				if m.results[0].Success {
 					m.Applications[appIdx].SubItems[subIdx].State = m.detectSubEntryState(subItem)
 					m.rebuildTable()
 				}
-				m.results = []ResultItem{{
-					Name:    subItem.SubEntry.Name,
-					Success: success,
-					Message: message,
-				}}
 			} else if appIdx >= 0 && subIdx < 0 {
 				// Restore all sub-entries for this application
-				m.results = nil
+				var subItems []*SubEntryItem
 				for i := range m.Applications[appIdx].SubItems {
-					subItem := &m.Applications[appIdx].SubItems[i]
-					if !subItem.SubEntry.IsConfig() {
-						continue
+					if m.Applications[appIdx].SubItems[i].SubEntry.IsConfig() {
+						subItems = append(subItems, &m.Applications[appIdx].SubItems[i])
 					}
-					success, message := m.performRestoreSubEntry(subItem.SubEntry, subItem.Target)
-					if success {
-						m.Applications[appIdx].SubItems[i].State = m.detectSubEntryState(subItem)
+				}
+
+				m.results = m.restoreSubEntries(subItems)
+				for i, subItem := range subItems {
+					if m.results[i].Success {
+						subItem.State = m.detectSubEntryState(subItem)
 					}
-					m.results = append(m.results, ResultItem{
-						Name:    subItem.SubEntry.Name,
-						Success: success,
-						Message: message,
-					})
 				}
 				m.rebuildTable()
 			}
@@ -797,6 +863,14 @@ func (m Model) renderHelpForCurrentState() string {
 		}
 		return RenderHelpFromBindings(m.width, ConfirmKeys.Yes, ConfirmKeys.No)
 
+	case m.confirmingUninstall:
+		if appIdx >= 0 {
+			app := m.Applications[appIdx]
+			return WarningStyle.Render(fmt.Sprintf("Uninstall '%s' via %s? ", app.Application.Name, app.PkgMethod)) +
+				RenderHelpFromBindings(m.width, ConfirmKeys.Yes, ConfirmKeys.No)
+		}
+		return RenderHelpFromBindings(m.width, ConfirmKeys.Yes, ConfirmKeys.No)
+
 	case m.confirmingFilterToggle:
 		// Filter toggle confirmation dialog
 		itemText := "item(s)"
@@ -833,6 +907,7 @@ func (m Model) renderHelpForCurrentState() string {
 				MultiSelectKeys.Clear,
 				MultiSelectKeys.Restore,
 				MultiSelectKeys.Install,
+				MultiSelectKeys.Uninstall,
 				MultiSelectKeys.Delete,
 				SharedKeys.Quit,
 			)
@@ -850,8 +925,12 @@ func (m Model) renderHelpForCurrentState() string {
 
 		// Show context-sensitive "i" help
 		if subIdx < 0 {
-			// App row: install
+			// App row: install, or uninstall when the package is installed
 			bindings = append(bindings, ListKeys.Install)
+			if appIdx >= 0 && appIdx < len(m.Applications) &&
+				m.Applications[appIdx].PkgInstalled != nil && *m.Applications[appIdx].PkgInstalled {
+				bindings = append(bindings, ListKeys.Uninstall)
+			}
 		} else if appIdx >= 0 && subIdx >= 0 && appIdx < len(m.Applications) &&
 			subIdx < len(m.Applications[appIdx].SubItems) &&
 			m.Applications[appIdx].SubItems[subIdx].State == StateModified {
@@ -909,6 +988,14 @@ func (m Model) viewListTable() string {
 	b.WriteString("\n")
 	linesUsed++
 
+	// Template diagnostics banner, so typos in when/paths are not silently hidden
+	if len(m.templateErrors) > 0 {
+		banner := fmt.Sprintf("  ⚠ %d template error(s): %v", len(m.templateErrors), m.templateErrors[0])
+		b.WriteString(ErrorStyle.MaxWidth(m.width).Render(banner))
+		b.WriteString("\n")
+		linesUsed++
+	}
+
 	// Table should already be initialized via Update()/initTableModel()
 	// Do not call initTableModel() from View() — mutating state in View is a Bubble Tea anti-pattern
 
@@ -1070,6 +1157,30 @@ func (m Model) installNextPackage() tea.Cmd {
 		}
 	}
 
+	if batch, cmd := m.nextInstallBatch(); cmd != nil {
+		return tea.Exec(&pauseOnFailExec{cmd: cmd}, func(err error) tea.Msg {
+			if err != nil {
+				return PackageBatchInstallMsg{
+					Packages: batch,
+					Success:  false,
+					Message:  fmt.Sprintf("Installation failed: %v", err),
+					Err:      err,
+				}
+			}
+
+			return PackageBatchInstallMsg{
+				Packages: batch,
+				Success:  true,
+				Message:  fmt.Sprintf("Installed via %s", pkg.Method),
+			}
+		})
+	}
+
+	// URL installs are downloaded first, reporting progress
+	if pkg.Method == packages.MethodURL {
+		return m.downloadPackage(pkg)
+	}
+
 	// Build the command
 	cmd := m.buildInstallCommand(pkg)
 	if cmd == nil {
@@ -1103,11 +1214,274 @@ func (m Model) installNextPackage() tea.Cmd {
 	})
 }
 
+// continuePackageInstall installs the next pending package, or returns to the
+// list once all are done.
+func (m Model) continuePackageInstall() (tea.Model, tea.Cmd) {
+	if m.currentPackageIndex < len(m.pendingPackages) {
+		return m, m.installNextPackage()
+	}
+
+	return m.finishPackageOperation()
+}
+
+// continuePackageUninstall uninstalls the next pending package, or returns to
+// the list once all are done.
+func (m Model) continuePackageUninstall() (tea.Model, tea.Cmd) {
+	if m.currentPackageIndex < len(m.pendingPackages) {
+		return m, m.uninstallNextPackage()
+	}
+
+	// The cached bulk lists still report the removed packages
+	if !m.DryRun {
+		packages.ResetInstalledCache()
+	}
+
+	return m.finishPackageOperation()
+}
+
+// finishPackageOperation returns to the list after installing or uninstalling
+// the pending packages.
+func (m Model) finishPackageOperation() (tea.Model, tea.Cmd) {
+	// All done - return to List view
+	m.processing = false
+	m.pendingPackages = nil
+	m.currentPackageIndex = 0
+	m.unbatchedUntil = 0
+	m.Operation = OpList
+	m.Screen = ScreenResults
+	m.rebuildTable()
+
+	return m, nil
+}
+
+// nextInstallBatch returns the pending packages from currentPackageIndex that
+// share its install method, with one command installing them all. It returns
+// a nil command when fewer than two packages can be batched, or while
+// packages of a failed batch are retried one by one.
+func (m Model) nextInstallBatch() ([]PackageItem, *exec.Cmd) {
+	start := m.currentPackageIndex
+	if start < m.unbatchedUntil {
+		return nil, nil
+	}
+
+	method := m.pendingPackages[start].Method
+
+	var batch []PackageItem
+	var converted []packages.Package
+
+	for _, pkg := range m.pendingPackages[start:] {
+		if pkg.Method != method {
+			break
+		}
+
+		p := packages.FromPackageSpec(pkg.Name, pkg.Package)
+		if p == nil {
+			break
+		}
+
+		batch = append(batch, pkg)
+		converted = append(converted, *p)
+	}
+
+	if len(batch) < 2 {
+		return nil, nil
+	}
+
+	cmd := packages.BuildBatchCommand(context.Background(), converted, method)
+	if cmd == nil {
+		return nil, nil
+	}
+
+	return batch, cmd
+}
+
+// groupPackagesByMethod orders packages so those installed with the same
+// method are adjacent, keeping the first-seen order of methods and of the
+// packages within each.
+func groupPackagesByMethod(pkgs []PackageItem) []PackageItem {
+	var methods []string
+
+	byMethod := make(map[string][]PackageItem)
+	for _, pkg := range pkgs {
+		if _, ok := byMethod[pkg.Method]; !ok {
+			methods = append(methods, pkg.Method)
+		}
+		byMethod[pkg.Method] = append(byMethod[pkg.Method], pkg)
+	}
+
+	grouped := make([]PackageItem, 0, len(pkgs))
+	for _, method := range methods {
+		grouped = append(grouped, byMethod[method]...)
+	}
+
+	return grouped
+}
+
+// uninstallNextPackage uninstalls the package at currentPackageIndex with the
+// method that installed it, resolved again as the CLI does.
+func (m Model) uninstallNextPackage() tea.Cmd {
+	pkg := m.pendingPackages[m.currentPackageIndex]
+	machine := config.NewMachine(m.Platform)
+
+	pkg.Method = uninstallMethodFromPackage(pkg.Name, pkg.Package, machine)
+	if pkg.Method == TypeNone {
+		return func() tea.Msg {
+			return PackageUninstallMsg{
+				Package: pkg,
+				Success: false,
+				Message: "Not installed by any configured method",
+			}
+		}
+	}
+
+	if m.DryRun {
+		return func() tea.Msg {
+			return PackageUninstallMsg{
+				Package: pkg,
+				Success: true,
+				Message: fmt.Sprintf("Would uninstall via %s", pkg.Method),
+			}
+		}
+	}
+
+	var cmd *exec.Cmd
+	if converted := packages.FromPackageSpec(pkg.Name, pkg.Package); converted != nil {
+		cmd = packages.BuildUninstallCommand(context.Background(), *converted, pkg.Method, machine)
+	}
+
+	if cmd == nil {
+		return func() tea.Msg {
+			return PackageUninstallMsg{
+				Package: pkg,
+				Success: false,
+				Message: fmt.Sprintf("%s cannot uninstall this package", pkg.Method),
+			}
+		}
+	}
+
+	// Run attached to the terminal so sudo can prompt, as installs do
+	return tea.Exec(&pauseOnFailExec{cmd: cmd}, func(err error) tea.Msg {
+		if err != nil {
+			return PackageUninstallMsg{
+				Package: pkg,
+				Success: false,
+				Message: fmt.Sprintf("Uninstall failed: %v", err),
+				Err:     err,
+			}
+		}
+
+		return PackageUninstallMsg{
+			Package: pkg,
+			Success: true,
+			Message: fmt.Sprintf("Uninstalled via %s", pkg.Method),
+		}
+	})
+}
+
+// downloadPackage fetches the file of a URL install in the background. Its
+// progress reaches the progress screen as downloadProgressMsg, followed by a
+// downloadDoneMsg.
+func (m Model) downloadPackage(pkg PackageItem) tea.Cmd {
+	var spec packages.URLInstall
+
+	converted := packages.FromPackageSpec(pkg.Name, pkg.Package)
+	if converted != nil {
+		spec, _ = config.ForOS(converted.URL, m.Platform.OS)
+	}
+
+	if spec.URL == "" {
+		return func() tea.Msg {
+			return PackageInstallMsg{
+				Package: pkg,
+				Success: false,
+				Message: "No installation method available",
+			}
+		}
+	}
+
+	events := make(chan tea.Msg, 1)
+
+	go func() {
+		defer close(events)
+
+		cacheDir, _ := packages.DownloadCacheDir()
+		file, cleanup, err := packages.Download(context.Background(), spec, cacheDir, func(received, total int64) {
+			// Drop reports while the screen is busy; the next one catches up
+			select {
+			case events <- downloadProgressMsg{events: events, name: pkg.Name, received: received, total: total}:
+			default:
+			}
+		})
+
+		events <- downloadDoneMsg{err: err, cleanup: cleanup, command: spec.Command, file: file, pkg: pkg}
+	}()
+
+	return waitForDownload(events)
+}
+
+// waitForDownload waits for the next report of a download.
+func waitForDownload(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+// handleDownloadDone runs the install command of a downloaded URL install,
+// or records the failed download.
+func (m Model) handleDownloadDone(msg downloadDoneMsg) (tea.Model, tea.Cmd) {
+	m.downloadItem = ""
+	pkg := msg.pkg
+
+	if msg.err != nil {
+		return m, func() tea.Msg {
+			return PackageInstallMsg{
+				Package: pkg,
+				Success: false,
+				Message: fmt.Sprintf("Download failed: %v", msg.err),
+				Err:     msg.err,
+			}
+		}
+	}
+
+	cmd := packages.BuildURLInstallCommand(context.Background(), msg.command, msg.file, m.Platform.OS)
+
+	return m, tea.Exec(&pauseOnFailExec{cmd: cmd}, func(err error) tea.Msg {
+		msg.cleanup()
+
+		if err != nil {
+			return PackageInstallMsg{
+				Package: pkg,
+				Success: false,
+				Message: fmt.Sprintf("Installation failed: %v", err),
+				Err:     err,
+			}
+		}
+
+		return PackageInstallMsg{
+			Package: pkg,
+			Success: true,
+			Message: fmt.Sprintf("Installed via %s", pkg.Method),
+		}
+	})
+}
+
+// formatDownloadProgress describes the progress of a download, such as
+// "4.2 MB of 10.0 MB (42%)".
+func formatDownloadProgress(received, total int64) string {
+	const mb = 1024 * 1024
+
+	if total <= 0 {
+		return fmt.Sprintf("%.1f MB", float64(received)/mb)
+	}
+
+	return fmt.Sprintf("%.1f MB of %.1f MB (%d%%)", float64(received)/mb, float64(total)/mb, received*100/total)
+}
+
 func (m Model) buildInstallCommand(pkg PackageItem) *exec.Cmd {
 	converted := packages.FromPackageSpec(pkg.Name, pkg.Package)
 	if converted == nil {
 		return nil
 	}
 
-	return packages.BuildCommand(context.Background(), *converted, pkg.Method, m.Platform.OS)
+	return packages.BuildCommand(context.Background(), *converted, pkg.Method, config.NewMachine(m.Platform))
 }
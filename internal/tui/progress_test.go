@@ -1,9 +1,13 @@
 package tui
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
+
 	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/AntoineGS/tidydots/internal/platform"
 )
@@ -267,3 +271,144 @@ func TestExpandNodePreservesPosition(t *testing.T) {
 		t.Errorf("After expansion, row 3 should be zsh, got %s", zshRowName)
 	}
 }
+
+func TestViewListTable_TemplateErrorBanner(t *testing.T) {
+	cfg := &config.Config{
+		Strict: true,
+		Applications: []config.Application{
+			{
+				Name: "typo",
+				When: `{{ eq .Env.TIDYDOTS_TEST_MISSING "x" }}`,
+			},
+		},
+	}
+	plat := &platform.Platform{OS: OSLinux}
+	m := NewModel(cfg, plat, false)
+	m.width = 200
+
+	if len(m.templateErrors) != 1 {
+		t.Fatalf("templateErrors = %v, want 1 error", m.templateErrors)
+	}
+
+	if view := m.viewListTable(); !strings.Contains(view, "1 template error(s)") {
+		t.Errorf("list view does not show the template error banner:\n%s", view)
+	}
+}
+
+func TestGroupPackagesByMethod(t *testing.T) {
+	pkgs := []PackageItem{
+		{Name: "vim", Method: "pacman"},
+		{Name: "ripgrep", Method: "cargo"},
+		{Name: "git", Method: "pacman"},
+		{Name: "tpm", Method: "git"},
+		{Name: "bat", Method: "cargo"},
+	}
+
+	var got []string
+	for _, pkg := range groupPackagesByMethod(pkgs) {
+		got = append(got, pkg.Name)
+	}
+
+	want := []string{"vim", "git", "ripgrep", "bat", "tpm"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("groupPackagesByMethod() = %v, want %v", got, want)
+	}
+}
+
+func TestUninstallKeyConfirmation(t *testing.T) {
+	clone := filepath.Join(t.TempDir(), "neovim")
+	if err := os.MkdirAll(filepath.Join(clone, ".git"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Version:    3,
+		BackupRoot: "/backup",
+		Applications: []config.Application{
+			{
+				Name: "neovim",
+				Package: &config.EntryPackage{
+					Managers: map[string]config.ManagerValue{
+						"pacman": {PackageName: "neovim"},
+						"git":    {Git: &config.GitPackage{URL: "https://github.com/neovim/neovim.git", Targets: map[string]string{"linux": clone}}},
+					},
+				},
+			},
+		},
+	}
+	plat := &platform.Platform{OS: platform.OSLinux}
+
+	m := NewModel(cfg, plat, true)
+	m.Screen = ScreenResults
+	m.Operation = OpList
+	m.rebuildTable()
+
+	press := func(m Model, r rune) (Model, tea.Cmd) {
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		return updated.(Model), cmd
+	}
+
+	// Not installed: nothing to uninstall
+	installed := false
+	m.Applications[0].PkgInstalled = &installed
+	if m, _ = press(m, 'u'); m.confirmingUninstall {
+		t.Fatal("uninstall should not be offered for a package that is not installed")
+	}
+
+	installed = true
+	m.Applications[0].PkgMethod = "pacman"
+
+	m, _ = press(m, 'u')
+	if !m.confirmingUninstall {
+		t.Fatal("expected uninstall confirmation after pressing u")
+	}
+
+	m, _ = press(m, 'n')
+	if m.confirmingUninstall || m.Operation != OpList {
+		t.Fatal("declining should cancel the uninstall")
+	}
+
+	m, _ = press(m, 'u')
+	m, cmd := press(m, 'y')
+	if m.Operation != OpUninstallPackages || m.Screen != ScreenProgress || cmd == nil {
+		t.Fatalf("confirming should start the uninstall, got operation %v on screen %v", m.Operation, m.Screen)
+	}
+
+	// The detected install method is stale: the package is uninstalled with
+	// the method that installed it, the git clone
+	msg, ok := cmd().(PackageUninstallMsg)
+	if !ok || !msg.Success || msg.Message != "Would uninstall via git" {
+		t.Errorf("dry-run uninstall message = %+v", msg)
+	}
+
+	if err := os.RemoveAll(clone); err != nil {
+		t.Fatal(err)
+	}
+
+	m.currentPackageIndex = 0
+	msg, ok = m.uninstallNextPackage()().(PackageUninstallMsg)
+	if !ok || msg.Success || msg.Message != "Not installed by any configured method" {
+		t.Errorf("uninstall message without the clone = %+v", msg)
+	}
+}
+
+func TestFormatDownloadProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		received int64
+		total    int64
+		want     string
+	}{
+		{"unknown size", 3 * 1024 * 1024, -1, "3.0 MB"},
+		{"started", 0, 10 * 1024 * 1024, "0.0 MB of 10.0 MB (0%)"},
+		{"halfway", 5 * 1024 * 1024, 10 * 1024 * 1024, "5.0 MB of 10.0 MB (50%)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDownloadProgress(tt.received, tt.total); got != tt.want {
+				t.Errorf("formatDownloadProgress(%d, %d) = %q, want %q", tt.received, tt.total, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 
 	"github.com/AntoineGS/tidydots/internal/config"
+	"github.com/AntoineGS/tidydots/internal/ignore"
 	"github.com/AntoineGS/tidydots/internal/manager"
 	"github.com/AntoineGS/tidydots/internal/platform"
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,7 +15,7 @@ func (m *Model) detectSubEntryState(item *SubEntryItem) PathState {
 	targetPath := config.ExpandPath(item.Target, m.Platform.EnvVars)
 	backupPath := m.resolvePath(item.SubEntry.Backup)
 
-	st := detectConfigState(backupPath, targetPath, item.SubEntry.IsFolder(), item.SubEntry.Files)
+	st := detectConfigState(backupPath, targetPath, item.SubEntry.IsFolder(), item.SubEntry.Files, entryIgnoreMatcher(m.Manager, item.SubEntry))
 
 	if st == StateLinked && item.SubEntry.IsConfig() && item.SubEntry.IsFolder() && m.Manager != nil {
 		if m.Manager.HasOutdatedTemplates(backupPath) {
@@ -33,6 +34,7 @@ func (m *Model) detectSubEntryState(item *SubEntryItem) PathState {
 func (m Model) checkPackageStatesCmd() tea.Cmd {
 	var cmds []tea.Cmd
 	osType := m.Platform.OS
+	machine := config.NewMachine(m.Platform)
 
 	for i, app := range m.Applications {
 		if app.IsFiltered || !app.Application.HasPackage() {
@@ -45,7 +47,7 @@ func (m Model) checkPackageStatesCmd() tea.Cmd {
 			method := getPackageInstallMethodFromPackage(pkg, osType)
 			installed := false
 			if method != TypeNone {
-				installed = isPackageInstalledFromPackage(pkg, method, name)
+				installed = isPackageInstalledFromPackage(pkg, method, name, machine)
 			}
 			return pkgCheckResultMsg{appIndex: appIndex, method: method, installed: installed}
 		})
@@ -54,6 +56,17 @@ func (m Model) checkPackageStatesCmd() tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// checkPackageUpdateCmd returns a tea.Cmd that asks the package manager of an
+// installed package whether a newer version is available.
+func (m Model) checkPackageUpdateCmd(appIndex int, method string) tea.Cmd {
+	pkg := m.Applications[appIndex].Application.Package
+
+	return func() tea.Msg {
+		outdated, versions := packageUpdateFromPackage(pkg, method)
+		return pkgUpdateResultMsg{appIndex: appIndex, outdated: outdated, versions: versions}
+	}
+}
+
 // checkSubEntryStatesCmd returns a tea.Cmd that checks all sub-entry states.
 // Each sub-entry gets its own concurrent command so results stream in individually.
 // Filtered apps are skipped; use checkFilteredStatesCmd when the filter is toggled off.
@@ -86,6 +99,7 @@ func (m Model) checkSubEntryStatesCmd() tea.Cmd {
 func (m Model) checkFilteredStatesCmd() tea.Cmd {
 	var cmds []tea.Cmd
 	osType := m.Platform.OS
+	machine := config.NewMachine(m.Platform)
 	plat := m.Platform
 	cfg := m.Config
 	mgr := m.Manager
@@ -104,7 +118,7 @@ func (m Model) checkFilteredStatesCmd() tea.Cmd {
 				method := getPackageInstallMethodFromPackage(pkg, osType)
 				installed := false
 				if method != TypeNone {
-					installed = isPackageInstalledFromPackage(pkg, method, name)
+					installed = isPackageInstalledFromPackage(pkg, method, name, machine)
 				}
 				return pkgCheckResultMsg{appIndex: appIndex, method: method, installed: installed}
 			})
@@ -134,7 +148,7 @@ func detectSubEntryStateStatic(item SubEntryItem, plat *platform.Platform, cfg *
 	targetPath := config.ExpandPath(item.Target, plat.EnvVars)
 	backupPath := resolvePathStatic(item.SubEntry.Backup, cfg, plat.EnvVars)
 
-	st := detectConfigState(backupPath, targetPath, item.SubEntry.IsFolder(), item.SubEntry.Files)
+	st := detectConfigState(backupPath, targetPath, item.SubEntry.IsFolder(), item.SubEntry.Files, entryIgnoreMatcher(mgr, item.SubEntry))
 
 	if st == StateLinked && item.SubEntry.IsConfig() && item.SubEntry.IsFolder() && mgr != nil {
 		if mgr.HasOutdatedTemplates(backupPath) {
@@ -148,6 +162,21 @@ func detectSubEntryStateStatic(item SubEntryItem, plat *platform.Platform, cfg *
 	return st
 }
 
+// entryIgnoreMatcher returns the ignore rules restore applies to an entry, or
+// nil without a manager or when the rules cannot be loaded.
+func entryIgnoreMatcher(mgr *manager.Manager, subEntry config.SubEntry) *ignore.Matcher {
+	if mgr == nil {
+		return nil
+	}
+
+	ign, err := mgr.IgnoreMatcher(subEntry)
+	if err != nil {
+		return nil
+	}
+
+	return ign
+}
+
 // resolvePathStatic resolves relative paths against BackupRoot and expands ~ without using Model receiver.
 func resolvePathStatic(path string, cfg *config.Config, envVars map[string]string) string {
 	expandedPath := config.ExpandPath(path, envVars)
@@ -19,6 +19,8 @@ func (m Model) viewSummary() string {
 	switch m.summaryOperation {
 	case OpInstallPackages:
 		title = "📦  Install Packages - Confirmation"
+	case OpUninstallPackages:
+		title = "🗑️  Uninstall Packages - Confirmation"
 	case OpRestore:
 		title = "🔄  Restore Configs - Confirmation"
 	case OpDelete, OpList:
@@ -32,6 +34,8 @@ func (m Model) viewSummary() string {
 	switch m.summaryOperation {
 	case OpInstallPackages:
 		b.WriteString(m.renderInstallSummary())
+	case OpUninstallPackages:
+		b.WriteString(m.renderUninstallSummary())
 	case OpRestore:
 		b.WriteString(m.renderHierarchicalSummary("restore"))
 	case OpDelete, OpList:
@@ -98,6 +102,33 @@ func (m Model) renderInstallSummary() string {
 	return b.String()
 }
 
+// renderUninstallSummary renders the uninstall packages summary.
+// Shows the selected applications whose package is installed.
+func (m Model) renderUninstallSummary() string {
+	var b strings.Builder
+
+	pkgs := m.selectedInstalledPackages()
+
+	b.WriteString(SubtitleStyle.Render(fmt.Sprintf("Will uninstall packages for %d application(s):", len(pkgs))))
+	b.WriteString("\n\n")
+
+	for _, pkg := range pkgs {
+		b.WriteString(CheckedStyle.Render("  • "))
+		b.WriteString(PathNameStyle.Render(pkg.Name))
+		if pkg.Method != "" && pkg.Method != TypeNone {
+			b.WriteString(MutedTextStyle.Render(fmt.Sprintf(" (%s)", pkg.Method)))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(pkgs) == 0 {
+		b.WriteString(MutedTextStyle.Render("  No packages to uninstall (none installed)"))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
 // renderHierarchicalSummary renders the hierarchical summary for restore/delete operations.
 // Shows selected apps + sub-entries with their details.
 func (m Model) renderHierarchicalSummary(operation string) string {
@@ -281,6 +312,8 @@ func (m Model) executeConfirmedOperation() (tea.Model, tea.Cmd) {
 		cmd = m.executeBatchRestore()
 	case OpInstallPackages:
 		cmd = m.executeBatchInstall()
+	case OpUninstallPackages:
+		cmd = m.executeBatchUninstall()
 	case OpDelete:
 		cmd = m.executeBatchDelete()
 	case OpList:
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/charmbracelet/bubbles/table"
 )
 
@@ -23,7 +24,7 @@ type TableRow struct {
 }
 
 // flattenApplications converts hierarchical apps to flat table rows
-func flattenApplications(apps []ApplicationItem, osType string, filterEnabled bool) []TableRow {
+func flattenApplications(apps []ApplicationItem, mc config.Machine, filterEnabled bool) []TableRow {
 	var rows []TableRow
 
 	for appIdx, app := range apps {
@@ -44,6 +45,12 @@ func flattenApplications(apps []ApplicationItem, osType string, filterEnabled bo
 		// Determine status text
 		statusText := getApplicationStatus(app)
 
+		// Show the available upgrade in the otherwise empty path column
+		pathText := ""
+		if statusText == StatusUpdate {
+			pathText = app.PkgVersions
+		}
+
 		// Entry count text
 		entryText := "entries"
 		if len(app.SubItems) == 1 {
@@ -58,7 +65,7 @@ func flattenApplications(apps []ApplicationItem, osType string, filterEnabled bo
 				expandChar + app.Application.Name,
 				statusText,
 				entryCount,
-				"", // No path for app rows
+				pathText, // No path for app rows, only an available upgrade
 			},
 			Level:           0,
 			TreeChar:        expandChar,
@@ -83,7 +90,7 @@ func flattenApplications(apps []ApplicationItem, osType string, filterEnabled bo
 				typeInfo := getTypeInfo(subItem)
 
 				// Get original unexpanded target from config (with ~ and relative paths)
-				displayTarget := subItem.SubEntry.GetTarget(osType)
+				displayTarget := subItem.SubEntry.TargetFor(mc)
 
 				rows = append(rows, TableRow{
 					Data: table.Row{
@@ -129,6 +136,9 @@ func getApplicationStatus(app ApplicationItem) string {
 	}
 
 	if *app.PkgInstalled {
+		if app.PkgOutdated {
+			return StatusUpdate
+		}
 		return StatusInstalled
 	}
 
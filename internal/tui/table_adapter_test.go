@@ -24,7 +24,7 @@ func TestFlattenApplications(t *testing.T) {
 			},
 		}
 
-		rows := flattenApplications(apps, "linux", false)
+		rows := flattenApplications(apps, config.Machine{OS: "linux"}, false)
 
 		if len(rows) != 1 {
 			t.Errorf("Expected 1 row, got %d", len(rows))
@@ -50,7 +50,7 @@ func TestFlattenApplications(t *testing.T) {
 			},
 		}
 
-		rows := flattenApplications(apps, "linux", false)
+		rows := flattenApplications(apps, config.Machine{OS: "linux"}, false)
 
 		if len(rows) != 2 {
 			t.Errorf("Expected 2 rows, got %d", len(rows))
@@ -82,7 +82,7 @@ func TestFlattenApplications(t *testing.T) {
 			},
 		}
 
-		rows := flattenApplications(apps, "linux", false)
+		rows := flattenApplications(apps, config.Machine{OS: "linux"}, false)
 
 		if len(rows) != 4 {
 			t.Errorf("Expected 4 rows, got %d", len(rows))
@@ -110,7 +110,7 @@ func TestFlattenApplications(t *testing.T) {
 			},
 		}
 
-		rows := flattenApplications(apps, "linux", false)
+		rows := flattenApplications(apps, config.Machine{OS: "linux"}, false)
 
 		if len(rows) != 1 {
 			t.Errorf("Expected 1 row, got %d", len(rows))
@@ -373,7 +373,7 @@ func TestFlattenApplications_WithFilterEnabled(t *testing.T) {
 	}
 
 	t.Run("filter enabled hides filtered apps", func(t *testing.T) {
-		rows := flattenApplications(apps, "linux", true)
+		rows := flattenApplications(apps, config.Machine{OS: "linux"}, true)
 
 		// Should only show visible-app (1 app + 1 sub-entry = 2 rows)
 		if len(rows) != 2 {
@@ -386,7 +386,7 @@ func TestFlattenApplications_WithFilterEnabled(t *testing.T) {
 	})
 
 	t.Run("filter disabled shows all apps", func(t *testing.T) {
-		rows := flattenApplications(apps, "linux", false)
+		rows := flattenApplications(apps, config.Machine{OS: "linux"}, false)
 
 		// Should show both apps (2 apps + 2 sub-entries = 4 rows)
 		if len(rows) != 4 {
@@ -413,7 +413,7 @@ func TestFlattenApplications_AppNameMapping(t *testing.T) {
 		},
 	}
 
-	rows := flattenApplications(apps, "linux", false)
+	rows := flattenApplications(apps, config.Machine{OS: "linux"}, false)
 
 	// Verify we have 4 rows (2 apps + 2 sub-entries)
 	if len(rows) != 4 {
@@ -491,6 +491,25 @@ func TestGetApplicationStatus_PackageState(t *testing.T) {
 			t.Errorf("Expected StatusInstalled, got %s", status)
 		}
 	})
+
+	t.Run("package with an available upgrade", func(t *testing.T) {
+		app := ApplicationItem{
+			Application:  config.Application{Name: "neovim"},
+			PkgInstalled: &installed,
+			PkgOutdated:  true,
+			PkgVersions:  "0.9.5 → 0.10.0",
+		}
+
+		status := getApplicationStatus(app)
+		if status != StatusUpdate {
+			t.Errorf("Expected StatusUpdate, got %s", status)
+		}
+
+		rows := flattenApplications([]ApplicationItem{app}, config.Machine{OS: "linux"}, false)
+		if rows[0].Data[3] != "0.9.5 → 0.10.0" || !rows[0].StatusAttention {
+			t.Errorf("Expected the upgrade in the path column with attention, got %q", rows[0].Data[3])
+		}
+	})
 }
 
 func TestAppInfoMaxState_Outdated(t *testing.T) {
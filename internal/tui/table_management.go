@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/AntoineGS/tidydots/internal/config"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
 )
@@ -146,7 +147,7 @@ func (m *Model) initTableModel() {
 		})
 	}
 
-	m.tableRows = flattenApplications(filtered, m.Platform.OS, m.filterEnabled)
+	m.tableRows = flattenApplications(filtered, config.NewMachine(m.Platform), m.filterEnabled)
 
 	// Apply sorting (only sorts sub-entries now, preserves app order)
 	m.sortTableRows()
@@ -439,7 +440,7 @@ func cellAttentionStyle(tr TableRow, col int) lipgloss.Style {
 	baseStyle := lipgloss.NewStyle().Padding(0, 1)
 
 	if col == 1 && tr.StatusAttention {
-		if tr.State == StateOutdated || tr.Data[1] == StatusOutdated {
+		if tr.State == StateOutdated || tr.Data[1] == StatusOutdated || tr.Data[1] == StatusUpdate {
 			return baseStyle.Foreground(accentColor)
 		}
 		if tr.State == StateModified || tr.Data[1] == StatusModified {
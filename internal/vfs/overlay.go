@@ -0,0 +1,696 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxSymlinkHops bounds symlink resolution, like the kernel's ELOOP limit.
+const maxSymlinkHops = 40
+
+type nodeKind int
+
+const (
+	kindDeleted nodeKind = iota
+	kindDir
+	kindFile
+	kindSymlink
+)
+
+// node is an entry changed in the overlay. Deleted nodes hide the base path
+// and everything below it.
+type node struct {
+	modTime time.Time
+	data    []byte
+	lower   string // base path holding the content of a file not yet written in the overlay
+	link    string
+	mode    fs.FileMode
+	kind    nodeKind
+	opaque  bool // a directory created in the overlay hides the base directory's children
+}
+
+// Overlay is a copy-on-write view over a base FS. Reads fall through to the
+// base; writes are kept in memory and never reach it. It is safe for
+// concurrent use.
+type Overlay struct {
+	base  FS
+	nodes map[string]*node
+	mu    sync.Mutex
+}
+
+// NewOverlay returns an empty overlay over base.
+func NewOverlay(base FS) *Overlay {
+	return &Overlay{base: base, nodes: make(map[string]*node)}
+}
+
+// Changed returns the paths created, modified or deleted in the overlay, sorted.
+func (o *Overlay) Changed() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	paths := make([]string, 0, len(o.nodes))
+	for p := range o.nodes {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	return paths
+}
+
+// Lstat returns file info for name without following a final symlink.
+func (o *Overlay) Lstat(name string) (fs.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, false)
+	if err != nil {
+		return nil, pathError("lstat", name, err)
+	}
+
+	return o.lstat(p, name)
+}
+
+// Stat returns file info for name, following symlinks.
+func (o *Overlay) Stat(name string) (fs.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, true)
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+
+	info, err := o.lstat(p, name)
+	if err != nil {
+		return nil, pathError("stat", name, unwrapPathError(err))
+	}
+
+	return info, nil
+}
+
+// Readlink returns the destination of the symlink name.
+func (o *Overlay) Readlink(name string) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, false)
+	if err != nil {
+		return "", pathError("readlink", name, err)
+	}
+
+	return o.readlink(p, name)
+}
+
+// ReadDir returns the entries of directory name, sorted by name.
+func (o *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, true)
+	if err != nil {
+		return nil, pathError("readdirent", name, err)
+	}
+
+	return o.readDir(p, name)
+}
+
+// ReadFile returns the content of file name.
+func (o *Overlay) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, true)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+
+	n, hidden := o.lookup(p)
+	switch {
+	case n == nil && !hidden:
+		return o.base.ReadFile(p)
+	case n == nil || n.kind == kindDeleted:
+		return nil, pathError("open", name, fs.ErrNotExist)
+	case n.kind == kindDir:
+		return nil, pathError("read", name, syscall.EISDIR)
+	case n.data == nil && n.lower != "":
+		return o.base.ReadFile(n.lower)
+	default:
+		return slices.Clone(n.data), nil
+	}
+}
+
+// WriteFile writes data to file name, creating it with perm if needed.
+func (o *Overlay) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, true)
+	if err != nil {
+		return pathError("open", name, err)
+	}
+
+	if err := o.checkParent(p, name); err != nil {
+		return err
+	}
+
+	mode := perm.Perm()
+	if info, err := o.lstat(p, name); err == nil {
+		if info.IsDir() {
+			return pathError("open", name, syscall.EISDIR)
+		}
+		mode = info.Mode().Perm()
+	}
+
+	o.nodes[p] = &node{kind: kindFile, data: slices.Clone(data), mode: mode, modTime: time.Now()}
+
+	return nil
+}
+
+// Mkdir creates directory name. Its parent must exist.
+func (o *Overlay) Mkdir(name string, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, false)
+	if err != nil {
+		return pathError("mkdir", name, err)
+	}
+
+	if _, err := o.lstat(p, name); err == nil {
+		return pathError("mkdir", name, fs.ErrExist)
+	}
+
+	if err := o.checkParent(p, name); err != nil {
+		return err
+	}
+
+	o.nodes[p] = &node{kind: kindDir, mode: fs.ModeDir | perm.Perm(), opaque: true, modTime: time.Now()}
+
+	return nil
+}
+
+// MkdirAll creates directory path and any missing parents.
+func (o *Overlay) MkdirAll(path string, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(path, true)
+	if err != nil {
+		return pathError("mkdir", path, err)
+	}
+
+	return o.mkdirAll(p, path, perm)
+}
+
+// Rename moves oldpath to newpath, replacing a file or empty directory there.
+func (o *Overlay) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	po, err := o.resolve(oldpath, false)
+	if err != nil {
+		return pathError("rename", oldpath, err)
+	}
+
+	pn, err := o.resolve(newpath, false)
+	if err != nil {
+		return pathError("rename", newpath, err)
+	}
+
+	info, err := o.lstat(po, oldpath)
+	if err != nil {
+		return err
+	}
+
+	if po == pn {
+		return nil
+	}
+
+	if isWithin(pn, po) {
+		return pathError("rename", newpath, syscall.EINVAL)
+	}
+
+	if err := o.checkParent(pn, newpath); err != nil {
+		return err
+	}
+
+	if existing, err := o.lstat(pn, newpath); err == nil {
+		switch {
+		case existing.IsDir() && !info.IsDir():
+			return pathError("rename", newpath, syscall.EISDIR)
+		case !existing.IsDir() && info.IsDir():
+			return pathError("rename", newpath, syscall.ENOTDIR)
+		case existing.IsDir():
+			entries, err := o.readDir(pn, newpath)
+			if err != nil {
+				return err
+			}
+			if len(entries) > 0 {
+				return pathError("rename", newpath, syscall.ENOTEMPTY)
+			}
+		}
+
+		o.markDeleted(pn)
+	}
+
+	if err := o.copyTree(po, pn); err != nil {
+		return err
+	}
+
+	o.markDeleted(po)
+
+	return nil
+}
+
+// Remove deletes a file, symlink or empty directory.
+func (o *Overlay) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, false)
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+
+	info, err := o.lstat(p, name)
+	if err != nil {
+		return pathError("remove", name, unwrapPathError(err))
+	}
+
+	if info.IsDir() {
+		entries, err := o.readDir(p, name)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return pathError("remove", name, syscall.ENOTEMPTY)
+		}
+	}
+
+	o.markDeleted(p)
+
+	return nil
+}
+
+// RemoveAll deletes path and everything below it. A missing path is not an error.
+func (o *Overlay) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(path, false)
+	if err != nil {
+		return pathError("unlinkat", path, err)
+	}
+
+	if _, err := o.lstat(p, path); err != nil {
+		return nil //nolint:nilerr // removing a missing path succeeds, as in os.RemoveAll
+	}
+
+	o.markDeleted(p)
+
+	return nil
+}
+
+// Symlink creates newname as a symlink to oldname.
+func (o *Overlay) Symlink(oldname, newname string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(newname, false)
+	if err != nil {
+		return linkError("symlink", oldname, newname, err)
+	}
+
+	if _, err := o.lstat(p, newname); err == nil {
+		return linkError("symlink", oldname, newname, fs.ErrExist)
+	}
+
+	if err := o.checkParent(p, newname); err != nil {
+		return linkError("symlink", oldname, newname, unwrapPathError(err))
+	}
+
+	o.nodes[p] = &node{kind: kindSymlink, link: oldname, mode: fs.ModeSymlink | 0o777, modTime: time.Now()}
+
+	return nil
+}
+
+// Chmod changes the permission bits of name, following symlinks.
+func (o *Overlay) Chmod(name string, mode fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, true)
+	if err != nil {
+		return pathError("chmod", name, err)
+	}
+
+	info, err := o.lstat(p, name)
+	if err != nil {
+		return pathError("chmod", name, unwrapPathError(err))
+	}
+
+	if n := o.nodes[p]; n != nil {
+		n.mode = n.mode.Type() | mode.Perm()
+		return nil
+	}
+
+	if info.IsDir() {
+		o.nodes[p] = &node{kind: kindDir, mode: fs.ModeDir | mode.Perm(), modTime: info.ModTime()}
+	} else {
+		o.nodes[p] = &node{kind: kindFile, lower: p, mode: mode.Perm(), modTime: info.ModTime()}
+	}
+
+	return nil
+}
+
+// Chown checks that name exists. Ownership is not simulated: the overlay
+// keeps reporting the base file's owner.
+func (o *Overlay) Chown(name string, _, _ int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	p, err := o.resolve(name, true)
+	if err != nil {
+		return pathError("chown", name, err)
+	}
+
+	if _, err := o.lstat(p, name); err != nil {
+		return pathError("chown", name, unwrapPathError(err))
+	}
+
+	return nil
+}
+
+// --- internals, called with o.mu held ---
+
+// lookup returns the overlay node for p. hidden reports that p has no node
+// but a deleted, non-directory or opaque ancestor hides the base path.
+func (o *Overlay) lookup(p string) (n *node, hidden bool) {
+	if n, ok := o.nodes[p]; ok {
+		return n, false
+	}
+
+	for dir := filepath.Dir(p); ; dir = filepath.Dir(dir) {
+		if a, ok := o.nodes[dir]; ok && (a.kind != kindDir || a.opaque) {
+			return nil, true
+		}
+
+		if parent := filepath.Dir(dir); parent == dir {
+			return nil, false
+		}
+	}
+}
+
+// lstat returns file info for the resolved path p. name is used in errors.
+func (o *Overlay) lstat(p, name string) (fs.FileInfo, error) {
+	n, hidden := o.lookup(p)
+	switch {
+	case n == nil && !hidden:
+		info, err := o.base.Lstat(p)
+		if err != nil {
+			return nil, pathError("lstat", name, unwrapPathError(err))
+		}
+		return info, nil
+	case n == nil || n.kind == kindDeleted:
+		return nil, pathError("lstat", name, fs.ErrNotExist)
+	case n.kind == kindDir && !n.opaque:
+		// Mode override on a base directory
+		info, err := o.base.Lstat(p)
+		if err != nil {
+			return nil, pathError("lstat", name, unwrapPathError(err))
+		}
+		return &fileInfo{name: filepath.Base(p), mode: n.mode, modTime: info.ModTime()}, nil
+	}
+
+	size := int64(len(n.data))
+	if n.kind == kindFile && n.data == nil && n.lower != "" {
+		if info, err := o.base.Lstat(n.lower); err == nil {
+			size = info.Size()
+		}
+	}
+	if n.kind == kindSymlink {
+		size = int64(len(n.link))
+	}
+
+	return &fileInfo{name: filepath.Base(p), size: size, mode: n.mode, modTime: n.modTime}, nil
+}
+
+func (o *Overlay) readlink(p, name string) (string, error) {
+	n, hidden := o.lookup(p)
+	switch {
+	case n == nil && !hidden:
+		return o.base.Readlink(p)
+	case n == nil || n.kind == kindDeleted:
+		return "", pathError("readlink", name, fs.ErrNotExist)
+	case n.kind != kindSymlink:
+		return "", pathError("readlink", name, syscall.EINVAL)
+	default:
+		return n.link, nil
+	}
+}
+
+func (o *Overlay) readDir(p, name string) ([]fs.DirEntry, error) {
+	info, err := o.lstat(p, name)
+	if err != nil {
+		return nil, pathError("open", name, unwrapPathError(err))
+	}
+
+	if !info.IsDir() {
+		return nil, pathError("readdirent", name, syscall.ENOTDIR)
+	}
+
+	entries := make(map[string]fs.DirEntry)
+
+	n, hidden := o.lookup(p)
+	if !hidden && (n == nil || !n.opaque) {
+		base, err := o.base.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range base {
+			entries[e.Name()] = e
+		}
+	}
+
+	for child, cn := range o.nodes {
+		if filepath.Dir(child) != p || child == p {
+			continue
+		}
+
+		base := filepath.Base(child)
+		if cn.kind == kindDeleted {
+			delete(entries, base)
+			continue
+		}
+
+		childInfo, err := o.lstat(child, child)
+		if err != nil {
+			return nil, err
+		}
+		entries[base] = fs.FileInfoToDirEntry(childInfo)
+	}
+
+	result := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e)
+	}
+	slices.SortFunc(result, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	return result, nil
+}
+
+func (o *Overlay) mkdirAll(p, name string, perm fs.FileMode) error {
+	if info, err := o.lstat(p, name); err == nil {
+		if info.IsDir() {
+			return nil
+		}
+		return pathError("mkdir", name, syscall.ENOTDIR)
+	}
+
+	if parent := filepath.Dir(p); parent != p {
+		resolved, err := o.resolve(parent, true)
+		if err != nil {
+			return pathError("mkdir", name, err)
+		}
+		if err := o.mkdirAll(resolved, name, perm); err != nil {
+			return err
+		}
+	}
+
+	o.nodes[p] = &node{kind: kindDir, mode: fs.ModeDir | perm.Perm(), opaque: true, modTime: time.Now()}
+
+	return nil
+}
+
+// checkParent returns an error unless the parent of p is an existing directory.
+func (o *Overlay) checkParent(p, name string) error {
+	info, err := o.lstat(filepath.Dir(p), name)
+	if err != nil {
+		return pathError("open", name, fs.ErrNotExist)
+	}
+
+	if !info.IsDir() {
+		return pathError("open", name, syscall.ENOTDIR)
+	}
+
+	return nil
+}
+
+// copyTree copies src to dst inside the overlay. File content is not read:
+// copied files refer back to the base path holding it.
+func (o *Overlay) copyTree(src, dst string) error {
+	info, err := o.lstat(src, src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := o.readlink(src, src)
+		if err != nil {
+			return err
+		}
+		o.nodes[dst] = &node{kind: kindSymlink, link: link, mode: info.Mode(), modTime: info.ModTime()}
+	case info.IsDir():
+		entries, err := o.readDir(src, src)
+		if err != nil {
+			return err
+		}
+
+		o.nodes[dst] = &node{kind: kindDir, mode: info.Mode(), opaque: true, modTime: info.ModTime()}
+
+		for _, e := range entries {
+			if err := o.copyTree(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+				return err
+			}
+		}
+	default:
+		if n := o.nodes[src]; n != nil && n.kind == kindFile {
+			copied := *n
+			o.nodes[dst] = &copied
+		} else {
+			o.nodes[dst] = &node{kind: kindFile, lower: src, mode: info.Mode(), modTime: info.ModTime()}
+		}
+	}
+
+	return nil
+}
+
+// markDeleted hides p and everything below it.
+func (o *Overlay) markDeleted(p string) {
+	for child := range o.nodes {
+		if isWithin(child, p) && child != p {
+			delete(o.nodes, child)
+		}
+	}
+
+	o.nodes[p] = &node{kind: kindDeleted}
+}
+
+// resolve returns name with every symlink in its directory components
+// resolved, and the final component too when followLast is set.
+func (o *Overlay) resolve(name string, followLast bool) (string, error) {
+	p, err := filepath.Abs(name)
+	if err != nil {
+		return "", err
+	}
+
+	for hops := 0; ; {
+		vol := filepath.VolumeName(p)
+		parts := strings.Split(strings.TrimPrefix(p[len(vol):], string(filepath.Separator)), string(filepath.Separator))
+
+		cur := vol + string(filepath.Separator)
+		restarted := false
+
+		for i, part := range parts {
+			if part == "" {
+				continue
+			}
+
+			next := filepath.Join(cur, part)
+			if i == len(parts)-1 && !followLast {
+				cur = next
+				break
+			}
+
+			info, err := o.lstat(next, name)
+			if err != nil {
+				// The rest of the path does not exist; nothing left to resolve
+				return filepath.Join(append([]string{cur}, parts[i:]...)...), nil
+			}
+
+			if info.Mode()&fs.ModeSymlink == 0 {
+				cur = next
+				continue
+			}
+
+			if hops++; hops > maxSymlinkHops {
+				return "", syscall.ELOOP
+			}
+
+			link, err := o.readlink(next, name)
+			if err != nil {
+				return "", unwrapPathError(err)
+			}
+			if !filepath.IsAbs(link) {
+				link = filepath.Join(cur, link)
+			}
+
+			p = filepath.Join(append([]string{link}, parts[i+1:]...)...)
+			restarted = true
+
+			break
+		}
+
+		if !restarted {
+			return cur, nil
+		}
+	}
+}
+
+// isWithin reports whether path is dir or lies below it.
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+func pathError(op, path string, err error) error {
+	return &fs.PathError{Op: op, Path: path, Err: unwrapPathError(err)}
+}
+
+func linkError(op, oldname, newname string, err error) error {
+	return &os.LinkError{Op: op, Old: oldname, New: newname, Err: err}
+}
+
+// unwrapPathError returns the underlying error of a *fs.PathError, so errors
+// re-reported under another name don't nest.
+func unwrapPathError(err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return pe.Err
+	}
+
+	return err
+}
+
+// fileInfo describes an overlay node.
+type fileInfo struct {
+	modTime time.Time
+	name    string
+	size    int64
+	mode    fs.FileMode
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *fileInfo) Sys() any           { return nil }
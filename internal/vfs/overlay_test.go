@@ -0,0 +1,224 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, fsys FS, path string) string {
+	t.Helper()
+
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(%s) error = %v", path, err)
+	}
+
+	return string(data)
+}
+
+func names(t *testing.T, fsys FS, dir string) []string {
+	t.Helper()
+
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir(%s) error = %v", dir, err)
+	}
+
+	var result []string
+	for _, e := range entries {
+		result = append(result, e.Name())
+	}
+
+	return result
+}
+
+func TestOverlay_WritesStayInMemory(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "base")
+
+	o := NewOverlay(OS{})
+
+	if err := o.WriteFile(filepath.Join(root, "a.txt"), []byte("changed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.MkdirAll(filepath.Join(root, "new", "dir"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.WriteFile(filepath.Join(root, "new", "dir", "b.txt"), []byte("b"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, o, filepath.Join(root, "a.txt")); got != "changed" {
+		t.Errorf("overlay a.txt = %q, want changed", got)
+	}
+	if got := readFile(t, o, filepath.Join(root, "new", "dir", "b.txt")); got != "b" {
+		t.Errorf("overlay b.txt = %q, want b", got)
+	}
+	if got := readFile(t, OS{}, filepath.Join(root, "a.txt")); got != "base" {
+		t.Errorf("base a.txt = %q, want base", got)
+	}
+	if Exists(OS{}, filepath.Join(root, "new")) {
+		t.Error("MkdirAll reached the base filesystem")
+	}
+
+	want := []string{filepath.Join(root, "a.txt"), filepath.Join(root, "new"), filepath.Join(root, "new", "dir"), filepath.Join(root, "new", "dir", "b.txt")}
+	if got := o.Changed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changed() = %v, want %v", got, want)
+	}
+}
+
+func TestOverlay_RenameDirectory(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "target", "init.lua"), "lua")
+	writeFile(t, filepath.Join(root, "target", "lua", "plugins.lua"), "plugins")
+
+	o := NewOverlay(OS{})
+
+	if err := o.MkdirAll(filepath.Join(root, "repo"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Rename(filepath.Join(root, "target"), filepath.Join(root, "repo", "nvim")); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+
+	if Exists(o, filepath.Join(root, "target")) {
+		t.Error("source still exists after Rename")
+	}
+	if got := readFile(t, o, filepath.Join(root, "repo", "nvim", "lua", "plugins.lua")); got != "plugins" {
+		t.Errorf("moved file = %q, want plugins", got)
+	}
+	if got, want := names(t, o, filepath.Join(root, "repo", "nvim")), []string{"init.lua", "lua"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadDir() = %v, want %v", got, want)
+	}
+	if !Exists(OS{}, filepath.Join(root, "target", "init.lua")) {
+		t.Error("Rename changed the base filesystem")
+	}
+}
+
+func TestOverlay_SymlinksResolve(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "repo", "nvim", "init.lua"), "lua")
+
+	o := NewOverlay(OS{})
+
+	link := filepath.Join(root, "home", "nvim")
+	if err := o.MkdirAll(filepath.Dir(link), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Symlink(filepath.Join(root, "repo", "nvim"), link); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+
+	if !IsSymlink(o, link) {
+		t.Error("IsSymlink() = false for overlay symlink")
+	}
+	if got := readFile(t, o, filepath.Join(link, "init.lua")); got != "lua" {
+		t.Errorf("read through symlink = %q, want lua", got)
+	}
+
+	info, err := o.Stat(link)
+	if err != nil || !info.IsDir() {
+		t.Errorf("Stat(link) = %v, %v; want the linked directory", info, err)
+	}
+
+	// Writes through the link land in the linked directory
+	if err := o.WriteFile(filepath.Join(link, "new.lua"), []byte("new"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, o, filepath.Join(root, "repo", "nvim", "new.lua")); got != "new" {
+		t.Errorf("file written through link = %q, want new", got)
+	}
+
+	if err := o.Symlink("elsewhere", link); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Symlink() over existing link error = %v, want fs.ErrExist", err)
+	}
+}
+
+func TestOverlay_Remove(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "dir", "a.txt"), "a")
+	writeFile(t, filepath.Join(root, "dir", "sub", "b.txt"), "b")
+
+	o := NewOverlay(OS{})
+
+	if err := o.Remove(filepath.Join(root, "dir")); !errors.Is(err, syscall.ENOTEMPTY) {
+		t.Errorf("Remove(non-empty) error = %v, want ENOTEMPTY", err)
+	}
+
+	if err := o.Remove(filepath.Join(root, "dir", "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(t, o, filepath.Join(root, "dir")), []string{"sub"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadDir() after Remove = %v, want %v", got, want)
+	}
+
+	if err := o.RemoveAll(filepath.Join(root, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Lstat(filepath.Join(root, "dir", "sub", "b.txt")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Lstat() below removed dir error = %v, want fs.ErrNotExist", err)
+	}
+
+	// A directory recreated at a removed path starts empty
+	if err := o.Mkdir(filepath.Join(root, "dir"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(t, o, filepath.Join(root, "dir")); len(got) != 0 {
+		t.Errorf("ReadDir() of recreated dir = %v, want empty", got)
+	}
+
+	if err := o.Remove(filepath.Join(root, "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Remove(missing) error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestWalkDir(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "a")
+	writeFile(t, filepath.Join(root, "skip", "x.txt"), "x")
+	writeFile(t, filepath.Join(root, "sub", "b.txt"), "b")
+
+	o := NewOverlay(OS{})
+	if err := o.WriteFile(filepath.Join(root, "sub", "c.txt"), []byte("c"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err := WalkDir(o, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "skip" {
+			return fs.SkipDir
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		got = append(got, rel)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{".", "a.txt", "sub", filepath.Join("sub", "b.txt"), filepath.Join("sub", "c.txt")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkDir() visited %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+// Package vfs provides the filesystem abstraction used by restore, backup,
+// merge and template code. OS operates on the real filesystem; Overlay is an
+// in-memory copy-on-write view over another FS, used to simulate a run.
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// FS is the set of filesystem operations tidydots needs. Paths are absolute.
+// Errors are *fs.PathError values comparable with errors.Is against the fs
+// and syscall errors the os package would return.
+type FS interface {
+	Lstat(name string) (fs.FileInfo, error)
+	Stat(name string) (fs.FileInfo, error)
+	Readlink(name string) (string, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Mkdir(name string, perm fs.FileMode) error
+	MkdirAll(path string, perm fs.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Symlink(oldname, newname string) error
+	Chmod(name string, mode fs.FileMode) error
+	Chown(name string, uid, gid int) error
+}
+
+// OS is the real filesystem.
+type OS struct{}
+
+// Lstat calls os.Lstat.
+func (OS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+
+// Stat calls os.Stat.
+func (OS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+// Readlink calls os.Readlink.
+func (OS) Readlink(name string) (string, error) { return os.Readlink(name) }
+
+// ReadDir calls os.ReadDir.
+func (OS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+// ReadFile calls os.ReadFile.
+func (OS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name) //nolint:gosec // paths come from config
+}
+
+// WriteFile calls os.WriteFile.
+func (OS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// Mkdir calls os.Mkdir.
+func (OS) Mkdir(name string, perm fs.FileMode) error { return os.Mkdir(name, perm) }
+
+// MkdirAll calls os.MkdirAll.
+func (OS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+
+// Rename calls os.Rename.
+func (OS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+
+// Remove calls os.Remove.
+func (OS) Remove(name string) error { return os.Remove(name) }
+
+// RemoveAll calls os.RemoveAll.
+func (OS) RemoveAll(path string) error { return os.RemoveAll(path) }
+
+// Symlink calls os.Symlink.
+func (OS) Symlink(oldname, newname string) error { return os.Symlink(oldname, newname) }
+
+// Chmod calls os.Chmod.
+func (OS) Chmod(name string, mode fs.FileMode) error { return os.Chmod(name, mode) }
+
+// Chown calls os.Chown.
+func (OS) Chown(name string, uid, gid int) error { return os.Chown(name, uid, gid) }
+
+// Exists reports whether name exists, without following a final symlink.
+func Exists(fsys FS, name string) bool {
+	_, err := fsys.Lstat(name)
+	return err == nil
+}
+
+// IsSymlink reports whether name is a symlink.
+func IsSymlink(fsys FS, name string) bool {
+	info, err := fsys.Lstat(name)
+	if err != nil {
+		return false
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return true
+	}
+
+	// On Windows, directory junctions (mklink /J) are not reported as
+	// ModeSymlink in recent Go versions, but Readlink still resolves them.
+	if runtime.GOOS == "windows" {
+		_, err := fsys.Readlink(name)
+		return err == nil
+	}
+
+	return false
+}
+
+// WalkDir walks the tree rooted at root like filepath.WalkDir, calling fn for
+// each file or directory in lexical order. Symlinks are not followed.
+func WalkDir(fsys FS, root string, fn fs.WalkDirFunc) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDir(fsys, root, fs.FileInfoToDirEntry(info), fn)
+	}
+
+	if errors.Is(err, filepath.SkipDir) || errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+
+	return err
+}
+
+func walkDir(fsys FS, path string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(path, d, nil); err != nil || !d.IsDir() {
+		if errors.Is(err, filepath.SkipDir) && d.IsDir() {
+			err = nil
+		}
+
+		return err
+	}
+
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		if err = fn(path, d, err); err != nil {
+			if errors.Is(err, filepath.SkipDir) && d.IsDir() {
+				err = nil
+			}
+
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		if err := walkDir(fsys, filepath.Join(path, entry.Name()), entry, fn); err != nil {
+			if errors.Is(err, filepath.SkipDir) {
+				break
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}